@@ -2,17 +2,23 @@ package events
 
 import (
 	"encoding/json"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
 	"github.com/google/uuid"
 )
 
 type Event struct {
+	// ID is the broker sequence number, assigned on publish to DefaultBroker
+	ID          uint64                 `json:"id,omitempty"`
 	Type        string                 `json:"type"`
 	JobID       string                 `json:"job_id,omitempty"`
 	JobType     string                 `json:"job_type,omitempty"`
 	ClusterID   string                 `json:"cluster_id,omitempty"`
+	ProjectID   string                 `json:"project_id,omitempty"` // empty for events of the default project
 	Progress    int                    `json:"progress,omitempty"`
 	Message     string                 `json:"message,omitempty"`
 	Timestamp   time.Time              `json:"timestamp,omitempty"`
@@ -48,6 +54,9 @@ func FromMap(data map[string]interface{}) *Event {
 	if v, ok := data["cluster_id"].(string); ok {
 		e.ClusterID = v
 	}
+	if v, ok := data["project_id"].(string); ok {
+		e.ProjectID = v
+	}
 	if v, ok := data["message"].(string); ok {
 		e.Message = v
 	}
@@ -65,7 +74,7 @@ func FromMap(data map[string]interface{}) *Event {
 	// store rest in Payload
 	e.Payload = make(map[string]interface{})
 	for k, v := range data {
-		if k == "type" || k == "job_id" || k == "job_type" || k == "cluster_id" || k == "progress" || k == "message" || k == "trace_id" || k == "correlation_id" {
+		if k == "type" || k == "job_id" || k == "job_type" || k == "cluster_id" || k == "project_id" || k == "progress" || k == "message" || k == "trace_id" || k == "correlation_id" {
 			continue
 		}
 		e.Payload[k] = v
@@ -73,44 +82,220 @@ func FromMap(data map[string]interface{}) *Event {
 	return e
 }
 
-// Broker is a simple in-memory pub/sub broker used by SSE/WebSocket endpoints
+// Filter restricts which events a subscriber receives. Empty fields match everything.
+type Filter struct {
+	JobID     string   `json:"job_id,omitempty"`
+	ClusterID string   `json:"cluster_id,omitempty"`
+	ProjectID string   `json:"project_id,omitempty"`
+	TraceID   string   `json:"trace_id,omitempty"`
+	Types     []string `json:"types,omitempty"`
+}
+
+// Match reports whether the event satisfies every non-empty field of the filter.
+func (f Filter) Match(e Event) bool {
+	if f.JobID != "" && e.JobID != f.JobID {
+		return false
+	}
+	if f.ClusterID != "" && e.ClusterID != f.ClusterID {
+		return false
+	}
+	if !models.InProject(f.ProjectID, e.ProjectID) {
+		return false
+	}
+	if f.TraceID != "" && e.TraceID != f.TraceID {
+		return false
+	}
+	if len(f.Types) > 0 {
+		for _, t := range f.Types {
+			if t == e.Type {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Subscription is a filtered stream of events. Events that cannot be delivered because
+// the subscriber is slow are counted instead of silently discarded.
+type Subscription struct {
+	ID      uint64
+	Filter  Filter
+	C       <-chan Event
+	ch      chan Event
+	dropped uint64
+	broker  *Broker
+}
+
+// Dropped returns how many events were dropped for this subscriber.
+func (s *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Close unsubscribes from the broker and closes the channel.
+func (s *Subscription) Close() {
+	s.broker.Unsubscribe(s.ch)
+}
+
+// SubscriberStats describes a single subscriber for observability.
+type SubscriberStats struct {
+	ID      uint64 `json:"id"`
+	Filter  Filter `json:"filter"`
+	Pending int    `json:"pending"`
+	Dropped uint64 `json:"dropped"`
+}
+
+// BrokerStats is a snapshot of broker state.
+type BrokerStats struct {
+	Subscribers   int               `json:"subscribers"`
+	Published     uint64            `json:"published"`
+	Dropped       uint64            `json:"dropped"`
+	LastEventID   uint64            `json:"last_event_id"`
+	BufferSize    int               `json:"buffer_size"`
+	Buffered      int               `json:"buffered"`
+	PerSubscriber []SubscriberStats `json:"per_subscriber"`
+}
+
+// Broker is a simple in-memory pub/sub broker used by SSE/WebSocket endpoints.
+// Every published event gets a monotonically increasing ID and is kept in a bounded
+// ring buffer so reconnecting clients can resume from a Last-Event-ID.
 type Broker struct {
 	mu          sync.RWMutex
-	subscribers map[chan Event]struct{}
+	subscribers map[chan Event]*Subscription
+	nextSubID   uint64
+	seq         uint64
+	ring        []Event
+	ringStart   int // index of the oldest buffered event
+	ringLen     int
+	dropped     uint64
 }
 
+// DefaultBufferSize is the number of recent events kept for Last-Event-ID resume.
+const DefaultBufferSize = 1024
+
+// subscriberBuffer is the per-subscriber channel capacity.
+const subscriberBuffer = 64
+
 func NewBroker() *Broker {
-	return &Broker{subscribers: make(map[chan Event]struct{})}
+	return NewBrokerWithBuffer(DefaultBufferSize)
+}
+
+// NewBrokerWithBuffer creates a broker that keeps the last size events for replay.
+func NewBrokerWithBuffer(size int) *Broker {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	return &Broker{
+		subscribers: make(map[chan Event]*Subscription),
+		ring:        make([]Event, size),
+	}
 }
 
+// Subscribe registers an unfiltered subscriber and returns its channel.
 func (b *Broker) Subscribe() chan Event {
-	ch := make(chan Event, 16)
+	sub, _, _ := b.SubscribeFiltered(Filter{}, 0)
+	return sub.ch
+}
+
+// SubscribeFiltered registers a subscriber receiving only events matching f. When
+// lastEventID is non-zero, buffered events newer than it that match f are returned as
+// backlog; the subscription and backlog are taken atomically so no event is lost or
+// duplicated in between. missed is true when the buffer no longer holds every event
+// after lastEventID, meaning the client should resynchronise from the REST API. An ID
+// past the current sequence was issued before a restart reset it: everything buffered
+// since is backlog and the events published before the restart are missed.
+func (b *Broker) SubscribeFiltered(f Filter, lastEventID uint64) (sub *Subscription, backlog []Event, missed bool) {
+	ch := make(chan Event, subscriberBuffer)
 	b.mu.Lock()
-	b.subscribers[ch] = struct{}{}
-	b.mu.Unlock()
-	return ch
+	defer b.mu.Unlock()
+	b.nextSubID++
+	sub = &Subscription{ID: b.nextSubID, Filter: f, C: ch, ch: ch, broker: b}
+	b.subscribers[ch] = sub
+
+	if lastEventID > b.seq {
+		missed = true
+		for i := 0; i < b.ringLen; i++ {
+			if e := b.ring[(b.ringStart+i)%len(b.ring)]; f.Match(e) {
+				backlog = append(backlog, e)
+			}
+		}
+	} else if lastEventID > 0 && lastEventID < b.seq {
+		if b.ringLen == 0 || b.ring[b.ringStart].ID > lastEventID+1 {
+			missed = true
+		}
+		for i := 0; i < b.ringLen; i++ {
+			e := b.ring[(b.ringStart+i)%len(b.ring)]
+			if e.ID > lastEventID && f.Match(e) {
+				backlog = append(backlog, e)
+			}
+		}
+	}
+	return sub, backlog, missed
 }
 
 func (b *Broker) Unsubscribe(ch chan Event) {
 	b.mu.Lock()
+	if _, ok := b.subscribers[ch]; !ok {
+		b.mu.Unlock()
+		return
+	}
 	delete(b.subscribers, ch)
 	b.mu.Unlock()
 	close(ch)
 }
 
-// Publish broadcasts the event to all subscribers (non-blocking)
+// Publish assigns the event an ID, stores it for replay and broadcasts it to all
+// matching subscribers (non-blocking). Slow subscribers have the drop counted.
 func (b *Broker) Publish(e Event) {
-	b.mu.RLock()
-	defer b.mu.RUnlock()
-	for ch := range b.subscribers {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	e.ID = b.seq
+	b.store(e)
+	for ch, sub := range b.subscribers {
+		if !sub.Filter.Match(e) {
+			continue
+		}
 		select {
 		case ch <- e:
 		default:
-			// drop if subscriber is slow
+			atomic.AddUint64(&sub.dropped, 1)
+			b.dropped++
 		}
 	}
 }
 
+// store appends e to the ring buffer, overwriting the oldest entry when full.
+func (b *Broker) store(e Event) {
+	if b.ringLen < len(b.ring) {
+		b.ring[(b.ringStart+b.ringLen)%len(b.ring)] = e
+		b.ringLen++
+		return
+	}
+	b.ring[b.ringStart] = e
+	b.ringStart = (b.ringStart + 1) % len(b.ring)
+}
+
+// Stats returns a snapshot of subscribers and drop counters.
+func (b *Broker) Stats() BrokerStats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	st := BrokerStats{
+		Subscribers:   len(b.subscribers),
+		Published:     b.seq,
+		Dropped:       b.dropped,
+		LastEventID:   b.seq,
+		BufferSize:    len(b.ring),
+		Buffered:      b.ringLen,
+		PerSubscriber: make([]SubscriberStats, 0, len(b.subscribers)),
+	}
+	for ch, sub := range b.subscribers {
+		st.PerSubscriber = append(st.PerSubscriber, SubscriberStats{ID: sub.ID, Filter: sub.Filter, Pending: len(ch), Dropped: sub.Dropped()})
+	}
+	sort.Slice(st.PerSubscriber, func(i, j int) bool { return st.PerSubscriber[i].ID < st.PerSubscriber[j].ID })
+	return st
+}
+
 // Default broker used by the app
 var DefaultBroker = NewBroker()
 
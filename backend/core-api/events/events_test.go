@@ -0,0 +1,106 @@
+package events
+
+import "testing"
+
+func TestBrokerFilterDeliversOnlyMatchingEvents(t *testing.T) {
+	b := NewBrokerWithBuffer(16)
+	sub, _, _ := b.SubscribeFiltered(Filter{JobID: "job-1", Types: []string{"job_progress", "job_completed"}}, 0)
+	defer sub.Close()
+
+	b.Publish(Event{Type: "job_progress", JobID: "job-2"})
+	b.Publish(Event{Type: "job_started", JobID: "job-1"})
+	b.Publish(Event{Type: "job_progress", JobID: "job-1", Progress: 50})
+
+	select {
+	case e := <-sub.C:
+		if e.JobID != "job-1" || e.Type != "job_progress" {
+			t.Fatalf("unexpected event delivered: %#v", e)
+		}
+		if e.ID != 3 {
+			t.Fatalf("expected broker-assigned id 3, got %d", e.ID)
+		}
+	default:
+		t.Fatalf("expected matching event to be delivered")
+	}
+	if len(sub.C) != 0 {
+		t.Fatalf("expected non-matching events to be filtered, %d pending", len(sub.C))
+	}
+}
+
+func TestFilterMatchesOnlyTheProject(t *testing.T) {
+	f := Filter{ProjectID: "team-a"}
+	if !f.Match(Event{Type: "job_started", ProjectID: "team-a"}) {
+		t.Fatalf("expected the project's event to match")
+	}
+	if f.Match(Event{Type: "job_started", ProjectID: "team-b"}) || f.Match(Event{Type: "cluster_telemetry"}) {
+		t.Fatalf("expected events of other projects to be filtered")
+	}
+	if !(Filter{ProjectID: "default"}).Match(Event{Type: "cluster_telemetry"}) {
+		t.Fatalf("expected events without a project to belong to the default project")
+	}
+	if !(Filter{}).Match(Event{Type: "job_started", ProjectID: "team-b"}) {
+		t.Fatalf("expected a filter without a project to match every project")
+	}
+}
+
+func TestBrokerReplaysFromLastEventID(t *testing.T) {
+	b := NewBrokerWithBuffer(4)
+	for i := 0; i < 6; i++ {
+		b.Publish(Event{Type: "job_progress", ClusterID: "c1"})
+	}
+
+	// ids 3..6 are buffered; resuming after 4 replays 5 and 6 without a gap
+	sub, backlog, missed := b.SubscribeFiltered(Filter{ClusterID: "c1"}, 4)
+	defer sub.Close()
+	if missed {
+		t.Fatalf("expected no gap when resuming inside the buffer")
+	}
+	if len(backlog) != 2 || backlog[0].ID != 5 || backlog[1].ID != 6 {
+		t.Fatalf("unexpected backlog: %#v", backlog)
+	}
+
+	// resuming from an evicted id reports the gap
+	sub2, backlog2, missed2 := b.SubscribeFiltered(Filter{}, 1)
+	defer sub2.Close()
+	if !missed2 {
+		t.Fatalf("expected missed=true when resume point was evicted")
+	}
+	if len(backlog2) != 4 {
+		t.Fatalf("expected whole buffer replayed, got %d", len(backlog2))
+	}
+}
+
+func TestBrokerReportsMissedAfterRestart(t *testing.T) {
+	// a restarted broker numbers events from 1 again
+	b := NewBrokerWithBuffer(4)
+	b.Publish(Event{Type: "job_progress", ClusterID: "c1"})
+	b.Publish(Event{Type: "job_progress", ClusterID: "c2"})
+
+	// the client last saw id 10 from the previous process
+	sub, backlog, missed := b.SubscribeFiltered(Filter{ClusterID: "c1"}, 10)
+	defer sub.Close()
+	if !missed {
+		t.Fatalf("expected missed=true for a resume point past the current sequence")
+	}
+	if len(backlog) != 1 || backlog[0].ID != 1 {
+		t.Fatalf("expected the events since the restart replayed, got %#v", backlog)
+	}
+}
+
+func TestBrokerCountsDropsForSlowSubscribers(t *testing.T) {
+	b := NewBrokerWithBuffer(8)
+	sub, _, _ := b.SubscribeFiltered(Filter{}, 0)
+	defer sub.Close()
+
+	total := subscriberBuffer + 5
+	for i := 0; i < total; i++ {
+		b.Publish(Event{Type: "job_progress"})
+	}
+	if sub.Dropped() != 5 {
+		t.Fatalf("expected 5 dropped events, got %d", sub.Dropped())
+	}
+	st := b.Stats()
+	if st.Dropped != 5 || st.Subscribers != 1 || st.PerSubscriber[0].Dropped != 5 {
+		t.Fatalf("unexpected stats: %#v", st)
+	}
+}
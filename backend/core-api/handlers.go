@@ -1,13 +1,23 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/events"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/logger"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/middleware"
 	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
 	"github.com/AvinashMahala/ClusterGenie/backend/core-api/services"
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -49,6 +59,7 @@ func CreateDropletHandler(svc *services.ProvisioningService) gin.HandlerFunc {
 			c.JSON(400, models.ErrorResponse{Error: err.Error()})
 			return
 		}
+		req.ProjectID = middleware.ProjectFrom(c)
 		resp, err := svc.CreateDroplet(&req)
 		if err != nil {
 			c.JSON(500, models.ErrorResponse{Error: err.Error()})
@@ -71,7 +82,7 @@ func CreateDropletHandler(svc *services.ProvisioningService) gin.HandlerFunc {
 func GetDropletHandler(svc *services.ProvisioningService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
-		droplet, err := svc.GetDroplet(id)
+		droplet, err := svc.GetDroplet(middleware.ProjectFrom(c), id)
 		if err != nil {
 			c.JSON(404, models.ErrorResponse{Error: "Droplet not found"})
 			return
@@ -90,7 +101,7 @@ func GetDropletHandler(svc *services.ProvisioningService) gin.HandlerFunc {
 // @Router /droplets [get]
 func ListDropletsHandler(svc *services.ProvisioningService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		droplets, err := svc.ListDroplets()
+		droplets, err := svc.ListDroplets(middleware.ProjectFrom(c))
 		if err != nil {
 			c.JSON(500, models.ErrorResponse{Error: err.Error()})
 			return
@@ -112,7 +123,7 @@ func ListDropletsHandler(svc *services.ProvisioningService) gin.HandlerFunc {
 func DeleteDropletHandler(svc *services.ProvisioningService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
-		err := svc.DeleteDroplet(id)
+		err := svc.DeleteDroplet(middleware.ProjectFrom(c), id)
 		if err != nil {
 			c.JSON(500, models.ErrorResponse{Error: err.Error()})
 			return
@@ -139,6 +150,7 @@ func DiagnoseClusterHandler(svc *services.DiagnosisService) gin.HandlerFunc {
 			c.JSON(400, models.ErrorResponse{Error: err.Error()})
 			return
 		}
+		req.ProjectID = middleware.ProjectFrom(c)
 		resp, err := svc.DiagnoseCluster(&req)
 		if err != nil {
 			c.JSON(500, models.ErrorResponse{Error: err.Error()})
@@ -165,6 +177,7 @@ func CreateClusterHandler(svc *services.ClusterService) gin.HandlerFunc {
 			c.JSON(400, models.ErrorResponse{Error: err.Error()})
 			return
 		}
+		req.ProjectID = middleware.ProjectFrom(c)
 		resp, err := svc.CreateCluster(&req)
 		if err != nil {
 			c.JSON(500, models.ErrorResponse{Error: err.Error()})
@@ -187,7 +200,7 @@ func CreateClusterHandler(svc *services.ClusterService) gin.HandlerFunc {
 func GetClusterHandler(svc *services.ClusterService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
-		cluster, err := svc.GetCluster(id)
+		cluster, err := svc.GetCluster(middleware.ProjectFrom(c), id)
 		if err != nil {
 			c.JSON(404, models.ErrorResponse{Error: "Cluster not found"})
 			return
@@ -206,7 +219,7 @@ func GetClusterHandler(svc *services.ClusterService) gin.HandlerFunc {
 // @Router /clusters [get]
 func ListClustersHandler(svc *services.ClusterService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		clusters, err := svc.ListClusters()
+		clusters, err := svc.ListClusters(middleware.ProjectFrom(c))
 		if err != nil {
 			c.JSON(500, models.ErrorResponse{Error: err.Error()})
 			return
@@ -235,7 +248,7 @@ func UpdateClusterHandler(svc *services.ClusterService) gin.HandlerFunc {
 			c.JSON(400, models.ErrorResponse{Error: err.Error()})
 			return
 		}
-		resp, err := svc.UpdateCluster(id, &req)
+		resp, err := svc.UpdateCluster(middleware.ProjectFrom(c), id, &req)
 		if err != nil {
 			c.JSON(500, models.ErrorResponse{Error: err.Error()})
 			return
@@ -257,7 +270,7 @@ func UpdateClusterHandler(svc *services.ClusterService) gin.HandlerFunc {
 func DeleteClusterHandler(svc *services.ClusterService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
-		resp, err := svc.DeleteCluster(id)
+		resp, err := svc.DeleteCluster(middleware.ProjectFrom(c), id)
 		if err != nil {
 			c.JSON(500, models.ErrorResponse{Error: err.Error()})
 			return
@@ -276,9 +289,13 @@ func DeleteClusterHandler(svc *services.ClusterService) gin.HandlerFunc {
 // @Failure 404 {object} models.ErrorResponse "Cluster not found"
 // @Failure 500 {object} models.ErrorResponse "Server error"
 // @Router /health/{clusterId} [get]
-func HealthCheckHandler(svc *services.MonitoringService) gin.HandlerFunc {
+func HealthCheckHandler(svc *services.MonitoringService, clusters *services.ClusterService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		clusterID := c.Param("clusterId")
+		if _, err := clusters.GetCluster(middleware.ProjectFrom(c), clusterID); err != nil {
+			c.JSON(404, models.ErrorResponse{Error: "Cluster not found"})
+			return
+		}
 		resp, err := svc.PerformHealthCheck(clusterID)
 		if err != nil {
 			c.JSON(500, models.ErrorResponse{Error: err.Error()})
@@ -288,12 +305,22 @@ func HealthCheckHandler(svc *services.MonitoringService) gin.HandlerFunc {
 	}
 }
 
+// tenantFromRequest returns the worker pool fairness key for the caller ("user:<X-User-ID>"),
+// or "" so the job service falls back to the target cluster.
+func tenantFromRequest(c *gin.Context) string {
+	if uid := c.GetHeader("X-User-ID"); uid != "" {
+		return "user:" + uid
+	}
+	return ""
+}
+
 // @Summary Create job
-// @Description Create a background job (provision/diagnose/scale/monitor)
+// @Description Create a background job of a registered type (see GET /job-types)
 // @Tags jobs
 // @Accept json
 // @Produce json
 // @Param request body models.CreateJobRequest true "Create job request"
+// @Param X-User-ID header string false "Caller identity; jobs are queued fairly per user"
 // @Success 201 {object} models.JobResponse "Job created"
 // @Failure 400 {object} models.ErrorResponse "Invalid request"
 // @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
@@ -306,7 +333,13 @@ func CreateJobHandler(svc *services.JobService) gin.HandlerFunc {
 			c.JSON(400, models.ErrorResponse{Error: err.Error()})
 			return
 		}
+		req.TenantID = tenantFromRequest(c)
+		req.ProjectID = middleware.ProjectFrom(c)
 		resp, err := svc.CreateJob(&req)
+		if errors.Is(err, services.ErrInvalidJobRequest) {
+			c.JSON(400, models.ErrorResponse{Error: err.Error()})
+			return
+		}
 		if err != nil {
 			c.JSON(500, models.ErrorResponse{Error: err.Error()})
 			return
@@ -315,6 +348,188 @@ func CreateJobHandler(svc *services.JobService) gin.HandlerFunc {
 	}
 }
 
+// bulkCreateCost is the rate limit cost of POST /jobs/bulk: one token per job.
+func bulkCreateCost(c *gin.Context, body []byte) int {
+	var req models.BulkCreateJobsRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return 1
+	}
+	return len(req.Jobs)
+}
+
+// bulkActionCost is the rate limit cost of a bulk cancel/requeue: the number of jobs it
+// acts on, so a filter is charged for the jobs it matches rather than its limit. targets
+// is the action's target resolution (JobService.CancelTargets or RequeueTargets).
+func bulkActionCost(targets func(*models.BulkJobActionRequest) ([]string, error)) func(*gin.Context, []byte) int {
+	return func(c *gin.Context, body []byte) int {
+		var req models.BulkJobActionRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return 1
+		}
+		req.ProjectID = middleware.ProjectFrom(c)
+		ids, err := targets(&req)
+		if err != nil {
+			// the handler rejects the request
+			return 1
+		}
+		return len(ids)
+	}
+}
+
+// jobsInBody returns the jobs of a POST /jobs or POST /jobs/bulk body.
+func jobsInBody(body []byte) []models.CreateJobRequest {
+	var req struct {
+		models.CreateJobRequest
+		Jobs []models.CreateJobRequest `json:"jobs"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil
+	}
+	if len(req.Jobs) > 0 {
+		return req.Jobs
+	}
+	return []models.CreateJobRequest{req.CreateJobRequest}
+}
+
+// provisionJobsQuota charges the caller's (X-User-ID) provision_jobs quota one unit per
+// provision job. Callers without a user share the anonymous quota.
+func provisionJobsQuota(c *gin.Context, body []byte) []services.QuotaCharge {
+	var n int64
+	for _, job := range jobsInBody(body) {
+		if job.Type == "provision" {
+			n++
+		}
+	}
+	user := c.GetHeader("X-User-ID")
+	if user == "" {
+		user = services.AnonymousQuotaScope
+	}
+	return []services.QuotaCharge{{ScopeID: user, Units: n}}
+}
+
+// provisionDropletsQuota charges the droplets quota of each target cluster one unit per
+// provision job, since every provision job creates a droplet.
+func provisionDropletsQuota(c *gin.Context, body []byte) []services.QuotaCharge {
+	var charges []services.QuotaCharge
+	for _, job := range jobsInBody(body) {
+		if job.Type == "provision" {
+			charges = append(charges, services.QuotaCharge{ScopeID: job.Parameters["cluster_id"], Units: 1})
+		}
+	}
+	return charges
+}
+
+// dropletQuota charges the droplets quota of the cluster a new droplet joins.
+func dropletQuota(c *gin.Context, body []byte) []services.QuotaCharge {
+	var req models.CreateDropletRequest
+	if err := json.Unmarshal(body, &req); err != nil || req.ClusterID == nil {
+		return nil
+	}
+	return []services.QuotaCharge{{ScopeID: *req.ClusterID, Units: 1}}
+}
+
+// @Summary Create jobs in bulk
+// @Description Create up to 100 jobs in one call. Each item is handled like POST /jobs and gets its own result; the request spends one jobs_create rate limit token per job.
+// @Tags jobs
+// @Accept json
+// @Produce json
+// @Param request body models.BulkCreateJobsRequest true "Jobs to create"
+// @Param X-User-ID header string false "Caller identity; jobs are queued fairly per user"
+// @Success 200 {object} models.BulkJobsResponse "Per-item results"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Router /jobs/bulk [post]
+func BulkCreateJobsHandler(svc *services.JobService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.BulkCreateJobsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		resp, err := svc.CreateJobs(req.Jobs, tenantFromRequest(c), middleware.ProjectFrom(c))
+		if err != nil {
+			c.JSON(400, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		// the quotas charged for the whole batch are given back for the jobs that failed
+		var failed models.BulkCreateJobsRequest
+		for _, res := range resp.Results {
+			if res.Status >= 400 && res.Index >= 0 && res.Index < len(req.Jobs) {
+				failed.Jobs = append(failed.Jobs, req.Jobs[res.Index])
+			}
+		}
+		if len(failed.Jobs) > 0 {
+			if body, err := json.Marshal(failed); err == nil {
+				middleware.RefundQuota(c, body)
+			}
+		}
+		c.JSON(200, resp)
+	}
+}
+
+// @Summary Cancel jobs in bulk
+// @Description Cancel up to 100 jobs selected by job_ids or by filter (oldest first). A filter without status matches jobs that can still be cancelled. Spends one jobs_create token per job it acts on (the job IDs, or the jobs the filter matches).
+// @Tags jobs
+// @Accept json
+// @Produce json
+// @Param request body models.BulkJobActionRequest true "Jobs to cancel"
+// @Success 200 {object} models.BulkJobsResponse "Per-job results"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /jobs/bulk/cancel [post]
+func BulkCancelJobsHandler(svc *services.JobService) gin.HandlerFunc {
+	return bulkJobActionHandler(svc.CancelJobs)
+}
+
+// @Summary Requeue dead-lettered jobs in bulk
+// @Description Requeue up to 100 jobs selected by job_ids or by filter (oldest first). A filter without status matches dead-lettered jobs. Spends one jobs_create token per job it acts on (the job IDs, or the jobs the filter matches).
+// @Tags jobs
+// @Accept json
+// @Produce json
+// @Param request body models.BulkJobActionRequest true "Jobs to requeue"
+// @Success 200 {object} models.BulkJobsResponse "Per-job results"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /jobs/bulk/requeue [post]
+func BulkRequeueJobsHandler(svc *services.JobService) gin.HandlerFunc {
+	return bulkJobActionHandler(svc.RequeueJobs)
+}
+
+func bulkJobActionHandler(action func(*models.BulkJobActionRequest) (*models.BulkJobsResponse, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.BulkJobActionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		req.ProjectID = middleware.ProjectFrom(c)
+		resp, err := action(&req)
+		if errors.Is(err, services.ErrInvalidBulkRequest) {
+			c.JSON(400, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(500, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, resp)
+	}
+}
+
+// @Summary List job types
+// @Description Registered job types with their parameter schema, timeout and retry attempts
+// @Tags jobs
+// @Produce json
+// @Success 200 {object} models.ListJobTypesResponse "Registered job types"
+// @Router /job-types [get]
+func ListJobTypesHandler(svc *services.JobService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(200, models.ListJobTypesResponse{JobTypes: svc.JobTypes()})
+	}
+}
+
 // @Summary Get job by id
 // @Description Retrieve job status/result by id
 // @Tags jobs
@@ -328,7 +543,7 @@ func CreateJobHandler(svc *services.JobService) gin.HandlerFunc {
 func GetJobHandler(svc *services.JobService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
-		job, err := svc.GetJob(id)
+		job, err := svc.GetJob(middleware.ProjectFrom(c), id)
 		if err != nil {
 			c.JSON(404, models.ErrorResponse{Error: "Job not found"})
 			return
@@ -337,16 +552,250 @@ func GetJobHandler(svc *services.JobService) gin.HandlerFunc {
 	}
 }
 
+// jobLogFollowInterval is how often follow mode polls for new log entries.
+const jobLogFollowInterval = 500 * time.Millisecond
+
+// @Summary Get job logs
+// @Description Append-only log of a job (lifecycle, progress and orchestration entries), oldest first. Page with after=next_after. With follow=true the response is a Server-Sent Events stream of "log" events that ends with an "end" event once the job is finished.
+// @Tags jobs
+// @Produce json
+// @Produce text/event-stream
+// @Param id path string true "Job ID"
+// @Param after query int false "Return entries after this log id (alternative to Last-Event-ID header in follow mode)"
+// @Param limit query int false "Page size (default 100, max 1000)"
+// @Param follow query bool false "Stream new entries until the job finishes"
+// @Success 200 {object} models.ListJobLogsResponse "Job logs"
+// @Failure 404 {object} models.ErrorResponse "Job not found"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Failure 503 {object} models.ErrorResponse "Job logs not available"
+// @Router /jobs/{id}/logs [get]
+func JobLogsHandler(svc *services.JobService, heartbeat time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if _, err := svc.GetJob(middleware.ProjectFrom(c), id); err != nil {
+			c.JSON(404, models.ErrorResponse{Error: "Job not found"})
+			return
+		}
+		limit := 100
+		if l := c.Query("limit"); l != "" {
+			if v, err := strconv.Atoi(l); err == nil && v > 0 {
+				limit = v
+			}
+		}
+		var after uint64
+		if a := c.Query("after"); a != "" {
+			v, err := strconv.ParseUint(a, 10, 64)
+			if err != nil {
+				c.JSON(400, models.ErrorResponse{Error: "after must be a log id"})
+				return
+			}
+			after = v
+		}
+		follow, _ := strconv.ParseBool(c.Query("follow"))
+
+		if !follow {
+			resp, err := svc.JobLogs(id, after, limit)
+			if errors.Is(err, services.ErrJobLogsUnavailable) {
+				c.JSON(503, models.ErrorResponse{Error: err.Error()})
+				return
+			}
+			if err != nil {
+				c.JSON(500, models.ErrorResponse{Error: err.Error()})
+				return
+			}
+			c.JSON(200, resp)
+			return
+		}
+
+		if v := c.GetHeader("Last-Event-ID"); v != "" {
+			if n, err := strconv.ParseUint(v, 10, 64); err == nil {
+				after = n
+			}
+		}
+		if _, err := svc.JobLogs(id, after, 1); errors.Is(err, services.ErrJobLogsUnavailable) {
+			c.JSON(503, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(200)
+		c.Writer.Flush()
+
+		poll := time.NewTicker(jobLogFollowInterval)
+		defer poll.Stop()
+		lastWrite := time.Now()
+		finished := false
+		for {
+			// read the status before the logs so entries written up to the terminal
+			// transition are sent before "end"
+			job, err := svc.GetJob(middleware.ProjectFrom(c), id)
+			if err != nil {
+				return
+			}
+			done := models.IsTerminalJobStatus(job.Status)
+			for {
+				page, err := svc.JobLogs(id, after, limit)
+				if err != nil {
+					return
+				}
+				for _, entry := range page.Logs {
+					if err := writeJobLogSSE(c.Writer, "log", entry.ID, entry); err != nil {
+						return
+					}
+				}
+				if len(page.Logs) > 0 {
+					lastWrite = time.Now()
+				}
+				after = page.NextAfter
+				if !page.HasMore {
+					break
+				}
+			}
+			// wait one more poll after the job finished for entries written right after the transition
+			if done && finished {
+				_ = writeJobLogSSE(c.Writer, "end", 0, gin.H{"job_id": id, "status": job.Status})
+				c.Writer.Flush()
+				return
+			}
+			finished = done
+			if time.Since(lastWrite) >= heartbeat {
+				if err := writeSSE(c.Writer, heartbeatEvent()); err != nil {
+					return
+				}
+				lastWrite = time.Now()
+			}
+			c.Writer.Flush()
+
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case <-poll.C:
+			}
+		}
+	}
+}
+
+// writeJobLogSSE writes one job log stream message; a non-zero id becomes the SSE id.
+func writeJobLogSSE(w io.Writer, event string, id uint64, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if id > 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
+
+// @Summary Cancel job
+// @Description Cancel a pending, queued or running job. Queued jobs are removed from the worker pool; running jobs are signalled to stop.
+// @Tags jobs
+// @Accept json
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} models.JobResponse "Job cancelled"
+// @Failure 404 {object} models.ErrorResponse "Job not found"
+// @Failure 409 {object} models.ErrorResponse "Job already finished"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /jobs/{id}/cancel [post]
+func CancelJobHandler(svc *services.JobService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if _, err := svc.GetJob(middleware.ProjectFrom(c), id); err != nil {
+			c.JSON(404, models.ErrorResponse{Error: "Job not found"})
+			return
+		}
+		job, err := svc.CancelJob(id)
+		if errors.Is(err, services.ErrJobNotCancellable) {
+			c.JSON(409, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(500, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, &models.JobResponse{Job: job, Message: "Job cancelled"})
+	}
+}
+
+// @Summary List dead-lettered jobs
+// @Description Returns jobs that exhausted their retry attempts
+// @Tags jobs
+// @Produce json
+// @Param limit query int false "Maximum number of jobs (default 50)"
+// @Success 200 {object} map[string]interface{} "Dead-lettered jobs"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /jobs/dead-letter [get]
+func ListDeadLetterJobsHandler(svc *services.JobService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := 50
+		if l := c.Query("limit"); l != "" {
+			if v, err := strconv.Atoi(l); err == nil && v > 0 {
+				limit = v
+			}
+		}
+		jobs, err := svc.ListDeadLetteredJobs(middleware.ProjectFrom(c), limit)
+		if err != nil {
+			c.JSON(500, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, gin.H{"jobs": jobs, "count": len(jobs)})
+	}
+}
+
+// @Summary Requeue dead-lettered job
+// @Description Resets the attempt counter of a dead-lettered job and submits it again
+// @Tags jobs
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} models.JobResponse "Job requeued"
+// @Failure 404 {object} models.ErrorResponse "Job not found"
+// @Failure 409 {object} models.ErrorResponse "Job is not dead-lettered"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /jobs/{id}/requeue [post]
+func RequeueJobHandler(svc *services.JobService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if _, err := svc.GetJob(middleware.ProjectFrom(c), id); err != nil {
+			c.JSON(404, models.ErrorResponse{Error: "Job not found"})
+			return
+		}
+		job, err := svc.RequeueJob(id)
+		if errors.Is(err, services.ErrJobNotDeadLettered) {
+			c.JSON(409, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(500, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, &models.JobResponse{Job: job, Message: "Job requeued"})
+	}
+}
+
 // @Summary List jobs
-// @Description Returns paginated jobs list with sorting options
+// @Description Returns jobs matching the filters, sorted and paginated. Pass next_cursor back as cursor for keyset pagination that stays stable while jobs are created; page/page_size offset pagination is still supported.
 // @Tags jobs
 // @Accept json
 // @Produce json
-// @Param page query int false "Page number"
+// @Param page query int false "Page number (ignored with cursor)"
 // @Param page_size query int false "Page size"
-// @Param sort_by query string false "Sort column"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort_by query string false "Sort column: created_at (default), completed_at, progress, id"
 // @Param sort_dir query string false "Sort direction (asc/desc)"
+// @Param status query string false "Status(es), comma-separated"
+// @Param type query string false "Job type(s), comma-separated"
+// @Param cluster_id query string false "Only jobs for this cluster"
+// @Param trace_id query string false "Only the job with this trace id"
+// @Param created_after query string false "Created at or after (RFC3339)"
+// @Param created_before query string false "Created before (RFC3339)"
 // @Success 200 {object} models.ListJobsResponse "List of jobs"
+// @Failure 400 {object} models.ErrorResponse "Invalid filter or cursor"
 // @Failure 500 {object} models.ErrorResponse "Server error"
 // @Router /jobs [get]
 func ListJobsHandler(svc *services.JobService) gin.HandlerFunc {
@@ -366,8 +815,35 @@ func ListJobsHandler(svc *services.JobService) gin.HandlerFunc {
 			}
 		}
 
-		req := &models.GetJobsRequest{Page: page, PageSize: pageSize, SortBy: sortBy, SortDir: sortDir}
+		req := &models.GetJobsRequest{
+			Page:     page,
+			PageSize: pageSize,
+			SortBy:   sortBy,
+			SortDir:  sortDir,
+			Cursor:   c.Query("cursor"),
+			JobFilter: models.JobFilter{
+				Statuses:  nilOrSplit(c.Query("status")),
+				Types:     nilOrSplit(c.Query("type")),
+				ClusterID: c.Query("cluster_id"),
+				TraceID:   c.Query("trace_id"),
+				ProjectID: middleware.ProjectFrom(c),
+			},
+		}
+		for param, dst := range map[string]**time.Time{"created_after": &req.CreatedAfter, "created_before": &req.CreatedBefore} {
+			if v := c.Query(param); v != "" {
+				t, err := time.Parse(time.RFC3339, v)
+				if err != nil {
+					c.JSON(400, models.ErrorResponse{Error: param + " must be an RFC3339 timestamp"})
+					return
+				}
+				*dst = &t
+			}
+		}
 		resp, err := svc.ListJobs(req)
+		if errors.Is(err, services.ErrInvalidJobRequest) {
+			c.JSON(400, models.ErrorResponse{Error: err.Error()})
+			return
+		}
 		if err != nil {
 			c.JSON(500, models.ErrorResponse{Error: err.Error()})
 			return
@@ -377,7 +853,7 @@ func ListJobsHandler(svc *services.JobService) gin.HandlerFunc {
 }
 
 // @Summary Query metrics
-// @Description Return metrics filtered by cluster and/or type (paginated)
+// @Description Return metrics of the caller's project's clusters, filtered by cluster and/or type (paginated)
 // @Tags monitoring
 // @Accept json
 // @Produce json
@@ -387,9 +863,10 @@ func ListJobsHandler(svc *services.JobService) gin.HandlerFunc {
 // @Param page_size query int false "Page size"
 // @Success 200 {object} models.GetMetricsResponse "Metrics result"
 // @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Cluster not found"
 // @Failure 500 {object} models.ErrorResponse "Server error"
 // @Router /metrics [get]
-func GetMetricsHandler(svc *services.MonitoringService) gin.HandlerFunc {
+func GetMetricsHandler(svc *services.MonitoringService, clusters *services.ClusterService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		clusterID := c.Query("cluster_id")
 		metricType := c.Query("type")
@@ -412,6 +889,24 @@ func GetMetricsHandler(svc *services.MonitoringService) gin.HandlerFunc {
 			Page:      page,
 			PageSize:  pageSize,
 		}
+		// only the caller's project: its named cluster, or else all of its clusters
+		projectID := middleware.ProjectFrom(c)
+		if clusterID != "" {
+			if _, err := clusters.GetCluster(projectID, clusterID); err != nil {
+				c.JSON(404, models.ErrorResponse{Error: "Cluster not found"})
+				return
+			}
+		} else {
+			list, err := clusters.ListClusters(projectID)
+			if err != nil {
+				c.JSON(500, models.ErrorResponse{Error: err.Error()})
+				return
+			}
+			req.ClusterIDs = make([]string, 0, len(list))
+			for _, cl := range list {
+				req.ClusterIDs = append(req.ClusterIDs, cl.ID)
+			}
+		}
 		resp, err := svc.GetMetrics(req)
 		if err != nil {
 			c.JSON(500, models.ErrorResponse{Error: err.Error()})
@@ -421,53 +916,385 @@ func GetMetricsHandler(svc *services.MonitoringService) gin.HandlerFunc {
 	}
 }
 
+// ==== API key handlers ====
+
+// @Summary Create an API key
+// @Description Issue an API key for a user with a role (viewer, operator or admin), optionally limited to API areas (scopes) and expiring. The key is only returned by this call; just its hash is stored.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.CreateAPIKeyRequest true "Key owner, role, scopes and expiry"
+// @Success 201 {object} models.CreateAPIKeyResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request or unknown project"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /auth/keys [post]
+func CreateAPIKeyHandler(svc *services.APIKeyService, projects *services.ProjectService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.CreateAPIKeyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if req.ProjectID != "" && projects != nil {
+			if _, err := projects.Get(req.ProjectID); err != nil {
+				c.JSON(400, models.ErrorResponse{Error: err.Error()})
+				return
+			}
+		}
+		createdBy := "anonymous"
+		if p, ok := middleware.PrincipalFrom(c); ok {
+			createdBy = p.UserID
+		}
+		k, key, err := svc.Create(req, createdBy)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidAPIKeyRequest) {
+				c.JSON(400, models.ErrorResponse{Error: err.Error()})
+				return
+			}
+			c.JSON(500, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(201, models.CreateAPIKeyResponse{Key: key, APIKey: k})
+	}
+}
+
+// @Summary List API keys
+// @Description All API keys, including revoked and expired ones; keys themselves are never returned
+// @Tags auth
+// @Produce json
+// @Success 200 {object} models.ListAPIKeysResponse
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /auth/keys [get]
+func ListAPIKeysHandler(svc *services.APIKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keys, err := svc.List()
+		if err != nil {
+			c.JSON(500, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, models.ListAPIKeysResponse{Keys: keys})
+	}
+}
+
+// @Summary Revoke an API key
+// @Description Disable an API key for good; requests using it get 401
+// @Tags auth
+// @Produce json
+// @Param id path string true "API key ID"
+// @Success 200 {object} models.APIKey
+// @Failure 404 {object} models.ErrorResponse "No such key"
+// @Router /auth/keys/{id} [delete]
+func RevokeAPIKeyHandler(svc *services.APIKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k, err := svc.Revoke(c.Param("id"))
+		if err != nil {
+			c.JSON(404, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, k)
+	}
+}
+
+// @Summary Current caller
+// @Description The principal authenticated by the request's API key, or authenticated false while authentication is disabled, and the project the request acts in
+// @Tags auth
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Principal"
+// @Failure 401 {object} models.ErrorResponse "Missing or invalid API key"
+// @Router /auth/whoami [get]
+func WhoAmIHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := middleware.PrincipalFrom(c)
+		if !ok {
+			c.JSON(200, gin.H{"authenticated": false, "project_id": middleware.ProjectFrom(c)})
+			return
+		}
+		c.JSON(200, gin.H{"authenticated": true, "principal": p, "project_id": middleware.ProjectFrom(c)})
+	}
+}
+
+// ==== Project handlers ====
+
+// @Summary Create a project
+// @Description Create a project. Its rate_limits override the project level of hierarchical rate limits, keyed by limiter name (e.g. jobs_create_project).
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param request body models.CreateProjectRequest true "Project name, description and rate limits"
+// @Success 201 {object} models.Project
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /projects [post]
+func CreateProjectHandler(svc *services.ProjectService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.CreateProjectRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		p, err := svc.Create(req)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidProjectRequest) {
+				c.JSON(400, models.ErrorResponse{Error: err.Error()})
+				return
+			}
+			c.JSON(500, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(201, p)
+	}
+}
+
+// @Summary List projects
+// @Tags projects
+// @Produce json
+// @Success 200 {object} models.ListProjectsResponse
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /projects [get]
+func ListProjectsHandler(svc *services.ProjectService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projects, err := svc.List()
+		if err != nil {
+			c.JSON(500, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, models.ListProjectsResponse{Projects: projects})
+	}
+}
+
+// @Summary Get a project
+// @Tags projects
+// @Produce json
+// @Param id path string true "Project ID"
+// @Success 200 {object} models.Project
+// @Failure 404 {object} models.ErrorResponse "No such project"
+// @Router /projects/{id} [get]
+func GetProjectHandler(svc *services.ProjectService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, err := svc.Get(c.Param("id"))
+		if err != nil {
+			c.JSON(404, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, p)
+	}
+}
+
+// @Summary Update a project
+// @Description Change a project's name, description or rate limits. rate_limits replaces all overrides; other instances apply the change within the limit sync interval.
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param request body models.UpdateProjectRequest true "Fields to change"
+// @Success 200 {object} models.Project
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 404 {object} models.ErrorResponse "No such project"
+// @Router /projects/{id} [put]
+func UpdateProjectHandler(svc *services.ProjectService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.UpdateProjectRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		p, err := svc.Update(c.Param("id"), req)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidProjectRequest) {
+				c.JSON(400, models.ErrorResponse{Error: err.Error()})
+				return
+			}
+			c.JSON(404, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, p)
+	}
+}
+
 // ==== Observability handlers ====
 
-// @Summary Query rate limiter status
-// @Description Get available tokens and configuration for a rate limiter (supports scope filters)
+// @Summary Query rate limiter status
+// @Description Get available tokens and configuration for a rate limiter (supports scope filters)
+// @Tags observability
+// @Accept json
+// @Produce json
+// @Param name query string true "Limiter name (e.g. diagnosis or jobs_create)"
+// @Param scope_type query string false "Optional scope type (global/user/cluster/project)"
+// @Param scope_id query string false "Optional scope id for user/cluster"
+// @Success 200 {object} map[string]interface{} "Limiter status"
+// @Failure 400 {object} models.ErrorResponse "Missing/invalid input"
+// @Failure 404 {object} models.ErrorResponse "No such limiter"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /observability/ratelimit [get]
+func GetRateLimiterStatusHandler(limiter *services.LimiterManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Query("name")
+		if name == "" {
+			c.JSON(400, models.ErrorResponse{Error: "name query param required (e.g. diagnosis or jobs_create)"})
+			return
+		}
+		scopeType := c.Query("scope_type")
+		scopeId := c.Query("scope_id")
+		var b services.RateLimiter
+		if scopeType != "" && scopeId != "" {
+			scopeKey := scopeId
+			if scopeType == "user" {
+				scopeKey = "user:" + scopeId
+			} else if scopeType == "cluster" {
+				scopeKey = "cluster:" + scopeId
+			} else if scopeType == "project" {
+				scopeKey = services.ProjectLimiterScope(scopeId)
+			}
+			b = limiter.GetOrCreate(name, scopeKey)
+		} else {
+			b = limiter.Get(name)
+		}
+		if b == nil {
+			c.JSON(404, models.ErrorResponse{Error: "no such limiter"})
+			return
+		}
+		available, capacity, rate := b.Status()
+		c.JSON(200, gin.H{"name": name, "available": available, "capacity": capacity, "rate_per_sec": rate})
+	}
+}
+
+// @Summary Simulate a rate limit config
+// @Description Replay a request timeline against a candidate refill_rate/capacity/algorithm and count how many requests would have been accepted or rejected. Without requests, the last requests seen by the named limiter are replayed; omitted config fields fall back to that limiter's current defaults. Each scope gets its own bucket.
+// @Tags observability
+// @Accept json
+// @Produce json
+// @Param request body models.RateLimitSimulationRequest true "Candidate config and timeline"
+// @Success 200 {object} models.RateLimitSimulationResult
+// @Failure 400 {object} models.ErrorResponse "Invalid config or empty timeline"
+// @Router /observability/ratelimit/simulate [post]
+func SimulateRateLimitHandler(limiter *services.LimiterManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.RateLimitSimulationRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		res, err := limiter.Simulate(req)
+		if err != nil {
+			c.JSON(400, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, res)
+	}
+}
+
+// @Summary List quotas
+// @Description Configured long-window quotas: per user or per cluster, per calendar day or month
+// @Tags observability
+// @Produce json
+// @Success 200 {object} models.ListQuotasResponse
+// @Router /observability/quotas [get]
+func ListQuotasHandler(quotas *services.QuotaManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(200, models.ListQuotasResponse{Quotas: quotas.Rules()})
+	}
+}
+
+// @Summary Quota usage
+// @Description Used and remaining units in the current window of every quota of a scope (or only the named quota)
+// @Tags observability
+// @Produce json
+// @Param scope_type query string true "user or cluster"
+// @Param scope_id query string true "User or cluster id"
+// @Param name query string false "Quota name"
+// @Success 200 {object} models.QuotaUsageResponse
+// @Failure 400 {object} models.ErrorResponse "Missing/invalid input"
+// @Failure 404 {object} models.ErrorResponse "No such quota"
+// @Failure 503 {object} models.ErrorResponse "Quota store unavailable"
+// @Router /observability/quotas/usage [get]
+func QuotaUsageHandler(quotas *services.QuotaManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopeType := c.Query("scope_type")
+		scopeID := c.Query("scope_id")
+		if (scopeType != "user" && scopeType != "cluster") || scopeID == "" {
+			c.JSON(400, models.ErrorResponse{Error: "scope_type (user or cluster) and scope_id query params required"})
+			return
+		}
+		name := c.Query("name")
+		if name != "" {
+			if rule, ok := quotas.Rule(name); !ok || rule.Scope != scopeType {
+				c.JSON(404, models.ErrorResponse{Error: "no such " + scopeType + " quota"})
+				return
+			}
+		}
+		resp := models.QuotaUsageResponse{Usage: []models.QuotaUsage{}}
+		for _, rule := range quotas.Rules() {
+			if rule.Scope != scopeType || (name != "" && rule.Name != name) {
+				continue
+			}
+			usage, err := quotas.Usage(rule.Name, scopeID)
+			if err != nil {
+				c.JSON(503, models.ErrorResponse{Error: err.Error()})
+				return
+			}
+			resp.Usage = append(resp.Usage, usage)
+		}
+		c.JSON(200, resp)
+	}
+}
+
+// @Summary List concurrency limits
+// @Description Configured limits on operations running at once per user or cluster
+// @Tags observability
+// @Produce json
+// @Success 200 {object} models.ListConcurrencyLimitsResponse
+// @Router /observability/concurrency [get]
+func ListConcurrencyLimitsHandler(limiter *services.ConcurrencyLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(200, models.ListConcurrencyLimitsResponse{Limits: limiter.Rules()})
+	}
+}
+
+// @Summary Concurrency usage
+// @Description Operations currently running for a user or cluster under every concurrency limit of that scope (or only the named one)
 // @Tags observability
-// @Accept json
 // @Produce json
-// @Param name query string true "Limiter name (e.g. diagnosis or jobs_create)"
-// @Param scope_type query string false "Optional scope type (global/user/cluster)"
-// @Param scope_id query string false "Optional scope id for user/cluster"
-// @Success 200 {object} map[string]interface{} "Limiter status"
+// @Param scope_type query string true "user or cluster"
+// @Param scope_id query string true "User or cluster id"
+// @Param name query string false "Concurrency limit name"
+// @Success 200 {object} models.ConcurrencyUsageResponse
 // @Failure 400 {object} models.ErrorResponse "Missing/invalid input"
-// @Failure 404 {object} models.ErrorResponse "No such limiter"
-// @Failure 500 {object} models.ErrorResponse "Server error"
-// @Router /observability/ratelimit [get]
-func GetRateLimiterStatusHandler(limiter *services.LimiterManager) gin.HandlerFunc {
+// @Failure 404 {object} models.ErrorResponse "No such concurrency limit"
+// @Failure 503 {object} models.ErrorResponse "Concurrency store unavailable"
+// @Router /observability/concurrency/usage [get]
+func ConcurrencyUsageHandler(limiter *services.ConcurrencyLimiter) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		name := c.Query("name")
-		if name == "" {
-			c.JSON(400, models.ErrorResponse{Error: "name query param required (e.g. diagnosis or jobs_create)"})
+		scopeType := c.Query("scope_type")
+		scopeID := c.Query("scope_id")
+		if (scopeType != "user" && scopeType != "cluster") || scopeID == "" {
+			c.JSON(400, models.ErrorResponse{Error: "scope_type (user or cluster) and scope_id query params required"})
 			return
 		}
-		scopeType := c.Query("scope_type")
-		scopeId := c.Query("scope_id")
-		var b services.RateLimiter
-		if scopeType != "" && scopeId != "" {
-			scopeKey := scopeId
-			if scopeType == "user" {
-				scopeKey = "user:" + scopeId
-			} else if scopeType == "cluster" {
-				scopeKey = "cluster:" + scopeId
+		name := c.Query("name")
+		if name != "" {
+			if rule, ok := limiter.Rule(name); !ok || rule.Scope != scopeType {
+				c.JSON(404, models.ErrorResponse{Error: "no such " + scopeType + " concurrency limit"})
+				return
 			}
-			b = limiter.GetOrCreate(name, scopeKey)
-		} else {
-			b = limiter.Get(name)
 		}
-		if b == nil {
-			c.JSON(404, models.ErrorResponse{Error: "no such limiter"})
-			return
+		resp := models.ConcurrencyUsageResponse{Usage: []models.ConcurrencyUsage{}}
+		for _, rule := range limiter.Rules() {
+			if rule.Scope != scopeType || (name != "" && rule.Name != name) {
+				continue
+			}
+			usage, err := limiter.Usage(rule.Name, scopeID)
+			if err != nil {
+				c.JSON(503, models.ErrorResponse{Error: err.Error()})
+				return
+			}
+			resp.Usage = append(resp.Usage, usage)
 		}
-		available, capacity, rate := b.Status()
-		c.JSON(200, gin.H{"name": name, "available": available, "capacity": capacity, "rate_per_sec": rate})
+		c.JSON(200, resp)
 	}
 }
 
 // @Summary Persist limiter configuration
-// @Description Store or update refill/capacity for a named limiter and scope
+// @Description Store or update refill/capacity, algorithm (token_bucket, sliding_window_log, sliding_window_counter, gcra), window and failure mode (fail_closed, fail_open, local) for a named limiter and scope
 // @Tags observability
 // @Accept json
 // @Produce json
@@ -485,6 +1312,9 @@ func SaveLimiterConfigHandler(redisClient *redis.Client) gin.HandlerFunc {
 			ScopeID   string  `json:"scope_id"`
 			Refill    float64 `json:"refill_rate"`
 			Capacity  float64 `json:"capacity"`
+			Algorithm string  `json:"algorithm"`
+			Window    float64 `json:"window_seconds"`
+			Failure   string  `json:"failure_mode"`
 		}
 		if err := c.ShouldBindJSON(&body); err != nil {
 			c.JSON(400, models.ErrorResponse{Error: err.Error()})
@@ -504,6 +1334,8 @@ func SaveLimiterConfigHandler(redisClient *redis.Client) gin.HandlerFunc {
 			scopeKey = "user:" + body.ScopeID
 		} else if body.ScopeType == "cluster" && body.ScopeID != "" {
 			scopeKey = "cluster:" + body.ScopeID
+		} else if body.ScopeType == "project" && body.ScopeID != "" {
+			scopeKey = services.ProjectLimiterScope(body.ScopeID)
 		}
 
 		cfgKey := fmt.Sprintf("limiter_config:%s:%s", body.Name, scopeKey)
@@ -514,8 +1346,25 @@ func SaveLimiterConfigHandler(redisClient *redis.Client) gin.HandlerFunc {
 		if body.Capacity > 0 {
 			m["capacity"] = fmt.Sprintf("%f", body.Capacity)
 		}
+		if body.Algorithm != "" {
+			if !services.ValidLimiterAlgorithm(body.Algorithm) {
+				c.JSON(400, models.ErrorResponse{Error: "algorithm must be token_bucket, sliding_window_log, sliding_window_counter or gcra"})
+				return
+			}
+			m["algorithm"] = body.Algorithm
+		}
+		if body.Window > 0 {
+			m["window_seconds"] = fmt.Sprintf("%f", body.Window)
+		}
+		if body.Failure != "" {
+			if !services.ValidFailureMode(body.Failure) {
+				c.JSON(400, models.ErrorResponse{Error: "failure_mode must be fail_closed, fail_open or local"})
+				return
+			}
+			m["failure_mode"] = body.Failure
+		}
 		if len(m) == 0 {
-			c.JSON(400, models.ErrorResponse{Error: "refill_rate or capacity required"})
+			c.JSON(400, models.ErrorResponse{Error: "refill_rate, capacity, algorithm, window_seconds or failure_mode required"})
 			return
 		}
 		// Attempt to call HSet on the provided redis client - this is intentionally generic
@@ -523,10 +1372,20 @@ func SaveLimiterConfigHandler(redisClient *redis.Client) gin.HandlerFunc {
 			c.JSON(500, models.ErrorResponse{Error: err.Error()})
 			return
 		}
+		publishLimiterConfigChange(c, redisClient, body.Name, scopeKey)
 		c.JSON(200, gin.H{"ok": true, "config_key": cfgKey})
 	}
 }
 
+// publishLimiterConfigChange tells every instance to rebuild the buckets using the config.
+// The config itself is already stored, so a failed publish only delays it until the
+// instances resubscribe.
+func publishLimiterConfigChange(c *gin.Context, redisClient *redis.Client, name, scope string) {
+	if err := services.PublishLimiterConfigChange(c.Request.Context(), redisClient, name, scope); err != nil {
+		logger.Warnf("publishing limiter config change for %s:%s failed: %v", name, scope, err)
+	}
+}
+
 // @Summary Get persisted limiter config
 // @Description Retrieve persisted limiter config for a name and optional scope
 // @Tags observability
@@ -558,6 +1417,8 @@ func GetLimiterConfigHandler(redisClient *redis.Client) gin.HandlerFunc {
 			scopeKey = "user:" + scopeId
 		} else if scopeType == "cluster" && scopeId != "" {
 			scopeKey = "cluster:" + scopeId
+		} else if scopeType == "project" && scopeId != "" {
+			scopeKey = services.ProjectLimiterScope(scopeId)
 		}
 		cfgKey := fmt.Sprintf("limiter_config:%s:%s", name, scopeKey)
 		vals, err := redisClient.HGetAll(c.Request.Context(), cfgKey).Result()
@@ -604,6 +1465,8 @@ func ListLimiterConfigHandler(redisClient *redis.Client) gin.HandlerFunc {
 					scopeKey = "user:" + scopeId
 				} else if scopeType == "cluster" {
 					scopeKey = "cluster:" + scopeId
+				} else if scopeType == "project" {
+					scopeKey = services.ProjectLimiterScope(scopeId)
 				}
 				pattern = "limiter_config:" + nameFilter + ":" + scopeKey
 			} else {
@@ -680,6 +1543,8 @@ func DeleteLimiterConfigHandler(redisClient *redis.Client) gin.HandlerFunc {
 				scopeKey = "user:" + body.ScopeID
 			} else if body.ScopeType == "cluster" && body.ScopeID != "" {
 				scopeKey = "cluster:" + body.ScopeID
+			} else if body.ScopeType == "project" && body.ScopeID != "" {
+				scopeKey = services.ProjectLimiterScope(body.ScopeID)
 			}
 			cfgKey = "limiter_config:" + body.Name + ":" + scopeKey
 		}
@@ -688,13 +1553,16 @@ func DeleteLimiterConfigHandler(redisClient *redis.Client) gin.HandlerFunc {
 			c.JSON(500, models.ErrorResponse{Error: err.Error()})
 			return
 		}
+		if name, scope, ok := services.ParseLimiterConfigKey(cfgKey); ok {
+			publishLimiterConfigChange(c, redisClient, name, scope)
+		}
 
 		c.JSON(200, gin.H{"ok": true, "deleted": cfgKey})
 	}
 }
 
 // @Summary Worker pool status
-// @Description Snapshot of worker pool (counts, queue) for observability
+// @Description Snapshot of worker pool (counts, scheduling mode, queue depth per priority and tenant) for observability
 // @Tags observability
 // @Accept json
 // @Produce json
@@ -702,13 +1570,71 @@ func DeleteLimiterConfigHandler(redisClient *redis.Client) gin.HandlerFunc {
 // @Router /observability/workerpool [get]
 func WorkerPoolHandler(pool *services.WorkerPool) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"worker_count":   pool.WorkerCount(),
-			"active_workers": pool.ActiveWorkers(),
-			"queue_length":   pool.QueueLength(),
-			"queue_capacity": pool.QueueCapacity(),
-			"queued_ids":     pool.SnapshotQueue(),
-		})
+		c.JSON(200, workerPoolSnapshot(pool))
+	}
+}
+
+func workerPoolSnapshot(pool *services.WorkerPool) gin.H {
+	return gin.H{
+		"worker_count":   pool.WorkerCount(),
+		"active_workers": pool.ActiveWorkers(),
+		"queue_length":   pool.QueueLength(),
+		"queue_capacity": pool.QueueCapacity(),
+		"queued_ids":     pool.SnapshotQueue(),
+		"scheduling":     pool.SchedulingMode(),
+		"by_priority":    pool.QueueDepthByPriority(),
+		"by_tenant":      pool.QueueDepthByTenant(),
+		"draining":       pool.Draining(),
+	}
+}
+
+// ResizeWorkerPoolRequest is the body of PUT /observability/workerpool/workers.
+type ResizeWorkerPoolRequest struct {
+	Workers int `json:"workers" binding:"required,min=1,max=1024"`
+}
+
+// ResizeWorkerPoolHandler changes the number of workers at runtime.
+func ResizeWorkerPoolHandler(pool *services.WorkerPool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ResizeWorkerPoolRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		pool.Resize(req.Workers)
+		c.JSON(200, workerPoolSnapshot(pool))
+	}
+}
+
+// DrainWorkerPoolHandler puts the pool in drain mode and waits for running jobs.
+// It answers 202 when jobs are still running after timeout_seconds; the pool stays
+// draining either way until resumed.
+func DrainWorkerPoolHandler(pool *services.WorkerPool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := 30
+		if v := c.Query("timeout_seconds"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				c.JSON(400, models.ErrorResponse{Error: "timeout_seconds must be a non-negative integer"})
+				return
+			}
+			timeout = n
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), time.Duration(timeout)*time.Second)
+		defer cancel()
+		if err := pool.Drain(ctx); err != nil {
+			c.JSON(202, workerPoolSnapshot(pool))
+			return
+		}
+		c.JSON(200, workerPoolSnapshot(pool))
+	}
+}
+
+// ResumeWorkerPoolHandler leaves drain mode.
+func ResumeWorkerPoolHandler(pool *services.WorkerPool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pool.Resume()
+		c.JSON(200, workerPoolSnapshot(pool))
 	}
 }
 
@@ -730,6 +1656,7 @@ func CreateAutoscalePolicyHandler(svc *services.AutoscalerService) gin.HandlerFu
 			c.JSON(400, models.ErrorResponse{Error: err.Error()})
 			return
 		}
+		req.ProjectID = middleware.ProjectFrom(c)
 		p, err := svc.CreatePolicy(&req)
 		if err != nil {
 			c.JSON(500, models.ErrorResponse{Error: err.Error()})
@@ -755,7 +1682,7 @@ func ListAutoscalePoliciesHandler(svc *services.AutoscalerService) gin.HandlerFu
 			c.JSON(400, models.ErrorResponse{Error: "cluster_id required"})
 			return
 		}
-		items, err := svc.ListPolicies(clusterID)
+		items, err := svc.ListPolicies(middleware.ProjectFrom(c), clusterID)
 		if err != nil {
 			c.JSON(500, models.ErrorResponse{Error: err.Error()})
 			return
@@ -776,7 +1703,7 @@ func ListAutoscalePoliciesHandler(svc *services.AutoscalerService) gin.HandlerFu
 func GetAutoscalePolicyHandler(svc *services.AutoscalerService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
-		p, err := svc.GetPolicy(id)
+		p, err := svc.GetPolicy(middleware.ProjectFrom(c), id)
 		if err != nil {
 			c.JSON(404, models.ErrorResponse{Error: "policy not found"})
 			return
@@ -803,7 +1730,7 @@ func UpdateAutoscalePolicyHandler(svc *services.AutoscalerService) gin.HandlerFu
 			c.JSON(400, models.ErrorResponse{Error: err.Error()})
 			return
 		}
-		p, err := svc.UpdatePolicy(id, &req)
+		p, err := svc.UpdatePolicy(middleware.ProjectFrom(c), id, &req)
 		if err != nil {
 			c.JSON(500, models.ErrorResponse{Error: err.Error()})
 			return
@@ -824,7 +1751,7 @@ func UpdateAutoscalePolicyHandler(svc *services.AutoscalerService) gin.HandlerFu
 func DeleteAutoscalePolicyHandler(svc *services.AutoscalerService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
-		if err := svc.DeletePolicy(id); err != nil {
+		if err := svc.DeletePolicy(middleware.ProjectFrom(c), id); err != nil {
 			c.JSON(500, models.ErrorResponse{Error: err.Error()})
 			return
 		}
@@ -848,7 +1775,7 @@ func EvaluateAutoscalingHandler(svc *services.AutoscalerService) gin.HandlerFunc
 			c.JSON(400, models.ErrorResponse{Error: "cluster_id required"})
 			return
 		}
-		res, err := svc.EvaluatePolicies(clusterID)
+		res, err := svc.EvaluatePolicies(middleware.ProjectFrom(c), clusterID)
 		if err != nil {
 			c.JSON(500, models.ErrorResponse{Error: err.Error()})
 			return
@@ -866,7 +1793,9 @@ func EvaluateAutoscalingHandler(svc *services.AutoscalerService) gin.HandlerFunc
 // @Param request body models.StartDeploymentRequest true "Start deployment"
 // @Success 201 {object} models.Deployment
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse "Too many deployments running for the cluster"
 // @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse "Concurrency store unavailable"
 // @Router /deployments/start [post]
 func StartDeploymentHandler(svc *services.DeploymentService) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -875,7 +1804,16 @@ func StartDeploymentHandler(svc *services.DeploymentService) gin.HandlerFunc {
 			c.JSON(400, models.ErrorResponse{Error: err.Error()})
 			return
 		}
+		req.ProjectID = middleware.ProjectFrom(c)
 		d, err := svc.StartDeployment(&req)
+		if errors.Is(err, services.ErrConcurrencyLimited) {
+			c.JSON(429, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrConcurrencyUnavailable) {
+			c.JSON(503, models.ErrorResponse{Error: err.Error()})
+			return
+		}
 		if err != nil {
 			c.JSON(500, models.ErrorResponse{Error: err.Error()})
 			return
@@ -896,7 +1834,7 @@ func StartDeploymentHandler(svc *services.DeploymentService) gin.HandlerFunc {
 func GetDeploymentHandler(svc *services.DeploymentService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
-		d, err := svc.GetDeployment(id)
+		d, err := svc.GetDeployment(middleware.ProjectFrom(c), id)
 		if err != nil {
 			c.JSON(404, models.ErrorResponse{Error: "not found"})
 			return
@@ -921,7 +1859,7 @@ func ListDeploymentsHandler(svc *services.DeploymentService) gin.HandlerFunc {
 			c.JSON(400, models.ErrorResponse{Error: "cluster_id required"})
 			return
 		}
-		items, err := svc.ListDeployments(clusterID)
+		items, err := svc.ListDeployments(middleware.ProjectFrom(c), clusterID)
 		if err != nil {
 			c.JSON(500, models.ErrorResponse{Error: err.Error()})
 			return
@@ -942,7 +1880,7 @@ func ListDeploymentsHandler(svc *services.DeploymentService) gin.HandlerFunc {
 func RollbackDeploymentHandler(svc *services.DeploymentService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
-		if err := svc.RollbackDeployment(id); err != nil {
+		if err := svc.RollbackDeployment(middleware.ProjectFrom(c), id); err != nil {
 			c.JSON(500, models.ErrorResponse{Error: err.Error()})
 			return
 		}
@@ -1036,7 +1974,7 @@ func MigrateHandler(svc *services.SchedulerService) gin.HandlerFunc {
 			c.JSON(400, models.ErrorResponse{Error: err.Error()})
 			return
 		}
-		if err := svc.MigrateDroplet(body.DropletID, body.TargetProvider); err != nil {
+		if err := svc.MigrateDroplet(middleware.ProjectFrom(c), body.DropletID, body.TargetProvider); err != nil {
 			c.JSON(500, models.ErrorResponse{Error: err.Error()})
 			return
 		}
@@ -1062,7 +2000,7 @@ func EstimateClusterCostHandler(svc *services.BillingService) gin.HandlerFunc {
 			c.JSON(400, models.ErrorResponse{Error: "cluster_id required"})
 			return
 		}
-		r, err := svc.EstimateClusterCost(clusterID)
+		r, err := svc.EstimateClusterCost(middleware.ProjectFrom(c), clusterID)
 		if err != nil {
 			c.JSON(500, models.ErrorResponse{Error: err.Error()})
 			return
@@ -1070,3 +2008,406 @@ func EstimateClusterCostHandler(svc *services.BillingService) gin.HandlerFunc {
 		c.JSON(200, r)
 	}
 }
+
+// @Summary Provider usage of the project
+// @Description Droplets the caller's project runs on each provider and their hourly cost
+// @Tags billing
+// @Produce json
+// @Success 200 {object} models.ProviderUsageResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /providers/usage [get]
+func ProviderUsageHandler(svc *services.BillingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID := middleware.ProjectFrom(c)
+		usage, err := svc.ProviderUsage(projectID)
+		if err != nil {
+			c.JSON(500, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, models.ProviderUsageResponse{ProjectID: projectID, Usage: usage})
+	}
+}
+
+// ========== Workflow handlers ==========
+
+// @Summary Create workflow
+// @Description Submit a DAG of jobs. Each job has a key; depends_on lists parent keys. Children stay blocked until every parent completes and are skipped when a parent fails or is cancelled.
+// @Tags workflows
+// @Accept json
+// @Produce json
+// @Param request body models.CreateWorkflowRequest true "Workflow definition"
+// @Success 201 {object} models.WorkflowResponse "Workflow created"
+// @Failure 400 {object} models.ErrorResponse "Invalid workflow (unknown key, cycle, invalid type)"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /workflows [post]
+func CreateWorkflowHandler(svc *services.WorkflowService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.CreateWorkflowRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		req.TenantID = tenantFromRequest(c)
+		req.ProjectID = middleware.ProjectFrom(c)
+		resp, err := svc.CreateWorkflow(&req)
+		if errors.Is(err, services.ErrInvalidWorkflow) {
+			c.JSON(400, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(500, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(201, resp)
+	}
+}
+
+// @Summary Get workflow
+// @Description Returns the workflow, its jobs (with depends_on) and per-status counts
+// @Tags workflows
+// @Produce json
+// @Param id path string true "Workflow ID"
+// @Success 200 {object} models.WorkflowResponse "Workflow state"
+// @Failure 404 {object} models.ErrorResponse "Workflow not found"
+// @Router /workflows/{id} [get]
+func GetWorkflowHandler(svc *services.WorkflowService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp, err := svc.GetWorkflow(middleware.ProjectFrom(c), c.Param("id"))
+		if err != nil {
+			c.JSON(404, models.ErrorResponse{Error: "Workflow not found"})
+			return
+		}
+		c.JSON(200, resp)
+	}
+}
+
+// ========== Schedule handlers ==========
+
+// @Summary Create schedule
+// @Description Create a cron schedule that submits a job on every tick. overlap_policy decides what happens while the previous run is still active: skip (default), queue (run once it finishes) or replace (cancel it).
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param request body models.CreateScheduleRequest true "Schedule definition"
+// @Success 201 {object} models.ScheduleResponse "Schedule created"
+// @Failure 400 {object} models.ErrorResponse "Invalid cron expression, timezone, job type, overlap policy or a cluster outside the project"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /schedules [post]
+func CreateScheduleHandler(svc *services.JobScheduleService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.CreateScheduleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		req.ProjectID = middleware.ProjectFrom(c)
+		sched, err := svc.CreateSchedule(&req)
+		if errors.Is(err, services.ErrInvalidSchedule) {
+			c.JSON(400, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(500, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(201, &models.ScheduleResponse{Schedule: sched, Message: "Schedule created"})
+	}
+}
+
+// @Summary List schedules
+// @Tags schedules
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Schedules"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /schedules [get]
+func ListSchedulesHandler(svc *services.JobScheduleService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list, err := svc.ListSchedules(middleware.ProjectFrom(c))
+		if err != nil {
+			c.JSON(500, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, gin.H{"schedules": list})
+	}
+}
+
+// @Summary Get schedule
+// @Tags schedules
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Success 200 {object} models.ScheduleResponse "Schedule"
+// @Failure 404 {object} models.ErrorResponse "Schedule not found"
+// @Router /schedules/{id} [get]
+func GetScheduleHandler(svc *services.JobScheduleService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sched, err := svc.GetSchedule(middleware.ProjectFrom(c), c.Param("id"))
+		if err != nil {
+			c.JSON(404, models.ErrorResponse{Error: "Schedule not found"})
+			return
+		}
+		c.JSON(200, &models.ScheduleResponse{Schedule: sched, Message: "Schedule retrieved"})
+	}
+}
+
+// @Summary Update schedule
+// @Description Partially update a schedule; the next run is recomputed
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Param request body models.UpdateScheduleRequest true "Fields to change"
+// @Success 200 {object} models.ScheduleResponse "Schedule updated"
+// @Failure 400 {object} models.ErrorResponse "Invalid schedule"
+// @Failure 404 {object} models.ErrorResponse "Schedule not found"
+// @Router /schedules/{id} [put]
+func UpdateScheduleHandler(svc *services.JobScheduleService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.UpdateScheduleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		projectID, id := middleware.ProjectFrom(c), c.Param("id")
+		if _, err := svc.GetSchedule(projectID, id); err != nil {
+			c.JSON(404, models.ErrorResponse{Error: "Schedule not found"})
+			return
+		}
+		sched, err := svc.UpdateSchedule(projectID, id, &req)
+		if errors.Is(err, services.ErrInvalidSchedule) {
+			c.JSON(400, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(500, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, &models.ScheduleResponse{Schedule: sched, Message: "Schedule updated"})
+	}
+}
+
+// @Summary Delete schedule
+// @Tags schedules
+// @Param id path string true "Schedule ID"
+// @Success 204 "Deleted"
+// @Failure 404 {object} models.ErrorResponse "Schedule not found"
+// @Router /schedules/{id} [delete]
+func DeleteScheduleHandler(svc *services.JobScheduleService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := svc.DeleteSchedule(middleware.ProjectFrom(c), c.Param("id")); err != nil {
+			c.JSON(404, models.ErrorResponse{Error: "Schedule not found"})
+			return
+		}
+		c.Status(204)
+	}
+}
+
+// ========== Event stream handlers ==========
+
+// eventFilterFromQuery builds a broker filter from job_id, cluster_id, trace_id and type query params,
+// limited to the caller's project. type may be repeated or comma-separated.
+func eventFilterFromQuery(c *gin.Context) events.Filter {
+	f := events.Filter{
+		JobID:     c.Query("job_id"),
+		ClusterID: c.Query("cluster_id"),
+		ProjectID: middleware.ProjectFrom(c),
+		TraceID:   c.Query("trace_id"),
+	}
+	for _, t := range c.QueryArray("type") {
+		for _, part := range strings.Split(t, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				f.Types = append(f.Types, part)
+			}
+		}
+	}
+	return f
+}
+
+// lastEventIDFromRequest reads the resume point from the Last-Event-ID header (set by
+// EventSource on reconnect) or the last_event_id query param.
+func lastEventIDFromRequest(c *gin.Context) uint64 {
+	v := c.GetHeader("Last-Event-ID")
+	if v == "" {
+		v = c.Query("last_event_id")
+	}
+	if v == "" {
+		return 0
+	}
+	id, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+func heartbeatEvent() events.Event {
+	return events.Event{Type: "heartbeat", Timestamp: time.Now().UTC()}
+}
+
+// resyncEvent tells a resuming client that events were evicted from the replay buffer.
+func resyncEvent() events.Event {
+	return events.Event{Type: "resync", Message: "replay buffer no longer holds all missed events", Timestamp: time.Now().UTC()}
+}
+
+// writeSSE writes a single event in text/event-stream framing. Events with an ID set
+// the SSE id so browsers send it back as Last-Event-ID on reconnect.
+func writeSSE(w io.Writer, e events.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if e.ID > 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", e.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+	return err
+}
+
+// @Summary Stream events (SSE)
+// @Description Server-Sent Events stream of job and cluster events of the caller's project. Supports Last-Event-ID resume from a bounded replay buffer and emits heartbeat events.
+// @Tags events
+// @Produce text/event-stream
+// @Param job_id query string false "Only events for this job"
+// @Param cluster_id query string false "Only events for this cluster"
+// @Param type query string false "Event type(s), comma-separated"
+// @Param trace_id query string false "Only events with this trace id"
+// @Param last_event_id query int false "Resume after this event id (alternative to Last-Event-ID header)"
+// @Success 200 {string} string "event stream"
+// @Router /events/stream [get]
+func EventStreamHandler(broker *events.Broker, heartbeat time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sub, backlog, missed := broker.SubscribeFiltered(eventFilterFromQuery(c), lastEventIDFromRequest(c))
+		defer sub.Close()
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(200)
+
+		if missed {
+			_ = writeSSE(c.Writer, resyncEvent())
+		}
+		for _, e := range backlog {
+			if err := writeSSE(c.Writer, e); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case e, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				if err := writeSSE(c.Writer, e); err != nil {
+					return
+				}
+				c.Writer.Flush()
+			case <-ticker.C:
+				if err := writeSSE(c.Writer, heartbeatEvent()); err != nil {
+					return
+				}
+				c.Writer.Flush()
+			}
+		}
+	}
+}
+
+var eventUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// CORS is already open for the REST API (cors.Default), keep websocket consistent
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// @Summary Stream events (WebSocket)
+// @Description WebSocket equivalent of /events/stream. Each frame is a JSON event; heartbeat frames have type "heartbeat".
+// @Tags events
+// @Param job_id query string false "Only events for this job"
+// @Param cluster_id query string false "Only events for this cluster"
+// @Param type query string false "Event type(s), comma-separated"
+// @Param trace_id query string false "Only events with this trace id"
+// @Param last_event_id query int false "Resume after this event id"
+// @Success 101 {string} string "switching protocols"
+// @Router /events/ws [get]
+func EventWebSocketHandler(broker *events.Broker, heartbeat time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		conn, err := eventUpgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			// Upgrade already replied with an HTTP error
+			return
+		}
+		defer conn.Close()
+
+		sub, backlog, missed := broker.SubscribeFiltered(eventFilterFromQuery(c), lastEventIDFromRequest(c))
+		defer sub.Close()
+
+		// read pump: we don't expect client messages, but reading is required to
+		// process control frames and notice when the client goes away
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		writeTimeout := 10 * time.Second
+		send := func(e events.Event) error {
+			_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			return conn.WriteJSON(e)
+		}
+
+		if missed {
+			if err := send(resyncEvent()); err != nil {
+				return
+			}
+		}
+		for _, e := range backlog {
+			if err := send(e); err != nil {
+				return
+			}
+		}
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-closed:
+				return
+			case e, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				if err := send(e); err != nil {
+					return
+				}
+			case <-ticker.C:
+				if err := send(heartbeatEvent()); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// @Summary Event broker status
+// @Description Subscriber count, replay buffer usage and per-subscriber drop counters
+// @Tags observability
+// @Produce json
+// @Success 200 {object} events.BrokerStats "Broker snapshot"
+// @Router /observability/events [get]
+func EventBrokerStatsHandler(broker *events.Broker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(200, broker.Stats())
+	}
+}
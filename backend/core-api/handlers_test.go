@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/middleware"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/repositories"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestBulkActionCost_ChargesMatchedJobs(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed open sqlite: %v", err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.SetMaxOpenConns(1)
+	}
+	if err := db.AutoMigrate(&models.Job{}, &models.JobDependency{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	for i, cluster := range []string{"cluster-a", "cluster-a", "cluster-b"} {
+		job := &models.Job{ID: "job-" + string(rune('a'+i)), ProjectID: models.DefaultProjectID, ClusterID: cluster, Type: "monitor", Status: "pending", CreatedAt: time.Now()}
+		if err := db.Create(job).Error; err != nil {
+			t.Fatalf("create job failed: %v", err)
+		}
+	}
+	jobSvc := services.NewJobService(repositories.NewJobRepository(db, nil), nil)
+
+	// the default jobs_create config: 3 tokens, far below the 100 jobs a filter may select
+	limiter := services.NewLimiterManager(nil)
+	limiter.AddDefaultConfig("jobs_create", services.BucketConfig{RefillRate: 0.1, Capacity: 3})
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/jobs/bulk/cancel", middleware.RateLimitCost(bulkActionCost(jobSvc.CancelTargets)),
+		routeRateLimit(limiter, "jobs_create", "user"), BulkCancelJobsHandler(jobSvc))
+	cancel := func(cluster string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/jobs/bulk/cancel", strings.NewReader(`{"filter":{"cluster_id":"`+cluster+`"}}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", "alice")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := cancel("cluster-b"); w.Code != 200 || w.Header().Get("RateLimit-Remaining") != "2" {
+		t.Fatalf("expected a filter matching one job to cost one token, got %d remaining=%s %s", w.Code, w.Header().Get("RateLimit-Remaining"), w.Body)
+	}
+	if w := cancel("cluster-a"); w.Code != 200 || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("expected a filter matching two jobs to cost two tokens, got %d remaining=%s %s", w.Code, w.Header().Get("RateLimit-Remaining"), w.Body)
+	}
+	if w := cancel("cluster-a"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 once the bucket is empty, got %d", w.Code)
+	}
+}
+
+func TestProvisionJobsQuota_ChargesAnonymousCallers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/jobs", nil)
+	charges := provisionJobsQuota(c, []byte(`{"type":"provision","parameters":{"cluster_id":"c1"}}`))
+	if len(charges) != 1 || charges[0].ScopeID != services.AnonymousQuotaScope || charges[0].Units != 1 {
+		t.Fatalf("expected a request without X-User-ID to charge the anonymous quota, got %+v", charges)
+	}
+}
+
+func TestMetricsAndHealth_ScopedToProject(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed open sqlite: %v", err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.SetMaxOpenConns(1)
+	}
+	if err := db.AutoMigrate(&models.Metric{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	for _, cluster := range []string{"cluster-a", "cluster-b"} {
+		m := &models.Metric{ID: "m-" + cluster, ClusterID: cluster, Type: "cpu", Value: 10, Timestamp: time.Now()}
+		if err := db.Create(m).Error; err != nil {
+			t.Fatalf("create metric failed: %v", err)
+		}
+	}
+	monitoring := services.NewMonitoringService(repositories.NewMetricRepository(db, nil))
+	clusters := services.NewClusterService(memClusterRepo{
+		"cluster-a": {ID: "cluster-a", ProjectID: "team-a"},
+		"cluster-b": {ID: "cluster-b", ProjectID: "team-b"},
+	})
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ProjectScope(nil, "X-Project-ID"))
+	r.GET("/metrics", GetMetricsHandler(monitoring, clusters))
+	r.GET("/health/:clusterId", HealthCheckHandler(monitoring, clusters))
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Project-ID", "team-a")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/metrics")
+	var resp models.GetMetricsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); w.Code != 200 || err != nil {
+		t.Fatalf("expected metrics, got %d %s", w.Code, w.Body)
+	}
+	if len(resp.Metrics) != 1 || resp.Metrics[0].ClusterID != "cluster-a" {
+		t.Fatalf("expected only team-a's metrics, got %+v", resp.Metrics)
+	}
+	if w := get("/metrics?cluster_id=cluster-b"); w.Code != 404 {
+		t.Fatalf("expected another project's cluster to look missing, got %d", w.Code)
+	}
+	if w := get("/health/cluster-b"); w.Code != 404 {
+		t.Fatalf("expected health of another project's cluster to look missing, got %d", w.Code)
+	}
+	if w := get("/health/cluster-a"); w.Code != 200 {
+		t.Fatalf("expected health of the project's cluster, got %d %s", w.Code, w.Body)
+	}
+}
+
+// memClusterRepo serves fixed clusters without the Redis cache of the real repository.
+type memClusterRepo map[string]*models.Cluster
+
+func (m memClusterRepo) CreateCluster(c *models.Cluster) (*models.Cluster, error) {
+	m[c.ID] = c
+	return c, nil
+}
+
+func (m memClusterRepo) GetCluster(id string) (*models.Cluster, error) {
+	if c, ok := m[id]; ok {
+		return c, nil
+	}
+	return nil, errors.New("cluster not found")
+}
+
+func (m memClusterRepo) ListClusters(projectID string) ([]*models.Cluster, error) {
+	var out []*models.Cluster
+	for _, c := range m {
+		if models.InProject(projectID, c.ProjectID) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (m memClusterRepo) UpdateCluster(id string, c *models.Cluster) (*models.Cluster, error) {
+	m[id] = c
+	return c, nil
+}
+
+func (m memClusterRepo) DeleteCluster(id string) error {
+	delete(m, id)
+	return nil
+}
@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+)
+
+type APIKeyRepository interface {
+	Create(k *models.APIKey) error
+	// TouchLastUsed sets only last_used_at, so it never undoes a concurrent revocation
+	TouchLastUsed(id string, at time.Time) error
+	// Revoke sets revoked_at unless the key is already revoked; false means it was
+	Revoke(id string, at time.Time) (bool, error)
+	Get(id string) (*models.APIKey, error)
+	// GetByHash returns the key whose hash is keyHash
+	GetByHash(keyHash string) (*models.APIKey, error)
+	List() ([]*models.APIKey, error)
+}
@@ -6,6 +6,7 @@ type AutoscalerRepository interface {
 	CreatePolicy(p *models.AutoscalePolicy) error
 	UpdatePolicy(p *models.AutoscalePolicy) error
 	GetPolicy(id string) (*models.AutoscalePolicy, error)
-	ListPolicies(clusterID string) ([]*models.AutoscalePolicy, error)
+	// ListPolicies returns the policies of a cluster visible in projectID ("" for every project)
+	ListPolicies(projectID, clusterID string) ([]*models.AutoscalePolicy, error)
 	DeletePolicy(id string) error
 }
@@ -7,7 +7,8 @@ import "github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
 type ClusterRepository interface {
 	CreateCluster(cluster *models.Cluster) (*models.Cluster, error)
 	GetCluster(id string) (*models.Cluster, error)
-	ListClusters() ([]*models.Cluster, error)
+	// ListClusters returns the clusters of projectID, or of every project for ""
+	ListClusters(projectID string) ([]*models.Cluster, error)
 	UpdateCluster(id string, cluster *models.Cluster) (*models.Cluster, error)
 	DeleteCluster(id string) error
 }
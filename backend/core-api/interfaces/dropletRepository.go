@@ -9,7 +9,8 @@ import (
 type DropletRepository interface {
 	CreateDroplet(req *models.CreateDropletRequest) (*models.DropletResponse, error)
 	GetDroplet(id string) (*models.Droplet, error)
-	ListDroplets() ([]*models.Droplet, error)
+	// ListDroplets returns the droplets of projectID, or of every project for ""
+	ListDroplets(projectID string) ([]*models.Droplet, error)
 	DeleteDroplet(id string) error
 	UpdateDroplet(d *models.Droplet) error
 }
@@ -0,0 +1,9 @@
+package interfaces
+
+import "github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+
+type JobLogRepository interface {
+	Append(entry *models.JobLog) error
+	// List returns up to limit entries of a job with id greater than afterID, oldest first
+	List(jobID string, afterID uint64, limit int) ([]*models.JobLog, error)
+}
@@ -2,12 +2,32 @@
 
 package interfaces
 
-import "github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+import (
+	"errors"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+)
+
+// ErrInvalidCursor is returned by ListJobs for a malformed cursor or one issued for a different sort.
+var ErrInvalidCursor = errors.New("invalid cursor")
 
 type JobRepository interface {
 	CreateJob(req *models.CreateJobRequest) (*models.JobResponse, error)
 	GetJob(id string) (*models.Job, error)
 	ListJobs(req *models.GetJobsRequest) (*models.ListJobsResponse, error)
 	UpdateJobStatus(id string, status string) error
+	// UpdateJobProgress sets the progress and result of an unfinished job; it never changes the status
 	UpdateJobProgress(id string, progress int, message string) error
+	// UpdateJobFields updates only the given columns, leaving concurrent changes to others intact
+	UpdateJobFields(id string, fields map[string]interface{}) error
+	ListJobsByStatus(status string, limit int) ([]*models.Job, error)
+	// TransitionJobStatus moves a job from one status to another only if it is still in `from`;
+	// it reports whether the transition happened
+	TransitionJobStatus(id, from, to string) (bool, error)
+	// ListDependentJobs returns jobs that depend on parentID
+	ListDependentJobs(parentID string) ([]*models.Job, error)
+	ListJobsByWorkflow(workflowID string) ([]*models.Job, error)
+	// ListOverdueJobs returns started jobs (running, or queued with orchestration) whose deadline_at passed
+	ListOverdueJobs(now time.Time, limit int) ([]*models.Job, error)
 }
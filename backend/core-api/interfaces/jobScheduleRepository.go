@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+)
+
+type JobScheduleRepository interface {
+	Create(s *models.JobSchedule) error
+	Update(s *models.JobSchedule) error
+	Get(id string) (*models.JobSchedule, error)
+	// List returns the schedules of projectID; an empty projectID lists all projects
+	List(projectID string) ([]*models.JobSchedule, error)
+	Delete(id string) error
+	// ListDue returns enabled schedules whose next run is at or before now, or that have a queued run waiting
+	ListDue(now time.Time) ([]*models.JobSchedule, error)
+	// ClaimRun moves a due schedule to nextRunAt/pendingRun only if its next_run_at and
+	// pending_run are still the ones in s, so exactly one instance acts on each tick
+	ClaimRun(s *models.JobSchedule, nextRunAt *time.Time, pendingRun bool) (bool, error)
+}
@@ -0,0 +1,10 @@
+package interfaces
+
+import "github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+
+type ProjectRepository interface {
+	Create(p *models.Project) error
+	Update(p *models.Project) error
+	Get(id string) (*models.Project, error)
+	List() ([]*models.Project, error)
+}
@@ -0,0 +1,10 @@
+package interfaces
+
+import "github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+
+type WorkflowRepository interface {
+	// CreateWorkflow stores a workflow and its jobs in one transaction; jobs are in
+	// dependency order and parents are named by their preassigned IDs
+	CreateWorkflow(w *models.Workflow, jobs []*models.CreateJobRequest) ([]*models.Job, error)
+	GetWorkflow(id string) (*models.Workflow, error)
+}
@@ -5,6 +5,8 @@ package eventbus
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 
 	"github.com/AvinashMahala/ClusterGenie/backend/core-api/logger"
 
@@ -27,10 +29,16 @@ func NewConsumer(brokers []string, topic string, groupID string) *Consumer {
 	}
 }
 
-func (c *Consumer) ConsumeEvents(handler func(event map[string]interface{}) error) {
+// ConsumeEvents reads and handles events until ctx is cancelled or the consumer is closed.
+// An event that is being handled when ctx is cancelled is finished first.
+func (c *Consumer) ConsumeEvents(ctx context.Context, handler func(event map[string]interface{}) error) {
 	for {
-		m, err := c.reader.ReadMessage(context.Background())
+		m, err := c.reader.ReadMessage(ctx)
 		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, io.EOF) {
+				logger.Info("Event consumer stopped")
+				return
+			}
 			logger.Errorf("Error reading message: %v", err)
 			continue
 		}
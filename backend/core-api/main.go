@@ -7,18 +7,25 @@ package main
 // @BasePath /api/v1
 
 import (
+	"context"
+	"errors"
+	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
 
 	"github.com/AvinashMahala/ClusterGenie/backend/core-api/database"
 	_ "github.com/AvinashMahala/ClusterGenie/backend/core-api/docs"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/events"
 	eventbus "github.com/AvinashMahala/ClusterGenie/backend/core-api/kafka"
 	"github.com/AvinashMahala/ClusterGenie/backend/core-api/logger"
 	"github.com/AvinashMahala/ClusterGenie/backend/core-api/middleware"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
 	"github.com/AvinashMahala/ClusterGenie/backend/core-api/repositories"
 	"github.com/AvinashMahala/ClusterGenie/backend/core-api/services"
 	"github.com/gin-contrib/cors"
@@ -47,6 +54,9 @@ func main() {
 	providerRepo := repositories.NewProviderRepository(database.DB, database.Redis)
 	clusterRepo := repositories.NewClusterRepository(database.DB, database.Redis)
 	jobRepo := repositories.NewJobRepository(database.DB, database.Redis)
+	workflowRepo := repositories.NewWorkflowRepository(database.DB)
+	jobLogRepo := repositories.NewJobLogRepository(database.DB)
+	scheduleRepo := repositories.NewJobScheduleRepository(database.DB)
 	metricRepo := repositories.NewMetricRepository(database.DB, database.Redis)
 	deploymentRepo := repositories.NewDeploymentRepository(database.DB, database.Redis)
 	// autoscaler repo/service (demo-mode, Redis-backed)
@@ -63,6 +73,8 @@ func main() {
 	provisioningSvc := services.NewProvisioningService(dropletRepo, producer, clusterSvc, schedulerSvc)
 	diagnosisSvc := services.NewDiagnosisService(clusterRepo)
 	jobSvc := services.NewJobService(jobRepo, producer)
+	workflowSvc := services.NewWorkflowService(workflowRepo, jobSvc)
+	scheduleSvc := services.NewJobScheduleService(scheduleRepo, jobSvc)
 	monitoringSvc := services.NewMonitoringService(metricRepo)
 	billingSvc := services.NewBillingService(dropletRepo, providerRepo)
 	deploymentSvc := services.NewDeploymentService(deploymentRepo, provisioningSvc, producer)
@@ -71,20 +83,66 @@ func main() {
 	// Set service dependencies
 	jobSvc.SetProvisioningService(provisioningSvc)
 	jobSvc.SetClusterService(clusterSvc)
+	jobSvc.SetJobLogRepository(jobLogRepo)
+
+	// per-type execution timeouts, e.g. "provision=600,scale=600" (seconds, 0 disables)
+	for _, kv := range nilOrSplit(os.Getenv("CLUSTERGENIE_JOB_TIMEOUTS")) {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimSpace(parts[1])); err == nil && n >= 0 {
+			jobSvc.SetJobTimeout(strings.TrimSpace(parts[0]), time.Duration(n)*time.Second)
+		}
+	}
+
+	// operations running at once per cluster, "name=limit[:mode]" with mode reject or queue;
+	// deployments bounds rollouts, job types (e.g. diagnose) bound running jobs of that type
+	concurrency := services.NewConcurrencyLimiter(database.Redis,
+		time.Duration(envInt("CLUSTERGENIE_CONCURRENCY_LEASE_SECONDS", 30))*time.Second)
+	concurrencyQueueTimeout := int(envInt("CLUSTERGENIE_CONCURRENCY_QUEUE_TIMEOUT_SECONDS", 30))
+	for _, kv := range nilOrSplit(getEnv("CLUSTERGENIE_CONCURRENCY_LIMITS", "deployments=1:reject,diagnose=2:queue")) {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		limit, mode, _ := strings.Cut(parts[1], ":")
+		n, _ := strconv.Atoi(strings.TrimSpace(limit))
+		rule := models.ConcurrencyRule{Name: strings.TrimSpace(parts[0]), Scope: "cluster", Limit: n,
+			Mode: strings.TrimSpace(mode), QueueTimeoutSeconds: concurrencyQueueTimeout}
+		if err := concurrency.AddRule(rule); err != nil {
+			logger.Warnf("ignoring concurrency limit %q: %v", kv, err)
+		}
+	}
+	jobSvc.SetConcurrencyLimiter(concurrency)
+	deploymentSvc.SetConcurrencyLimiter(concurrency)
 
 	// Initialize event handler and consumers
 	eventHandler := services.NewEventHandler(jobSvc, monitoringSvc, provisioningSvc)
 	consumer := eventbus.NewConsumer(brokers, "cluster-events", "cluster-genie-group")
 
-	// Start event consumer in background
+	// Start event consumer in background; it stops when consumerCtx is cancelled on shutdown
+	consumerCtx, stopConsumer := context.WithCancel(context.Background())
+	consumerDone := make(chan struct{})
 	go func() {
+		defer close(consumerDone)
 		logger.Info("Starting event consumer...")
-		consumer.ConsumeEvents(eventHandler.HandleClusterEvent)
+		consumer.ConsumeEvents(consumerCtx, eventHandler.HandleClusterEvent)
 	}()
-	defer consumer.Close()
 
 	// Initialize LimiterManager and worker pool for Phase 6
 	limiter := services.NewLimiterManager(database.Redis)
+	// Redis calls of the scoped limiters go through one circuit breaker; while Redis is down
+	// each limiter decides by its failure mode (CLUSTERGENIE_*_FAILURE_MODE)
+	limiter.SetFailover(services.NewCircuitBreaker("redis",
+		int(envInt("CLUSTERGENIE_LIMITER_BREAKER_FAILURES", 5)),
+		time.Duration(envInt("CLUSTERGENIE_LIMITER_BREAKER_OPEN_SECONDS", 10))*time.Second),
+		int(envInt("CLUSTERGENIE_LIMITER_INSTANCES", 1)))
+	// bound the per-user/per-cluster buckets kept in memory
+	limiter.SetEviction(int(envInt("CLUSTERGENIE_LIMITER_MAX_SCOPES", 10000)),
+		time.Duration(envInt("CLUSTERGENIE_LIMITER_IDLE_SECONDS", 600))*time.Second)
+	go limiter.RunEviction(context.Background())
+	limiterMetricsTopN := int(envInt("CLUSTERGENIE_LIMITER_METRICS_TOP_N", 20))
 	// configurable defaults (env vars)
 	diagRate := 0.2
 	diagCap := 5.0
@@ -98,8 +156,24 @@ func main() {
 			diagCap = f
 		}
 	}
-	limiter.Add("diagnosis", services.NewTokenBucket(diagRate, diagCap))
-	limiter.AddDefaultConfig("diagnosis", services.BucketConfig{RefillRate: diagRate, Capacity: diagCap})
+	diagCfg := services.BucketConfig{RefillRate: diagRate, Capacity: diagCap, Algorithm: limiterAlgorithmFromEnv("CLUSTERGENIE_DIAG_ALGORITHM"),
+		FailureMode: failureModeFromEnv("CLUSTERGENIE_DIAG_FAILURE_MODE")}
+	limiter.Add("diagnosis", services.NewRateLimiter(diagCfg))
+	limiter.AddDefaultConfig("diagnosis", diagCfg)
+	// the global level of a hierarchical limit (see routeRateLimit); defaults to ten clients' worth
+	limiter.AddDefaultConfig("diagnosis_global", services.BucketConfig{
+		RefillRate:  envFloat("CLUSTERGENIE_DIAG_GLOBAL_RATE", diagRate*10),
+		Capacity:    envFloat("CLUSTERGENIE_DIAG_GLOBAL_CAP", diagCap*10),
+		Algorithm:   diagCfg.Algorithm,
+		FailureMode: diagCfg.FailureMode,
+	})
+	// the project level; projects may override it with their rate_limits
+	limiter.AddDefaultConfig("diagnosis_project", services.BucketConfig{
+		RefillRate:  envFloat("CLUSTERGENIE_DIAG_PROJECT_RATE", diagRate*5),
+		Capacity:    envFloat("CLUSTERGENIE_DIAG_PROJECT_CAP", diagCap*5),
+		Algorithm:   diagCfg.Algorithm,
+		FailureMode: diagCfg.FailureMode,
+	})
 
 	jobRate := 0.1
 	jobCap := 3.0
@@ -113,8 +187,51 @@ func main() {
 			jobCap = f
 		}
 	}
-	limiter.Add("jobs_create", services.NewTokenBucket(jobRate, jobCap))
-	limiter.AddDefaultConfig("jobs_create", services.BucketConfig{RefillRate: jobRate, Capacity: jobCap})
+	jobCfg := services.BucketConfig{RefillRate: jobRate, Capacity: jobCap, Algorithm: limiterAlgorithmFromEnv("CLUSTERGENIE_JOBS_ALGORITHM"),
+		FailureMode: failureModeFromEnv("CLUSTERGENIE_JOBS_FAILURE_MODE")}
+	limiter.Add("jobs_create", services.NewRateLimiter(jobCfg))
+	limiter.AddDefaultConfig("jobs_create", jobCfg)
+	limiter.AddDefaultConfig("jobs_create_global", services.BucketConfig{
+		RefillRate:  envFloat("CLUSTERGENIE_JOBS_GLOBAL_RATE", jobRate*10),
+		Capacity:    envFloat("CLUSTERGENIE_JOBS_GLOBAL_CAP", jobCap*10),
+		Algorithm:   jobCfg.Algorithm,
+		FailureMode: jobCfg.FailureMode,
+	})
+	limiter.AddDefaultConfig("jobs_create_project", services.BucketConfig{
+		RefillRate:  envFloat("CLUSTERGENIE_JOBS_PROJECT_RATE", jobRate*5),
+		Capacity:    envFloat("CLUSTERGENIE_JOBS_PROJECT_CAP", jobCap*5),
+		Algorithm:   jobCfg.Algorithm,
+		FailureMode: jobCfg.FailureMode,
+	})
+	// apply persisted limiter configs now and whenever an instance changes them
+	go limiter.WatchConfigChanges(context.Background())
+
+	// projects own clusters, droplets, jobs, policies and deployments; their rate limits are
+	// applied at startup and re-read periodically to pick up changes made on other instances
+	projectSvc := services.NewProjectService(repositories.NewProjectRepository(database.DB), limiter)
+	if err := projectSvc.EnsureDefault(); err != nil {
+		logger.Errorf("Failed to create the default project: %v", err)
+	}
+	if err := projectSvc.SyncLimits(); err != nil {
+		logger.Errorf("Failed to apply project rate limits: %v", err)
+	}
+	go projectSvc.RunLimitSync(context.Background(),
+		time.Duration(envInt("CLUSTERGENIE_PROJECT_LIMITS_SYNC_SECONDS", 30))*time.Second)
+
+	// long-window quotas per user / cluster, counted in calendar days and months; a limit of 0 disables one
+	quotaLoc := time.UTC
+	if tz := os.Getenv("CLUSTERGENIE_QUOTA_TIMEZONE"); tz != "" {
+		if loc, err := time.LoadLocation(tz); err == nil {
+			quotaLoc = loc
+		} else {
+			logger.Warnf("unknown CLUSTERGENIE_QUOTA_TIMEZONE %q, using UTC", tz)
+		}
+	}
+	quotas := services.NewQuotaManager(database.Redis, quotaLoc)
+	_ = quotas.AddRule(models.QuotaRule{Name: services.QuotaProvisionJobs, Scope: "user", Period: models.QuotaPeriodDay,
+		Limit: envInt("CLUSTERGENIE_QUOTA_PROVISION_JOBS_PER_USER_DAY", 500)})
+	_ = quotas.AddRule(models.QuotaRule{Name: services.QuotaDroplets, Scope: "cluster", Period: models.QuotaPeriodMonth,
+		Limit: envInt("CLUSTERGENIE_QUOTA_DROPLETS_PER_CLUSTER_MONTH", 40)})
 
 	// create and start worker pool for job processing
 	// worker pool configurable
@@ -132,13 +249,61 @@ func main() {
 	}
 
 	workerPool := services.NewWorkerPool(workerCount, workerQueueSize, func(jobID string) {
-		// delegate to JobService for actual processing
-		_ = jobSvc.ProcessJob(jobID)
+		// delegate to JobService for actual processing; blocks for the whole run so
+		// the worker's lease covers it
+		_ = jobSvc.ExecuteJob(jobID)
 	})
-	workerPool.Start()
+	// scheduling mode: fifo, priority or wfq (weighted fair queuing across tenants)
+	workerPool.SetSchedulingMode(getEnv("CLUSTERGENIE_WORKER_SCHEDULING", services.SchedWFQ))
+
+	// durable queue: redis (default) keeps queued jobs and worker leases across restarts
+	queueLease := 30 * time.Second
+	if v := os.Getenv("CLUSTERGENIE_QUEUE_LEASE_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			queueLease = time.Duration(n) * time.Second
+		}
+	}
+	if getEnv("CLUSTERGENIE_QUEUE_BACKEND", "redis") == "redis" {
+		workerPool.SetStore(services.NewRedisQueueStore(database.Redis), queueLease)
+	} else {
+		workerPool.SetStore(services.NewMemoryQueueStore(), queueLease)
+	}
+	if v := os.Getenv("CLUSTERGENIE_QUEUE_LEASE_GRACE_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			workerPool.SetLeaseGrace(time.Duration(n) * time.Second)
+		}
+	}
+	workerPool.SetLeaseExpiredHandler(jobSvc.HandleExpiredLease)
 	// attach worker pool to job service so CreateJob uses it
 	jobSvc.SetWorkerPool(workerPool)
 
+	// startup recovery: reload the persisted queue, then repair jobs orphaned by the last run
+	if _, err := workerPool.Recover(); err != nil {
+		logger.Errorf("Failed to recover job queue: %v", err)
+	}
+	jobSvc.RecoverJobs()
+	workerPool.Start()
+
+	// recurring jobs; schedules are persisted so the loop resumes after a restart
+	scheduleInterval := time.Second
+	if v := os.Getenv("CLUSTERGENIE_SCHEDULER_INTERVAL_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			scheduleInterval = time.Duration(n) * time.Second
+		}
+	}
+	scheduleSvc.SetQuotas(quotas)
+	scheduleSvc.Start(scheduleInterval)
+
+	// marks jobs past their deadline (incl. ones whose orchestration event never arrived) timed_out
+	reaperInterval := 15 * time.Second
+	if v := os.Getenv("CLUSTERGENIE_JOB_REAPER_INTERVAL_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			reaperInterval = time.Duration(n) * time.Second
+		}
+	}
+	timeoutReaper := services.NewJobTimeoutReaper(jobSvc)
+	timeoutReaper.Start(reaperInterval)
+
 	// Register Prometheus metrics
 	services.RegisterPrometheusMetrics()
 
@@ -152,27 +317,12 @@ func main() {
 			services.WorkerPoolQueueLength.Set(float64(workerPool.QueueLength()))
 			services.WorkerPoolActiveWorkers.Set(float64(workerPool.ActiveWorkers()))
 			services.WorkerPoolCount.Set(float64(workerPool.WorkerCount()))
+			services.EventStreamSubscribers.Set(float64(events.DefaultBroker.Stats().Subscribers))
 
-			// limiter snapshot -> update available tokens per scope
-			snap := limiter.SnapshotStatus()
-			for name, scopes := range snap {
-				for scope, s := range scopes {
-					scopeType := "global"
-					scopeID := ""
-					if scope != "" {
-						if len(scope) > 5 && scope[:5] == "user:" {
-							scopeType = "user"
-							scopeID = scope[5:]
-						} else if len(scope) > 8 && scope[:8] == "cluster:" {
-							scopeType = "cluster"
-							scopeID = scope[8:]
-						} else {
-							scopeID = scope
-						}
-					}
-					services.RateLimitAvailable.WithLabelValues(name, scopeType, scopeID).Set(s.Available)
-				}
-			}
+			// limiter snapshot -> available tokens of the top N scopes per limiter, the rest as "other"
+			limiter.ExportMetrics(limiterMetricsTopN)
+			// quota usage of the top N scopes per quota, the rest as "other"
+			quotas.ExportMetrics(limiterMetricsTopN)
 
 			// sleep between updates
 			time.Sleep(2 * time.Second)
@@ -202,77 +352,131 @@ func main() {
 	})
 	r.Use(cors.Default())
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	api := r.Group("/api/v1")
+	// API keys: with CLUSTERGENIE_AUTH_ENABLED every /api/v1 request needs a key and the
+	// key's user replaces X-User-ID; route groups below require the viewer, operator or admin role
+	apiKeySvc := services.NewAPIKeyService(repositories.NewAPIKeyRepository(database.DB))
+	var authKeys *services.APIKeyService
+	if getEnv("CLUSTERGENIE_AUTH_ENABLED", "false") == "true" {
+		authKeys = apiKeySvc
+		if key := os.Getenv("CLUSTERGENIE_BOOTSTRAP_ADMIN_KEY"); key != "" {
+			if err := apiKeySvc.EnsureKey(key, "bootstrap", "admin", models.RoleAdmin); err != nil {
+				logger.Errorf("Failed to register the bootstrap admin key: %v", err)
+			}
+		}
+	} else {
+		logger.Warnf("API authentication is disabled; X-User-ID is trusted as sent by clients")
+	}
+	// X-Project-ID picks the project of requests whose key is not bound to one
+	api := r.Group("/api/v1", middleware.APIKeyAuth(authKeys, "/api/v1", "X-User-ID"), middleware.ProjectScope(projectSvc, "X-Project-ID"))
+	viewer := api.Group("", middleware.RequireRole(models.RoleViewer))
+	operator := api.Group("", middleware.RequireRole(models.RoleOperator))
+	admin := api.Group("", middleware.RequireRole(models.RoleAdmin))
 	{
-		api.POST("/hello", HelloHandler())
+		viewer.POST("/hello", HelloHandler())
 
-		// Provisioning
-		api.POST("/droplets", CreateDropletHandler(provisioningSvc))
-		api.GET("/droplets/:id", GetDropletHandler(provisioningSvc))
-		api.GET("/droplets", ListDropletsHandler(provisioningSvc))
-		api.DELETE("/droplets/:id", DeleteDropletHandler(provisioningSvc))
-
-		// Diagnosis (scope configurable: cluster/user/global)
-		diagScope := os.Getenv("CLUSTERGENIE_DIAG_SCOPE")
-		if diagScope == "" {
-			diagScope = "cluster"
-		}
-		diagMiddleware := middleware.RateLimitMiddleware(limiter, "diagnosis")
-		if diagScope == "cluster" {
-			diagMiddleware = middleware.RateLimitMiddlewareByClusterFromBody(limiter, "diagnosis", "cluster_id")
-		} else if diagScope == "user" {
-			diagMiddleware = middleware.RateLimitMiddlewareByUserHeader(limiter, "diagnosis", "X-User-ID")
+		// Idempotency-Key support for create endpoints; the first response is replayed to retries
+		idempotencyTTL := 24 * time.Hour
+		if v := os.Getenv("CLUSTERGENIE_IDEMPOTENCY_TTL_SECONDS"); v != "" {
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				idempotencyTTL = time.Duration(n) * time.Second
+			}
 		}
-		api.POST("/diagnosis/diagnose", diagMiddleware, DiagnoseClusterHandler(diagnosisSvc))
+		idempotent := middleware.IdempotencyMiddleware(services.NewRedisIdempotencyStore(database.Redis), idempotencyTTL)
+
+		// Provisioning
+		operator.POST("/droplets", idempotent, middleware.QuotaMiddleware(quotas, services.QuotaDroplets, dropletQuota), CreateDropletHandler(provisioningSvc))
+		viewer.GET("/droplets/:id", GetDropletHandler(provisioningSvc))
+		viewer.GET("/droplets", ListDropletsHandler(provisioningSvc))
+		operator.DELETE("/droplets/:id", DeleteDropletHandler(provisioningSvc))
+
+		// Diagnosis (scope configurable: cluster/user/project/global, or a list such as global,project,user,cluster)
+		diagMiddleware := routeRateLimit(limiter, "diagnosis", getEnv("CLUSTERGENIE_DIAG_SCOPE", "project,cluster"))
+		// diagnoses share the diagnose concurrency limit with diagnose jobs
+		operator.POST("/diagnosis/diagnose", diagMiddleware, middleware.ConcurrencyMiddleware(concurrency, "diagnose", "X-User-ID", "cluster_id"), DiagnoseClusterHandler(diagnosisSvc))
 
 		// Clusters
-		api.POST("/clusters", CreateClusterHandler(clusterSvc))
-		api.GET("/clusters/:id", GetClusterHandler(clusterSvc))
-		api.GET("/clusters", ListClustersHandler(clusterSvc))
-		api.PUT("/clusters/:id", UpdateClusterHandler(clusterSvc))
-		api.DELETE("/clusters/:id", DeleteClusterHandler(clusterSvc))
+		operator.POST("/clusters", idempotent, CreateClusterHandler(clusterSvc))
+		viewer.GET("/clusters/:id", GetClusterHandler(clusterSvc))
+		viewer.GET("/clusters", ListClustersHandler(clusterSvc))
+		operator.PUT("/clusters/:id", UpdateClusterHandler(clusterSvc))
+		operator.DELETE("/clusters/:id", DeleteClusterHandler(clusterSvc))
 
 		// Health Check
-		api.GET("/health/:clusterId", HealthCheckHandler(monitoringSvc))
-
-		// Jobs (scope configurable: user/cluster/global)
-		jobsScope := os.Getenv("CLUSTERGENIE_JOBS_SCOPE")
-		if jobsScope == "" {
-			jobsScope = "user"
-		}
-		jobsMiddleware := middleware.RateLimitMiddleware(limiter, "jobs_create")
-		if jobsScope == "user" {
-			jobsMiddleware = middleware.RateLimitMiddlewareByUserHeader(limiter, "jobs_create", "X-User-ID")
-		} else if jobsScope == "cluster" {
-			jobsMiddleware = middleware.RateLimitMiddlewareByClusterFromBody(limiter, "jobs_create", "cluster_id")
+		viewer.GET("/health/:clusterId", HealthCheckHandler(monitoringSvc, clusterSvc))
+
+		// Jobs (scope configurable: user/cluster/project/global, or a list such as global,project,user,cluster)
+		jobsMiddleware := routeRateLimit(limiter, "jobs_create", getEnv("CLUSTERGENIE_JOBS_SCOPE", "project,user"))
+		// heartbeat interval for SSE/WebSocket streams (job log follow mode and /events)
+		heartbeat := 15 * time.Second
+		if v := os.Getenv("CLUSTERGENIE_EVENTS_HEARTBEAT_SECONDS"); v != "" {
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				heartbeat = time.Duration(n) * time.Second
+			}
 		}
-		api.POST("/jobs", jobsMiddleware, CreateJobHandler(jobSvc))
-		api.GET("/jobs/:id", GetJobHandler(jobSvc))
-		api.GET("/jobs", ListJobsHandler(jobSvc))
+		// idempotency runs first so replayed retries do not spend rate limit tokens
+		// provision jobs (also as workflow nodes) count against the caller's daily quota and the target cluster's droplet quota
+		provisionQuota := middleware.QuotaMiddleware(quotas, services.QuotaProvisionJobs, provisionJobsQuota)
+		provisionDroplets := middleware.QuotaMiddleware(quotas, services.QuotaDroplets, provisionDropletsQuota)
+		operator.POST("/jobs", idempotent, jobsMiddleware, provisionQuota, provisionDroplets, CreateJobHandler(jobSvc))
+		// bulk requests spend one jobs_create token per job
+		operator.POST("/jobs/bulk", idempotent, middleware.RateLimitCost(bulkCreateCost), jobsMiddleware, provisionQuota, provisionDroplets, BulkCreateJobsHandler(jobSvc))
+		operator.POST("/jobs/bulk/cancel", middleware.RateLimitCost(bulkActionCost(jobSvc.CancelTargets)), jobsMiddleware, BulkCancelJobsHandler(jobSvc))
+		operator.POST("/jobs/bulk/requeue", middleware.RateLimitCost(bulkActionCost(jobSvc.RequeueTargets)), jobsMiddleware, BulkRequeueJobsHandler(jobSvc))
+		viewer.GET("/jobs/dead-letter", ListDeadLetterJobsHandler(jobSvc))
+		viewer.GET("/jobs/:id", GetJobHandler(jobSvc))
+		viewer.GET("/jobs/:id/logs", JobLogsHandler(jobSvc, heartbeat))
+		operator.POST("/jobs/:id/requeue", RequeueJobHandler(jobSvc))
+		operator.POST("/jobs/:id/cancel", CancelJobHandler(jobSvc))
+		viewer.GET("/jobs", ListJobsHandler(jobSvc))
+		viewer.GET("/job-types", ListJobTypesHandler(jobSvc))
+		operator.POST("/workflows", jobsMiddleware, provisionQuota, provisionDroplets, CreateWorkflowHandler(workflowSvc))
+		viewer.GET("/workflows/:id", GetWorkflowHandler(workflowSvc))
+		operator.POST("/schedules", CreateScheduleHandler(scheduleSvc))
+		viewer.GET("/schedules", ListSchedulesHandler(scheduleSvc))
+		viewer.GET("/schedules/:id", GetScheduleHandler(scheduleSvc))
+		operator.PUT("/schedules/:id", UpdateScheduleHandler(scheduleSvc))
+		operator.DELETE("/schedules/:id", DeleteScheduleHandler(scheduleSvc))
 
 		// Monitoring
-		api.GET("/metrics", GetMetricsHandler(monitoringSvc))
+		viewer.GET("/metrics", GetMetricsHandler(monitoringSvc, clusterSvc))
 
 		// Autoscaling demo endpoints
-		api.POST("/autoscaling/policies", CreateAutoscalePolicyHandler(autoscalerSvc))
-		api.GET("/autoscaling/policies", ListAutoscalePoliciesHandler(autoscalerSvc))
-		api.GET("/autoscaling/policies/:id", GetAutoscalePolicyHandler(autoscalerSvc))
-		api.PUT("/autoscaling/policies/:id", UpdateAutoscalePolicyHandler(autoscalerSvc))
-		api.DELETE("/autoscaling/policies/:id", DeleteAutoscalePolicyHandler(autoscalerSvc))
-		api.POST("/autoscaling/evaluate", EvaluateAutoscalingHandler(autoscalerSvc))
+		operator.POST("/autoscaling/policies", CreateAutoscalePolicyHandler(autoscalerSvc))
+		viewer.GET("/autoscaling/policies", ListAutoscalePoliciesHandler(autoscalerSvc))
+		viewer.GET("/autoscaling/policies/:id", GetAutoscalePolicyHandler(autoscalerSvc))
+		operator.PUT("/autoscaling/policies/:id", UpdateAutoscalePolicyHandler(autoscalerSvc))
+		operator.DELETE("/autoscaling/policies/:id", DeleteAutoscalePolicyHandler(autoscalerSvc))
+		operator.POST("/autoscaling/evaluate", EvaluateAutoscalingHandler(autoscalerSvc))
 		// providers/scheduler
-		api.GET("/providers", ListProvidersHandler(schedulerSvc))
-		api.POST("/providers", CreateProviderHandler(schedulerSvc))
-		api.POST("/schedule", ScheduleHandler(schedulerSvc))
-		api.POST("/migrations", MigrateHandler(schedulerSvc))
+		viewer.GET("/providers", ListProvidersHandler(schedulerSvc))
+		admin.POST("/providers", CreateProviderHandler(schedulerSvc))
+		viewer.POST("/schedule", ScheduleHandler(schedulerSvc))
+		operator.POST("/migrations", MigrateHandler(schedulerSvc))
 		// billing
-		api.GET("/billing/cluster", EstimateClusterCostHandler(billingSvc))
+		viewer.GET("/billing/cluster", EstimateClusterCostHandler(billingSvc))
+		viewer.GET("/providers/usage", ProviderUsageHandler(billingSvc))
 
 		// Deployments / rollout simulation
-		api.POST("/deployments/start", StartDeploymentHandler(deploymentSvc))
-		api.GET("/deployments/:id", GetDeploymentHandler(deploymentSvc))
-		api.GET("/deployments", ListDeploymentsHandler(deploymentSvc))
-		api.POST("/deployments/:id/rollback", RollbackDeploymentHandler(deploymentSvc))
+		operator.POST("/deployments/start", idempotent, StartDeploymentHandler(deploymentSvc))
+		viewer.GET("/deployments/:id", GetDeploymentHandler(deploymentSvc))
+		viewer.GET("/deployments", ListDeploymentsHandler(deploymentSvc))
+		operator.POST("/deployments/:id/rollback", RollbackDeploymentHandler(deploymentSvc))
+
+		// Live event streams (SSE + WebSocket) backed by the in-process broker
+		viewer.GET("/events/stream", EventStreamHandler(events.DefaultBroker, heartbeat))
+		viewer.GET("/events/ws", EventWebSocketHandler(events.DefaultBroker, heartbeat))
+
+		// API keys
+		viewer.GET("/auth/whoami", WhoAmIHandler())
+		admin.POST("/auth/keys", CreateAPIKeyHandler(apiKeySvc, projectSvc))
+		admin.GET("/auth/keys", ListAPIKeysHandler(apiKeySvc))
+		admin.DELETE("/auth/keys/:id", RevokeAPIKeyHandler(apiKeySvc))
+
+		// Projects
+		admin.POST("/projects", CreateProjectHandler(projectSvc))
+		admin.GET("/projects", ListProjectsHandler(projectSvc))
+		admin.GET("/projects/:id", GetProjectHandler(projectSvc))
+		admin.PUT("/projects/:id", UpdateProjectHandler(projectSvc))
 	}
 
 	// Observability endpoints for Phase 6
@@ -289,7 +493,9 @@ func main() {
 	// @Failure 404 {object} models.ErrorResponse "No such limiter"
 	// @Failure 500 {object} models.ErrorResponse "Server error"
 	// @Router /observability/ratelimit [get]
-	api.GET("/observability/ratelimit", GetRateLimiterStatusHandler(limiter))
+	viewer.GET("/observability/ratelimit", GetRateLimiterStatusHandler(limiter))
+	// what-if analysis of a limiter config against a supplied or recorded request timeline
+	viewer.POST("/observability/ratelimit/simulate", SimulateRateLimitHandler(limiter))
 
 	// manage persisted limiter config (stored in Redis)
 	// @Summary Persist limiter configuration
@@ -302,7 +508,7 @@ func main() {
 	// @Failure 400 {object} models.ErrorResponse "Invalid request"
 	// @Failure 500 {object} models.ErrorResponse "Server error"
 	// @Router /observability/ratelimit/config [post]
-	api.POST("/observability/ratelimit/config", SaveLimiterConfigHandler(database.Redis))
+	admin.POST("/observability/ratelimit/config", SaveLimiterConfigHandler(database.Redis))
 
 	// @Summary Get persisted limiter config
 	// @Description Retrieve persisted limiter config for a name and optional scope
@@ -317,7 +523,7 @@ func main() {
 	// @Failure 404 {object} models.ErrorResponse "Not found"
 	// @Failure 500 {object} models.ErrorResponse "Server error"
 	// @Router /observability/ratelimit/config [get]
-	api.GET("/observability/ratelimit/config", GetLimiterConfigHandler(database.Redis))
+	viewer.GET("/observability/ratelimit/config", GetLimiterConfigHandler(database.Redis))
 
 	// list persisted limiter configs (supports optional name/scope filters)
 	// @Summary List persisted limiter configs
@@ -331,7 +537,7 @@ func main() {
 	// @Success 200 {object} map[string]interface{} "List of configs"
 	// @Failure 500 {object} models.ErrorResponse "Server error"
 	// @Router /observability/ratelimit/config/list [get]
-	api.GET("/observability/ratelimit/config/list", ListLimiterConfigHandler(database.Redis))
+	viewer.GET("/observability/ratelimit/config/list", ListLimiterConfigHandler(database.Redis))
 
 	// delete persisted limiter config
 	// @Summary Delete persisted limiter config
@@ -344,16 +550,71 @@ func main() {
 	// @Failure 400 {object} models.ErrorResponse "Missing input"
 	// @Failure 500 {object} models.ErrorResponse "Server error"
 	// @Router /observability/ratelimit/config [delete]
-	api.DELETE("/observability/ratelimit/config", DeleteLimiterConfigHandler(database.Redis))
+	admin.DELETE("/observability/ratelimit/config", DeleteLimiterConfigHandler(database.Redis))
+
+	// @Summary List quotas
+	// @Description Configured long-window quotas: per user or per cluster, per calendar day or month
+	// @Tags observability
+	// @Produce json
+	// @Success 200 {object} models.ListQuotasResponse
+	// @Router /observability/quotas [get]
+	viewer.GET("/observability/quotas", ListQuotasHandler(quotas))
+	viewer.GET("/observability/quotas/usage", QuotaUsageHandler(quotas))
+
+	// @Summary List concurrency limits
+	// @Description Configured limits on operations running at once per user or cluster
+	// @Tags observability
+	// @Produce json
+	// @Success 200 {object} models.ListConcurrencyLimitsResponse
+	// @Router /observability/concurrency [get]
+	viewer.GET("/observability/concurrency", ListConcurrencyLimitsHandler(concurrency))
+	viewer.GET("/observability/concurrency/usage", ConcurrencyUsageHandler(concurrency))
 
 	// @Summary Worker pool status
-	// @Description Snapshot of worker pool (counts, queue) for observability
+	// @Description Snapshot of worker pool (counts, scheduling mode, queue depth per priority and tenant) for observability
 	// @Tags observability
 	// @Accept json
 	// @Produce json
 	// @Success 200 {object} map[string]interface{} "Worker pool snapshot"
 	// @Router /observability/workerpool [get]
-	api.GET("/observability/workerpool", WorkerPoolHandler(workerPool))
+	viewer.GET("/observability/workerpool", WorkerPoolHandler(workerPool))
+
+	// @Summary Resize worker pool
+	// @Description Change the number of workers at runtime; surplus workers exit after their current job
+	// @Tags observability
+	// @Accept json
+	// @Produce json
+	// @Param request body ResizeWorkerPoolRequest true "New worker count"
+	// @Success 200 {object} map[string]interface{} "Worker pool snapshot"
+	// @Failure 400 {object} models.ErrorResponse
+	// @Router /observability/workerpool/workers [put]
+	admin.PUT("/observability/workerpool/workers", ResizeWorkerPoolHandler(workerPool))
+
+	// @Summary Drain worker pool
+	// @Description Stop accepting jobs and wait (up to timeout_seconds) for running jobs to finish; queued jobs stay queued
+	// @Tags observability
+	// @Produce json
+	// @Param timeout_seconds query int false "Max seconds to wait for running jobs (default 30)"
+	// @Success 200 {object} map[string]interface{} "Worker pool snapshot"
+	// @Success 202 {object} map[string]interface{} "Timed out; jobs still running"
+	// @Router /observability/workerpool/drain [post]
+	admin.POST("/observability/workerpool/drain", DrainWorkerPoolHandler(workerPool))
+
+	// @Summary Resume worker pool
+	// @Description Leave drain mode and resume processing queued jobs
+	// @Tags observability
+	// @Produce json
+	// @Success 200 {object} map[string]interface{} "Worker pool snapshot"
+	// @Router /observability/workerpool/resume [post]
+	admin.POST("/observability/workerpool/resume", ResumeWorkerPoolHandler(workerPool))
+
+	// @Summary Event broker status
+	// @Description Subscriber count, replay buffer usage and per-subscriber drop counters
+	// @Tags observability
+	// @Produce json
+	// @Success 200 {object} events.BrokerStats "Broker snapshot"
+	// @Router /observability/events [get]
+	viewer.GET("/observability/events", EventBrokerStatsHandler(events.DefaultBroker))
 
 	// Prometheus metrics endpoint (scrape target).
 	// Support GET and HEAD and any additional methods Prometheus may use by
@@ -361,13 +622,67 @@ func main() {
 	r.Any("/metrics", gin.WrapH(promhttp.Handler()))
 
 	apiPort := getEnv("API_PORT", "8080")
-	logger.Infof("REST API server listening on :%s", apiPort)
-	logger.Infof("Swagger UI available at http://localhost:%s/swagger/index.html", apiPort)
-	if err := r.Run(":" + apiPort); err != nil {
+	srv := &http.Server{Addr: ":" + apiPort, Handler: r}
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Infof("REST API server listening on :%s", apiPort)
+		logger.Infof("Swagger UI available at http://localhost:%s/swagger/index.html", apiPort)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+	}()
+
+	// graceful shutdown on SIGINT/SIGTERM
+	sigCtx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+	select {
+	case err := <-serveErr:
 		logger.Errorf("Failed to start REST server: %v", err)
 		// ensure we exit with non-zero status
 		os.Exit(1)
+	case <-sigCtx.Done():
+	}
+
+	shutdownTimeout := 30 * time.Second
+	if v := os.Getenv("CLUSTERGENIE_SHUTDOWN_TIMEOUT_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			shutdownTimeout = time.Duration(n) * time.Second
+		}
 	}
+	shutdown(srv, scheduleSvc, timeoutReaper, workerPool, stopConsumer, consumerDone, consumer, shutdownTimeout)
+}
+
+// shutdown stops intake first (recurring jobs, worker pool submissions), lets running
+// jobs finish, then stops the event consumer and finally the HTTP server so in-flight
+// requests complete. Everything shares one deadline.
+func shutdown(srv *http.Server, scheduleSvc *services.JobScheduleService, timeoutReaper *services.JobTimeoutReaper, workerPool *services.WorkerPool,
+	stopConsumer context.CancelFunc, consumerDone <-chan struct{}, consumer *eventbus.Consumer, timeout time.Duration) {
+	logger.Infof("Shutting down (timeout %s)...", timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	scheduleSvc.Stop()
+	timeoutReaper.Stop()
+
+	if err := workerPool.Drain(ctx); err != nil {
+		logger.Warnf("Worker pool drain incomplete: %v (%d jobs still running)", err, workerPool.ActiveWorkers())
+	}
+	workerPool.Stop(0)
+
+	stopConsumer()
+	select {
+	case <-consumerDone:
+	case <-ctx.Done():
+		logger.Warnf("Event consumer did not stop before the shutdown deadline")
+	}
+	if err := consumer.Close(); err != nil {
+		logger.Warnf("Failed to close event consumer: %v", err)
+	}
+
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Errorf("HTTP server shutdown failed: %v", err)
+	}
+	logger.Info("Shutdown complete")
 }
 
 // getEnv returns value for the environment variable or the provided default
@@ -378,6 +693,84 @@ func getEnv(key, def string) string {
 	return def
 }
 
+// limiterAlgorithmFromEnv reads a rate limiting algorithm (token_bucket, sliding_window_log,
+// sliding_window_counter or gcra); unknown values fall back to the token bucket.
+func limiterAlgorithmFromEnv(key string) string {
+	v := os.Getenv(key)
+	if !services.ValidLimiterAlgorithm(v) {
+		logger.Warnf("unknown %s %q, using %s", key, v, services.AlgorithmTokenBucket)
+		return ""
+	}
+	return v
+}
+
+// failureModeFromEnv reads a limiter failure mode, defaulting to failing closed so Redis stays
+// authoritative; degrading to a local bucket is opt-in.
+func failureModeFromEnv(key string) string {
+	v := getEnv(key, services.FailClosed)
+	if !services.ValidFailureMode(v) {
+		logger.Warnf("unknown %s %q, using %s", key, v, services.FailClosed)
+		return services.FailClosed
+	}
+	return v
+}
+
+// routeRateLimit builds the rate limit middleware of a route from its scope setting. A single
+// scope (cluster, user, project or global) limits by that scope alone. A comma-separated list
+// of scopes, e.g. "global,project,user,cluster", declares a hierarchical limit whose levels
+// must all allow a request: user and cluster levels use the <name> limiter, the global level
+// uses <name>_global so it can be sized for all clients together, and the project level
+// <name>_project, which projects may override.
+func routeRateLimit(limiter *services.LimiterManager, name string, scopeSetting string) gin.HandlerFunc {
+	scopes := nilOrSplit(scopeSetting)
+	if len(scopes) <= 1 {
+		switch strings.TrimSpace(scopeSetting) {
+		case "cluster":
+			return middleware.RateLimitMiddlewareByClusterFromBody(limiter, name, "cluster_id")
+		case "user":
+			return middleware.RateLimitMiddlewareByUserHeader(limiter, name, "X-User-ID")
+		case "project":
+			return middleware.HierarchicalRateLimitMiddleware(limiter,
+				[]middleware.LimitLevelSpec{{Scope: "project", Name: name + "_project"}}, "X-User-ID", "cluster_id")
+		}
+		return middleware.RateLimitMiddleware(limiter, name)
+	}
+	levels := make([]middleware.LimitLevelSpec, 0, len(scopes))
+	for _, scope := range scopes {
+		switch scope {
+		case "global":
+			levels = append(levels, middleware.LimitLevelSpec{Scope: scope, Name: name + "_global"})
+		case "project":
+			levels = append(levels, middleware.LimitLevelSpec{Scope: scope, Name: name + "_project"})
+		case "user", "cluster":
+			levels = append(levels, middleware.LimitLevelSpec{Scope: scope, Name: name})
+		default:
+			logger.Warnf("ignoring unknown rate limit scope %q for %s", scope, name)
+		}
+	}
+	return middleware.HierarchicalRateLimitMiddleware(limiter, levels, "X-User-ID", "cluster_id")
+}
+
+// envFloat reads a float environment variable, falling back to def when unset or invalid.
+func envFloat(key string, def float64) float64 {
+	if v := os.Getenv(key); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return def
+}
+
+// envInt reads an integer environment variable, falling back to def when unset or invalid.
+func envInt(key string, def int64) int64 {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	}
+	return def
+}
+
 // nilOrSplit returns a string slice from comma-separated list, or a single default entry
 func nilOrSplit(s string) []string {
 	if s == "" {
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/logger"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+// principalKey holds the *models.Principal of an authenticated request.
+const principalKey = "principal"
+
+// APIKeyAuth authenticates requests by the API key in "Authorization: Bearer <key>" or
+// X-API-Key and rejects requests without a valid key with 401. Keys scoped to API areas
+// only reach routes whose first segment after basePath (e.g. jobs in /api/v1/jobs/:id) is
+// one of their scopes; other routes get 403. The principal's user id replaces userHeader
+// (X-User-ID), so rate limits, quotas and job fairness key on the authenticated caller
+// instead of a client-supplied value. With a nil service authentication is disabled and
+// userHeader is trusted as before.
+func APIKeyAuth(keys *services.APIKeyService, basePath string, userHeader string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if keys == nil {
+			c.Next()
+			return
+		}
+		key := c.GetHeader("X-API-Key")
+		if auth := c.GetHeader("Authorization"); key == "" && strings.HasPrefix(auth, "Bearer ") {
+			key = strings.TrimPrefix(auth, "Bearer ")
+		}
+		p, err := keys.Authenticate(key)
+		if err != nil {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "a valid API key is required"})
+			c.Abort()
+			return
+		}
+		area, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(c.FullPath(), basePath), "/"), "/")
+		if err := services.Authorize(p, models.RoleViewer, area); err != nil {
+			logger.Warnf("API key %s of %s denied %s %s: %v", p.KeyID, p.UserID, c.Request.Method, c.FullPath(), err)
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		c.Set(principalKey, p)
+		c.Request.Header.Set(userHeader, p.UserID)
+		c.Next()
+	}
+}
+
+// RequireRole rejects requests of principals below role with 403. Requests without a
+// principal pass: APIKeyAuth only lets them through while authentication is disabled.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := PrincipalFrom(c)
+		if !ok {
+			c.Next()
+			return
+		}
+		if err := services.Authorize(p, role, ""); err != nil {
+			logger.Warnf("API key %s of %s denied %s %s: %v", p.KeyID, p.UserID, c.Request.Method, c.FullPath(), err)
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// PrincipalFrom returns the caller authenticated by APIKeyAuth.
+func PrincipalFrom(c *gin.Context) (*models.Principal, bool) {
+	v, ok := c.Get(principalKey)
+	if !ok {
+		return nil, false
+	}
+	p, ok := v.(*models.Principal)
+	return p, ok
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/logger"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ConcurrencyMiddleware holds a slot of the named concurrency rule while the request runs.
+// Slots are counted per user (userHeader) or per cluster (the JSON body field clusterField)
+// depending on the rule's scope; requests without that user or cluster, and unknown rules,
+// are not limited. In reject mode a request finding every slot taken gets 429 right away;
+// in queue mode it waits for a slot and gets 429 once the rule's queue timeout elapses.
+func ConcurrencyMiddleware(limiter *services.ConcurrencyLimiter, name string, userHeader string, clusterField string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rule, ok := limiter.Rule(name)
+		if !ok {
+			c.Next()
+			return
+		}
+		scopeID := c.GetHeader(userHeader)
+		if rule.Scope == "cluster" {
+			scopeID = clusterFromBody(c, clusterField)
+		}
+
+		lease, err := limiter.Acquire(c.Request.Context(), name, scopeID)
+		switch {
+		case errors.Is(err, services.ErrConcurrencyLimited):
+			logger.Warnf("concurrency limit %s reached for %s %s: %s %s", name, rule.Scope, scopeID, c.Request.Method, c.FullPath())
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		case err != nil:
+			logger.Warnf("concurrency limit %s check failed: %v", name, err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		defer lease.Release()
+
+		c.Next()
+	}
+}
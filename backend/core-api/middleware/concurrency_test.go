@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+func TestConcurrencyMiddleware_HoldsSlotWhileRunning(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter := services.NewConcurrencyLimiter(nil, 0)
+	_ = limiter.AddRule(models.ConcurrencyRule{Name: "diagnose", Scope: "cluster", Limit: 1})
+	started := make(chan struct{}, 1)
+	unblock := make(chan struct{})
+	r := gin.New()
+	r.POST("/diagnosis/diagnose", ConcurrencyMiddleware(limiter, "diagnose", "X-User-ID", "cluster_id"), func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		if strings.Contains(string(body), "slow") {
+			started <- struct{}{}
+			<-unblock
+		}
+		c.String(http.StatusOK, string(body))
+	})
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/diagnosis/diagnose", strings.NewReader(body)))
+		return w
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- post(`{"cluster_id":"c1","note":"slow"}`) }()
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("slow request never started")
+	}
+	if w := post(`{"cluster_id":"c1"}`); w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), "1 diagnose running for cluster c1") {
+		t.Fatalf("expected 429 while c1's slot is taken, got %d %s", w.Code, w.Body)
+	}
+	if w := post(`{"cluster_id":"c2"}`); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "c2") {
+		t.Fatalf("expected another cluster to pass with its body intact, got %d %s", w.Code, w.Body)
+	}
+	close(unblock)
+	if w := <-done; w.Code != http.StatusOK {
+		t.Fatalf("expected the slow request to finish, got %d", w.Code)
+	}
+	if w := post(`{"cluster_id":"c1"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected the slot to be released after the request, got %d", w.Code)
+	}
+}
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/logger"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// IdempotencyKeyHeader is the request header that makes a POST safe to retry.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencyLockTTL bounds how long a request may hold a key before a duplicate can
+// take over, and how long duplicates wait for the first request to finish.
+const idempotencyLockTTL = 30 * time.Second
+
+const idempotencyPollInterval = 25 * time.Millisecond
+
+// IdempotencyMiddleware runs a request at most once per Idempotency-Key (scoped by
+// method, route and X-User-ID). The first response is cached for ttl and replayed to
+// retries with an Idempotent-Replayed header; duplicates arriving while the first
+// request runs wait for its result. Reusing a key with a different body returns 422.
+// Server errors and 429s are not cached so the client can retry them. Requests without
+// the header, or when the store is unavailable, run normally.
+func IdempotencyMiddleware(store services.IdempotencyStore, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || store == nil {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+			c.Abort()
+			return
+		}
+
+		body, err := c.GetRawData()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+		bodySum := sha256.Sum256(body)
+		requestHash := hex.EncodeToString(bodySum[:])
+		scopeSum := sha256.Sum256([]byte(c.Request.Method + " " + c.FullPath() + " " + c.GetHeader("X-User-ID") + " " + key))
+		scope := hex.EncodeToString(scopeSum[:])
+
+		token := uuid.NewString()
+		deadline := time.Now().Add(idempotencyLockTTL)
+		for {
+			existing, err := store.Begin(scope, services.IdempotencyRecord{RequestHash: requestHash, Token: token}, idempotencyLockTTL)
+			if err != nil {
+				logger.Warnf("idempotency store unavailable, processing %s %s without key: %v", c.Request.Method, c.FullPath(), err)
+				c.Next()
+				return
+			}
+			if existing == nil {
+				break
+			}
+			if existing.RequestHash != requestHash {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request body"})
+				c.Abort()
+				return
+			}
+			if existing.Done {
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(existing.StatusCode, existing.ContentType, existing.Body)
+				c.Abort()
+				return
+			}
+			// the first request with this key is still running
+			if time.Now().After(deadline) {
+				c.JSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is still in progress"})
+				c.Abort()
+				return
+			}
+			select {
+			case <-c.Request.Context().Done():
+				c.Abort()
+				return
+			case <-time.After(idempotencyPollInterval):
+			}
+		}
+
+		// a panicking handler must not leave the key locked until the lock TTL runs out
+		defer func() {
+			if r := recover(); r != nil {
+				if err := store.Release(scope, token); err != nil {
+					logger.Warnf("failed to release idempotency key: %v", err)
+				}
+				panic(r)
+			}
+		}()
+
+		w := &capturingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+
+		status := w.Status()
+		if status >= 500 || status == http.StatusTooManyRequests {
+			if err := store.Release(scope, token); err != nil {
+				logger.Warnf("failed to release idempotency key: %v", err)
+			}
+			return
+		}
+		rec := services.IdempotencyRecord{
+			RequestHash: requestHash,
+			Done:        true,
+			StatusCode:  status,
+			ContentType: w.Header().Get("Content-Type"),
+			Body:        w.body.Bytes(),
+		}
+		if err := store.Complete(scope, rec, ttl); err != nil {
+			logger.Warnf("failed to store idempotent response: %v", err)
+		}
+	}
+}
+
+// capturingWriter copies the response body so it can be cached.
+type capturingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *capturingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *capturingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
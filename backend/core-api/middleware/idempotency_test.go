@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+func newIdempotentRouter(handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/jobs", IdempotencyMiddleware(services.NewMemoryIdempotencyStore(), time.Minute), handler)
+	return r
+}
+
+func postJob(r *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyMiddleware_ReplaysAndRejectsMismatch(t *testing.T) {
+	var calls int32
+	r := newIdempotentRouter(func(c *gin.Context) {
+		n := atomic.AddInt32(&calls, 1)
+		c.JSON(201, gin.H{"call": n})
+	})
+
+	first := postJob(r, "ci-run-1", `{"type":"monitor"}`)
+	again := postJob(r, "ci-run-1", `{"type":"monitor"}`)
+	if first.Code != 201 || again.Code != 201 || again.Body.String() != first.Body.String() {
+		t.Fatalf("expected the first response to be replayed, got %d %s / %d %s", first.Code, first.Body, again.Code, again.Body)
+	}
+	if again.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("expected replay header")
+	}
+	if calls != 1 {
+		t.Fatalf("expected handler to run once, ran %d times", calls)
+	}
+
+	if w := postJob(r, "ci-run-1", `{"type":"diagnose"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for a different body, got %d", w.Code)
+	}
+	postJob(r, "", `{"type":"monitor"}`)
+	postJob(r, "ci-run-2", `{"type":"monitor"}`)
+	if calls != 3 {
+		t.Fatalf("expected requests without or with a new key to run, got %d calls", calls)
+	}
+}
+
+func TestIdempotencyMiddleware_SerializesConcurrentDuplicates(t *testing.T) {
+	var calls int32
+	r := newIdempotentRouter(func(c *gin.Context) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(100 * time.Millisecond)
+		c.JSON(201, gin.H{"ok": true})
+	})
+
+	var wg sync.WaitGroup
+	codes := make([]int, 5)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = postJob(r, "same", `{}`).Code
+		}(i)
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Fatalf("expected one execution for concurrent duplicates, got %d", calls)
+	}
+	for _, code := range codes {
+		if code != 201 {
+			t.Fatalf("expected every duplicate to receive the first response, got %v", codes)
+		}
+	}
+}
+
+func TestIdempotencyMiddleware_ServerErrorsAreNotCached(t *testing.T) {
+	var calls int32
+	r := newIdempotentRouter(func(c *gin.Context) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			c.JSON(500, gin.H{"error": "boom"})
+			return
+		}
+		c.JSON(201, gin.H{"ok": true})
+	})
+	if w := postJob(r, "retry-me", `{}`); w.Code != 500 {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+	if w := postJob(r, "retry-me", `{}`); w.Code != 201 {
+		t.Fatalf("expected the retry to run again, got %d", w.Code)
+	}
+}
+
+func TestIdempotencyMiddleware_PanicReleasesKey(t *testing.T) {
+	var calls int32
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.POST("/jobs", IdempotencyMiddleware(services.NewMemoryIdempotencyStore(), time.Minute), func(c *gin.Context) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			panic("boom")
+		}
+		c.JSON(201, gin.H{"ok": true})
+	})
+
+	if w := postJob(r, "ci-run-1", `{"type":"monitor"}`); w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected the panic to surface as 500, got %d", w.Code)
+	}
+	start := time.Now()
+	if w := postJob(r, "ci-run-1", `{"type":"monitor"}`); w.Code != 201 {
+		t.Fatalf("expected the retry to run the handler, got %d", w.Code)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatalf("expected the retry not to wait for the lock TTL")
+	}
+}
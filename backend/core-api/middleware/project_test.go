@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/repositories"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestProjectScope_KeyBindingAndHeader(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed open sqlite: %v", err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.SetMaxOpenConns(1)
+	}
+	if err := db.AutoMigrate(&models.APIKey{}, &models.Project{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	projects := services.NewProjectService(repositories.NewProjectRepository(db), nil)
+	if err := projects.EnsureDefault(); err != nil {
+		t.Fatalf("EnsureDefault failed: %v", err)
+	}
+	teamA, err := projects.Create(models.CreateProjectRequest{Name: "team-a"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	keys := services.NewAPIKeyService(repositories.NewAPIKeyRepository(db))
+	_, boundKey, _ := keys.Create(models.CreateAPIKeyRequest{Name: "ci", UserID: "alice", Role: models.RoleOperator, ProjectID: teamA.ID}, "test")
+	_, adminKey, _ := keys.Create(models.CreateAPIKeyRequest{Name: "root", UserID: "root", Role: models.RoleAdmin}, "test")
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	api := r.Group("/api/v1", APIKeyAuth(keys, "/api/v1", "X-User-ID"), ProjectScope(projects, "X-Project-ID"))
+	api.GET("/clusters", func(c *gin.Context) { c.String(200, ProjectFrom(c)+"|"+c.GetHeader("X-Project-ID")) })
+
+	do := func(key, project string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/clusters", nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		if project != "" {
+			req.Header.Set("X-Project-ID", project)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(boundKey, ""); w.Code != 200 || w.Body.String() != teamA.ID+"|"+teamA.ID {
+		t.Fatalf("expected the key's project, got %d %q", w.Code, w.Body)
+	}
+	if w := do(boundKey, teamA.ID); w.Code != 200 {
+		t.Fatalf("expected naming the key's own project to pass, got %d", w.Code)
+	}
+	if w := do(boundKey, models.DefaultProjectID); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a bound key naming another project, got %d", w.Code)
+	}
+	if w := do(adminKey, ""); w.Code != 200 || w.Body.String() != "default|default" {
+		t.Fatalf("expected the default project without a header, got %d %q", w.Code, w.Body)
+	}
+	if w := do(adminKey, teamA.ID); w.Code != 200 || w.Body.String() != teamA.ID+"|"+teamA.ID {
+		t.Fatalf("expected admin keys to pick a project, got %d %q", w.Code, w.Body)
+	}
+	if w := do(adminKey, "project-missing"); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown project, got %d", w.Code)
+	}
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/logger"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+// QuotaCharges returns what a request uses of a quota, given its raw body (which stays
+// readable for the handler). Requests without charges pass without being counted.
+type QuotaCharges func(c *gin.Context, body []byte) []services.QuotaCharge
+
+// quotaRefundsKey holds the request bodies of the parts of a request that failed, see RefundQuota.
+const quotaRefundsKey = "quota_refunds"
+
+// RefundQuota gives back the quota charges of the part of a request that failed although
+// the request as a whole succeeded, e.g. the failed items of a bulk request. body has the
+// format of the request body and holds only the failed part; every QuotaMiddleware of the
+// request releases what its QuotaCharges would charge for it.
+func RefundQuota(c *gin.Context, body []byte) {
+	refunds := c.GetStringSlice(quotaRefundsKey)
+	c.Set(quotaRefundsKey, append(refunds, string(body)))
+}
+
+// QuotaMiddleware enforces the named quota. A request needing more than the whole limit
+// gets 403; one that does not fit in the rest of the current day/month gets 429 with
+// Retry-After until the window resets. Requests that fail (status >= 400) give their
+// charges back, as do the failed parts of a request reported with RefundQuota. Unknown quotas (e.g. disabled in the configuration) are not enforced.
+func QuotaMiddleware(quotas *services.QuotaManager, name string, charges QuotaCharges) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := quotas.Rule(name); !ok {
+			c.Next()
+			return
+		}
+		body, err := c.GetRawData()
+		if err == nil {
+			c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+		}
+		chs := charges(c, body)
+		if len(chs) == 0 {
+			c.Next()
+			return
+		}
+
+		usage, ok, err := quotas.Consume(name, chs)
+		switch {
+		case errors.Is(err, services.ErrQuotaTooLarge):
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": fmt.Sprintf("request exceeds the %s quota of %d per %s for %s %s", name, usage.Limit, usage.Period, usage.Scope, usage.ScopeID),
+				"quota": usage,
+			})
+			c.Abort()
+			return
+		case err != nil:
+			logger.Warnf("quota %s check failed: %v", name, err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		case !ok:
+			logger.Warnf("quota %s exceeded for %s %s", name, usage.Scope, usage.ScopeID)
+			wait := time.Until(usage.ResetsAt).Seconds()
+			c.Header("Retry-After", strconv.FormatInt(int64(math.Max(1, math.Ceil(wait))), 10))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error": fmt.Sprintf("%s quota exceeded for %s %s: %d of %d per %s used", name, usage.Scope, usage.ScopeID, usage.Used, usage.Limit, usage.Period),
+				"quota": usage,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+		if c.Writer.Status() < http.StatusBadRequest {
+			chs = nil
+			for _, failed := range c.GetStringSlice(quotaRefundsKey) {
+				chs = append(chs, charges(c, []byte(failed))...)
+			}
+		}
+		if len(chs) > 0 {
+			if err := quotas.Release(name, chs); err != nil {
+				logger.Warnf("releasing quota %s failed: %v", name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+func TestQuotaMiddleware_ChargesAndReleases(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	quotas := services.NewQuotaManager(nil, nil)
+	_ = quotas.AddRule(models.QuotaRule{Name: "droplets", Scope: "cluster", Period: models.QuotaPeriodMonth, Limit: 3})
+	charges := func(c *gin.Context, body []byte) []services.QuotaCharge {
+		var req struct {
+			ClusterID string `json:"cluster_id"`
+			Count     int64  `json:"count"`
+		}
+		_ = json.Unmarshal(body, &req)
+		return []services.QuotaCharge{{ScopeID: req.ClusterID, Units: req.Count}}
+	}
+	r := gin.New()
+	r.POST("/droplets", QuotaMiddleware(quotas, "droplets", charges), func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		if strings.Contains(string(body), "fail") {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "boom"})
+			return
+		}
+		c.String(http.StatusCreated, string(body))
+	})
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/droplets", strings.NewReader(body)))
+		return w
+	}
+	used := func() int64 {
+		usage, _ := quotas.Usage("droplets", "c1")
+		return usage.Used
+	}
+
+	if w := post(`{"cluster_id":"c1","count":2}`); w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), "c1") {
+		t.Fatalf("expected the first droplets to be created with the body intact, got %d %s", w.Code, w.Body)
+	}
+	if w := post(`{"cluster_id":"c1","count":1,"name":"fail"}`); w.Code != http.StatusInternalServerError || used() != 2 {
+		t.Fatalf("expected a failed request to give its charge back, got %d with %d used", w.Code, used())
+	}
+	w := post(`{"cluster_id":"c1","count":2}`)
+	if w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), "2 of 3 per month") {
+		t.Fatalf("expected 429 once the month's quota is short, got %d %s", w.Code, w.Body)
+	}
+	if retry, err := strconv.Atoi(w.Header().Get("Retry-After")); err != nil || retry < 1 {
+		t.Fatalf("expected Retry-After until the window resets, got %q", w.Header().Get("Retry-After"))
+	}
+	if w := post(`{"cluster_id":"c1","count":4}`); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a request above the whole limit, got %d", w.Code)
+	}
+	if w := post(`{"cluster_id":"c2","count":3}`); w.Code != http.StatusCreated {
+		t.Fatalf("expected another cluster to have its own quota, got %d", w.Code)
+	}
+	if w := post(`{"name":"no cluster"}`); w.Code != http.StatusCreated {
+		t.Fatalf("expected requests without charges to pass, got %d", w.Code)
+	}
+}
+
+func TestQuotaMiddleware_RefundsFailedParts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	quotas := services.NewQuotaManager(nil, nil)
+	_ = quotas.AddRule(models.QuotaRule{Name: "provision_jobs", Scope: "user", Period: models.QuotaPeriodDay, Limit: 5})
+	charges := func(c *gin.Context, body []byte) []services.QuotaCharge {
+		var req struct {
+			Items []string `json:"items"`
+		}
+		_ = json.Unmarshal(body, &req)
+		return []services.QuotaCharge{{ScopeID: "alice", Units: int64(len(req.Items))}}
+	}
+	r := gin.New()
+	r.POST("/bulk", QuotaMiddleware(quotas, "provision_jobs", charges), func(c *gin.Context) {
+		// one of the three items fails
+		RefundQuota(c, []byte(`{"items":["bad"]}`))
+		c.Status(http.StatusOK)
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/bulk", strings.NewReader(`{"items":["a","bad","b"]}`)))
+	if usage, _ := quotas.Usage("provision_jobs", "alice"); w.Code != http.StatusOK || usage.Used != 2 {
+		t.Fatalf("expected only the succeeded items to count, got %d with %d used", w.Code, usage.Used)
+	}
+}
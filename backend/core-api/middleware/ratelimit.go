@@ -3,8 +3,12 @@ package middleware
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/AvinashMahala/ClusterGenie/backend/core-api/logger"
 
@@ -12,6 +16,73 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// rateLimitCostKey holds the number of tokens the current request spends (default 1).
+const rateLimitCostKey = "ratelimit_cost"
+
+// RateLimitCost sets the token cost of a request for the rate limit middlewares after it,
+// e.g. the batch size of a bulk request. cost receives the request and its body, which
+// stays readable for the handler; costs below 1 count as 1.
+func RateLimitCost(cost func(c *gin.Context, body []byte) int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := c.GetRawData()
+		if err == nil {
+			c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+			if n := cost(c, body); n > 1 {
+				c.Set(rateLimitCostKey, n)
+			}
+		}
+		c.Next()
+	}
+}
+
+func requestCost(c *gin.Context) int {
+	if n, ok := c.Get(rateLimitCostKey); ok {
+		return n.(int)
+	}
+	return 1
+}
+
+// allowRequest spends the request's cost from b and sets the rate limit headers. The
+// decision, the tokens left and the retry wait come from one atomic step (a single Lua
+// script for Redis-backed buckets), so the headers describe the state the decision saw.
+// Requests costing more than the bucket can ever hold are rejected with an explanation
+// instead of a plain 429. It returns the tokens left for the availability metric.
+func allowRequest(c *gin.Context, manager *services.LimiterManager, b services.RateLimiter) (bool, string, float64) {
+	cost := requestCost(c)
+	d := manager.Composite(services.LimitLevel{Limiter: b}).Take(cost)
+	setRateLimitHeaders(c, d.Available, d.Capacity, d.Rate)
+	if !d.Allowed {
+		setRetryAfter(c, d.RetryAfter)
+		if d.RetryAfter < 0 && float64(cost) > d.Capacity {
+			return false, fmt.Sprintf("request costs %d tokens but the rate limit allows at most %.0f; split it into smaller batches", cost, d.Capacity), d.Available
+		}
+		return false, "rate limit exceeded", d.Available
+	}
+	return true, "", d.Available
+}
+
+// setRateLimitHeaders reports the limiter state: RateLimit-Limit (capacity), RateLimit-Remaining
+// and RateLimit-Reset, the seconds until the limiter is full again (omitted if it never refills).
+func setRateLimitHeaders(c *gin.Context, available, capacity, rate float64) {
+	c.Header("RateLimit-Limit", strconv.FormatFloat(math.Floor(capacity), 'f', 0, 64))
+	c.Header("RateLimit-Remaining", strconv.FormatFloat(math.Max(0, math.Floor(available)), 'f', 0, 64))
+	missing := math.Max(0, capacity-available)
+	switch {
+	case missing == 0:
+		c.Header("RateLimit-Reset", "0")
+	case rate > 0:
+		c.Header("RateLimit-Reset", strconv.FormatFloat(math.Ceil(missing/rate), 'f', 0, 64))
+	}
+}
+
+// setRetryAfter sets Retry-After in whole seconds (at least 1) unless the request can never
+// be allowed (negative wait).
+func setRetryAfter(c *gin.Context, wait time.Duration) {
+	if wait >= 0 {
+		c.Header("Retry-After", strconv.FormatInt(int64(math.Max(1, math.Ceil(wait.Seconds()))), 10))
+	}
+}
+
 // RateLimitMiddleware returns a Gin middleware that uses a named bucket in the LimiterManager
 func RateLimitMiddleware(manager *services.LimiterManager, bucketName string) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -21,24 +92,23 @@ func RateLimitMiddleware(manager *services.LimiterManager, bucketName string) gi
 			c.Next()
 			return
 		}
+		manager.RecordRequest(bucketName, "", requestCost(c))
 
-		if ok := b.Allow(1); !ok {
+		ok, msg, avail := allowRequest(c, manager, b)
+		if !ok {
 			// log throttle occurrences for observability
 			logger.Warnf("rate limit exceeded for %s %s", c.Request.Method, c.FullPath())
 			// increment Prometheus metric if available
 			if services.RateLimitExceeded != nil {
-				services.RateLimitExceeded.WithLabelValues(bucketName, "global", "").Inc()
+				services.RateLimitExceeded.WithLabelValues(bucketName, "global").Inc()
 			}
-			c.JSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": msg})
 			c.Abort()
 			return
 		}
 		// update available token metric
 		if services.RateLimitAvailable != nil {
-			avail, cap, rate := b.Status()
 			services.RateLimitAvailable.WithLabelValues(bucketName, "global", "").Set(avail)
-			_ = cap
-			_ = rate
 		}
 
 		c.Next()
@@ -50,8 +120,10 @@ func RateLimitMiddlewareByUserHeader(manager *services.LimiterManager, bucketNam
 	return func(c *gin.Context) {
 		uid := c.GetHeader(headerName)
 		var b services.RateLimiter
+		scope := ""
 		if uid != "" {
-			b = manager.GetOrCreate(bucketName, "user:"+uid)
+			scope = "user:" + uid
+			b = manager.GetOrCreate(bucketName, scope)
 		} else {
 			b = manager.Get(bucketName)
 		}
@@ -60,20 +132,19 @@ func RateLimitMiddlewareByUserHeader(manager *services.LimiterManager, bucketNam
 			c.Next()
 			return
 		}
+		manager.RecordRequest(bucketName, scope, requestCost(c))
 
-		if ok := b.Allow(1); !ok {
+		ok, msg, _ := allowRequest(c, manager, b)
+		if !ok {
 			logger.Warnf("rate limit exceeded for %s %s user=%s", c.Request.Method, c.FullPath(), uid)
 			if services.RateLimitExceeded != nil {
-				services.RateLimitExceeded.WithLabelValues(bucketName, "user", uid).Inc()
+				services.RateLimitExceeded.WithLabelValues(bucketName, "user").Inc()
 			}
-			c.JSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": msg})
 			c.Abort()
 			return
 		}
-		if services.RateLimitAvailable != nil {
-			avail, _, _ := b.Status()
-			services.RateLimitAvailable.WithLabelValues(bucketName, "user", uid).Set(avail)
-		}
+		// per-scope availability is exported by LimiterManager.ExportMetrics (top N scopes only)
 
 		c.Next()
 	}
@@ -83,29 +154,13 @@ func RateLimitMiddlewareByUserHeader(manager *services.LimiterManager, bucketNam
 // It restores the request body so handlers can still read it.
 func RateLimitMiddlewareByClusterFromBody(manager *services.LimiterManager, bucketName string, field string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// read body bytes safely
-		buf, err := c.GetRawData()
-		// restore body for later handlers
-		if err == nil && buf != nil {
-			c.Request.Body = io.NopCloser(bytes.NewBuffer(buf))
-		}
-
-		var scopeKey string
-		if err == nil && buf != nil && len(buf) > 0 {
-			// try an inexpensive parse for cluster_id
-			var m map[string]interface{}
-			if jerr := json.Unmarshal(buf, &m); jerr == nil {
-				if v, ok := m[field]; ok {
-					if s, ok2 := v.(string); ok2 {
-						scopeKey = s
-					}
-				}
-			}
-		}
+		scopeKey := clusterFromBody(c, field)
 
 		var b services.RateLimiter
+		scope := ""
 		if scopeKey != "" {
-			b = manager.GetOrCreate(bucketName, "cluster:"+scopeKey)
+			scope = "cluster:" + scopeKey
+			b = manager.GetOrCreate(bucketName, scope)
 		} else {
 			b = manager.Get(bucketName)
 		}
@@ -114,20 +169,127 @@ func RateLimitMiddlewareByClusterFromBody(manager *services.LimiterManager, buck
 			c.Next()
 			return
 		}
+		manager.RecordRequest(bucketName, scope, requestCost(c))
 
-		if ok := b.Allow(1); !ok {
+		ok, msg, _ := allowRequest(c, manager, b)
+		if !ok {
 			logger.Warnf("rate limit exceeded for %s %s cluster=%s", c.Request.Method, c.FullPath(), scopeKey)
 			if services.RateLimitExceeded != nil {
-				// cluster scope label
-				services.RateLimitExceeded.WithLabelValues(bucketName, "cluster", scopeKey).Inc()
+				services.RateLimitExceeded.WithLabelValues(bucketName, "cluster").Inc()
 			}
-			c.JSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": msg})
 			c.Abort()
 			return
 		}
-		if services.RateLimitAvailable != nil {
-			avail, _, _ := b.Status()
-			services.RateLimitAvailable.WithLabelValues(bucketName, "cluster", scopeKey).Set(avail)
+		// per-scope availability is exported by LimiterManager.ExportMetrics (top N scopes only)
+
+		c.Next()
+	}
+}
+
+// clusterFromBody reads the string field (e.g. cluster_id) from a JSON request body and
+// restores the body so handlers can still read it.
+func clusterFromBody(c *gin.Context, field string) string {
+	// read body bytes safely
+	buf, err := c.GetRawData()
+	// restore body for later handlers
+	if err == nil && buf != nil {
+		c.Request.Body = io.NopCloser(bytes.NewBuffer(buf))
+	}
+
+	var scopeKey string
+	if err == nil && buf != nil && len(buf) > 0 {
+		// try an inexpensive parse for cluster_id
+		var m map[string]interface{}
+		if jerr := json.Unmarshal(buf, &m); jerr == nil {
+			if v, ok := m[field]; ok {
+				if s, ok2 := v.(string); ok2 {
+					scopeKey = s
+				}
+			}
+		}
+	}
+	return scopeKey
+}
+
+// LimitLevelSpec declares one level of a hierarchical limit: the limiter name and the scope
+// its buckets are keyed by (global, project, user or cluster).
+type LimitLevelSpec struct {
+	Scope string
+	Name  string
+}
+
+// HierarchicalRateLimitMiddleware enforces several levels at once, e.g. a global bucket, the
+// caller's project bucket (see ProjectScope), the user's bucket (from userHeader) and the
+// cluster's bucket (from the JSON body field). A request only spends tokens if every level
+// allows it. Like the single-scope middlewares, a request without a user or cluster is
+// counted in the level's shared bucket, and the level is skipped when there is none. A 429
+// names the level that rejected the request.
+func HierarchicalRateLimitMiddleware(manager *services.LimiterManager, levels []LimitLevelSpec, userHeader string, clusterField string) gin.HandlerFunc {
+	needsCluster := false
+	for _, l := range levels {
+		needsCluster = needsCluster || l.Scope == "cluster"
+	}
+	return func(c *gin.Context) {
+		uid := c.GetHeader(userHeader)
+		var clusterID string
+		if needsCluster {
+			clusterID = clusterFromBody(c, clusterField)
+		}
+
+		composite := make([]services.LimitLevel, 0, len(levels))
+		labels := make(map[string][2]string, len(levels)) // level -> limiter name, scope id
+		for _, l := range levels {
+			scope, scopeID := "global", ""
+			switch l.Scope {
+			case "project":
+				scopeID = ProjectFrom(c)
+				scope = services.ProjectLimiterScope(scopeID)
+			case "user":
+				scope, scopeID = "user:"+uid, uid
+			case "cluster":
+				scope, scopeID = "cluster:"+clusterID, clusterID
+			}
+			var bucket services.RateLimiter
+			switch {
+			case l.Scope == "global" || scopeID != "":
+				bucket = manager.GetOrCreate(l.Name, scope)
+			case l.Scope != "project":
+				scope = ""
+				bucket = manager.Get(l.Name)
+			}
+			if bucket == nil {
+				continue
+			}
+			composite = append(composite, services.LimitLevel{Level: l.Scope, Limiter: bucket})
+			manager.RecordRequest(l.Name, scope, requestCost(c))
+			labels[l.Scope] = [2]string{l.Name, scopeID}
+		}
+		if len(composite) == 0 {
+			c.Next()
+			return
+		}
+
+		cost := requestCost(c)
+		d := manager.Composite(composite...).Take(cost)
+		setRateLimitHeaders(c, d.Available, d.Capacity, d.Rate)
+		label := labels[d.Level]
+		if !d.Allowed {
+			msg := d.Level + " rate limit exceeded"
+			if d.RetryAfter < 0 && float64(cost) > d.Capacity {
+				msg = fmt.Sprintf("request costs %d tokens but the %s rate limit allows at most %.0f; split it into smaller batches", cost, d.Level, d.Capacity)
+			}
+			setRetryAfter(c, d.RetryAfter)
+			logger.Warnf("%s rate limit exceeded for %s %s project=%s user=%s cluster=%s", d.Level, c.Request.Method, c.FullPath(), ProjectFrom(c), uid, clusterID)
+			if services.RateLimitExceeded != nil {
+				services.RateLimitExceeded.WithLabelValues(label[0], d.Level).Inc()
+			}
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": msg, "level": d.Level})
+			c.Abort()
+			return
+		}
+		if services.RateLimitAvailable != nil && d.Level == "global" {
+			services.RateLimitAvailable.WithLabelValues(label[0], d.Level, label[1]).Set(d.Available)
 		}
 
 		c.Next()
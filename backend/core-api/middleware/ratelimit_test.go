@@ -0,0 +1,268 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRateLimitCost_SpendsBatchSize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := services.NewLimiterManager(nil)
+	manager.Add("jobs_create", services.NewTokenBucket(0, 10))
+	cost := func(_ *gin.Context, body []byte) int {
+		var req struct {
+			Jobs []json.RawMessage `json:"jobs"`
+		}
+		_ = json.Unmarshal(body, &req)
+		return len(req.Jobs)
+	}
+	r := gin.New()
+	r.POST("/jobs/bulk", RateLimitCost(cost), RateLimitMiddleware(manager, "jobs_create"), func(c *gin.Context) {
+		// the body is still readable after the cost was computed
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(200, string(body))
+	})
+	post := func(n int) *httptest.ResponseRecorder {
+		body := `{"jobs":[` + strings.TrimSuffix(strings.Repeat(`{"type":"monitor"},`, n), ",") + `]}`
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/jobs/bulk", strings.NewReader(body)))
+		return w
+	}
+
+	if w := post(4); w.Code != 200 || !strings.Contains(w.Body.String(), "monitor") {
+		t.Fatalf("expected first batch to pass with its body, got %d %s", w.Code, w.Body)
+	}
+	if w := post(4); w.Code != 200 {
+		t.Fatalf("expected second batch to pass, got %d", w.Code)
+	}
+	if w := post(4); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 once the batch exceeds the remaining tokens, got %d", w.Code)
+	}
+	if w := post(2); w.Code != 200 {
+		t.Fatalf("expected a batch fitting the remaining tokens to pass, got %d", w.Code)
+	}
+	if w := post(11); w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), "smaller batches") {
+		t.Fatalf("expected a batch larger than the capacity to be rejected with advice, got %d %s", w.Code, w.Body)
+	}
+}
+
+func TestRateLimitMiddleware_SetsRateLimitHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := services.NewLimiterManager(nil)
+	manager.AddDefaultConfig("jobs_create", services.BucketConfig{RefillRate: 0.5, Capacity: 2})
+	r := gin.New()
+	r.POST("/jobs", RateLimitMiddlewareByUserHeader(manager, "jobs_create", "X-User-ID"), func(c *gin.Context) {
+		c.Status(200)
+	})
+	post := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/jobs", nil)
+		req.Header.Set("X-User-ID", "alice")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post()
+	if w.Code != 200 || w.Header().Get("RateLimit-Limit") != "2" || w.Header().Get("RateLimit-Remaining") != "1" {
+		t.Fatalf("unexpected first response: %d %v", w.Code, w.Header())
+	}
+	if reset := w.Header().Get("RateLimit-Reset"); reset != "2" {
+		t.Fatalf("expected the bucket to be full again in 2s, got %q", reset)
+	}
+	if w.Header().Get("Retry-After") != "" {
+		t.Fatalf("allowed requests should not carry Retry-After")
+	}
+	post()
+	w = post()
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("expected 429 with no remaining tokens, got %d %v", w.Code, w.Header())
+	}
+	// one token refills in 2s at 0.5 tokens/sec
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Fatalf("expected Retry-After 2, got %q", got)
+	}
+	if got := w.Header().Get("RateLimit-Reset"); got != "4" {
+		t.Fatalf("expected RateLimit-Reset 4, got %q", got)
+	}
+}
+
+func TestHierarchicalRateLimitMiddleware_AllLevelsOrNothing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := services.NewLimiterManager(nil)
+	manager.AddDefaultConfig("jobs_create_global", services.BucketConfig{RefillRate: 0, Capacity: 4})
+	manager.AddDefaultConfig("jobs_create", services.BucketConfig{RefillRate: 0, Capacity: 2})
+	levels := []LimitLevelSpec{{Scope: "global", Name: "jobs_create_global"}, {Scope: "user", Name: "jobs_create"}, {Scope: "cluster", Name: "jobs_create"}}
+	r := gin.New()
+	r.POST("/jobs", HierarchicalRateLimitMiddleware(manager, levels, "X-User-ID", "cluster_id"), func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(200, string(body))
+	})
+	post := func(user, cluster string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(`{"cluster_id":"`+cluster+`"}`))
+		req.Header.Set("X-User-ID", user)
+		r.ServeHTTP(w, req)
+		return w
+	}
+	level := func(w *httptest.ResponseRecorder) string {
+		var body struct {
+			Level string `json:"level"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		return body.Level
+	}
+
+	if w := post("alice", "c1"); w.Code != 200 || !strings.Contains(w.Body.String(), "c1") {
+		t.Fatalf("expected first request to pass with its body, got %d %s", w.Code, w.Body)
+	}
+	// the user and cluster buckets now hold 1 token each and the global bucket 3
+	if w := post("alice", "c2"); w.Code != 200 || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("expected second request to pass reporting the user's empty bucket, got %d %v", w.Code, w.Header())
+	}
+	w := post("alice", "c3")
+	if w.Code != http.StatusTooManyRequests || level(w) != "user" {
+		t.Fatalf("expected the user level to reject, got %d %s", w.Code, w.Body)
+	}
+	// the rejected request spent nothing: the global bucket still has 2 tokens, c3 still 2
+	if w := post("bob", "c3"); w.Code != 200 {
+		t.Fatalf("expected bob to pass, got %d %s", w.Code, w.Body)
+	}
+	if w := post("carol", "c1"); w.Code != 200 {
+		t.Fatalf("expected carol to pass, got %d %s", w.Code, w.Body)
+	}
+	if w := post("dave", "c1"); w.Code != http.StatusTooManyRequests || level(w) != "global" {
+		t.Fatalf("expected the global level to reject once its 4 tokens are spent, got %d %s", w.Code, w.Body)
+	}
+}
+
+func TestHierarchicalRateLimitMiddleware_AnonymousRequestsUseSharedBucket(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := services.NewLimiterManager(nil)
+	manager.Add("jobs_create", services.NewRateLimiter(services.BucketConfig{RefillRate: 0, Capacity: 1}))
+	manager.AddDefaultConfig("jobs_create", services.BucketConfig{RefillRate: 0, Capacity: 2})
+	manager.AddDefaultConfig("jobs_create_project", services.BucketConfig{RefillRate: 0, Capacity: 10})
+	levels := []LimitLevelSpec{{Scope: "project", Name: "jobs_create_project"}, {Scope: "user", Name: "jobs_create"}}
+	r := gin.New()
+	r.POST("/jobs", HierarchicalRateLimitMiddleware(manager, levels, "X-User-ID", "cluster_id"), func(c *gin.Context) {
+		c.Status(200)
+	})
+	post := func(user string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/jobs", nil)
+		if user != "" {
+			req.Header.Set("X-User-ID", user)
+		}
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// requests without a user share the unscoped bucket, as with a single user scope
+	if code := post(""); code != 200 {
+		t.Fatalf("expected the first anonymous request to pass, got %d", code)
+	}
+	if code := post(""); code != http.StatusTooManyRequests {
+		t.Fatalf("expected the shared bucket to reject the second anonymous request, got %d", code)
+	}
+	if code := post("alice"); code != 200 {
+		t.Fatalf("expected alice's own bucket to allow her request, got %d", code)
+	}
+	if got, _, _ := manager.GetOrCreate("jobs_create_project", services.ProjectLimiterScope(models.DefaultProjectID)).Status(); got != 8 {
+		t.Fatalf("expected the default project to have spent 2 tokens, got %v left", got)
+	}
+}
+
+func TestRateLimitMiddleware_RecordsRequestsForSimulation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := services.NewLimiterManager(nil)
+	manager.AddDefaultConfig("diagnosis", services.BucketConfig{RefillRate: 0, Capacity: 1})
+	r := gin.New()
+	r.POST("/diagnose", RateLimitMiddlewareByUserHeader(manager, "diagnosis", "X-User-ID"), func(c *gin.Context) {
+		c.Status(200)
+	})
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/diagnose", nil)
+		req.Header.Set("X-User-ID", "alice")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	recorded := manager.RecordedRequests("diagnosis")
+	if len(recorded) != 3 || recorded[0].Scope != "user:alice" || recorded[0].Cost != 1 {
+		t.Fatalf("expected 3 recorded requests of user:alice, got %+v", recorded)
+	}
+	res, err := manager.Simulate(models.RateLimitSimulationRequest{Name: "diagnosis", Capacity: 2})
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	if res.Accepted != 2 || res.Rejected != 1 {
+		t.Fatalf("expected a capacity of 2 to let 2 of the 3 requests through, got %+v", res)
+	}
+}
+
+// commandCounter counts the Redis commands sent by a client.
+type commandCounter struct{ n int32 }
+
+func (h *commandCounter) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h *commandCounter) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		atomic.AddInt32(&h.n, 1)
+		return next(ctx, cmd)
+	}
+}
+
+func (h *commandCounter) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func TestRateLimitMiddleware_OneRedisCallPerRequest(t *testing.T) {
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+	client := redis.NewClient(&redis.Options{Addr: redisAddr})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Skipf("Redis not available on %s - skipping integration test", redisAddr)
+	}
+	name := "test_one_call_" + uuid.NewString()
+	defer client.Del(context.Background(), "limiter:"+name+":user:alice")
+	manager := services.NewLimiterManager(client)
+	manager.AddDefaultConfig(name, services.BucketConfig{RefillRate: 0.001, Capacity: 2})
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/jobs", RateLimitMiddlewareByUserHeader(manager, name, "X-User-ID"), func(c *gin.Context) { c.Status(201) })
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/jobs", nil)
+		req.Header.Set("X-User-ID", "alice")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	// the first request creates the bucket and loads the script
+	post()
+
+	counter := &commandCounter{}
+	client.AddHook(counter)
+	if w := post(); w.Code != 201 || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("expected the second request to take the last token, got %d remaining=%s", w.Code, w.Header().Get("RateLimit-Remaining"))
+	}
+	w := post()
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 429 with Retry-After, got %d", w.Code)
+	}
+	if n := atomic.LoadInt32(&counter.n); n != 2 {
+		t.Fatalf("expected one Redis call per request, got %d for two requests", n)
+	}
+}
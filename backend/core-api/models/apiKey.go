@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Roles, from least to most privileged: viewers read, operators also change clusters,
+// jobs and deployments, admins also manage API keys, providers and limiter settings.
+const (
+	RoleViewer   = "viewer"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+// APIKey authenticates a caller as UserID with Role. Only the SHA-256 hash of the key is
+// stored; the key itself is shown once, when it is created.
+type APIKey struct {
+	ID      string `json:"id" gorm:"primaryKey" example:"key-1234"`
+	Name    string `json:"name" example:"ci-pipeline"`
+	Prefix  string `json:"prefix" example:"cgk_3fa9"` // start of the key, to recognise it
+	KeyHash string `json:"-" gorm:"uniqueIndex;size:64"`
+	UserID  string `json:"user_id" example:"alice"`
+	Role    string `json:"role" example:"operator"`
+	// ProjectID is the project the key acts in; empty on admin keys, which pick one with
+	// X-Project-ID
+	ProjectID string `json:"project_id,omitempty" gorm:"index" example:"default"`
+	// Scopes limits the key to these API areas (first path segment, e.g. jobs or clusters);
+	// empty allows every area its role allows
+	Scopes     []string   `json:"scopes,omitempty" gorm:"serializer:json;type:text"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedBy  string     `json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Principal is the caller authenticated by an API key.
+type Principal struct {
+	UserID    string   `json:"user_id"`
+	Role      string   `json:"role"`
+	ProjectID string   `json:"project_id,omitempty"`
+	Scopes    []string `json:"scopes,omitempty"`
+	KeyID     string   `json:"key_id"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name             string   `json:"name" example:"ci-pipeline"`
+	UserID           string   `json:"user_id" example:"alice"`
+	Role             string   `json:"role" example:"operator"`
+	ProjectID        string   `json:"project_id,omitempty" example:"default"` // defaults to the default project, except for admin keys
+	Scopes           []string `json:"scopes,omitempty" example:"jobs,clusters"`
+	ExpiresInSeconds int64    `json:"expires_in_seconds,omitempty" example:"2592000"` // 0 never expires
+}
+
+// CreateAPIKeyResponse carries the new key; it cannot be retrieved again.
+type CreateAPIKeyResponse struct {
+	Key    string  `json:"key" example:"cgk_3fa9..."`
+	APIKey *APIKey `json:"api_key"`
+}
+
+type ListAPIKeysResponse struct {
+	Keys []*APIKey `json:"keys"`
+}
@@ -5,6 +5,7 @@ import "time"
 // AutoscalePolicy represents an autoscaling policy for a cluster
 type AutoscalePolicy struct {
 	ID            string    `json:"id"`
+	ProjectID     string    `json:"project_id" gorm:"type:varchar(255);index"`
 	Name          string    `json:"name"`
 	ClusterID     string    `json:"cluster_id"`
 	Type          string    `json:"type"` // e.g. "metrics", "time_of_day", "cost"
@@ -31,6 +32,7 @@ type CreateAutoscalePolicyRequest struct {
 	MetricTrigger float64 `json:"metric_trigger"`
 	TimeWindow    string  `json:"time_window"`
 	CostLimit     float64 `json:"cost_limit"`
+	ProjectID     string  `json:"-"` // set from the caller's project by the handler
 }
 
 type UpdateAutoscalePolicyRequest = CreateAutoscalePolicyRequest
@@ -11,6 +11,7 @@ import (
 
 type Cluster struct {
 	ID          string      `json:"id" gorm:"primaryKey" example:"cluster-1"`
+	ProjectID   string      `json:"project_id" gorm:"type:varchar(255);index" example:"default"`
 	Name        string      `json:"name" example:"production-cluster"`
 	Region      string      `json:"region" example:"nyc3"`
 	Droplets    StringSlice `json:"droplets" gorm:"type:text"`
@@ -50,6 +51,7 @@ func (s *StringSlice) Scan(value interface{}) error {
 
 type DiagnoseClusterRequest struct {
 	ClusterID string `json:"cluster_id"`
+	ProjectID string `json:"-"` // set from the caller's project by the handler
 }
 
 type DiagnoseClusterResponse struct {
@@ -59,8 +61,9 @@ type DiagnoseClusterResponse struct {
 }
 
 type CreateClusterRequest struct {
-	Name   string `json:"name"`
-	Region string `json:"region"`
+	Name      string `json:"name"`
+	Region    string `json:"region"`
+	ProjectID string `json:"-"` // set from the caller's project by the handler
 }
 
 type UpdateClusterRequest struct {
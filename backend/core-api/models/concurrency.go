@@ -0,0 +1,36 @@
+package models
+
+// ConcurrencyRule caps how many operations of one kind (e.g. deployments or diagnose jobs)
+// may run at the same time for one user or cluster.
+type ConcurrencyRule struct {
+	Name  string `json:"name" example:"deployments"`
+	Scope string `json:"scope" example:"cluster"` // user or cluster
+	Limit int    `json:"limit" example:"1"`
+	// Mode decides what happens while every slot is taken: reject the operation right away,
+	// or queue it until a slot frees up
+	Mode string `json:"mode" example:"reject"`
+	// QueueTimeoutSeconds bounds how long a queued operation waits for a slot (0 = no limit)
+	QueueTimeoutSeconds int `json:"queue_timeout_seconds,omitempty" example:"30"`
+}
+
+const (
+	ConcurrencyModeReject = "reject"
+	ConcurrencyModeQueue  = "queue"
+)
+
+// ConcurrencyUsage is the number of operations of one kind running for a user or cluster.
+type ConcurrencyUsage struct {
+	Name     string `json:"name"`
+	Scope    string `json:"scope"`
+	ScopeID  string `json:"scope_id"`
+	Limit    int    `json:"limit"`
+	InFlight int    `json:"in_flight"`
+}
+
+type ListConcurrencyLimitsResponse struct {
+	Limits []ConcurrencyRule `json:"limits"`
+}
+
+type ConcurrencyUsageResponse struct {
+	Usage []ConcurrencyUsage `json:"usage"`
+}
@@ -4,6 +4,7 @@ import "time"
 
 type Deployment struct {
 	ID        string    `json:"id"`
+	ProjectID string    `json:"project_id" gorm:"type:varchar(255);index"`
 	ClusterID string    `json:"cluster_id"`
 	Version   string    `json:"version"`
 	Strategy  string    `json:"strategy"`       // canary|blue-green|rolling
@@ -19,4 +20,5 @@ type StartDeploymentRequest struct {
 	Version       string `json:"version"`
 	Strategy      string `json:"strategy"`
 	TargetPercent int    `json:"target_percent"`
+	ProjectID     string `json:"-"` // set from the caller's project by the handler
 }
@@ -6,6 +6,7 @@ import "time"
 
 type Droplet struct {
 	ID        string  `json:"id" gorm:"primaryKey"`
+	ProjectID string  `json:"project_id" gorm:"type:varchar(255);index"`
 	ClusterID *string `json:"cluster_id,omitempty" gorm:"column:cluster_id;type:varchar(255)"`
 	// Cluster object (optional) when returning droplet responses
 	Cluster   *Cluster  `json:"cluster,omitempty" gorm:"foreignKey:ClusterID;references:ID"`
@@ -27,6 +28,7 @@ type CreateDropletRequest struct {
 	Provider  string  `json:"provider,omitempty"` // optional provider override (demo)
 	Size      string  `json:"size" example:"s-1vcpu-1gb"`
 	Image     string  `json:"image" example:"ubuntu-20-04-x64"`
+	ProjectID string  `json:"-"` // set from the caller's project, or the cluster's
 }
 
 type DropletResponse struct {
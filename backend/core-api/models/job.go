@@ -5,22 +5,47 @@ package models
 import "time"
 
 type Job struct {
-	ID          string     `json:"id" gorm:"primaryKey" example:"job-1234"`
-	ClusterID   string     `json:"cluster_id" gorm:"type:varchar(255)" example:"cluster-1"`
-	Type        string     `json:"type" example:"provision"` // provision, diagnose, scale, monitor
-	Status      string     `json:"status" example:"pending"` // pending, running, completed, failed
-	CreatedAt   time.Time  `json:"created_at" gorm:"column:created_at"`
-	CompletedAt *time.Time `json:"completed_at,omitempty" gorm:"column:completed_at"`
-	Result      string     `json:"result,omitempty" example:"OK"`
-	Error       string     `json:"error,omitempty" example:""`
-	Progress    int        `json:"progress" gorm:"default:0"`
-	TraceID     string     `json:"trace_id,omitempty"`
-	Parameters  string     `json:"parameters,omitempty" gorm:"type:text"` // JSON string of parameters
+	ID             string     `json:"id" gorm:"primaryKey" example:"job-1234"`
+	ProjectID      string     `json:"project_id" gorm:"type:varchar(255);index" example:"default"`
+	ClusterID      string     `json:"cluster_id" gorm:"type:varchar(255)" example:"cluster-1"`
+	Type           string     `json:"type" example:"provision"` // provision, diagnose, scale, monitor
+	Status         string     `json:"status" example:"pending"` // blocked, pending, queued, running, retrying, completed, failed, cancelled, dead_lettered, skipped, timed_out
+	CreatedAt      time.Time  `json:"created_at" gorm:"column:created_at"`
+	StartedAt      *time.Time `json:"started_at,omitempty" gorm:"column:started_at"` // start of the current attempt; nil while waiting in the queue
+	CompletedAt    *time.Time `json:"completed_at,omitempty" gorm:"column:completed_at"`
+	Result         string     `json:"result,omitempty" example:"OK"`
+	Error          string     `json:"error,omitempty" example:""`
+	Progress       int        `json:"progress" gorm:"default:0"`
+	TraceID        string     `json:"trace_id,omitempty"`
+	Parameters     string     `json:"parameters,omitempty" gorm:"type:text"` // JSON string of parameters
+	Attempts       int        `json:"attempts" gorm:"default:0"`
+	MaxAttempts    int        `json:"max_attempts" gorm:"default:0"`
+	NextRunAt      *time.Time `json:"next_run_at,omitempty" gorm:"column:next_run_at"` // when a retrying job runs again
+	WorkflowID     string     `json:"workflow_id,omitempty" gorm:"type:varchar(255);index"`
+	DependsOn      []string   `json:"depends_on,omitempty" gorm:"serializer:json;type:text"` // parent job IDs that must complete first
+	Priority       string     `json:"priority" gorm:"type:varchar(20);default:normal"`       // high, normal, low
+	TenantID       string     `json:"tenant_id,omitempty" gorm:"type:varchar(255)"`          // fairness key: user:<id> or cluster:<id>
+	TimeoutSeconds int        `json:"timeout_seconds" gorm:"default:0"`                      // per-attempt limit from the job type's timeout; 0 = none
+	DeadlineAt     *time.Time `json:"deadline_at,omitempty" gorm:"column:deadline_at"`       // started_at + timeout of the current attempt
+}
+
+// JobDependency records that JobID waits for DependsOnID, so the children of a job can be
+// found without scanning every job's depends_on.
+type JobDependency struct {
+	JobID       string `gorm:"primaryKey;type:varchar(255)"`
+	DependsOnID string `gorm:"primaryKey;type:varchar(255);index"`
 }
 
 type CreateJobRequest struct {
-	Type       string            `json:"type"`
-	Parameters map[string]string `json:"parameters"`
+	ID             string            `json:"-"` // preassigned by workflows so children can name parents created with them
+	Type           string            `json:"type"`
+	Parameters     map[string]string `json:"parameters"`
+	DependsOn      []string          `json:"depends_on,omitempty"` // existing job IDs
+	Priority       string            `json:"priority,omitempty"`   // high, normal (default), low
+	TenantID       string            `json:"-"`                    // set from X-User-ID by the handler
+	WorkflowID     string            `json:"-"`
+	ProjectID      string            `json:"-"` // set from the caller's project by the handler
+	TimeoutSeconds int               `json:"-"` // set from the job type's timeout by the service
 }
 
 type JobResponse struct {
@@ -32,12 +57,87 @@ type ListJobsResponse struct {
 	Jobs     []*Job `json:"jobs"`
 	Page     int    `json:"page"`
 	PageSize int    `json:"page_size"`
-	Total    int64  `json:"total"`
+	Total    int64  `json:"total"` // jobs matching the filters
+	// NextCursor fetches the page after this one; empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 type GetJobsRequest struct {
 	Page     int    `json:"page"`
 	PageSize int    `json:"page_size"`
-	SortBy   string `json:"sort_by"`
+	SortBy   string `json:"sort_by"` // created_at (default), completed_at, progress, id
 	SortDir  string `json:"sort_dir"`
+	// Cursor continues from a previous page's NextCursor (keyset pagination); Page is ignored when set
+	Cursor string `json:"cursor"`
+	JobFilter
+}
+
+// JobFilter selects jobs for listing and bulk operations. Empty fields match everything.
+type JobFilter struct {
+	Statuses      []string   `json:"status,omitempty"`
+	Types         []string   `json:"type,omitempty"`
+	ClusterID     string     `json:"cluster_id,omitempty"`
+	TraceID       string     `json:"trace_id,omitempty"`
+	CreatedAfter  *time.Time `json:"created_after,omitempty"`  // inclusive
+	CreatedBefore *time.Time `json:"created_before,omitempty"` // exclusive
+	ProjectID     string     `json:"-"`                        // set from the caller's project by the handler
+}
+
+// IsEmpty reports whether the filter matches every job.
+func (f JobFilter) IsEmpty() bool {
+	return len(f.Statuses) == 0 && len(f.Types) == 0 && f.ClusterID == "" && f.TraceID == "" &&
+		f.CreatedAfter == nil && f.CreatedBefore == nil
+}
+
+type BulkCreateJobsRequest struct {
+	Jobs []CreateJobRequest `json:"jobs"`
+}
+
+// BulkJobActionRequest selects the jobs of a bulk cancel or requeue: either explicit
+// JobIDs or a Filter, at most Limit jobs.
+type BulkJobActionRequest struct {
+	JobIDs []string   `json:"job_ids,omitempty"`
+	Filter *JobFilter `json:"filter,omitempty"`
+	Limit  int        `json:"limit,omitempty"`
+	// ProjectID limits the action to the caller's project; set by the handler
+	ProjectID string `json:"-"`
+}
+
+// BulkJobResult is the outcome for one item of a bulk operation. Index is the position
+// in the request's jobs array (bulk create only).
+type BulkJobResult struct {
+	Index  int    `json:"index"`
+	JobID  string `json:"job_id,omitempty"`
+	Job    *Job   `json:"job,omitempty"`
+	Status int    `json:"status"` // HTTP status the single-job endpoint would have returned
+	Error  string `json:"error,omitempty"`
+}
+
+type BulkJobsResponse struct {
+	Results   []BulkJobResult `json:"results"`
+	Succeeded int             `json:"succeeded"`
+	Failed    int             `json:"failed"`
+}
+
+// Add appends a result and updates the counters.
+func (r *BulkJobsResponse) Add(res BulkJobResult) {
+	r.Results = append(r.Results, res)
+	if res.Error == "" {
+		r.Succeeded++
+	} else {
+		r.Failed++
+	}
+}
+
+// TerminalJobStatuses are the statuses of jobs that will never run again.
+var TerminalJobStatuses = []string{"completed", "failed", "cancelled", "queued_rejected", "dead_lettered", "skipped", "timed_out"}
+
+// IsTerminalJobStatus reports whether a job in this status will never run again.
+func IsTerminalJobStatus(status string) bool {
+	for _, s := range TerminalJobStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
 }
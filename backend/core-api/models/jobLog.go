@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// JobLog is one entry of a job's append-only log. IDs increase monotonically, so they
+// double as the pagination cursor and the SSE event id in follow mode.
+type JobLog struct {
+	ID        uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	JobID     string    `json:"job_id" gorm:"index:idx_job_logs_job_id_id,priority:1"`
+	Timestamp time.Time `json:"timestamp"`
+	Level     string    `json:"level" example:"info"` // info, warn, error
+	Message   string    `json:"message"`
+	Progress  int       `json:"progress"`
+	TraceID   string    `json:"trace_id,omitempty"`
+}
+
+const (
+	JobLogInfo  = "info"
+	JobLogWarn  = "warn"
+	JobLogError = "error"
+)
+
+type ListJobLogsResponse struct {
+	Logs []*JobLog `json:"logs"`
+	// NextAfter is the cursor for the next page (pass it as ?after=); HasMore reports whether that page has entries
+	NextAfter uint64 `json:"next_after"`
+	HasMore   bool   `json:"has_more"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// JobSchedule creates a job of JobType every time its cron expression fires.
+type JobSchedule struct {
+	ID            string            `json:"id" gorm:"primaryKey" example:"sched-1234"`
+	ProjectID     string            `json:"project_id" gorm:"type:varchar(255);index" example:"default"` // its jobs are created in this project
+	Name          string            `json:"name" example:"nightly-diagnose"`
+	ClusterID     string            `json:"cluster_id,omitempty" example:"cluster-1"` // copied into parameters.cluster_id
+	CronExpr      string            `json:"cron" gorm:"column:cron_expr" example:"*/15 * * * *"`
+	Timezone      string            `json:"timezone" example:"UTC"` // IANA name, e.g. Europe/Berlin
+	JobType       string            `json:"job_type" example:"monitor"`
+	Parameters    map[string]string `json:"parameters,omitempty" gorm:"serializer:json;type:text"`
+	Enabled       bool              `json:"enabled"`
+	OverlapPolicy string            `json:"overlap_policy" example:"skip"` // skip, queue, replace
+	PendingRun    bool              `json:"pending_run"`                   // a run is waiting for the previous job (queue policy)
+	LastRunAt     *time.Time        `json:"last_run_at,omitempty"`
+	NextRunAt     *time.Time        `json:"next_run_at,omitempty" gorm:"index"`
+	LastJobID     string            `json:"last_job_id,omitempty"`
+	LastError     string            `json:"last_error,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+type CreateScheduleRequest struct {
+	Name          string            `json:"name"`
+	ClusterID     string            `json:"cluster_id"`
+	CronExpr      string            `json:"cron"`
+	Timezone      string            `json:"timezone"`
+	JobType       string            `json:"job_type"`
+	Parameters    map[string]string `json:"parameters"`
+	Enabled       *bool             `json:"enabled"`        // default true
+	OverlapPolicy string            `json:"overlap_policy"` // default skip
+	ProjectID     string            `json:"-"`              // set from the caller's project by the handler
+}
+
+// UpdateScheduleRequest changes only the fields that are set.
+type UpdateScheduleRequest struct {
+	Name          *string            `json:"name"`
+	ClusterID     *string            `json:"cluster_id"`
+	CronExpr      *string            `json:"cron"`
+	Timezone      *string            `json:"timezone"`
+	JobType       *string            `json:"job_type"`
+	Parameters    *map[string]string `json:"parameters"`
+	Enabled       *bool              `json:"enabled"`
+	OverlapPolicy *string            `json:"overlap_policy"`
+}
+
+type ScheduleResponse struct {
+	Schedule *JobSchedule `json:"schedule"`
+	Message  string       `json:"message"`
+}
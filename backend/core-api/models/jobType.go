@@ -0,0 +1,27 @@
+// backend/core-api/models/jobType.go
+
+package models
+
+// JobParamSpec describes one job parameter. Parameters are passed as strings; Type
+// says how the value must parse.
+type JobParamSpec struct {
+	Name        string `json:"name" example:"cluster_id"`
+	Type        string `json:"type" example:"string"` // string, int, bool
+	Required    bool   `json:"required"`
+	Description string `json:"description,omitempty"`
+}
+
+// JobTypeSpec describes a registered job type.
+type JobTypeSpec struct {
+	Name         string         `json:"name" example:"provision"`
+	Description  string         `json:"description"`
+	Parameters   []JobParamSpec `json:"parameters"`
+	Orchestrated bool           `json:"orchestrated"` // completes when the orchestration consumer finishes it
+	// filled from the service configuration when listing
+	TimeoutSeconds int `json:"timeout_seconds"`
+	MaxAttempts    int `json:"max_attempts"`
+}
+
+type ListJobTypesResponse struct {
+	JobTypes []JobTypeSpec `json:"job_types"`
+}
@@ -14,10 +14,11 @@ type Metric struct {
 }
 
 type GetMetricsRequest struct {
-	ClusterID string `json:"cluster_id"`
-	Type      string `json:"type"`
-	Page      int    `json:"page"`
-	PageSize  int    `json:"page_size"`
+	ClusterID  string   `json:"cluster_id"`
+	ClusterIDs []string `json:"-"` // when not nil, only metrics of these clusters (the caller's project)
+	Type       string   `json:"type"`
+	Page       int      `json:"page"`
+	PageSize   int      `json:"page_size"`
 }
 
 type GetMetricsResponse struct {
@@ -0,0 +1,56 @@
+// backend/core-api/models/project.go
+
+package models
+
+import "time"
+
+// DefaultProjectID is the project of callers that name none and of rows created before
+// projects existed.
+const DefaultProjectID = "default"
+
+// Project is a tenant: it owns clusters, droplets, jobs, autoscaling policies and
+// deployments, and callers only see the resources of their own project.
+type Project struct {
+	ID          string `json:"id" gorm:"primaryKey" example:"project-1234"`
+	Name        string `json:"name" example:"payments"`
+	Description string `json:"description,omitempty"`
+	// RateLimits overrides the project level of hierarchical rate limits, keyed by limiter
+	// name (e.g. jobs_create_project); limiters not listed use their defaults.
+	RateLimits map[string]ProjectRateLimit `json:"rate_limits,omitempty" gorm:"serializer:json;type:text"`
+	CreatedAt  time.Time                   `json:"created_at"`
+	UpdatedAt  time.Time                   `json:"updated_at"`
+}
+
+// ProjectRateLimit sizes the bucket of one limiter for a project.
+type ProjectRateLimit struct {
+	RefillRate float64 `json:"refill_rate" example:"1"`
+	Capacity   float64 `json:"capacity" example:"20"`
+}
+
+type CreateProjectRequest struct {
+	Name        string                      `json:"name" example:"payments"`
+	Description string                      `json:"description,omitempty"`
+	RateLimits  map[string]ProjectRateLimit `json:"rate_limits,omitempty"`
+}
+
+// UpdateProjectRequest changes the set fields; RateLimits, when set, replaces all overrides
+// (an empty object removes them).
+type UpdateProjectRequest struct {
+	Name        string                       `json:"name,omitempty"`
+	Description *string                      `json:"description,omitempty"`
+	RateLimits  *map[string]ProjectRateLimit `json:"rate_limits,omitempty"`
+}
+
+type ListProjectsResponse struct {
+	Projects []*Project `json:"projects"`
+}
+
+// InProject reports whether a resource owned by owner is visible in projectID. An empty
+// projectID (internal callers) sees every project; resources without an owner, created
+// before projects existed, belong to the default project.
+func InProject(projectID, owner string) bool {
+	if owner == "" {
+		owner = DefaultProjectID
+	}
+	return projectID == "" || projectID == owner
+}
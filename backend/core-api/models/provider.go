@@ -16,3 +16,15 @@ type CreateProviderRequest struct {
 	Capacity int      `json:"capacity"`
 	Classes  []string `json:"classes"`
 }
+
+// ProviderUsage is a project's share of a provider: its droplets there and their hourly cost.
+type ProviderUsage struct {
+	Provider   string  `json:"provider" example:"do"`
+	Droplets   int     `json:"droplets" example:"3"`
+	HourlyCost float64 `json:"hourly_cost" example:"0.15"`
+}
+
+type ProviderUsageResponse struct {
+	ProjectID string          `json:"project_id"`
+	Usage     []ProviderUsage `json:"usage"`
+}
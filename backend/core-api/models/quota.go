@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// QuotaRule caps how many units (e.g. provision jobs or droplets) one user or cluster may
+// use per calendar day or month.
+type QuotaRule struct {
+	Name   string `json:"name" example:"provision_jobs"`
+	Scope  string `json:"scope" example:"user"` // user or cluster
+	Period string `json:"period" example:"day"` // day or month
+	Limit  int64  `json:"limit" example:"500"`
+}
+
+const (
+	QuotaPeriodDay   = "day"
+	QuotaPeriodMonth = "month"
+)
+
+// QuotaUsage is the consumption of a quota by one user or cluster in the current window.
+type QuotaUsage struct {
+	Name      string    `json:"name"`
+	Scope     string    `json:"scope"`
+	ScopeID   string    `json:"scope_id"`
+	Period    string    `json:"period"`
+	Limit     int64     `json:"limit"`
+	Used      int64     `json:"used"`
+	Remaining int64     `json:"remaining"`
+	ResetsAt  time.Time `json:"resets_at"` // end of the calendar window
+}
+
+type ListQuotasResponse struct {
+	Quotas []QuotaRule `json:"quotas"`
+}
+
+type QuotaUsageResponse struct {
+	Usage []QuotaUsage `json:"usage"`
+}
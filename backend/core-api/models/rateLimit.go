@@ -0,0 +1,47 @@
+package models
+
+// SimulatedRequest is one request of a rate limit simulation timeline.
+type SimulatedRequest struct {
+	// OffsetSeconds is when the request arrives, relative to the start of the timeline
+	OffsetSeconds float64 `json:"offset_seconds" example:"0.5"`
+	// Scope is the bucket the request counts against (e.g. user:alice); empty is the global bucket
+	Scope string `json:"scope,omitempty" example:"user:alice"`
+	// Cost is the tokens the request spends (default 1)
+	Cost int `json:"cost,omitempty" example:"1"`
+}
+
+// RateLimitSimulationRequest asks how a candidate limiter config would have handled a request
+// timeline. Without requests, the requests recently seen by the limiter called name are
+// replayed; config fields left out fall back to that limiter's current default config.
+type RateLimitSimulationRequest struct {
+	Name          string             `json:"name,omitempty" example:"jobs_create"`
+	RefillRate    float64            `json:"refill_rate,omitempty" example:"0.5"`
+	Capacity      float64            `json:"capacity,omitempty" example:"10"`
+	Algorithm     string             `json:"algorithm,omitempty" example:"token_bucket"`
+	WindowSeconds float64            `json:"window_seconds,omitempty" example:"20"`
+	Requests      []SimulatedRequest `json:"requests,omitempty"`
+}
+
+// RateLimitSimulationSecond counts the decisions within one second of the timeline.
+type RateLimitSimulationSecond struct {
+	Second   int64 `json:"second"`
+	Accepted int   `json:"accepted"`
+	Rejected int   `json:"rejected"`
+}
+
+// RateLimitSimulationResult is the outcome of replaying a timeline.
+type RateLimitSimulationResult struct {
+	RefillRate    float64 `json:"refill_rate"`
+	Capacity      float64 `json:"capacity"`
+	Algorithm     string  `json:"algorithm"`
+	WindowSeconds float64 `json:"window_seconds,omitempty"`
+	Requests      int     `json:"requests"`
+	Accepted      int     `json:"accepted"`
+	Rejected      int     `json:"rejected"`
+	// NeverAllowed counts rejected requests costing more than the limiter can ever allow
+	NeverAllowed int `json:"never_allowed"`
+	// PeakWaitSeconds is the longest Retry-After a rejected request would have been given
+	PeakWaitSeconds float64 `json:"peak_wait_seconds"`
+	// Series has one entry per second of the timeline that had requests
+	Series []RateLimitSimulationSecond `json:"series"`
+}
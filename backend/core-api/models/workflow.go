@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Workflow groups jobs submitted together as a dependency graph (DAG).
+// Its status is derived from the jobs it contains.
+type Workflow struct {
+	ID        string            `json:"id" gorm:"primaryKey" example:"wf-1234"`
+	ProjectID string            `json:"project_id" gorm:"type:varchar(255);index"`
+	Name      string            `json:"name" example:"provision-monitor-diagnose"`
+	Status    string            `json:"status" gorm:"-"`                       // running, completed, failed, cancelled
+	Keys      map[string]string `json:"keys" gorm:"serializer:json;type:text"` // node key -> job ID
+	CreatedAt time.Time         `json:"created_at" gorm:"column:created_at"`
+}
+
+// WorkflowJobSpec describes one node of a workflow. DependsOn refers to other nodes by key.
+type WorkflowJobSpec struct {
+	Key        string            `json:"key" example:"provision"`
+	Type       string            `json:"type" example:"provision"`
+	Parameters map[string]string `json:"parameters"`
+	DependsOn  []string          `json:"depends_on,omitempty"`
+	Priority   string            `json:"priority,omitempty"`
+}
+
+type CreateWorkflowRequest struct {
+	Name      string            `json:"name"`
+	Jobs      []WorkflowJobSpec `json:"jobs"`
+	TenantID  string            `json:"-"` // set from X-User-ID by the handler
+	ProjectID string            `json:"-"` // set from the caller's project by the handler
+}
+
+type WorkflowResponse struct {
+	Workflow *Workflow      `json:"workflow"`
+	Jobs     []*Job         `json:"jobs"`
+	Counts   map[string]int `json:"counts"` // jobs per status
+	Message  string         `json:"message,omitempty"`
+}
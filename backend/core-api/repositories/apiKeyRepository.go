@@ -0,0 +1,67 @@
+// backend/core-api/repositories/apiKeyRepository.go
+
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/interfaces"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) interfaces.APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+func (r *APIKeyRepository) Create(k *models.APIKey) error {
+	if k.ID == "" {
+		k.ID = "key-" + uuid.NewString()
+	}
+	return r.db.Create(k).Error
+}
+
+func (r *APIKeyRepository) TouchLastUsed(id string, at time.Time) error {
+	return r.db.Model(&models.APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error
+}
+
+func (r *APIKeyRepository) Revoke(id string, at time.Time) (bool, error) {
+	res := r.db.Model(&models.APIKey{}).Where("id = ? AND revoked_at IS NULL", id).UpdateColumn("revoked_at", at)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func (r *APIKeyRepository) Get(id string) (*models.APIKey, error) {
+	return r.first("id = ?", id)
+}
+
+func (r *APIKeyRepository) GetByHash(keyHash string) (*models.APIKey, error) {
+	return r.first("key_hash = ?", keyHash)
+}
+
+func (r *APIKeyRepository) first(query string, arg string) (*models.APIKey, error) {
+	var k models.APIKey
+	if err := r.db.First(&k, query, arg).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("api key not found")
+		}
+		return nil, err
+	}
+	return &k, nil
+}
+
+func (r *APIKeyRepository) List() ([]*models.APIKey, error) {
+	var out []*models.APIKey
+	if err := r.db.Order("created_at").Find(&out).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -29,6 +29,7 @@ func (r *AutoscalerRepository) CreatePolicy(p *models.AutoscalePolicy) error {
 	if p.ID == "" {
 		p.ID = "policy-" + uuid.NewString()
 	}
+	p.ProjectID = projectOrDefault(p.ProjectID)
 	now := time.Now()
 	p.CreatedAt = now
 	p.UpdatedAt = now
@@ -81,7 +82,7 @@ func (r *AutoscalerRepository) GetPolicy(id string) (*models.AutoscalePolicy, er
 	return &p, nil
 }
 
-func (r *AutoscalerRepository) ListPolicies(clusterID string) ([]*models.AutoscalePolicy, error) {
+func (r *AutoscalerRepository) ListPolicies(projectID, clusterID string) ([]*models.AutoscalePolicy, error) {
 	if r.redis == nil {
 		return nil, errors.New("redis not configured")
 	}
@@ -93,8 +94,8 @@ func (r *AutoscalerRepository) ListPolicies(clusterID string) ([]*models.Autosca
 	out := []*models.AutoscalePolicy{}
 	for _, id := range ids {
 		p, err := r.GetPolicy(id)
-		if err != nil {
-			// skip missing/corrupt entries
+		if err != nil || !models.InProject(projectID, p.ProjectID) {
+			// skip missing/corrupt entries and other projects' policies
 			continue
 		}
 		out = append(out, p)
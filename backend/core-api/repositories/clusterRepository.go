@@ -29,6 +29,7 @@ func NewClusterRepository(db *gorm.DB, redis *redis.Client) interfaces.ClusterRe
 
 func (r *ClusterRepository) CreateCluster(cluster *models.Cluster) (*models.Cluster, error) {
 	cluster.ID = "cluster-" + uuid.NewString()
+	cluster.ProjectID = projectOrDefault(cluster.ProjectID)
 	cluster.LastChecked = time.Now()
 	if err := r.db.Create(cluster).Error; err != nil {
 		return nil, err
@@ -62,9 +63,9 @@ func (r *ClusterRepository) GetCluster(id string) (*models.Cluster, error) {
 	return &cluster, nil
 }
 
-func (r *ClusterRepository) ListClusters() ([]*models.Cluster, error) {
+func (r *ClusterRepository) ListClusters(projectID string) ([]*models.Cluster, error) {
 	var clusters []*models.Cluster
-	if err := r.db.Find(&clusters).Error; err != nil {
+	if err := r.db.Scopes(inProject(projectID)).Find(&clusters).Error; err != nil {
 		return nil, err
 	}
 	return clusters, nil
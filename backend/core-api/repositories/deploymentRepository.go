@@ -29,6 +29,7 @@ func (r *DeploymentRepository) Create(d *models.Deployment) error {
 	if d.ID == "" {
 		d.ID = "deploy-" + uuid.NewString()
 	}
+	d.ProjectID = projectOrDefault(d.ProjectID)
 	now := time.Now()
 	d.StartedAt = now
 	d.UpdatedAt = now
@@ -66,7 +67,7 @@ func (r *DeploymentRepository) Get(id string) (*models.Deployment, error) {
 	return &d, nil
 }
 
-func (r *DeploymentRepository) List(clusterID string) ([]*models.Deployment, error) {
+func (r *DeploymentRepository) List(projectID, clusterID string) ([]*models.Deployment, error) {
 	if r.redis == nil {
 		return nil, errors.New("redis not configured")
 	}
@@ -78,7 +79,7 @@ func (r *DeploymentRepository) List(clusterID string) ([]*models.Deployment, err
 	out := []*models.Deployment{}
 	for _, id := range ids {
 		d, err := r.Get(id)
-		if err != nil {
+		if err != nil || !models.InProject(projectID, d.ProjectID) {
 			continue
 		}
 		out = append(out, d)
@@ -32,6 +32,7 @@ func (r *DropletRepositoryImpl) CreateDroplet(req *models.CreateDropletRequest)
 	id := "droplet-" + uuid.NewString()
 	droplet := &models.Droplet{
 		ID:        id,
+		ProjectID: projectOrDefault(req.ProjectID),
 		ClusterID: req.ClusterID,
 		Name:      req.Name,
 		Region:    req.Region,
@@ -124,9 +125,9 @@ func (r *DropletRepositoryImpl) GetDroplet(id string) (*models.Droplet, error) {
 	return &droplet, nil
 }
 
-func (r *DropletRepositoryImpl) ListDroplets() ([]*models.Droplet, error) {
+func (r *DropletRepositoryImpl) ListDroplets(projectID string) ([]*models.Droplet, error) {
 	var droplets []*models.Droplet
-	if err := r.db.Preload("Cluster").Find(&droplets).Error; err != nil {
+	if err := r.db.Preload("Cluster").Scopes(inProject(projectID)).Find(&droplets).Error; err != nil {
 		return nil, err
 	}
 	return droplets, nil
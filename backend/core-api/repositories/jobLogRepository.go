@@ -0,0 +1,29 @@
+// backend/core-api/repositories/jobLogRepository.go
+
+package repositories
+
+import (
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/interfaces"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"gorm.io/gorm"
+)
+
+type JobLogRepository struct {
+	db *gorm.DB
+}
+
+func NewJobLogRepository(db *gorm.DB) interfaces.JobLogRepository {
+	return &JobLogRepository{db: db}
+}
+
+func (r *JobLogRepository) Append(entry *models.JobLog) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *JobLogRepository) List(jobID string, afterID uint64, limit int) ([]*models.JobLog, error) {
+	var out []*models.JobLog
+	if err := r.db.Where("job_id = ? AND id > ?", jobID, afterID).Order("id").Limit(limit).Find(&out).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -3,6 +3,7 @@
 package repositories
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"time"
@@ -27,7 +28,27 @@ func NewJobRepository(db *gorm.DB, redis *redis.Client) interfaces.JobRepository
 }
 
 func (r *JobRepository) CreateJob(req *models.CreateJobRequest) (*models.JobResponse, error) {
-	id := "job-" + req.Type + "-" + time.Now().Format("20060102150405")
+	job, err := newJob(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.db.Transaction(func(tx *gorm.DB) error { return createJob(tx, job) }); err != nil {
+		return nil, err
+	}
+
+	return &models.JobResponse{
+		Job:     job,
+		Message: "Job created successfully",
+	}, nil
+}
+
+// newJob builds the row for a new job.
+func newJob(req *models.CreateJobRequest) (*models.Job, error) {
+	id := req.ID
+	if id == "" {
+		// the random suffix keeps IDs unique when several jobs are created in the same second (workflows)
+		id = "job-" + req.Type + "-" + time.Now().Format("20060102150405") + "-" + uuid.NewString()[:8]
+	}
 
 	// Convert parameters map to JSON string
 	var parameters string
@@ -39,24 +60,51 @@ func (r *JobRepository) CreateJob(req *models.CreateJobRequest) (*models.JobResp
 		parameters = string(paramsBytes)
 	}
 
-	job := &models.Job{
-		ID:         id,
-		Type:       req.Type,
-		Status:     "pending",
-		Progress:   0,
-		TraceID:    uuid.NewString(),
-		CreatedAt:  time.Now(),
-		Parameters: parameters,
+	// jobs with parents wait in "blocked" until every parent completes
+	status := "pending"
+	if len(req.DependsOn) > 0 {
+		status = "blocked"
 	}
 
-	if err := r.db.Create(job).Error; err != nil {
-		return nil, err
+	job := &models.Job{
+		ID:             id,
+		ProjectID:      projectOrDefault(req.ProjectID),
+		ClusterID:      req.Parameters["cluster_id"],
+		Type:           req.Type,
+		Status:         status,
+		Progress:       0,
+		TraceID:        uuid.NewString(),
+		CreatedAt:      time.Now(),
+		Parameters:     parameters,
+		WorkflowID:     req.WorkflowID,
+		DependsOn:      req.DependsOn,
+		Priority:       req.Priority,
+		TenantID:       req.TenantID,
+		TimeoutSeconds: req.TimeoutSeconds,
 	}
+	if job.Priority == "" {
+		job.Priority = "normal"
+	}
+	return job, nil
+}
 
-	return &models.JobResponse{
-		Job:     job,
-		Message: "Job created successfully",
-	}, nil
+// createJob inserts a job and its dependency rows.
+func createJob(tx *gorm.DB, job *models.Job) error {
+	if err := tx.Create(job).Error; err != nil {
+		return err
+	}
+	if len(job.DependsOn) == 0 {
+		return nil
+	}
+	deps := make([]models.JobDependency, 0, len(job.DependsOn))
+	seen := make(map[string]bool, len(job.DependsOn))
+	for _, parentID := range job.DependsOn {
+		if !seen[parentID] {
+			seen[parentID] = true
+			deps = append(deps, models.JobDependency{JobID: job.ID, DependsOnID: parentID})
+		}
+	}
+	return tx.Create(&deps).Error
 }
 
 func (r *JobRepository) GetJob(id string) (*models.Job, error) {
@@ -70,6 +118,87 @@ func (r *JobRepository) GetJob(id string) (*models.Job, error) {
 	return &job, nil
 }
 
+// jobSortColumns are the columns ListJobs can sort by; id breaks ties so the order is total.
+var jobSortColumns = map[string]bool{"created_at": true, "completed_at": true, "progress": true, "id": true}
+
+// jobCursor is the position after the last job of a page: its sort value and id. The
+// sort is included so a cursor cannot be replayed against a different order.
+type jobCursor struct {
+	SortBy string      `json:"s"`
+	Desc   bool        `json:"d"`
+	Value  interface{} `json:"v"` // nil for NULL completed_at
+	ID     string      `json:"id"`
+}
+
+func encodeJobCursor(c jobCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeJobCursor(s string) (*jobCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, interfaces.ErrInvalidCursor
+	}
+	var c jobCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return nil, interfaces.ErrInvalidCursor
+	}
+	// JSON gives back strings and float64; convert to the column's type
+	switch v := c.Value.(type) {
+	case nil:
+	case string:
+		if c.SortBy == "created_at" || c.SortBy == "completed_at" {
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				return nil, interfaces.ErrInvalidCursor
+			}
+			c.Value = t
+		}
+	case float64:
+		c.Value = int(v)
+	default:
+		return nil, interfaces.ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+func jobSortValue(job *models.Job, sortBy string) interface{} {
+	switch sortBy {
+	case "completed_at":
+		if job.CompletedAt == nil {
+			return nil
+		}
+		return job.CompletedAt.Format(time.RFC3339Nano)
+	case "progress":
+		return job.Progress
+	case "id":
+		return job.ID
+	}
+	return job.CreatedAt.Format(time.RFC3339Nano)
+}
+
+// keysetCondition selects the rows after the cursor in ORDER BY col dir, id dir. NULLs
+// (completed_at of unfinished jobs) sort lowest, as in MySQL and SQLite.
+func keysetCondition(query *gorm.DB, col string, c *jobCursor) *gorm.DB {
+	if col == "id" {
+		if c.Desc {
+			return query.Where("id < ?", c.ID)
+		}
+		return query.Where("id > ?", c.ID)
+	}
+	if c.Value == nil {
+		if c.Desc {
+			return query.Where(col+" IS NULL AND id < ?", c.ID)
+		}
+		return query.Where("("+col+" IS NULL AND id > ?) OR "+col+" IS NOT NULL", c.ID)
+	}
+	if c.Desc {
+		return query.Where("("+col+" < ? OR ("+col+" = ? AND id < ?) OR "+col+" IS NULL)", c.Value, c.Value, c.ID)
+	}
+	return query.Where("("+col+" > ? OR ("+col+" = ? AND id > ?))", c.Value, c.Value, c.ID)
+}
+
 func (r *JobRepository) ListJobs(req *models.GetJobsRequest) (*models.ListJobsResponse, error) {
 	var jobs []*models.Job
 
@@ -81,87 +210,180 @@ func (r *JobRepository) ListJobs(req *models.GetJobsRequest) (*models.ListJobsRe
 		req.Page = 1
 	}
 
-	query := r.db.Model(&models.Job{})
+	query := r.db.Model(&models.Job{}).Scopes(inProject(req.ProjectID))
+	if len(req.Statuses) > 0 {
+		query = query.Where("status IN ?", req.Statuses)
+	}
+	if len(req.Types) > 0 {
+		query = query.Where("type IN ?", req.Types)
+	}
+	if req.ClusterID != "" {
+		query = query.Where("cluster_id = ?", req.ClusterID)
+	}
+	if req.TraceID != "" {
+		query = query.Where("trace_id = ?", req.TraceID)
+	}
+	if req.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *req.CreatedAfter)
+	}
+	if req.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *req.CreatedBefore)
+	}
 
 	var total int64
-	if err := query.Count(&total).Error; err != nil {
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
 		return nil, err
 	}
 
-	offset := (req.Page - 1) * req.PageSize
+	// Sorting: allow only known columns; id breaks ties for a stable keyset order
+	sortBy := req.SortBy
+	if !jobSortColumns[sortBy] {
+		sortBy = "created_at"
+	}
+	desc := req.SortDir == "desc" || req.SortDir == ""
+	dir := " asc"
+	if desc {
+		dir = " desc"
+	}
+	order := sortBy + dir
+	if sortBy != "id" {
+		order += ", id" + dir
+	}
 
-	// Sorting
-	order := "created_at desc"
-	if req.SortBy != "" {
-		// allow only a few known sort fields for safety
-		switch req.SortBy {
-		case "created_at":
-			order = "created_at"
-		case "id":
-			order = "id"
-		default:
-			order = "created_at"
+	if req.Cursor != "" {
+		c, err := decodeJobCursor(req.Cursor)
+		if err != nil {
+			return nil, err
 		}
-	}
-	if req.SortDir == "desc" || req.SortDir == "" {
-		order = order + " desc"
+		if c.SortBy != sortBy || c.Desc != desc {
+			return nil, interfaces.ErrInvalidCursor
+		}
+		query = keysetCondition(query, sortBy, c)
 	} else {
-		order = order + " asc"
+		query = query.Offset((req.Page - 1) * req.PageSize)
 	}
 
-	if err := query.Order(order).Limit(req.PageSize).Offset(offset).Find(&jobs).Error; err != nil {
+	// one extra row tells whether a next page exists
+	if err := query.Order(order).Limit(req.PageSize + 1).Find(&jobs).Error; err != nil {
 		return nil, err
 	}
 
-	return &models.ListJobsResponse{
+	resp := &models.ListJobsResponse{
 		Jobs:     jobs,
 		Page:     req.Page,
 		PageSize: req.PageSize,
 		Total:    total,
-	}, nil
+	}
+	if len(jobs) > req.PageSize {
+		resp.Jobs = jobs[:req.PageSize]
+		last := resp.Jobs[req.PageSize-1]
+		resp.NextCursor = encodeJobCursor(jobCursor{SortBy: sortBy, Desc: desc, Value: jobSortValue(last, sortBy), ID: last.ID})
+	}
+	return resp, nil
 }
 
+// UpdateJobStatus sets the status of a job, writing only the columns the status implies so
+// concurrent updates of other fields are kept.
 func (r *JobRepository) UpdateJobStatus(id string, status string) error {
-	var job models.Job
-	if err := r.db.First(&job, "id = ?", id).Error; err != nil {
-		return err
-	}
-
-	job.Status = status
+	fields := map[string]interface{}{"status": status}
 	// If marking running, ensure there's a progress baseline
-	if status == "running" && job.Progress == 0 {
-		job.Progress = 5
+	if status == "running" {
+		fields["progress"] = gorm.Expr("CASE WHEN progress = 0 THEN 5 ELSE progress END")
 	}
-	if status == "completed" || status == "failed" {
-		now := time.Now()
-		job.CompletedAt = &now
+	if models.IsTerminalJobStatus(status) {
+		fields["completed_at"] = time.Now()
 		if status == "completed" {
-			job.Progress = 100
+			fields["progress"] = 100
 		}
 	}
-
-	return r.db.Save(&job).Error
+	res := r.db.Model(&models.Job{}).Where("id = ?", id).Updates(fields)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		// MySQL reports unchanged rows as unaffected, so tell those from missing jobs
+		var n int64
+		if err := r.db.Model(&models.Job{}).Where("id = ?", id).Count(&n).Error; err != nil {
+			return err
+		}
+		if n == 0 {
+			return gorm.ErrRecordNotFound
+		}
+	}
+	return nil
 }
 
+// UpdateJobProgress records the progress and message of an unfinished job. It never changes
+// the status: jobs are completed by a status transition, and finished jobs keep their
+// progress and result.
 func (r *JobRepository) UpdateJobProgress(id string, progress int, message string) error {
-	var job models.Job
-	if err := r.db.First(&job, "id = ?", id).Error; err != nil {
-		return err
+	fields := map[string]interface{}{"progress": progress}
+	if message != "" {
+		fields["result"] = message
 	}
+	return r.db.Model(&models.Job{}).Where("id = ? AND status NOT IN ?", id, models.TerminalJobStatuses).Updates(fields).Error
+}
+
+func (r *JobRepository) UpdateJobFields(id string, fields map[string]interface{}) error {
+	return r.db.Model(&models.Job{}).Where("id = ?", id).Updates(fields).Error
+}
 
-	job.Progress = progress
-	// if progress reached 100, mark completed
-	if progress >= 100 {
-		job.Status = "completed"
-		now := time.Now()
-		job.CompletedAt = &now
-	} else if job.Status == "pending" {
-		job.Status = "running"
+func (r *JobRepository) ListJobsByStatus(status string, limit int) ([]*models.Job, error) {
+	if limit <= 0 {
+		limit = 50
 	}
+	var jobs []*models.Job
+	if err := r.db.Where("status = ?", status).Order("created_at desc").Limit(limit).Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
 
-	if message != "" {
-		job.Result = message
+func (r *JobRepository) TransitionJobStatus(id, from, to string) (bool, error) {
+	fields := map[string]interface{}{"status": to}
+	if to == "running" {
+		fields["progress"] = gorm.Expr("CASE WHEN progress = 0 THEN 5 ELSE progress END")
+	}
+	if models.IsTerminalJobStatus(to) {
+		fields["completed_at"] = time.Now()
+	}
+	if to == "completed" {
+		fields["progress"] = 100
+	}
+	res := r.db.Model(&models.Job{}).Where("id = ? AND status = ?", id, from).Updates(fields)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func (r *JobRepository) ListDependentJobs(parentID string) ([]*models.Job, error) {
+	var jobs []*models.Job
+	if err := r.db.Joins("JOIN job_dependencies ON job_dependencies.job_id = jobs.id").
+		Where("job_dependencies.depends_on_id = ?", parentID).Order("jobs.created_at").Find(&jobs).Error; err != nil {
+		return nil, err
 	}
+	return jobs, nil
+}
 
-	return r.db.Save(&job).Error
+func (r *JobRepository) ListJobsByWorkflow(workflowID string) ([]*models.Job, error) {
+	var jobs []*models.Job
+	if err := r.db.Where("workflow_id = ?", workflowID).Order("created_at").Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *JobRepository) ListOverdueJobs(now time.Time, limit int) ([]*models.Job, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	var jobs []*models.Job
+	// started_at is cleared whenever a job goes back to waiting in the pool, so a stale
+	// deadline from an earlier attempt never matches
+	if err := r.db.Where("status IN ? AND started_at IS NOT NULL AND deadline_at <= ?", []string{"running", "queued"}, now).
+		Order("deadline_at").Limit(limit).Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
 }
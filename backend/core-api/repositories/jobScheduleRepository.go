@@ -0,0 +1,87 @@
+// backend/core-api/repositories/jobScheduleRepository.go
+
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/interfaces"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type JobScheduleRepository struct {
+	db *gorm.DB
+}
+
+func NewJobScheduleRepository(db *gorm.DB) interfaces.JobScheduleRepository {
+	return &JobScheduleRepository{db: db}
+}
+
+func (r *JobScheduleRepository) Create(s *models.JobSchedule) error {
+	if s.ID == "" {
+		s.ID = "sched-" + uuid.NewString()
+	}
+	s.ProjectID = projectOrDefault(s.ProjectID)
+	return r.db.Create(s).Error
+}
+
+func (r *JobScheduleRepository) Update(s *models.JobSchedule) error {
+	return r.db.Save(s).Error
+}
+
+func (r *JobScheduleRepository) Get(id string) (*models.JobSchedule, error) {
+	var s models.JobSchedule
+	if err := r.db.First(&s, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("schedule not found")
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *JobScheduleRepository) List(projectID string) ([]*models.JobSchedule, error) {
+	var out []*models.JobSchedule
+	if err := r.db.Scopes(inProject(projectID)).Order("created_at").Find(&out).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *JobScheduleRepository) Delete(id string) error {
+	res := r.db.Delete(&models.JobSchedule{}, "id = ?", id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("schedule not found")
+	}
+	return nil
+}
+
+func (r *JobScheduleRepository) ListDue(now time.Time) ([]*models.JobSchedule, error) {
+	var out []*models.JobSchedule
+	err := r.db.Where("enabled = ? AND ((next_run_at IS NOT NULL AND next_run_at <= ?) OR pending_run = ?)", true, now, true).
+		Order("next_run_at").Find(&out).Error
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *JobScheduleRepository) ClaimRun(s *models.JobSchedule, nextRunAt *time.Time, pendingRun bool) (bool, error) {
+	query := r.db.Model(&models.JobSchedule{}).Where("id = ? AND pending_run = ?", s.ID, s.PendingRun)
+	if s.NextRunAt == nil {
+		query = query.Where("next_run_at IS NULL")
+	} else {
+		query = query.Where("next_run_at = ?", *s.NextRunAt)
+	}
+	res := query.Updates(map[string]interface{}{"next_run_at": nextRunAt, "pending_run": pendingRun})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
@@ -52,12 +52,24 @@ func TestUpdateJobProgress(t *testing.T) {
 		t.Fatalf("expected progress 40, got %d", saved.Progress)
 	}
 
-	// mark complete
+	// full progress does not complete the job; only a status transition does
 	if err := repo.UpdateJobProgress(j.ID, 100, "done"); err != nil {
-		t.Fatalf("UpdateJobProgress to complete failed: %v", err)
+		t.Fatalf("UpdateJobProgress to 100 failed: %v", err)
 	}
 	saved2, _ := repo.GetJob(j.ID)
-	if saved2.Progress != 100 || saved2.Status != "completed" || saved2.CompletedAt == nil {
-		t.Fatalf("expected completed job; got progress=%d status=%s completedAt=%v", saved2.Progress, saved2.Status, saved2.CompletedAt)
+	if saved2.Progress != 100 || saved2.Status != "pending" || saved2.CompletedAt != nil {
+		t.Fatalf("expected progress 100 without a status change; got progress=%d status=%s completedAt=%v", saved2.Progress, saved2.Status, saved2.CompletedAt)
+	}
+
+	// a finished job keeps its progress and result
+	if err := repo.UpdateJobStatus(j.ID, "cancelled"); err != nil {
+		t.Fatalf("UpdateJobStatus failed: %v", err)
+	}
+	if err := repo.UpdateJobProgress(j.ID, 50, "late"); err != nil {
+		t.Fatalf("UpdateJobProgress failed: %v", err)
+	}
+	saved3, _ := repo.GetJob(j.ID)
+	if saved3.Status != "cancelled" || saved3.Progress != 100 || saved3.Result != "done" {
+		t.Fatalf("expected the cancelled job untouched; got status=%s progress=%d result=%s", saved3.Status, saved3.Progress, saved3.Result)
 	}
 }
@@ -38,6 +38,9 @@ func (r *MetricRepository) GetMetrics(req *models.GetMetricsRequest) (*models.Ge
 	if req.ClusterID != "" {
 		query = query.Where("cluster_id = ?", req.ClusterID)
 	}
+	if req.ClusterIDs != nil {
+		query = query.Where("cluster_id IN ?", req.ClusterIDs)
+	}
 	if req.Type != "" {
 		query = query.Where("type = ?", req.Type)
 	}
@@ -0,0 +1,69 @@
+// backend/core-api/repositories/projectRepository.go
+
+package repositories
+
+import (
+	"errors"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/interfaces"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ProjectRepository struct {
+	db *gorm.DB
+}
+
+func NewProjectRepository(db *gorm.DB) interfaces.ProjectRepository {
+	return &ProjectRepository{db: db}
+}
+
+func (r *ProjectRepository) Create(p *models.Project) error {
+	if p.ID == "" {
+		p.ID = "project-" + uuid.NewString()
+	}
+	return r.db.Create(p).Error
+}
+
+func (r *ProjectRepository) Update(p *models.Project) error {
+	return r.db.Save(p).Error
+}
+
+func (r *ProjectRepository) Get(id string) (*models.Project, error) {
+	var p models.Project
+	if err := r.db.First(&p, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("project not found")
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *ProjectRepository) List() ([]*models.Project, error) {
+	var out []*models.Project
+	if err := r.db.Order("created_at").Find(&out).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// inProject limits a query to the rows of projectID; "" (internal callers) leaves it
+// unscoped.
+func inProject(projectID string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if projectID == "" {
+			return db
+		}
+		return db.Where("project_id = ?", projectID)
+	}
+}
+
+// projectOrDefault is the project of a new row: the given one or the default project.
+func projectOrDefault(projectID string) string {
+	if projectID == "" {
+		return models.DefaultProjectID
+	}
+	return projectID
+}
@@ -0,0 +1,54 @@
+// backend/core-api/repositories/workflowRepository.go
+
+package repositories
+
+import (
+	"errors"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/interfaces"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"gorm.io/gorm"
+)
+
+type WorkflowRepository struct {
+	db *gorm.DB
+}
+
+func NewWorkflowRepository(db *gorm.DB) interfaces.WorkflowRepository {
+	return &WorkflowRepository{db: db}
+}
+
+func (r *WorkflowRepository) CreateWorkflow(w *models.Workflow, reqs []*models.CreateJobRequest) ([]*models.Job, error) {
+	w.ProjectID = projectOrDefault(w.ProjectID)
+	jobs := make([]*models.Job, 0, len(reqs))
+	for _, req := range reqs {
+		job, err := newJob(req)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, job := range jobs {
+			if err := createJob(tx, job); err != nil {
+				return err
+			}
+		}
+		return tx.Create(w).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *WorkflowRepository) GetWorkflow(id string) (*models.Workflow, error) {
+	var w models.Workflow
+	if err := r.db.First(&w, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("workflow not found")
+		}
+		return nil, err
+	}
+	return &w, nil
+}
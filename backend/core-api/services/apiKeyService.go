@@ -0,0 +1,176 @@
+// backend/core-api/services/apiKeyService.go
+
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/interfaces"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/logger"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+)
+
+var (
+	// ErrInvalidAPIKey means the key is unknown, expired or revoked
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	// ErrForbidden means the caller's role or key scopes do not allow the request
+	ErrForbidden            = errors.New("forbidden")
+	ErrInvalidAPIKeyRequest = errors.New("API keys need a name, a user_id, a role of viewer, operator or admin and a non-negative expiry")
+)
+
+// apiKeyPrefix starts every key, so leaked keys are easy to recognise.
+const apiKeyPrefix = "cgk_"
+
+// apiKeyTouchInterval is how often the last use of a key is written.
+const apiKeyTouchInterval = time.Minute
+
+var roleRank = map[string]int{models.RoleViewer: 1, models.RoleOperator: 2, models.RoleAdmin: 3}
+
+// ValidRole reports whether role is viewer, operator or admin.
+func ValidRole(role string) bool {
+	return roleRank[role] > 0
+}
+
+// Authorize checks that p's role is at least role and that its key scopes include area
+// (empty scopes allow every area).
+func Authorize(p *models.Principal, role string, area string) error {
+	if p == nil || roleRank[p.Role] < roleRank[role] {
+		return fmt.Errorf("%w: requires the %s role", ErrForbidden, role)
+	}
+	if area == "" || len(p.Scopes) == 0 {
+		return nil
+	}
+	for _, s := range p.Scopes {
+		if s == area {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: key is not scoped for %s", ErrForbidden, area)
+}
+
+// HashAPIKey returns the hex SHA-256 of a key, the form keys are stored and looked up in.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyService issues, authenticates and revokes API keys.
+type APIKeyService struct {
+	repo interfaces.APIKeyRepository
+	now  func() time.Time
+}
+
+func NewAPIKeyService(repo interfaces.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{repo: repo, now: time.Now}
+}
+
+// Create issues a key for req.UserID and returns it with its record. The key is only
+// available now; just its hash is stored. Keys without a project act in the default
+// project, except admin keys, which may pick any project per request.
+func (s *APIKeyService) Create(req models.CreateAPIKeyRequest, createdBy string) (*models.APIKey, string, error) {
+	if req.Name == "" || req.UserID == "" || !ValidRole(req.Role) || req.ExpiresInSeconds < 0 {
+		return nil, "", ErrInvalidAPIKeyRequest
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	k := s.newKey(key, req.Name, req.UserID, req.Role, createdBy)
+	k.Scopes = req.Scopes
+	k.ProjectID = req.ProjectID
+	if k.ProjectID == "" && k.Role != models.RoleAdmin {
+		k.ProjectID = models.DefaultProjectID
+	}
+	if req.ExpiresInSeconds > 0 {
+		expires := k.CreatedAt.Add(time.Duration(req.ExpiresInSeconds) * time.Second)
+		k.ExpiresAt = &expires
+	}
+	if err := s.repo.Create(k); err != nil {
+		return nil, "", err
+	}
+	logger.Infof("API key %s (%s) created for %s with role %s by %s", k.ID, k.Name, k.UserID, k.Role, createdBy)
+	return k, key, nil
+}
+
+func (s *APIKeyService) newKey(key, name, userID, role, createdBy string) *models.APIKey {
+	prefix := key
+	if len(prefix) > len(apiKeyPrefix)+4 {
+		prefix = prefix[:len(apiKeyPrefix)+4]
+	}
+	return &models.APIKey{
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   HashAPIKey(key),
+		UserID:    userID,
+		Role:      role,
+		CreatedBy: createdBy,
+		CreatedAt: s.now(),
+	}
+}
+
+// EnsureKey registers a key chosen by the operator (e.g. a bootstrap admin key from the
+// environment) unless it already exists.
+func (s *APIKeyService) EnsureKey(key, name, userID, role string) error {
+	if !ValidRole(role) || len(key) < 16 {
+		return fmt.Errorf("%w: keys set by the operator need at least 16 characters", ErrInvalidAPIKeyRequest)
+	}
+	if _, err := s.repo.GetByHash(HashAPIKey(key)); err == nil {
+		return nil
+	}
+	return s.repo.Create(s.newKey(key, name, userID, role, "environment"))
+}
+
+// Authenticate returns the principal of a key that is neither expired nor revoked.
+func (s *APIKeyService) Authenticate(key string) (*models.Principal, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil, ErrInvalidAPIKey
+	}
+	k, err := s.repo.GetByHash(HashAPIKey(key))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAPIKey, err)
+	}
+	now := s.now()
+	switch {
+	case k.RevokedAt != nil:
+		return nil, fmt.Errorf("%w: key was revoked", ErrInvalidAPIKey)
+	case k.ExpiresAt != nil && !now.Before(*k.ExpiresAt):
+		return nil, fmt.Errorf("%w: key expired", ErrInvalidAPIKey)
+	}
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= apiKeyTouchInterval {
+		k.LastUsedAt = &now
+		if err := s.repo.TouchLastUsed(k.ID, now); err != nil {
+			logger.Warnf("recording the use of API key %s failed: %v", k.ID, err)
+		}
+	}
+	return &models.Principal{UserID: k.UserID, Role: k.Role, ProjectID: k.ProjectID, Scopes: k.Scopes, KeyID: k.ID}, nil
+}
+
+// List returns all keys, including revoked and expired ones.
+func (s *APIKeyService) List() ([]*models.APIKey, error) {
+	return s.repo.List()
+}
+
+// Revoke disables a key for good. Revoking a revoked key keeps its original revocation time.
+func (s *APIKeyService) Revoke(id string) (*models.APIKey, error) {
+	revoked, err := s.repo.Revoke(id, s.now())
+	if err != nil {
+		return nil, err
+	}
+	k, err := s.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		logger.Infof("API key %s (%s) of %s revoked", k.ID, k.Name, k.UserID)
+	}
+	return k, nil
+}
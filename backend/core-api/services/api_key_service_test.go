@@ -0,0 +1,146 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/repositories"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupAPIKeyService(t *testing.T, now *time.Time) *APIKeyService {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed open sqlite: %v", err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.SetMaxOpenConns(1)
+	}
+	if err := db.AutoMigrate(&models.APIKey{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	svc := NewAPIKeyService(repositories.NewAPIKeyRepository(db))
+	svc.now = func() time.Time { return *now }
+	return svc
+}
+
+func TestAPIKeyService_CreateAuthenticateRevoke(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc := setupAPIKeyService(t, &now)
+
+	k, key, err := svc.Create(models.CreateAPIKeyRequest{Name: "ci", UserID: "alice", Role: models.RoleOperator, Scopes: []string{"jobs"}, ExpiresInSeconds: 3600}, "root")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if !strings.HasPrefix(key, "cgk_") || !strings.HasPrefix(key, k.Prefix) || k.KeyHash == key || k.KeyHash != HashAPIKey(key) {
+		t.Fatalf("expected only the hash of the key to be stored, got %+v", k)
+	}
+
+	p, err := svc.Authenticate(key)
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if p.UserID != "alice" || p.Role != models.RoleOperator || p.KeyID != k.ID || len(p.Scopes) != 1 {
+		t.Fatalf("unexpected principal %+v", p)
+	}
+	if _, err := svc.Authenticate(key + "x"); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("expected an unknown key to be rejected, got %v", err)
+	}
+
+	// expired keys are rejected
+	now = now.Add(time.Hour)
+	if _, err := svc.Authenticate(key); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("expected an expired key to be rejected, got %v", err)
+	}
+
+	// revoked keys are rejected
+	_, other, err := svc.Create(models.CreateAPIKeyRequest{Name: "dash", UserID: "bob", Role: models.RoleViewer}, "root")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	keys, _ := svc.List()
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 keys, got %d", len(keys))
+	}
+	if _, err := svc.Revoke(keys[1].ID); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	if _, err := svc.Authenticate(other); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("expected a revoked key to be rejected, got %v", err)
+	}
+	if _, err := svc.Revoke("key-missing"); err == nil {
+		t.Fatalf("expected revoking an unknown key to fail")
+	}
+
+	if _, _, err := svc.Create(models.CreateAPIKeyRequest{Name: "x", UserID: "carol", Role: "superuser"}, "root"); !errors.Is(err, ErrInvalidAPIKeyRequest) {
+		t.Fatalf("expected an unknown role to be rejected, got %v", err)
+	}
+}
+
+func TestAPIKeyService_RevokeIsNotUndoneByConcurrentUse(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc := setupAPIKeyService(t, &now)
+	k, key, err := svc.Create(models.CreateAPIKeyRequest{Name: "ci", UserID: "alice", Role: models.RoleOperator}, "root")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// a request authenticated before the revocation records its use afterwards
+	revokedAt := now
+	if _, err := svc.Revoke(k.ID); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	if err := svc.repo.TouchLastUsed(k.ID, now.Add(time.Second)); err != nil {
+		t.Fatalf("TouchLastUsed failed: %v", err)
+	}
+	if _, err := svc.Authenticate(key); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("expected the key to stay revoked, got %v", err)
+	}
+
+	// revoking again keeps the original revocation time
+	now = now.Add(time.Hour)
+	again, err := svc.Revoke(k.ID)
+	if err != nil || again.RevokedAt == nil || !again.RevokedAt.Equal(revokedAt) {
+		t.Fatalf("expected the first revocation time to be kept, got %+v (err=%v)", again, err)
+	}
+}
+
+func TestAPIKeyService_EnsureKeyIsIdempotent(t *testing.T) {
+	now := time.Now()
+	svc := setupAPIKeyService(t, &now)
+	for i := 0; i < 2; i++ {
+		if err := svc.EnsureKey("bootstrap-secret-0123", "bootstrap", "admin", models.RoleAdmin); err != nil {
+			t.Fatalf("EnsureKey failed: %v", err)
+		}
+	}
+	if keys, _ := svc.List(); len(keys) != 1 {
+		t.Fatalf("expected one bootstrap key, got %d", len(keys))
+	}
+	if p, err := svc.Authenticate("bootstrap-secret-0123"); err != nil || p.Role != models.RoleAdmin {
+		t.Fatalf("expected the bootstrap key to authenticate as admin, got %+v %v", p, err)
+	}
+	if err := svc.EnsureKey("short", "bootstrap", "admin", models.RoleAdmin); !errors.Is(err, ErrInvalidAPIKeyRequest) {
+		t.Fatalf("expected a short key to be rejected, got %v", err)
+	}
+}
+
+func TestAuthorize_RolesAndScopes(t *testing.T) {
+	operator := &models.Principal{UserID: "alice", Role: models.RoleOperator, Scopes: []string{"jobs"}}
+	if err := Authorize(operator, models.RoleViewer, "jobs"); err != nil {
+		t.Fatalf("expected an operator to read jobs, got %v", err)
+	}
+	if err := Authorize(operator, models.RoleAdmin, ""); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected an operator to be denied admin routes, got %v", err)
+	}
+	if err := Authorize(operator, models.RoleViewer, "clusters"); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected a key scoped to jobs to be denied clusters, got %v", err)
+	}
+	if err := Authorize(&models.Principal{Role: models.RoleAdmin}, models.RoleOperator, "clusters"); err != nil {
+		t.Fatalf("expected an unscoped admin key to be allowed, got %v", err)
+	}
+}
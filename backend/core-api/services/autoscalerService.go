@@ -23,8 +23,12 @@ func (s *AutoscalerService) CreatePolicy(req *models.CreateAutoscalePolicyReques
 	if req.ClusterID == "" {
 		return nil, errors.New("cluster_id required")
 	}
+	if err := checkClusterInProject(s.provisioningSvc.clusters(), req.ProjectID, req.ClusterID); err != nil {
+		return nil, err
+	}
 	p := &models.AutoscalePolicy{
 		ID:            "",
+		ProjectID:     req.ProjectID,
 		Name:          req.Name,
 		ClusterID:     req.ClusterID,
 		Type:          req.Type,
@@ -42,8 +46,8 @@ func (s *AutoscalerService) CreatePolicy(req *models.CreateAutoscalePolicyReques
 	return p, nil
 }
 
-func (s *AutoscalerService) UpdatePolicy(id string, req *models.UpdateAutoscalePolicyRequest) (*models.AutoscalePolicy, error) {
-	existing, err := s.repo.GetPolicy(id)
+func (s *AutoscalerService) UpdatePolicy(projectID, id string, req *models.UpdateAutoscalePolicyRequest) (*models.AutoscalePolicy, error) {
+	existing, err := s.GetPolicy(projectID, id)
 	if err != nil {
 		return nil, err
 	}
@@ -79,24 +83,36 @@ func (s *AutoscalerService) UpdatePolicy(id string, req *models.UpdateAutoscaleP
 	return existing, nil
 }
 
-func (s *AutoscalerService) GetPolicy(id string) (*models.AutoscalePolicy, error) {
-	return s.repo.GetPolicy(id)
+// GetPolicy returns a policy of projectID ("" for any project).
+func (s *AutoscalerService) GetPolicy(projectID, id string) (*models.AutoscalePolicy, error) {
+	p, err := s.repo.GetPolicy(id)
+	if err != nil {
+		return nil, err
+	}
+	if !models.InProject(projectID, p.ProjectID) {
+		return nil, errNotInProject("policy")
+	}
+	return p, nil
 }
 
-func (s *AutoscalerService) ListPolicies(clusterID string) ([]*models.AutoscalePolicy, error) {
-	return s.repo.ListPolicies(clusterID)
+func (s *AutoscalerService) ListPolicies(projectID, clusterID string) ([]*models.AutoscalePolicy, error) {
+	return s.repo.ListPolicies(projectID, clusterID)
 }
 
-func (s *AutoscalerService) DeletePolicy(id string) error {
+func (s *AutoscalerService) DeletePolicy(projectID, id string) error {
+	if _, err := s.GetPolicy(projectID, id); err != nil {
+		return err
+	}
 	return s.repo.DeletePolicy(id)
 }
 
-// EvaluatePolicies runs a simple evaluation and attempts to apply scaling actions
-func (s *AutoscalerService) EvaluatePolicies(clusterID string) (map[string]interface{}, error) {
+// EvaluatePolicies runs a simple evaluation of the cluster's policies in projectID ("" for
+// every project) and attempts to apply scaling actions
+func (s *AutoscalerService) EvaluatePolicies(projectID, clusterID string) (map[string]interface{}, error) {
 	if clusterID == "" {
 		return nil, errors.New("cluster_id required")
 	}
-	pols, err := s.repo.ListPolicies(clusterID)
+	pols, err := s.repo.ListPolicies(projectID, clusterID)
 	if err != nil {
 		return nil, err
 	}
@@ -1,53 +1,68 @@
 package services
 
 import (
-    "testing"
-    "github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"testing"
 )
 
-type memAutoRepo struct{ store map[string]*models.AutoscalePolicy }
-func (m *memAutoRepo) CreatePolicy(p *models.AutoscalePolicy) error { if m.store==nil { m.store = map[string]*models.AutoscalePolicy{} }; m.store[p.ID]=p; return nil }
-func (m *memAutoRepo) UpdatePolicy(p *models.AutoscalePolicy) error { m.store[p.ID]=p; return nil }
-func (m *memAutoRepo) GetPolicy(id string) (*models.AutoscalePolicy, error) { if p,ok:=m.store[id]; ok { return p,nil }; return nil,nil }
-func (m *memAutoRepo) ListPolicies(clusterID string) ([]*models.AutoscalePolicy, error) { out:=[]*models.AutoscalePolicy{}; for _,v:=range m.store { if v.ClusterID==clusterID { out=append(out,v) } }; return out,nil }
-func (m *memAutoRepo) DeletePolicy(id string) error { delete(m.store,id); return nil }
-
-// fake monitoring service returns a metric with high value
-type fakeMon struct{}
-func (f *fakeMon) GetMetrics(req *models.GetMetricsRequest) (*models.GetMetricsResponse, error) {
-    return &models.GetMetricsResponse{Metrics: []models.Metric{{ClusterID: req.ClusterID, Type: req.Type, Value: 95.0}}}, nil
+type memAutoRepo struct {
+	store map[string]*models.AutoscalePolicy
 }
 
+func (m *memAutoRepo) CreatePolicy(p *models.AutoscalePolicy) error {
+	if m.store == nil {
+		m.store = map[string]*models.AutoscalePolicy{}
+	}
+	m.store[p.ID] = p
+	return nil
+}
+func (m *memAutoRepo) UpdatePolicy(p *models.AutoscalePolicy) error { m.store[p.ID] = p; return nil }
+func (m *memAutoRepo) GetPolicy(id string) (*models.AutoscalePolicy, error) {
+	if p, ok := m.store[id]; ok {
+		return p, nil
+	}
+	return nil, nil
+}
+func (m *memAutoRepo) ListPolicies(projectID, clusterID string) ([]*models.AutoscalePolicy, error) {
+	out := []*models.AutoscalePolicy{}
+	for _, v := range m.store {
+		if v.ClusterID == clusterID {
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+func (m *memAutoRepo) DeletePolicy(id string) error { delete(m.store, id); return nil }
+
 // fake provisioning that counts scale calls
 type countingProv struct{ count int }
-func (c *countingProv) CreateDroplet(req *models.CreateDropletRequest) (*models.DropletResponse, error) { c.count++; return &models.DropletResponse{}, nil }
+
+func (c *countingProv) CreateDroplet(req *models.CreateDropletRequest) (*models.DropletResponse, error) {
+	c.count++
+	return &models.DropletResponse{}, nil
+}
 func (c *countingProv) ScaleCluster(clusterID string, action string) error { c.count++; return nil }
 
 func TestEvaluatePoliciesMetricTriggersScale(t *testing.T) {
-    repo := &memAutoRepo{store: map[string]*models.AutoscalePolicy{}}
-    // set policy that triggers at 80%
-    repo.store["p1"] = &models.AutoscalePolicy{ID: "p1", Name: "cpu-high", ClusterID: "c1", Type: "metrics", Enabled: true, MetricType: "cpu", MetricTrigger: 0.8}
-
-    prov := &countingProv{}
-    mon := &MonitoringService{metricRepo: nil}
-    // replace monitoring svc GetMetrics method by using wrapper fake
-    autosvc := NewAutoscalerService(repo, &ProvisioningService{dropletRepo:nil, producer:nil, clusterSvc:nil, scheduler:nil}, mon)
-    // monkey patch monitoring svc pointer
-    autosvc.monitoringSvc = &MonitoringService{metricRepo: nil}
-
-    // override GetMetrics via pointer to our fake
-    autosvc.monitoringSvc = &MonitoringService{metricRepo: nil}
-    // instead of using internal method we directly invoke EvaluatePolicies but it will call autosvc.monitoringSvc.GetMetrics
-    // so replace monitoringSvc variable with a small wrapper that satisfies methods
-    type monIface interface{ GetMetrics(req *models.GetMetricsRequest) (*models.GetMetricsResponse, error) }
-
-    // inject fake via interface conversion
-    var fi monIface = &fakeMon{}
-    // use type assertion to replace pointer's method by wrapping the autoscaler to call fi
-    // simpler: test the logic by calling provisioning directly via ScaleCluster - ensure it exists
-    // here we assert ListPolicies returns our policy (sanity check)
-    list, err := autosvc.ListPolicies("c1")
-    if err != nil { t.Fatalf("ListPolicies error: %v", err) }
-    if len(list) != 1 { t.Fatalf("expected 1 policy, got %d", len(list)) }
-    // This unit-level scenario demonstrates that the policy engine is wired; deeper integration tests cover Evaluate paths.
+	repo := &memAutoRepo{store: map[string]*models.AutoscalePolicy{}}
+	// set policy that triggers at 80%
+	repo.store["p1"] = &models.AutoscalePolicy{ID: "p1", Name: "cpu-high", ClusterID: "c1", Type: "metrics", Enabled: true, MetricType: "cpu", MetricTrigger: 0.8}
+
+	mon := &MonitoringService{metricRepo: nil}
+	// replace monitoring svc GetMetrics method by using wrapper fake
+	autosvc := NewAutoscalerService(repo, &ProvisioningService{dropletRepo: nil, producer: nil, clusterSvc: nil, scheduler: nil}, mon)
+	// monkey patch monitoring svc pointer
+	autosvc.monitoringSvc = &MonitoringService{metricRepo: nil}
+
+	// override GetMetrics via pointer to our fake
+	autosvc.monitoringSvc = &MonitoringService{metricRepo: nil}
+	// here we assert ListPolicies returns our policy (sanity check)
+	list, err := autosvc.ListPolicies("", "c1")
+	if err != nil {
+		t.Fatalf("ListPolicies error: %v", err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("expected 1 policy, got %d", len(list))
+	}
+	// This unit-level scenario demonstrates that the policy engine is wired; deeper integration tests cover Evaluate paths.
 }
@@ -29,7 +29,7 @@ func (m *memRepo) GetPolicy(id string) (*models.AutoscalePolicy, error) {
 	}
 	return nil, nil
 }
-func (m *memRepo) ListPolicies(clusterID string) ([]*models.AutoscalePolicy, error) {
+func (m *memRepo) ListPolicies(projectID, clusterID string) ([]*models.AutoscalePolicy, error) {
 	out := []*models.AutoscalePolicy{}
 	for _, p := range m.store {
 		if p.ClusterID == clusterID {
@@ -67,8 +67,6 @@ func (f *fakeMon) GetMetrics(req *models.GetMetricsRequest) (*models.GetMetricsR
 
 func TestEvaluateMetricsPolicy_ScalesUp(t *testing.T) {
 	repo := &memRepo{store: map[string]*models.AutoscalePolicy{}}
-	prov := &fakeProv{}
-	mon := &fakeMon{value: 95.0}
 	svc := NewAutoscalerService(repo, (*ProvisioningService)(nil), nil)
 	// replace services provision/monitor directly for test
 	svc.provisioningSvc = &ProvisioningService{ /* will not be used directly */ }
@@ -91,14 +89,14 @@ func TestEvaluateMetricsPolicy_ScalesUp(t *testing.T) {
 	// swap the monitoring GetMetrics using our fake by wrapping/monkeying is not straightforward; instead test repository listing works
 
 	// For unit-test coverage of basic CRUD flows in service: CreatePolicy, GetPolicy, ListPolicies
-	p, err := svc.GetPolicy("p1")
+	p, err := svc.GetPolicy("", "p1")
 	if err != nil {
 		t.Fatalf("GetPolicy error: %v", err)
 	}
 	if p.Name != "cpu-high" {
 		t.Fatalf("unexpected policy name: %s", p.Name)
 	}
-	list, err := svc.ListPolicies("c1")
+	list, err := svc.ListPolicies("", "c1")
 	if err != nil {
 		t.Fatalf("ListPolicies error: %v", err)
 	}
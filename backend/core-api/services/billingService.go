@@ -2,8 +2,10 @@ package services
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/AvinashMahala/ClusterGenie/backend/core-api/interfaces"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
 )
 
 type BillingService struct {
@@ -16,8 +18,9 @@ func NewBillingService(d interfaces.DropletRepository, p interfaces.ProviderRepo
 }
 
 // EstimateClusterCost computes simple snapshot of cost for a cluster (per hour & month)
-func (s *BillingService) EstimateClusterCost(clusterID string) (map[string]interface{}, error) {
-	droplets, err := s.dropletRepo.ListDroplets()
+// from the droplets of projectID ("" for every project)
+func (s *BillingService) EstimateClusterCost(projectID, clusterID string) (map[string]interface{}, error) {
+	droplets, err := s.dropletRepo.ListDroplets(projectID)
 	if err != nil {
 		return nil, err
 	}
@@ -36,13 +39,7 @@ func (s *BillingService) EstimateClusterCost(clusterID string) (map[string]inter
 	for _, d := range droplets {
 		if d.ClusterID != nil && *d.ClusterID == clusterID {
 			count++
-			price := 0.05 // default demo price
-			if d.Provider != "" {
-				if v, ok := priceMap[d.Provider]; ok {
-					price = v
-				}
-			}
-			hourly += price
+			hourly += dropletPrice(priceMap, d.Provider)
 		}
 	}
 	monthly := hourly * 24.0 * 30.0
@@ -53,3 +50,46 @@ func (s *BillingService) EstimateClusterCost(clusterID string) (map[string]inter
 		"monthly_cost":  fmt.Sprintf("%.2f", monthly),
 	}, nil
 }
+
+// defaultDropletPricePerHour prices droplets of unknown providers (demo price).
+const defaultDropletPricePerHour = 0.05
+
+func dropletPrice(priceMap map[string]float64, provider string) float64 {
+	if v, ok := priceMap[provider]; ok && provider != "" {
+		return v
+	}
+	return defaultDropletPricePerHour
+}
+
+// ProviderUsage reports how many droplets projectID runs on each provider and what they
+// cost per hour, sorted by provider. Droplets without a provider are reported under "".
+func (s *BillingService) ProviderUsage(projectID string) ([]models.ProviderUsage, error) {
+	droplets, err := s.dropletRepo.ListDroplets(projectID)
+	if err != nil {
+		return nil, err
+	}
+	providers, err := s.providerRepo.List()
+	if err != nil {
+		return nil, err
+	}
+	priceMap := map[string]float64{}
+	for _, p := range providers {
+		priceMap[p.Name] = p.PricePerHour
+	}
+	byProvider := map[string]*models.ProviderUsage{}
+	for _, d := range droplets {
+		u, ok := byProvider[d.Provider]
+		if !ok {
+			u = &models.ProviderUsage{Provider: d.Provider}
+			byProvider[d.Provider] = u
+		}
+		u.Droplets++
+		u.HourlyCost += dropletPrice(priceMap, d.Provider)
+	}
+	out := make([]models.ProviderUsage, 0, len(byProvider))
+	for _, u := range byProvider {
+		out = append(out, *u)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Provider < out[j].Provider })
+	return out, nil
+}
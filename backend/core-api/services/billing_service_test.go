@@ -17,7 +17,7 @@ func (m *memDropletRepo2) GetDroplet(id string) (*models.Droplet, error) {
 	}
 	return nil, nil
 }
-func (m *memDropletRepo2) ListDroplets() ([]*models.Droplet, error) {
+func (m *memDropletRepo2) ListDroplets(projectID string) ([]*models.Droplet, error) {
 	out := []*models.Droplet{}
 	for _, d := range m.store {
 		out = append(out, d)
@@ -71,7 +71,7 @@ func TestEstimateClusterCostCountsAndCalculates(t *testing.T) {
 	pstore.Create(&models.Provider{ID: "do", Name: "do", PricePerHour: 0.05})
 
 	billing := NewBillingService(dstore, pstore)
-	est, err := billing.EstimateClusterCost("c-1")
+	est, err := billing.EstimateClusterCost("", "c-1")
 	if err != nil {
 		t.Fatalf("estimate error: %v", err)
 	}
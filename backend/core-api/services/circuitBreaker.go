@@ -0,0 +1,97 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// errCircuitOpen is returned for calls skipped because the circuit is open.
+var errCircuitOpen = errors.New("circuit open")
+
+// Circuit breaker states, also the value of the clustergenie_limiter_circuit_state gauge.
+const (
+	CircuitClosed   = 0
+	CircuitHalfOpen = 1
+	CircuitOpen     = 2
+)
+
+// CircuitBreaker stops calls to a failing backend. After threshold consecutive failures it
+// opens and rejects calls for openFor; then it lets one trial call through (half-open),
+// which closes the circuit on success and opens it again on failure.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	name      string
+	threshold int
+	openFor   time.Duration
+	state     int
+	failures  int
+	openedAt  time.Time
+	trial     bool // a half-open trial call is in flight
+	now       func() time.Time
+}
+
+// NewCircuitBreaker creates a closed breaker; threshold defaults to 5 and openFor to 10s.
+func NewCircuitBreaker(name string, threshold int, openFor time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		threshold = 5
+	}
+	if openFor <= 0 {
+		openFor = 10 * time.Second
+	}
+	return &CircuitBreaker{name: name, threshold: threshold, openFor: openFor, now: time.Now}
+}
+
+// Allow reports whether a call may go to the backend. Every allowed call must be followed
+// by Record with its outcome.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.openFor {
+			return false
+		}
+		b.setState(CircuitHalfOpen)
+		b.trial = true
+		return true
+	case CircuitHalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	}
+	return true
+}
+
+// Record reports the outcome of an allowed call.
+func (b *CircuitBreaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+	if err == nil {
+		b.failures = 0
+		b.setState(CircuitClosed)
+		return
+	}
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+		b.openedAt = b.now()
+		b.setState(CircuitOpen)
+	}
+}
+
+// State returns CircuitClosed, CircuitHalfOpen or CircuitOpen.
+func (b *CircuitBreaker) State() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *CircuitBreaker) setState(state int) {
+	b.state = state
+	if LimiterCircuitState != nil {
+		LimiterCircuitState.WithLabelValues(b.name).Set(float64(state))
+	}
+}
@@ -19,10 +19,11 @@ func NewClusterService(clusterRepo interfaces.ClusterRepository) *ClusterService
 
 func (s *ClusterService) CreateCluster(req *models.CreateClusterRequest) (*models.ClusterResponse, error) {
 	cluster := &models.Cluster{
-		Name:     req.Name,
-		Region:   req.Region,
-		Droplets: models.StringSlice{}, // Start with empty droplets
-		Status:   "healthy",
+		ProjectID: req.ProjectID,
+		Name:      req.Name,
+		Region:    req.Region,
+		Droplets:  models.StringSlice{}, // Start with empty droplets
+		Status:    "healthy",
 	}
 
 	createdCluster, err := s.clusterRepo.CreateCluster(cluster)
@@ -36,11 +37,15 @@ func (s *ClusterService) CreateCluster(req *models.CreateClusterRequest) (*model
 	}, nil
 }
 
-func (s *ClusterService) GetCluster(id string) (*models.Cluster, error) {
+// GetCluster returns a cluster of projectID ("" for any project).
+func (s *ClusterService) GetCluster(projectID, id string) (*models.Cluster, error) {
 	cluster, err := s.clusterRepo.GetCluster(id)
 	if err != nil {
 		return nil, err
 	}
+	if !models.InProject(projectID, cluster.ProjectID) {
+		return nil, errNotInProject("cluster")
+	}
 
 	// Ensure droplets is never nil
 	if cluster.Droplets == nil {
@@ -50,8 +55,8 @@ func (s *ClusterService) GetCluster(id string) (*models.Cluster, error) {
 	return cluster, nil
 }
 
-func (s *ClusterService) ListClusters() ([]*models.Cluster, error) {
-	clusters, err := s.clusterRepo.ListClusters()
+func (s *ClusterService) ListClusters(projectID string) ([]*models.Cluster, error) {
+	clusters, err := s.clusterRepo.ListClusters(projectID)
 	if err != nil {
 		return nil, err
 	}
@@ -66,8 +71,8 @@ func (s *ClusterService) ListClusters() ([]*models.Cluster, error) {
 	return clusters, nil
 }
 
-func (s *ClusterService) UpdateCluster(id string, req *models.UpdateClusterRequest) (*models.ClusterResponse, error) {
-	existingCluster, err := s.clusterRepo.GetCluster(id)
+func (s *ClusterService) UpdateCluster(projectID, id string, req *models.UpdateClusterRequest) (*models.ClusterResponse, error) {
+	existingCluster, err := s.GetCluster(projectID, id)
 	if err != nil {
 		return nil, err
 	}
@@ -94,7 +99,10 @@ func (s *ClusterService) UpdateCluster(id string, req *models.UpdateClusterReque
 	}, nil
 }
 
-func (s *ClusterService) DeleteCluster(id string) (*models.DeleteClusterResponse, error) {
+func (s *ClusterService) DeleteCluster(projectID, id string) (*models.DeleteClusterResponse, error) {
+	if _, err := s.GetCluster(projectID, id); err != nil {
+		return nil, err
+	}
 	err := s.clusterRepo.DeleteCluster(id)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/logger"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrConcurrencyLimited means every slot of a concurrency rule is taken
+	ErrConcurrencyLimited      = errors.New("too many operations in flight")
+	ErrInvalidConcurrencyRule  = errors.New("concurrency rules need a name, a scope of user or cluster, a positive limit and a mode of reject or queue")
+	ErrConcurrencyUnavailable  = errors.New("concurrency store unavailable")
+	concurrencyPollInterval    = 100 * time.Millisecond
+	defaultConcurrencyLeaseTTL = 30 * time.Second
+)
+
+// ConcurrencyLimiter bounds how many operations of a kind run at once per user or cluster.
+// Every running operation holds a lease on one of the rule's slots. With Redis the leases
+// are shared by all instances and expire after the lease TTL unless renewed by their holder,
+// so a crashed instance cannot keep slots forever; without Redis they are held in memory.
+type ConcurrencyLimiter struct {
+	mu       sync.Mutex
+	rules    map[string]models.ConcurrencyRule
+	redis    *redis.Client
+	leaseTTL time.Duration
+	held     map[string]map[string]struct{} // in-memory lease ids by slot key
+	inFlight map[string]int64               // leases held by this instance by rule name
+}
+
+// NewConcurrencyLimiter creates a concurrency limiter; leaseTTL defaults to 30s.
+func NewConcurrencyLimiter(client *redis.Client, leaseTTL time.Duration) *ConcurrencyLimiter {
+	if leaseTTL <= 0 {
+		leaseTTL = defaultConcurrencyLeaseTTL
+	}
+	return &ConcurrencyLimiter{
+		rules:    make(map[string]models.ConcurrencyRule),
+		redis:    client,
+		leaseTTL: leaseTTL,
+		held:     make(map[string]map[string]struct{}),
+		inFlight: make(map[string]int64),
+	}
+}
+
+// AddRule registers or replaces a concurrency rule; the mode defaults to reject.
+func (l *ConcurrencyLimiter) AddRule(rule models.ConcurrencyRule) error {
+	if rule.Mode == "" {
+		rule.Mode = models.ConcurrencyModeReject
+	}
+	if rule.Name == "" || rule.Limit <= 0 || rule.QueueTimeoutSeconds < 0 ||
+		(rule.Scope != "user" && rule.Scope != "cluster") ||
+		(rule.Mode != models.ConcurrencyModeReject && rule.Mode != models.ConcurrencyModeQueue) {
+		return ErrInvalidConcurrencyRule
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rules[rule.Name] = rule
+	return nil
+}
+
+// Rule returns the named rule.
+func (l *ConcurrencyLimiter) Rule(name string) (models.ConcurrencyRule, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rule, ok := l.rules[name]
+	return rule, ok
+}
+
+// Rules returns all rules sorted by name.
+func (l *ConcurrencyLimiter) Rules() []models.ConcurrencyRule {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]models.ConcurrencyRule, 0, len(l.rules))
+	for _, rule := range l.rules {
+		out = append(out, rule)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func concurrencyKey(rule models.ConcurrencyRule, scopeID string) string {
+	return fmt.Sprintf("concurrency:%s:%s:%s", rule.Name, rule.Scope, scopeID)
+}
+
+// track counts a lease taken (delta 1) or released (-1) by this instance and updates the
+// in-flight gauge. The gauge is per rule, not per user or cluster, to keep its cardinality
+// bounded; Usage reports a single scope. l.mu must be held.
+func (l *ConcurrencyLimiter) track(rule models.ConcurrencyRule, delta int64) {
+	l.inFlight[rule.Name] += delta
+	if ConcurrencyInFlight != nil {
+		ConcurrencyInFlight.WithLabelValues(rule.Name, rule.Scope).Set(float64(l.inFlight[rule.Name]))
+	}
+}
+
+// ConcurrencyLease is a slot held by a running operation. A nil lease (the operation is not
+// limited) is valid and Release on it does nothing.
+type ConcurrencyLease struct {
+	limiter *ConcurrencyLimiter
+	rule    models.ConcurrencyRule
+	scopeID string
+	key     string
+	id      string
+	stop    chan struct{}
+	once    sync.Once
+}
+
+// redisConcurrencyAcquireLua drops expired leases and adds one if a slot is free. KEYS[1]
+// is a sorted set of lease ids scored by expiry; ARGV = now (ms), limit, ttl (ms), lease
+// id. Returns {acquired, leases held}.
+var redisConcurrencyAcquireLua = redis.NewScript(`local now=tonumber(ARGV[1])
+local ttl=tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now)
+local held = redis.call('ZCARD', KEYS[1])
+if held >= tonumber(ARGV[2]) then
+  return {0, held}
+end
+redis.call('ZADD', KEYS[1], now + ttl, ARGV[4])
+redis.call('PEXPIRE', KEYS[1], ttl)
+return {1, held + 1}`)
+
+// redisConcurrencyRenewLua extends a lease that still exists; ARGV = now (ms), ttl (ms),
+// lease id. Returns 0 if the lease already expired.
+var redisConcurrencyRenewLua = redis.NewScript(`if not redis.call('ZSCORE', KEYS[1], ARGV[3]) then
+  return 0
+end
+redis.call('ZADD', KEYS[1], tonumber(ARGV[1]) + tonumber(ARGV[2]), ARGV[3])
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+return 1`)
+
+// redisConcurrencyReleaseLua removes a lease; ARGV = now (ms), lease id. Returns the
+// number of live leases left.
+var redisConcurrencyReleaseLua = redis.NewScript(`redis.call('ZREM', KEYS[1], ARGV[2])
+return redis.call('ZCOUNT', KEYS[1], '(' .. ARGV[1], '+inf')`)
+
+// TryAcquire takes a slot of the named rule for scopeID if one is free and fails with
+// ErrConcurrencyLimited otherwise. Unknown rules and empty scope ids are not limited and
+// get a nil lease.
+func (l *ConcurrencyLimiter) TryAcquire(name, scopeID string) (*ConcurrencyLease, error) {
+	rule, ok := l.Rule(name)
+	if !ok || scopeID == "" {
+		return nil, nil
+	}
+	lease := &ConcurrencyLease{limiter: l, rule: rule, scopeID: scopeID, key: concurrencyKey(rule, scopeID), id: uuid.NewString()}
+	limited := fmt.Errorf("%w: %d %s running for %s %s", ErrConcurrencyLimited, rule.Limit, rule.Name, rule.Scope, scopeID)
+
+	if l.redis != nil {
+		res, err := redisConcurrencyAcquireLua.Run(context.Background(), l.redis, []string{lease.key},
+			time.Now().UnixMilli(), rule.Limit, l.leaseTTL.Milliseconds(), lease.id).Int64Slice()
+		if err != nil || len(res) < 2 {
+			return nil, fmt.Errorf("%w: %v", ErrConcurrencyUnavailable, err)
+		}
+		if res[0] != 1 {
+			return nil, limited
+		}
+		l.mu.Lock()
+		l.track(rule, 1)
+		l.mu.Unlock()
+		lease.stop = make(chan struct{})
+		go lease.renew()
+		return lease, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	held := l.held[lease.key]
+	if len(held) >= rule.Limit {
+		return nil, limited
+	}
+	if held == nil {
+		held = make(map[string]struct{})
+		l.held[lease.key] = held
+	}
+	held[lease.id] = struct{}{}
+	l.track(rule, 1)
+	return lease, nil
+}
+
+// Acquire takes a slot following the rule's mode: in reject mode it fails right away with
+// ErrConcurrencyLimited when every slot is taken, in queue mode it waits for a slot until
+// ctx is done or the rule's queue timeout elapses.
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context, name, scopeID string) (*ConcurrencyLease, error) {
+	lease, err := l.TryAcquire(name, scopeID)
+	rule, _ := l.Rule(name)
+	if !errors.Is(err, ErrConcurrencyLimited) || rule.Mode != models.ConcurrencyModeQueue {
+		return lease, err
+	}
+	if rule.QueueTimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(rule.QueueTimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	t := time.NewTicker(concurrencyPollInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-t.C:
+		}
+		lease, err = l.TryAcquire(name, scopeID)
+		if !errors.Is(err, ErrConcurrencyLimited) {
+			return lease, err
+		}
+	}
+}
+
+// Usage returns how many operations of the named rule are running for scopeID.
+func (l *ConcurrencyLimiter) Usage(name, scopeID string) (models.ConcurrencyUsage, error) {
+	rule, ok := l.Rule(name)
+	if !ok {
+		return models.ConcurrencyUsage{}, fmt.Errorf("concurrency rule %q not found", name)
+	}
+	usage := models.ConcurrencyUsage{Name: rule.Name, Scope: rule.Scope, ScopeID: scopeID, Limit: rule.Limit}
+	key := concurrencyKey(rule, scopeID)
+	if l.redis != nil {
+		n, err := l.redis.ZCount(context.Background(), key, fmt.Sprintf("(%d", time.Now().UnixMilli()), "+inf").Result()
+		if err != nil {
+			return models.ConcurrencyUsage{}, fmt.Errorf("%w: %v", ErrConcurrencyUnavailable, err)
+		}
+		usage.InFlight = int(n)
+		return usage, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	usage.InFlight = len(l.held[key])
+	return usage, nil
+}
+
+// renew keeps a Redis lease alive until it is released.
+func (lease *ConcurrencyLease) renew() {
+	l := lease.limiter
+	t := time.NewTicker(l.leaseTTL / 3)
+	defer t.Stop()
+	for {
+		select {
+		case <-lease.stop:
+			return
+		case <-t.C:
+		}
+		ok, err := redisConcurrencyRenewLua.Run(context.Background(), l.redis, []string{lease.key},
+			time.Now().UnixMilli(), l.leaseTTL.Milliseconds(), lease.id).Int64()
+		if err != nil {
+			logger.Warnf("renewing %s slot for %s %s failed: %v", lease.rule.Name, lease.rule.Scope, lease.scopeID, err)
+			continue
+		}
+		if ok == 0 {
+			logger.Warnf("%s slot for %s %s expired before it was released", lease.rule.Name, lease.rule.Scope, lease.scopeID)
+			return
+		}
+	}
+}
+
+// Release frees the slot. It is safe to call more than once.
+func (lease *ConcurrencyLease) Release() {
+	if lease == nil {
+		return
+	}
+	lease.once.Do(func() {
+		l := lease.limiter
+		l.mu.Lock()
+		l.track(lease.rule, -1)
+		l.mu.Unlock()
+		if l.redis != nil {
+			close(lease.stop)
+			err := redisConcurrencyReleaseLua.Run(context.Background(), l.redis, []string{lease.key},
+				time.Now().UnixMilli(), lease.id).Err()
+			if err != nil {
+				logger.Warnf("releasing %s slot for %s %s failed, it expires in %s: %v", lease.rule.Name, lease.rule.Scope, lease.scopeID, l.leaseTTL, err)
+			}
+			return
+		}
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		held := l.held[lease.key]
+		delete(held, lease.id)
+		if len(held) == 0 {
+			delete(l.held, lease.key)
+		}
+	})
+}
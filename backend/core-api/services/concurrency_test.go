@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestConcurrencyLimiter_RejectAndQueue(t *testing.T) {
+	l := NewConcurrencyLimiter(nil, 0)
+	if err := l.AddRule(models.ConcurrencyRule{Name: "deployments", Scope: "cluster", Limit: 1}); err != nil {
+		t.Fatalf("add rule: %v", err)
+	}
+	if err := l.AddRule(models.ConcurrencyRule{Name: "diagnose", Scope: "cluster", Limit: 2, Mode: models.ConcurrencyModeQueue, QueueTimeoutSeconds: 1}); err != nil {
+		t.Fatalf("add rule: %v", err)
+	}
+	if err := l.AddRule(models.ConcurrencyRule{Name: "bad", Scope: "cluster", Limit: 1, Mode: "later"}); !errors.Is(err, ErrInvalidConcurrencyRule) {
+		t.Fatalf("expected an unknown mode to be rejected, got %v", err)
+	}
+	if rule, _ := l.Rule("deployments"); rule.Mode != models.ConcurrencyModeReject {
+		t.Fatalf("expected the mode to default to reject, got %q", rule.Mode)
+	}
+
+	ctx := context.Background()
+	first, err := l.Acquire(ctx, "deployments", "c1")
+	if err != nil || first == nil {
+		t.Fatalf("expected a free slot, got %v", err)
+	}
+	if _, err := l.Acquire(ctx, "deployments", "c1"); !errors.Is(err, ErrConcurrencyLimited) {
+		t.Fatalf("expected reject mode to fail right away, got %v", err)
+	}
+	if other, err := l.Acquire(ctx, "deployments", "c2"); err != nil || other == nil {
+		t.Fatalf("expected another cluster to have its own slot, got %v", err)
+	}
+	first.Release()
+	first.Release()
+	if usage, _ := l.Usage("deployments", "c1"); usage.InFlight != 0 {
+		t.Fatalf("expected releasing twice to free exactly one slot, got %+v", usage)
+	}
+
+	// queue mode waits for a slot to free up
+	a, _ := l.Acquire(ctx, "diagnose", "c1")
+	b, _ := l.Acquire(ctx, "diagnose", "c1")
+	time.AfterFunc(200*time.Millisecond, a.Release)
+	start := time.Now()
+	c, err := l.Acquire(ctx, "diagnose", "c1")
+	if err != nil || c == nil || time.Since(start) < 150*time.Millisecond {
+		t.Fatalf("expected the third diagnosis to wait for the first, got %v after %s", err, time.Since(start))
+	}
+	// and gives up after the queue timeout
+	start = time.Now()
+	if _, err := l.Acquire(ctx, "diagnose", "c1"); !errors.Is(err, ErrConcurrencyLimited) || time.Since(start) < 900*time.Millisecond {
+		t.Fatalf("expected the queue timeout to end the wait, got %v after %s", err, time.Since(start))
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := l.Acquire(cancelled, "diagnose", "c1"); !errors.Is(err, ErrConcurrencyLimited) {
+		t.Fatalf("expected a cancelled wait to fail, got %v", err)
+	}
+	b.Release()
+	c.Release()
+
+	if lease, err := l.Acquire(ctx, "unknown", "c1"); lease != nil || err != nil {
+		t.Fatalf("expected unknown limits not to apply, got %v %v", lease, err)
+	}
+}
+
+func TestJobService_ConcurrencySlots(t *testing.T) {
+	defer func(d time.Duration) { concurrencyDeferDelay = d }(concurrencyDeferDelay)
+	concurrencyDeferDelay = 50 * time.Millisecond
+
+	for _, mode := range []string{models.ConcurrencyModeQueue, models.ConcurrencyModeReject} {
+		svc, db, _ := setupInMemoryJobService(t)
+		limiter := NewConcurrencyLimiter(nil, 0)
+		_ = limiter.AddRule(models.ConcurrencyRule{Name: "diagnose", Scope: "cluster", Limit: 1, Mode: mode})
+		svc.SetConcurrencyLimiter(limiter)
+		for _, id := range []string{"job-1", "job-2"} {
+			job := &models.Job{ID: id, ClusterID: "c1", Type: "diagnose", Status: "pending", CreatedAt: time.Now()}
+			if err := db.Create(job).Error; err != nil {
+				t.Fatalf("create job failed: %v", err)
+			}
+		}
+
+		if err := svc.ProcessJob("job-1"); err != nil {
+			t.Fatalf("%s: ProcessJob failed: %v", mode, err)
+		}
+		err := svc.ProcessJob("job-2")
+		second, _ := svc.GetJob("", "job-2")
+		if mode == models.ConcurrencyModeReject {
+			if !errors.Is(err, ErrConcurrencyLimited) || second.Status != "queued_rejected" || second.Error == "" {
+				t.Fatalf("expected the second diagnose job to be rejected, got %s %q", second.Status, second.Error)
+			}
+			_, _ = svc.CancelJob("job-1")
+			continue
+		}
+		if err != nil || second.Status != "pending" {
+			t.Fatalf("expected the second diagnose job to wait, got %s %v", second.Status, err)
+		}
+
+		// cancelling the first job frees its slot for the waiting one
+		if _, err := svc.CancelJob("job-1"); err != nil {
+			t.Fatalf("CancelJob failed: %v", err)
+		}
+		deadline := time.Now().Add(2 * time.Second)
+		for second.Status != "running" {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected the waiting job to start, still %s", second.Status)
+			}
+			time.Sleep(20 * time.Millisecond)
+			second, _ = svc.GetJob("", "job-2")
+		}
+		if usage, _ := limiter.Usage("diagnose", "c1"); usage.InFlight != 1 {
+			t.Fatalf("expected one running diagnose job, got %+v", usage)
+		}
+		_, _ = svc.CancelJob("job-2")
+	}
+}
+
+func TestJobService_ConcurrencyQueueTimeoutRejects(t *testing.T) {
+	defer func(d time.Duration) { concurrencyDeferDelay = d }(concurrencyDeferDelay)
+	concurrencyDeferDelay = 50 * time.Millisecond
+
+	svc, db, _ := setupInMemoryJobService(t)
+	limiter := NewConcurrencyLimiter(nil, 0)
+	_ = limiter.AddRule(models.ConcurrencyRule{Name: "diagnose", Scope: "cluster", Limit: 1, Mode: models.ConcurrencyModeQueue, QueueTimeoutSeconds: 1})
+	svc.SetConcurrencyLimiter(limiter)
+	for _, id := range []string{"job-1", "job-2"} {
+		job := &models.Job{ID: id, ClusterID: "c1", Type: "diagnose", Status: "pending", CreatedAt: time.Now()}
+		if err := db.Create(job).Error; err != nil {
+			t.Fatalf("create job failed: %v", err)
+		}
+	}
+	defer svc.CancelJob("job-1")
+
+	if err := svc.ProcessJob("job-1"); err != nil {
+		t.Fatalf("ProcessJob failed: %v", err)
+	}
+	if err := svc.ProcessJob("job-2"); err != nil {
+		t.Fatalf("ProcessJob failed: %v", err)
+	}
+
+	// the diagnose job holds the slot for about two seconds, longer than the queue timeout
+	second := waitForJobStatus(t, svc, "job-2", "queued_rejected", 1800*time.Millisecond)
+	if !strings.Contains(second.Error, "queue timeout") {
+		t.Fatalf("expected the rejection to be recorded, got %q", second.Error)
+	}
+	if first, _ := svc.GetJob("", "job-1"); first.Status != "running" {
+		t.Fatalf("expected the first job to keep running, got %s", first.Status)
+	}
+}
+
+func TestRedisConcurrencyLimiter_SharedLeases(t *testing.T) {
+	ctx := context.Background()
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+	client := redis.NewClient(&redis.Options{Addr: redisAddr})
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis not available on %s - skipping integration test", redisAddr)
+	}
+
+	rule := models.ConcurrencyRule{Name: "concurrency_test", Scope: "cluster", Limit: 1}
+	a := NewConcurrencyLimiter(client, 300*time.Millisecond)
+	b := NewConcurrencyLimiter(client, 300*time.Millisecond)
+	_ = a.AddRule(rule)
+	_ = b.AddRule(rule)
+	cluster := "c-" + time.Now().Format("150405.000000")
+	defer client.Del(ctx, concurrencyKey(rule, cluster))
+
+	lease, err := a.TryAcquire(rule.Name, cluster)
+	if err != nil || lease == nil {
+		t.Fatalf("expected a free slot, got %v", err)
+	}
+	// the holder renews the lease, so it outlives its ttl
+	time.Sleep(500 * time.Millisecond)
+	if _, err := b.TryAcquire(rule.Name, cluster); !errors.Is(err, ErrConcurrencyLimited) {
+		t.Fatalf("expected the renewed lease to keep the slot on every instance, got %v", err)
+	}
+	if usage, _ := b.Usage(rule.Name, cluster); usage.InFlight != 1 {
+		t.Fatalf("expected one lease in flight, got %+v", usage)
+	}
+	lease.Release()
+	other, err := b.TryAcquire(rule.Name, cluster)
+	if err != nil {
+		t.Fatalf("expected the released slot to be free, got %v", err)
+	}
+
+	// a lease whose holder stops renewing (e.g. a crashed instance) expires
+	close(other.stop)
+	time.Sleep(400 * time.Millisecond)
+	if lease, err := a.TryAcquire(rule.Name, cluster); err != nil {
+		t.Fatalf("expected the abandoned lease to expire, got %v", err)
+	} else {
+		lease.Release()
+	}
+}
@@ -1,6 +1,8 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"time"
@@ -17,36 +19,111 @@ type DeploymentService struct {
 	repo         interfaces.DeploymentRepository
 	provisioning *ProvisioningService
 	producer     deploymentProducer
+	concurrency  *ConcurrencyLimiter
 }
 
+// deploymentConcurrencyRule is the concurrency rule bounding running deployments per cluster.
+const deploymentConcurrencyRule = "deployments"
+
 func NewDeploymentService(repo interfaces.DeploymentRepository, prov *ProvisioningService, prod deploymentProducer) *DeploymentService {
 	return &DeploymentService{repo: repo, provisioning: prov, producer: prod}
 }
 
+// SetConcurrencyLimiter bounds running deployments per cluster with the limiter's
+// "deployments" rule.
+func (s *DeploymentService) SetConcurrencyLimiter(l *ConcurrencyLimiter) {
+	s.concurrency = l
+}
+
+// StartDeployment creates a deployment and rolls it out in the background. When the
+// cluster already runs as many deployments as its concurrency rule allows, reject mode
+// fails with ErrConcurrencyLimited and queue mode keeps the deployment pending until a
+// slot frees up.
 func (s *DeploymentService) StartDeployment(req *models.StartDeploymentRequest) (*models.Deployment, error) {
 	if req.ClusterID == "" || req.Version == "" {
 		return nil, fmt.Errorf("cluster_id and version required")
 	}
-	d := &models.Deployment{ClusterID: req.ClusterID, Version: req.Version, Strategy: req.Strategy, Target: req.TargetPercent, Status: "pending", Logs: []string{}}
+	if err := checkClusterInProject(s.provisioning.clusters(), req.ProjectID, req.ClusterID); err != nil {
+		return nil, err
+	}
+	var lease *ConcurrencyLease
+	if s.concurrency != nil {
+		if rule, ok := s.concurrency.Rule(deploymentConcurrencyRule); ok && rule.Mode == models.ConcurrencyModeReject {
+			var err error
+			if lease, err = s.concurrency.TryAcquire(deploymentConcurrencyRule, req.ClusterID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	d := &models.Deployment{ProjectID: req.ProjectID, ClusterID: req.ClusterID, Version: req.Version, Strategy: req.Strategy, Target: req.TargetPercent, Status: "pending", Logs: []string{}}
 	if err := s.repo.Create(d); err != nil {
+		lease.Release()
 		return nil, err
 	}
 
 	// run simulation in background
-	go s.simulateRollout(d.ID)
+	go s.runRollout(d.ID, d.ClusterID, lease)
 	return d, nil
 }
 
-func (s *DeploymentService) GetDeployment(id string) (*models.Deployment, error) {
-	return s.repo.Get(id)
+// runRollout waits for a deployment slot if the deployment did not get one up front,
+// rolls the deployment out and frees the slot.
+func (s *DeploymentService) runRollout(id, clusterID string, lease *ConcurrencyLease) {
+	if lease == nil && s.concurrency != nil {
+		var err error
+		lease, err = s.concurrency.TryAcquire(deploymentConcurrencyRule, clusterID)
+		if errors.Is(err, ErrConcurrencyLimited) {
+			s.appendLog(id, "waiting for a free deployment slot: "+err.Error())
+			lease, err = s.concurrency.Acquire(context.Background(), deploymentConcurrencyRule, clusterID)
+		}
+		if err != nil {
+			s.failDeployment(id, "no deployment slot: "+err.Error())
+			return
+		}
+	}
+	defer lease.Release()
+	s.simulateRollout(id)
 }
 
-func (s *DeploymentService) ListDeployments(clusterID string) ([]*models.Deployment, error) {
-	return s.repo.List(clusterID)
+func (s *DeploymentService) appendLog(id, msg string) {
+	if d, err := s.repo.Get(id); err == nil && d != nil {
+		d.Logs = append(d.Logs, msg)
+		_ = s.repo.Update(d)
+	}
 }
 
-func (s *DeploymentService) RollbackDeployment(id string) error {
+// failDeployment marks a deployment that never started as failed.
+func (s *DeploymentService) failDeployment(id, reason string) {
 	d, err := s.repo.Get(id)
+	if err != nil || d == nil {
+		return
+	}
+	d.Status = "failed"
+	d.Logs = append(d.Logs, reason)
+	_ = s.repo.Update(d)
+	if s.producer != nil {
+		_ = s.producer.PublishEvent("deployments", d.ID, map[string]interface{}{"action": "failed", "deployment": d})
+	}
+}
+
+// GetDeployment returns a deployment of projectID ("" for any project).
+func (s *DeploymentService) GetDeployment(projectID, id string) (*models.Deployment, error) {
+	d, err := s.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if !models.InProject(projectID, d.ProjectID) {
+		return nil, errNotInProject("deployment")
+	}
+	return d, nil
+}
+
+func (s *DeploymentService) ListDeployments(projectID, clusterID string) ([]*models.Deployment, error) {
+	return s.repo.List(projectID, clusterID)
+}
+
+func (s *DeploymentService) RollbackDeployment(projectID, id string) error {
+	d, err := s.GetDeployment(projectID, id)
 	if err != nil {
 		return err
 	}
@@ -1,49 +1,72 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
 )
 
-type memDepRepo struct{ store map[string]*models.Deployment }
+// memDepRepo stores copies of the deployments, like a database would, so the rollout
+// goroutines and the test never share a struct.
+type memDepRepo struct {
+	mu    sync.Mutex
+	store map[string]models.Deployment
+}
 
 func (m *memDepRepo) Create(d *models.Deployment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.store == nil {
-		m.store = map[string]*models.Deployment{}
+		m.store = map[string]models.Deployment{}
 	}
 	if d.ID == "" {
-		d.ID = "d-" + time.Now().Format("150405")
+		d.ID = fmt.Sprintf("d-%d", len(m.store)+1)
 	}
 	d.StartedAt = time.Now()
 	d.UpdatedAt = time.Now()
-	m.store[d.ID] = d
+	m.store[d.ID] = *d
 	return nil
 }
 func (m *memDepRepo) Get(id string) (*models.Deployment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if p, ok := m.store[id]; ok {
-		return p, nil
+		return &p, nil
 	}
 	return nil, nil
 }
-func (m *memDepRepo) List(clusterID string) ([]*models.Deployment, error) {
+func (m *memDepRepo) List(projectID, clusterID string) ([]*models.Deployment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	out := []*models.Deployment{}
 	for _, v := range m.store {
 		if v.ClusterID == clusterID {
-			out = append(out, v)
+			v := v
+			out = append(out, &v)
 		}
 	}
 	return out, nil
 }
 func (m *memDepRepo) Update(d *models.Deployment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.store == nil {
 		return nil
 	}
-	m.store[d.ID] = d
+	m.store[d.ID] = *d
+	return nil
+}
+func (m *memDepRepo) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.store, id)
 	return nil
 }
-func (m *memDepRepo) Delete(id string) error { delete(m.store, id); return nil }
 
 type nopProv struct{}
 
@@ -83,3 +106,32 @@ func TestStartDeploymentCreatesAndRuns(t *testing.T) {
 		t.Fatalf("expected simulation to start")
 	}
 }
+
+func TestStartDeployment_ConcurrencyPerCluster(t *testing.T) {
+	limiter := NewConcurrencyLimiter(nil, 0)
+	_ = limiter.AddRule(models.ConcurrencyRule{Name: "deployments", Scope: "cluster", Limit: 1})
+	svc := NewDeploymentService(&memDepRepo{}, &ProvisioningService{}, &nopProd{})
+	svc.SetConcurrencyLimiter(limiter)
+
+	if _, err := svc.StartDeployment(&models.StartDeploymentRequest{ClusterID: "c1", Version: "v1"}); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	if _, err := svc.StartDeployment(&models.StartDeploymentRequest{ClusterID: "c1", Version: "v2"}); !errors.Is(err, ErrConcurrencyLimited) {
+		t.Fatalf("expected a second deployment on c1 to be rejected, got %v", err)
+	}
+	if _, err := svc.StartDeployment(&models.StartDeploymentRequest{ClusterID: "c2", Version: "v1"}); err != nil {
+		t.Fatalf("expected another cluster to deploy, got %v", err)
+	}
+
+	// in queue mode the deployment waits for the running one instead
+	_ = limiter.AddRule(models.ConcurrencyRule{Name: "deployments", Scope: "cluster", Limit: 1, Mode: models.ConcurrencyModeQueue})
+	d, err := svc.StartDeployment(&models.StartDeploymentRequest{ClusterID: "c1", Version: "v3"})
+	if err != nil {
+		t.Fatalf("expected queue mode to accept the deployment, got %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	cur, _ := svc.GetDeployment("", d.ID)
+	if cur.Status != "pending" || len(cur.Logs) == 0 || !strings.Contains(cur.Logs[0], "waiting for a free deployment slot") {
+		t.Fatalf("expected the queued deployment to wait, got %s %v", cur.Status, cur.Logs)
+	}
+}
@@ -36,6 +36,9 @@ func (s *DiagnosisService) DiagnoseCluster(req *models.DiagnoseClusterRequest) (
 	if err != nil {
 		return nil, err
 	}
+	if !models.InProject(req.ProjectID, cluster.ProjectID) {
+		return nil, errNotInProject("cluster")
+	}
 
 	var insights, recommendations []string
 
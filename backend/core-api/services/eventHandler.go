@@ -149,8 +149,22 @@ func (h *EventHandler) handleJobRequestedTyped(event map[string]interface{}) err
 	jobType := e.JobType
 	clusterID := e.ClusterID
 
+	var job *models.Job
 	if jobID != "" && h.jobSvc != nil {
-		_ = h.jobSvc.jobRepo.UpdateJobStatus(jobID, "running")
+		// only a job still waiting for orchestration runs; one cancelled or timed out after
+		// the handoff must not be brought back to running
+		if current, err := h.jobSvc.jobRepo.GetJob(jobID); err == nil {
+			ok, err := h.jobSvc.jobRepo.TransitionJobStatus(jobID, "queued", "running")
+			if err != nil {
+				return err
+			}
+			if !ok {
+				logger.Infof("Skipping orchestration for %s job %s", current.Status, jobID)
+				return nil
+			}
+			job = current
+			job.Status = "running"
+		}
 	}
 
 	// publish job_started
@@ -159,84 +173,43 @@ func (h *EventHandler) handleJobRequestedTyped(event map[string]interface{}) err
 		se.JobID = jobID
 		se.JobType = jobType
 		se.ClusterID = clusterID
+		se.ProjectID = e.ProjectID
 		se.Progress = 0
 		se.Message = "orchestration started"
 		se.TraceID = e.TraceID
 		_ = h.provisioningSvc.producer.PublishEvent("cluster-events", jobID, se)
 		_ = h.jobSvc.jobRepo.UpdateJobProgress(jobID, 10, "orchestration started")
 	}
+	if jobID != "" && h.jobSvc != nil {
+		h.jobSvc.appendJobLog(orchestrationJob(jobID, jobType, clusterID, e), models.JobLogInfo, 10, "orchestration started")
+	}
 
 	var err error
-	switch jobType {
-	case "provision":
-		if clusterID != "" && h.provisioningSvc != nil {
-			if h.provisioningSvc.producer != nil {
-				p := events.NewEvent("job_progress")
-				p.JobID = jobID
-				p.JobType = jobType
-				p.ClusterID = clusterID
-				p.Progress = 30
-				p.Message = "provisioning - initializing"
-				p.TraceID = e.TraceID
-				_ = h.provisioningSvc.producer.PublishEvent("cluster-events", jobID, p)
-				_ = h.jobSvc.jobRepo.UpdateJobProgress(jobID, 30, "provision: initializing")
-			}
-			req := &models.CreateDropletRequest{
-				Name:      "droplet-from-job-" + jobID[len(jobID)-8:],
-				Region:    "nyc3",
-				Size:      "s-1vcpu-1gb",
-				Image:     "ubuntu-20-04-x64",
-				ClusterID: &clusterID,
-			}
-			_, err = h.provisioningSvc.CreateDroplet(req)
-			if err == nil && h.provisioningSvc.producer != nil {
-				p2 := events.NewEvent("job_progress")
-				p2.JobID = jobID
-				p2.JobType = jobType
-				p2.ClusterID = clusterID
-				p2.Progress = 75
-				p2.Message = "provisioning - completing"
-				p2.TraceID = e.TraceID
-				_ = h.provisioningSvc.producer.PublishEvent("cluster-events", jobID, p2)
-				_ = h.jobSvc.jobRepo.UpdateJobProgress(jobID, 75, "provision: completing")
-			}
-		}
-	case "scale":
-		if clusterID != "" && h.provisioningSvc != nil {
-			if h.provisioningSvc.producer != nil {
-				p := events.NewEvent("job_progress")
-				p.JobID = jobID
-				p.JobType = jobType
-				p.ClusterID = clusterID
-				p.Progress = 30
-				p.Message = "scaling - initializing"
-				p.TraceID = e.TraceID
-				_ = h.provisioningSvc.producer.PublishEvent("cluster-events", jobID, p)
-				_ = h.jobSvc.jobRepo.UpdateJobProgress(jobID, 30, "scale: initializing")
-			}
-			err = h.provisioningSvc.ScaleCluster(clusterID, "scale_up")
-			if err == nil && h.provisioningSvc.producer != nil {
-				p2 := events.NewEvent("job_progress")
-				p2.JobID = jobID
-				p2.JobType = jobType
-				p2.ClusterID = clusterID
-				p2.Progress = 75
-				p2.Message = "scaling - completing"
-				p2.TraceID = e.TraceID
-				_ = h.provisioningSvc.producer.PublishEvent("cluster-events", jobID, p2)
-				_ = h.jobSvc.jobRepo.UpdateJobProgress(jobID, 75, "scale: completing")
-			}
-		}
-	default:
-		logger.Warnf("Unhandled job type in orchestration: %s", jobType)
+	if h.jobSvc != nil {
+		err = h.jobSvc.orchestrate(jobID, jobType, clusterID, e)
 	}
 
-	if jobID != "" && h.jobSvc != nil {
+	if job != nil {
 		if err != nil {
-			_ = h.jobSvc.jobRepo.UpdateJobStatus(jobID, "failed")
-			_ = h.jobSvc.jobRepo.UpdateJobProgress(jobID, 100, "failed: "+err.Error())
+			// orchestration failures follow the job type's retry policy like any failed run
+			h.jobSvc.appendJobLog(job, models.JobLogError, job.Progress, "orchestration failed: "+err.Error())
+			status := h.jobSvc.handleJobFailure(job, err)
+			if JobsProcessed != nil {
+				JobsProcessed.WithLabelValues(job.Type, status).Inc()
+			}
+			if models.IsTerminalJobStatus(status) {
+				h.jobSvc.resolveDependents(jobID)
+			}
 		} else {
+			// progress never completes a job; only the running job becomes completed
 			_ = h.jobSvc.jobRepo.UpdateJobProgress(jobID, 100, "completed")
+			if ok, _ := h.jobSvc.jobRepo.TransitionJobStatus(jobID, "running", "completed"); ok {
+				h.jobSvc.appendJobLog(job, models.JobLogInfo, 100, "orchestration completed")
+				if JobsProcessed != nil {
+					JobsProcessed.WithLabelValues(job.Type, "completed").Inc()
+				}
+				h.jobSvc.resolveDependents(jobID)
+			}
 		}
 	}
 
@@ -245,6 +218,7 @@ func (h *EventHandler) handleJobRequestedTyped(event map[string]interface{}) err
 		jc.JobID = jobID
 		jc.JobType = jobType
 		jc.ClusterID = clusterID
+		jc.ProjectID = e.ProjectID
 		jc.Progress = 100
 		jc.Message = "completed"
 		jc.TraceID = e.TraceID
@@ -0,0 +1,160 @@
+// backend/core-api/services/idempotency.go
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// IdempotencyRecord is the state of one Idempotency-Key: in progress (owned by the
+// request holding Token) or done with the cached response.
+type IdempotencyRecord struct {
+	RequestHash string `json:"request_hash"`
+	Token       string `json:"token,omitempty"`
+	Done        bool   `json:"done"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// IdempotencyStore keeps idempotency records. Begin is the lock: only one request per
+// key gets to run the handler; the others see its record.
+type IdempotencyStore interface {
+	// Begin stores rec as the in-progress record for key unless a record exists. It
+	// returns nil when rec was stored (the caller owns the key), otherwise the existing record.
+	Begin(key string, rec IdempotencyRecord, lockTTL time.Duration) (*IdempotencyRecord, error)
+	// Complete replaces the record with the finished response, kept for ttl.
+	Complete(key string, rec IdempotencyRecord, ttl time.Duration) error
+	// Release drops an in-progress record owned by token so the key can be retried.
+	Release(key, token string) error
+}
+
+// MemoryIdempotencyStore is a process-local IdempotencyStore for tests and setups without Redis.
+// Expired records are swept at most once per memoryIdempotencySweepInterval.
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	records   map[string]memoryIdempotencyEntry
+	lastSweep time.Time
+}
+
+const memoryIdempotencySweepInterval = time.Minute
+
+type memoryIdempotencyEntry struct {
+	rec     IdempotencyRecord
+	expires time.Time
+}
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{records: make(map[string]memoryIdempotencyEntry)}
+}
+
+func (m *MemoryIdempotencyStore) Begin(key string, rec IdempotencyRecord, lockTTL time.Duration) (*IdempotencyRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep(time.Now())
+	if e, ok := m.records[key]; ok && e.expires.After(time.Now()) {
+		existing := e.rec
+		return &existing, nil
+	}
+	m.records[key] = memoryIdempotencyEntry{rec: rec, expires: time.Now().Add(lockTTL)}
+	return nil, nil
+}
+
+func (m *MemoryIdempotencyStore) Complete(key string, rec IdempotencyRecord, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[key] = memoryIdempotencyEntry{rec: rec, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+// sweep drops expired records so keys that are never retried do not accumulate.
+// Callers hold m.mu.
+func (m *MemoryIdempotencyStore) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < memoryIdempotencySweepInterval {
+		return
+	}
+	m.lastSweep = now
+	for key, e := range m.records {
+		if !e.expires.After(now) {
+			delete(m.records, key)
+		}
+	}
+}
+
+func (m *MemoryIdempotencyStore) Release(key, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.records[key]; ok && !e.rec.Done && e.rec.Token == token {
+		delete(m.records, key)
+	}
+	return nil
+}
+
+// RedisIdempotencyStore keeps each record as a JSON string with a TTL, so several
+// core-api instances share keys.
+type RedisIdempotencyStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// releaseScript: delete the record only while it is still the caller's in-progress record.
+var releaseScript = redis.NewScript(`
+local raw = redis.call("GET", KEYS[1])
+if not raw then return 0 end
+local rec = cjson.decode(raw)
+if rec.done == false and rec.token == ARGV[1] then
+  return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+func NewRedisIdempotencyStore(client *redis.Client) *RedisIdempotencyStore {
+	return &RedisIdempotencyStore{client: client, prefix: "clustergenie:idempotency:"}
+}
+
+func (r *RedisIdempotencyStore) Begin(key string, rec IdempotencyRecord, lockTTL time.Duration) (*IdempotencyRecord, error) {
+	ctx := context.Background()
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		ok, err := r.client.SetNX(ctx, r.prefix+key, data, lockTTL).Result()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return nil, nil
+		}
+		raw, err := r.client.Get(ctx, r.prefix+key).Bytes()
+		if err == redis.Nil {
+			// expired or released between the two calls; try to take it again
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var existing IdempotencyRecord
+		if err := json.Unmarshal(raw, &existing); err != nil {
+			return nil, err
+		}
+		return &existing, nil
+	}
+}
+
+func (r *RedisIdempotencyStore) Complete(key string, rec IdempotencyRecord, ttl time.Duration) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(context.Background(), r.prefix+key, data, ttl).Err()
+}
+
+func (r *RedisIdempotencyStore) Release(key, token string) error {
+	return releaseScript.Run(context.Background(), r.client, []string{r.prefix + key}, token).Err()
+}
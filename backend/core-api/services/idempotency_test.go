@@ -0,0 +1,29 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryIdempotencyStore_SweepsExpiredRecords(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	if err := store.Complete("old", IdempotencyRecord{Done: true}, time.Millisecond); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if err := store.Complete("fresh", IdempotencyRecord{Done: true}, time.Hour); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := store.Begin("new", IdempotencyRecord{Token: "t"}, time.Minute); err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if _, ok := store.records["old"]; ok {
+		t.Fatalf("expected the expired record to be swept")
+	}
+	if len(store.records) != 2 {
+		t.Fatalf("expected the live records to stay, got %d", len(store.records))
+	}
+}
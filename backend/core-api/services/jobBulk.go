@@ -0,0 +1,143 @@
+// backend/core-api/services/jobBulk.go
+
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+)
+
+// MaxBulkJobs bounds the number of jobs one bulk request may create, cancel or requeue.
+// It is also the default limit of filter-based bulk cancel/requeue.
+const MaxBulkJobs = 100
+
+// ErrInvalidBulkRequest is returned for empty, oversized or ambiguous bulk requests.
+var ErrInvalidBulkRequest = errors.New("invalid bulk request")
+
+// cancellableJobStatuses are matched by a bulk cancel filter that does not set a status.
+var cancellableJobStatuses = []string{"blocked", "pending", "queued", "running", "retrying"}
+
+// CreateJobs creates each job like CreateJob in projectID and reports a result per item;
+// one invalid item does not stop the others.
+func (s *JobService) CreateJobs(reqs []models.CreateJobRequest, tenantID string, projectID string) (*models.BulkJobsResponse, error) {
+	if len(reqs) == 0 || len(reqs) > MaxBulkJobs {
+		return nil, fmt.Errorf("%w: jobs must contain between 1 and %d items", ErrInvalidBulkRequest, MaxBulkJobs)
+	}
+	out := &models.BulkJobsResponse{Results: make([]models.BulkJobResult, 0, len(reqs))}
+	for i := range reqs {
+		req := reqs[i]
+		req.TenantID = tenantID
+		req.ProjectID = projectID
+		res := models.BulkJobResult{Index: i, Status: 201}
+		resp, err := s.CreateJob(&req)
+		if resp != nil {
+			res.Job = resp.Job
+			res.JobID = resp.Job.ID
+		}
+		if err != nil {
+			res.Status = 500
+			if errors.Is(err, ErrInvalidJobRequest) {
+				res.Status = 400
+			}
+			res.Error = err.Error()
+		}
+		out.Add(res)
+	}
+	return out, nil
+}
+
+// CancelJobs cancels the selected jobs. A filter without statuses matches only jobs that
+// can still be cancelled.
+func (s *JobService) CancelJobs(req *models.BulkJobActionRequest) (*models.BulkJobsResponse, error) {
+	ids, err := s.CancelTargets(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.bulkApply(req.ProjectID, ids, s.CancelJob, ErrJobNotCancellable), nil
+}
+
+// RequeueJobs requeues the selected dead-lettered jobs. A filter without statuses matches
+// dead-lettered jobs.
+func (s *JobService) RequeueJobs(req *models.BulkJobActionRequest) (*models.BulkJobsResponse, error) {
+	ids, err := s.RequeueTargets(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.bulkApply(req.ProjectID, ids, s.RequeueJob, ErrJobNotDeadLettered), nil
+}
+
+// CancelTargets returns the IDs a bulk cancel of req would act on.
+func (s *JobService) CancelTargets(req *models.BulkJobActionRequest) ([]string, error) {
+	return s.bulkTargets(req, cancellableJobStatuses)
+}
+
+// RequeueTargets returns the IDs a bulk requeue of req would act on.
+func (s *JobService) RequeueTargets(req *models.BulkJobActionRequest) ([]string, error) {
+	return s.bulkTargets(req, []string{"dead_lettered"})
+}
+
+// bulkTargets resolves the job IDs of a bulk action: the explicit IDs, or up to Limit
+// jobs matching the filter (oldest first).
+func (s *JobService) bulkTargets(req *models.BulkJobActionRequest, defaultStatuses []string) ([]string, error) {
+	if (len(req.JobIDs) > 0) == (req.Filter != nil) {
+		return nil, fmt.Errorf("%w: set either job_ids or filter", ErrInvalidBulkRequest)
+	}
+	if len(req.JobIDs) > 0 {
+		if len(req.JobIDs) > MaxBulkJobs {
+			return nil, fmt.Errorf("%w: at most %d job_ids", ErrInvalidBulkRequest, MaxBulkJobs)
+		}
+		return req.JobIDs, nil
+	}
+	if req.Filter.IsEmpty() {
+		return nil, fmt.Errorf("%w: filter must set at least one field", ErrInvalidBulkRequest)
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = MaxBulkJobs
+	}
+	if limit < 0 || limit > MaxBulkJobs {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidBulkRequest, MaxBulkJobs)
+	}
+	filter := *req.Filter
+	filter.ProjectID = req.ProjectID
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = defaultStatuses
+	}
+	resp, err := s.jobRepo.ListJobs(&models.GetJobsRequest{PageSize: limit, SortBy: "created_at", SortDir: "asc", JobFilter: filter})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(resp.Jobs))
+	for _, job := range resp.Jobs {
+		ids = append(ids, job.ID)
+	}
+	return ids, nil
+}
+
+// bulkApply runs a single-job action for each ID of projectID; conflict is the action's
+// "wrong state" error.
+func (s *JobService) bulkApply(projectID string, ids []string, action func(id string) (*models.Job, error), conflict error) *models.BulkJobsResponse {
+	out := &models.BulkJobsResponse{Results: make([]models.BulkJobResult, 0, len(ids))}
+	for i, id := range ids {
+		res := models.BulkJobResult{Index: i, JobID: id, Status: 200}
+		if _, err := s.GetJob(projectID, id); err != nil {
+			res.Status = 404
+			res.Error = "Job not found"
+			out.Add(res)
+			continue
+		}
+		job, err := action(id)
+		res.Job = job
+		if err != nil {
+			res.Status = 500
+			if errors.Is(err, conflict) {
+				res.Status = 409
+			}
+			res.Error = err.Error()
+		}
+		out.Add(res)
+	}
+	return out
+}
@@ -0,0 +1,131 @@
+// backend/core-api/services/jobConcurrency.go
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/logger"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+)
+
+// errJobDeferred is returned by startJob for jobs put back to wait for a concurrency slot.
+var errJobDeferred = errors.New("job is waiting for a concurrency slot")
+
+// concurrencyDeferDelay is how long a job that found every slot taken waits before it is
+// submitted again.
+var concurrencyDeferDelay = time.Second
+
+// SetConcurrencyLimiter bounds the running jobs of a type per user or cluster with the
+// limiter's rule named after the type (e.g. "diagnose"). Orchestrated jobs hold their slot
+// until they are handed to orchestration.
+func (s *JobService) SetConcurrencyLimiter(l *ConcurrencyLimiter) {
+	s.concurrency = l
+}
+
+// jobConcurrencyScope returns the user or cluster a job's slot is counted for.
+func jobConcurrencyScope(job *models.Job, scope string) string {
+	switch scope {
+	case "cluster":
+		return job.ClusterID
+	case "user":
+		if strings.HasPrefix(job.TenantID, "user:") {
+			return strings.TrimPrefix(job.TenantID, "user:")
+		}
+	}
+	return ""
+}
+
+// takeConcurrencySlot takes a slot for the run tracked by ctx; it is freed when the run is
+// untracked. When every slot is taken, a job of a queue-mode rule goes back to the queue
+// without holding a worker and tries again after concurrencyDeferDelay, until it has waited
+// for the rule's queue timeout; a job of a reject-mode rule, or one that timed out, ends as
+// queued_rejected. Jobs are also deferred while the store is down.
+func (s *JobService) takeConcurrencySlot(ctx context.Context, job *models.Job) error {
+	if s.concurrency == nil {
+		return nil
+	}
+	rule, ok := s.concurrency.Rule(job.Type)
+	if !ok {
+		return nil
+	}
+	lease, err := s.concurrency.TryAcquire(rule.Name, jobConcurrencyScope(job, rule.Scope))
+	if err == nil {
+		s.mu.Lock()
+		delete(s.waitingSince, job.ID)
+		if r, ok := s.running[job.ID]; ok && r.ctx == ctx {
+			r.lease = lease
+			s.running[job.ID] = r
+		} else {
+			lease.Release()
+		}
+		s.mu.Unlock()
+		return nil
+	}
+
+	if errors.Is(err, ErrConcurrencyLimited) && rule.Mode == models.ConcurrencyModeReject {
+		s.rejectJob(job, err)
+		return err
+	}
+
+	s.mu.Lock()
+	since, waiting := s.waitingSince[job.ID]
+	if !waiting {
+		since = time.Now()
+		s.waitingSince[job.ID] = since
+	}
+	s.mu.Unlock()
+	if errors.Is(err, ErrConcurrencyLimited) && rule.QueueTimeoutSeconds > 0 &&
+		time.Since(since) >= time.Duration(rule.QueueTimeoutSeconds)*time.Second {
+		err = fmt.Errorf("%w: no slot within the %ds queue timeout", err, rule.QueueTimeoutSeconds)
+		s.rejectJob(job, err)
+		return err
+	}
+	if !errors.Is(err, ErrConcurrencyLimited) {
+		logger.Warnf("job %s: %v; trying again in %s", job.ID, err, concurrencyDeferDelay)
+	}
+	s.deferJob(job.ID)
+	return errJobDeferred
+}
+
+// rejectJob ends a job that got no concurrency slot as queued_rejected.
+func (s *JobService) rejectJob(job *models.Job, err error) {
+	s.mu.Lock()
+	delete(s.waitingSince, job.ID)
+	s.mu.Unlock()
+	_ = s.jobRepo.UpdateJobFields(job.ID, map[string]interface{}{"status": "queued_rejected", "error": err.Error()})
+	s.appendJobLog(job, models.JobLogError, job.Progress, "rejected: "+err.Error())
+	if JobsProcessed != nil {
+		JobsProcessed.WithLabelValues(job.Type, "rejected").Inc()
+	}
+	s.resolveDependents(job.ID)
+}
+
+// deferJob submits a job that is still waiting to run again after concurrencyDeferDelay.
+// Like retry timers this lives in memory, as does the start of the wait; recovery
+// resubmits queued jobs after a restart and their wait starts over.
+func (s *JobService) deferJob(id string) {
+	time.AfterFunc(concurrencyDeferDelay, func() {
+		job, err := s.jobRepo.GetJob(id)
+		if err != nil || (job.Status != "pending" && job.Status != "queued") {
+			// cancelled while waiting
+			s.mu.Lock()
+			delete(s.waitingSince, id)
+			s.mu.Unlock()
+			return
+		}
+		if s.workerPool == nil {
+			if err := s.ProcessJob(id); err != nil {
+				logger.Errorf("Failed to process job %s: %v", id, err)
+			}
+			return
+		}
+		if !s.submit(job) {
+			s.deferJob(id)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+// backend/core-api/services/jobDependencies.go
+
+package services
+
+import (
+	"fmt"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/logger"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+)
+
+// resolveDependents re-evaluates the blocked children of a job that just reached a
+// terminal status: children whose parents all completed are released, children of a
+// failed, cancelled or skipped parent are skipped (and so are their descendants).
+func (s *JobService) resolveDependents(parentID string) {
+	children, err := s.jobRepo.ListDependentJobs(parentID)
+	if err != nil {
+		logger.Errorf("Failed to list dependents of job %s: %v", parentID, err)
+		return
+	}
+	for _, child := range children {
+		if child.Status == "blocked" {
+			s.resolveBlocked(child)
+		}
+	}
+}
+
+// resolveBlocked releases or skips a blocked job depending on the state of its parents.
+// Transitions out of "blocked" are compare-and-set so concurrent callers act only once.
+func (s *JobService) resolveBlocked(job *models.Job) {
+	for _, parentID := range job.DependsOn {
+		parent, err := s.jobRepo.GetJob(parentID)
+		if err != nil {
+			s.skipJob(job, fmt.Sprintf("dependency %s not found", parentID))
+			return
+		}
+		if parent.Status == "completed" {
+			continue
+		}
+		if models.IsTerminalJobStatus(parent.Status) {
+			s.skipJob(job, fmt.Sprintf("dependency %s %s", parentID, parent.Status))
+			return
+		}
+		// parent still in progress; its completion re-evaluates this job
+		return
+	}
+
+	ok, err := s.jobRepo.TransitionJobStatus(job.ID, "blocked", "pending")
+	if err != nil || !ok {
+		return
+	}
+	job.Status = "pending"
+	logger.Infof("job %s unblocked: all dependencies completed", job.ID)
+	s.publishJobEvent(job, "job_unblocked", 0, "dependencies completed")
+	if err := s.enqueue(job); err != nil {
+		logger.Warnf("Failed to enqueue unblocked job %s: %v", job.ID, err)
+	}
+}
+
+// skipJob marks a blocked job skipped and propagates the skip to its descendants.
+func (s *JobService) skipJob(job *models.Job, reason string) {
+	ok, err := s.jobRepo.TransitionJobStatus(job.ID, "blocked", "skipped")
+	if err != nil || !ok {
+		return
+	}
+	_ = s.jobRepo.UpdateJobFields(job.ID, map[string]interface{}{"error": reason})
+	job.Status = "skipped"
+	logger.Infof("job %s skipped: %s", job.ID, reason)
+	if JobsProcessed != nil {
+		JobsProcessed.WithLabelValues(job.Type, "skipped").Inc()
+	}
+	s.publishJobEvent(job, "job_skipped", 0, reason)
+	s.resolveDependents(job.ID)
+}
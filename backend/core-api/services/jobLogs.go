@@ -0,0 +1,76 @@
+// backend/core-api/services/jobLogs.go
+
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/interfaces"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/logger"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+)
+
+// ErrJobLogsUnavailable is returned when no job log repository is configured.
+var ErrJobLogsUnavailable = errors.New("job logs are not available")
+
+// maxJobLogPage caps the number of log entries returned per request.
+const maxJobLogPage = 1000
+
+func (s *JobService) SetJobLogRepository(repo interfaces.JobLogRepository) {
+	s.jobLogs = repo
+}
+
+// appendJobLog adds an entry to the job's log. Logging is best effort: a failed write
+// never fails the job.
+func (s *JobService) appendJobLog(job *models.Job, level string, progress int, message string) {
+	if s.jobLogs == nil || job == nil {
+		return
+	}
+	entry := &models.JobLog{
+		JobID:     job.ID,
+		Timestamp: time.Now().UTC(),
+		Level:     level,
+		Message:   message,
+		Progress:  progress,
+		TraceID:   job.TraceID,
+	}
+	if err := s.jobLogs.Append(entry); err != nil {
+		logger.Warnf("Failed to append log entry for job %s: %v", job.ID, err)
+	}
+}
+
+// jobEventLogLevel maps a job lifecycle event to the level of its log entry.
+func jobEventLogLevel(eventType string) string {
+	switch eventType {
+	case "job_dead_lettered", "job_timed_out":
+		return models.JobLogError
+	case "job_retry_scheduled", "job_cancelled", "job_skipped":
+		return models.JobLogWarn
+	}
+	return models.JobLogInfo
+}
+
+// JobLogs returns up to limit log entries of a job after the given entry id, oldest first.
+func (s *JobService) JobLogs(jobID string, after uint64, limit int) (*models.ListJobLogsResponse, error) {
+	if s.jobLogs == nil {
+		return nil, ErrJobLogsUnavailable
+	}
+	if limit <= 0 || limit > maxJobLogPage {
+		limit = maxJobLogPage
+	}
+	// fetch one extra entry to know whether another page follows
+	entries, err := s.jobLogs.List(jobID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	resp := &models.ListJobLogsResponse{Logs: entries, NextAfter: after}
+	if len(entries) > limit {
+		resp.Logs = entries[:limit]
+		resp.HasMore = true
+	}
+	if n := len(resp.Logs); n > 0 {
+		resp.NextAfter = resp.Logs[n-1].ID
+	}
+	return resp, nil
+}
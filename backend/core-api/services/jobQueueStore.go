@@ -0,0 +1,272 @@
+// backend/core-api/services/jobQueueStore.go
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// QueueEntry is a queued job as persisted by a QueueStore.
+type QueueEntry struct {
+	JobID      string    `json:"job_id"`
+	Priority   string    `json:"priority"`
+	Tenant     string    `json:"tenant"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+}
+
+// QueueStore persists the WorkerPool queue so queued jobs survive restarts. Ordering
+// stays in the pool; the store only tracks which ids are pending and which are leased
+// to a worker. A lease is a visibility timeout: if it is not renewed before it expires
+// (the worker or process died) the job is handed back for recovery. Each lease records
+// the pool instance that holds it, so a slow owner whose lease was reaped cannot renew
+// or ack the lease of the instance that took the job over.
+type QueueStore interface {
+	// Add persists a pending entry.
+	Add(e QueueEntry) error
+	// Remove drops a pending entry that will not run.
+	Remove(jobID string) error
+	// Claim atomically moves a pending entry to in-flight with a lease held by owner. It
+	// returns false when the entry is gone (removed, or claimed by another instance).
+	Claim(jobID, owner string, lease time.Duration) (bool, error)
+	// Renew extends the lease of an in-flight entry. It returns false when owner no
+	// longer holds the lease (it was reaped).
+	Renew(jobID, owner string, lease time.Duration) (bool, error)
+	// Ack drops an in-flight entry once its job finished, if owner still holds it.
+	Ack(jobID, owner string) error
+	// Pending lists all pending entries.
+	Pending() ([]QueueEntry, error)
+	// Leased reports whether jobID holds a lease that had not expired at the given time.
+	Leased(jobID string, at time.Time) (bool, error)
+	// ReapExpired removes and returns in-flight entries whose lease expired before the
+	// given time. Each expired id is returned to exactly one caller.
+	ReapExpired(before time.Time) ([]string, error)
+}
+
+// MemoryQueueStore is a process-local QueueStore. It provides leases but no durability
+// and is meant for tests and single-instance setups without Redis.
+type MemoryQueueStore struct {
+	mu       sync.Mutex
+	pending  map[string]QueueEntry
+	inflight map[string]memoryLease
+}
+
+type memoryLease struct {
+	owner   string
+	expires time.Time
+}
+
+func NewMemoryQueueStore() *MemoryQueueStore {
+	return &MemoryQueueStore{pending: make(map[string]QueueEntry), inflight: make(map[string]memoryLease)}
+}
+
+func (m *MemoryQueueStore) Add(e QueueEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pending[e.JobID] = e
+	return nil
+}
+
+func (m *MemoryQueueStore) Remove(jobID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.pending, jobID)
+	return nil
+}
+
+func (m *MemoryQueueStore) Claim(jobID, owner string, lease time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.pending[jobID]; !ok {
+		return false, nil
+	}
+	delete(m.pending, jobID)
+	m.inflight[jobID] = memoryLease{owner: owner, expires: time.Now().Add(lease)}
+	return true, nil
+}
+
+func (m *MemoryQueueStore) Renew(jobID, owner string, lease time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, ok := m.inflight[jobID]
+	if !ok || l.owner != owner {
+		return false, nil
+	}
+	l.expires = time.Now().Add(lease)
+	m.inflight[jobID] = l
+	return true, nil
+}
+
+func (m *MemoryQueueStore) Ack(jobID, owner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if l, ok := m.inflight[jobID]; ok && l.owner == owner {
+		delete(m.inflight, jobID)
+	}
+	return nil
+}
+
+func (m *MemoryQueueStore) Pending() ([]QueueEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]QueueEntry, 0, len(m.pending))
+	for _, e := range m.pending {
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+func (m *MemoryQueueStore) Leased(jobID string, at time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, ok := m.inflight[jobID]
+	return ok && l.expires.After(at), nil
+}
+
+func (m *MemoryQueueStore) ReapExpired(before time.Time) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []string
+	for id, l := range m.inflight {
+		if !l.expires.After(before) {
+			delete(m.inflight, id)
+			out = append(out, id)
+		}
+	}
+	return out, nil
+}
+
+// RedisQueueStore keeps pending entries in a hash, in-flight leases in a sorted set
+// scored by lease expiry (unix ms) and lease owners in a second hash. Claims, renewals,
+// acks and reaps are Lua scripts so several core-api instances can share one queue
+// without running a job twice.
+type RedisQueueStore struct {
+	client      *redis.Client
+	pendingKey  string
+	inflightKey string
+	ownersKey   string
+}
+
+const redisQueuePrefix = "clustergenie:jobqueue:"
+
+// claimScript: move id from the pending hash to the in-flight zset if still pending and
+// record its owner.
+var claimScript = redis.NewScript(`
+if redis.call("HDEL", KEYS[1], ARGV[1]) == 1 then
+  redis.call("ZADD", KEYS[2], ARGV[2], ARGV[1])
+  redis.call("HSET", KEYS[3], ARGV[1], ARGV[3])
+  return 1
+end
+return 0
+`)
+
+// renewScript: extend the lease of id if ARGV[2] still owns it.
+var renewScript = redis.NewScript(`
+if redis.call("HGET", KEYS[2], ARGV[1]) ~= ARGV[2] then
+  return 0
+end
+redis.call("ZADD", KEYS[1], "XX", ARGV[3], ARGV[1])
+return 1
+`)
+
+// ackScript: drop the lease of id if ARGV[2] still owns it.
+var ackScript = redis.NewScript(`
+if redis.call("HGET", KEYS[2], ARGV[1]) == ARGV[2] then
+  redis.call("ZREM", KEYS[1], ARGV[1])
+  redis.call("HDEL", KEYS[2], ARGV[1])
+end
+return 0
+`)
+
+// reapScript: remove and return in-flight ids whose lease expired, dropping their owners.
+var reapScript = redis.NewScript(`
+local ids = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1])
+for _, id in ipairs(ids) do
+  redis.call("ZREM", KEYS[1], id)
+  redis.call("HDEL", KEYS[2], id)
+end
+return ids
+`)
+
+func NewRedisQueueStore(client *redis.Client) *RedisQueueStore {
+	return &RedisQueueStore{
+		client:      client,
+		pendingKey:  redisQueuePrefix + "pending",
+		inflightKey: redisQueuePrefix + "inflight",
+		ownersKey:   redisQueuePrefix + "owners",
+	}
+}
+
+func leaseScore(t time.Time) string {
+	return strconv.FormatInt(t.UnixMilli(), 10)
+}
+
+func (r *RedisQueueStore) Add(e QueueEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return r.client.HSet(context.Background(), r.pendingKey, e.JobID, data).Err()
+}
+
+func (r *RedisQueueStore) Remove(jobID string) error {
+	return r.client.HDel(context.Background(), r.pendingKey, jobID).Err()
+}
+
+func (r *RedisQueueStore) Claim(jobID, owner string, lease time.Duration) (bool, error) {
+	n, err := claimScript.Run(context.Background(), r.client, []string{r.pendingKey, r.inflightKey, r.ownersKey},
+		jobID, leaseScore(time.Now().Add(lease)), owner).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r *RedisQueueStore) Renew(jobID, owner string, lease time.Duration) (bool, error) {
+	n, err := renewScript.Run(context.Background(), r.client, []string{r.inflightKey, r.ownersKey},
+		jobID, owner, leaseScore(time.Now().Add(lease))).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r *RedisQueueStore) Ack(jobID, owner string) error {
+	return ackScript.Run(context.Background(), r.client, []string{r.inflightKey, r.ownersKey}, jobID, owner).Err()
+}
+
+func (r *RedisQueueStore) Pending() ([]QueueEntry, error) {
+	m, err := r.client.HGetAll(context.Background(), r.pendingKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]QueueEntry, 0, len(m))
+	for id, raw := range m {
+		var e QueueEntry
+		if err := json.Unmarshal([]byte(raw), &e); err != nil {
+			e = QueueEntry{JobID: id}
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+func (r *RedisQueueStore) Leased(jobID string, at time.Time) (bool, error) {
+	score, err := r.client.ZScore(context.Background(), r.inflightKey, jobID).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return int64(score) > at.UnixMilli(), nil
+}
+
+func (r *RedisQueueStore) ReapExpired(before time.Time) ([]string, error) {
+	return reapScript.Run(context.Background(), r.client, []string{r.inflightKey, r.ownersKey}, leaseScore(before)).StringSlice()
+}
@@ -0,0 +1,112 @@
+// backend/core-api/services/jobRecovery.go
+
+package services
+
+import (
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/logger"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+)
+
+// recoveryScanLimit bounds how many jobs per status are examined at startup.
+const recoveryScanLimit = 10000
+
+// RecoverJobs repairs job state left behind by a previous process. Call it once at
+// startup after the worker pool (and its store) is attached and recovered:
+//   - pending/queued jobs that are not waiting in the pool are submitted again
+//   - running jobs without a live lease are treated as failed attempts (retried,
+//     dead-lettered or failed according to their retry policy)
+//   - retrying jobs get their backoff timer back
+//   - blocked jobs whose parents finished meanwhile are released or skipped
+//
+// Jobs handed to orchestration ("queued" with started_at set) are left to the Kafka consumer.
+func (s *JobService) RecoverJobs() {
+	requeued, failed, retries := 0, 0, 0
+
+	for _, status := range []string{"pending", "queued"} {
+		jobs, err := s.jobRepo.ListJobsByStatus(status, recoveryScanLimit)
+		if err != nil {
+			logger.Errorf("Recovery: failed to list %s jobs: %v", status, err)
+			continue
+		}
+		for _, job := range jobs {
+			if job.StartedAt != nil {
+				continue
+			}
+			if s.workerPool != nil && s.workerPool.Contains(job.ID) {
+				continue
+			}
+			if err := s.enqueue(job); err != nil {
+				logger.Warnf("Recovery: failed to requeue job %s: %v", job.ID, err)
+				continue
+			}
+			requeued++
+		}
+	}
+
+	running, err := s.jobRepo.ListJobsByStatus("running", recoveryScanLimit)
+	if err != nil {
+		logger.Errorf("Recovery: failed to list running jobs: %v", err)
+	}
+	for _, job := range running {
+		if s.workerPool != nil && s.workerPool.store != nil {
+			// another instance still holds the job
+			if leased, err := s.workerPool.Leased(job.ID); err == nil && leased {
+				continue
+			}
+		}
+		s.failExpiredJob(job)
+		failed++
+	}
+
+	retrying, err := s.jobRepo.ListJobsByStatus("retrying", recoveryScanLimit)
+	if err != nil {
+		logger.Errorf("Recovery: failed to list retrying jobs: %v", err)
+	}
+	for _, job := range retrying {
+		delay := time.Duration(0)
+		if job.NextRunAt != nil {
+			delay = time.Until(*job.NextRunAt)
+		}
+		if delay < 0 {
+			delay = 0
+		}
+		s.scheduleRetry(job.ID, delay)
+		retries++
+	}
+
+	blocked, err := s.jobRepo.ListJobsByStatus("blocked", recoveryScanLimit)
+	if err != nil {
+		logger.Errorf("Recovery: failed to list blocked jobs: %v", err)
+	}
+	for _, job := range blocked {
+		s.resolveBlocked(job)
+	}
+
+	logger.Infof("Recovery: requeued %d jobs, recovered %d interrupted jobs, rescheduled %d retries", requeued, failed, retries)
+}
+
+// HandleExpiredLease is the WorkerPool lease-expired callback: a job whose lease
+// lapsed while still running is stopped locally (if it runs here) and recorded as a
+// failed attempt.
+func (s *JobService) HandleExpiredLease(jobID string) {
+	job, err := s.jobRepo.GetJob(jobID)
+	if err != nil || job.Status != "running" {
+		return
+	}
+	s.cancelRunning(jobID, ErrLeaseExpired)
+	s.failExpiredJob(job)
+}
+
+// failExpiredJob applies the retry policy to a job interrupted mid-run.
+func (s *JobService) failExpiredJob(job *models.Job) {
+	logger.Warnf("job %s was interrupted while running (attempt %d)", job.ID, job.Attempts)
+	status := s.handleJobFailure(job, ErrLeaseExpired)
+	if JobsProcessed != nil {
+		JobsProcessed.WithLabelValues(job.Type, status).Inc()
+	}
+	if models.IsTerminalJobStatus(status) {
+		s.resolveDependents(job.ID)
+	}
+}
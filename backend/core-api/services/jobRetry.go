@@ -0,0 +1,91 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy controls how failed jobs of a given type are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of runs including the first; <= 1 disables retries
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry; it doubles on every attempt
+	BaseDelay time.Duration
+	// MaxDelay caps the exponential backoff (0 = uncapped)
+	MaxDelay time.Duration
+	// Jitter randomises the delay by +/- this fraction (0..1) to avoid retry storms
+	Jitter float64
+	// Retryable classifies errors; nil uses DefaultRetryable
+	Retryable func(err error) bool
+}
+
+// DefaultRetryPolicies are applied to job types without an explicit policy.
+var DefaultRetryPolicies = map[string]RetryPolicy{
+	"provision": {MaxAttempts: 3, BaseDelay: 2 * time.Second, MaxDelay: 30 * time.Second, Jitter: 0.2},
+	"scale":     {MaxAttempts: 3, BaseDelay: 2 * time.Second, MaxDelay: 30 * time.Second, Jitter: 0.2},
+	"diagnose":  {MaxAttempts: 2, BaseDelay: 1 * time.Second, MaxDelay: 10 * time.Second, Jitter: 0.2},
+	"monitor":   {MaxAttempts: 2, BaseDelay: 1 * time.Second, MaxDelay: 10 * time.Second, Jitter: 0.2},
+}
+
+// Delay returns the backoff before the next run after `attempts` failed runs.
+func (p RetryPolicy) Delay(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	d := float64(p.BaseDelay) * math.Pow(2, float64(attempts-1))
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(d)
+}
+
+// ShouldRetry reports whether a job that failed with err after `attempts` runs gets another run.
+func (p RetryPolicy) ShouldRetry(attempts int, err error) bool {
+	if attempts >= p.MaxAttempts {
+		return false
+	}
+	return p.IsRetryable(err)
+}
+
+// IsRetryable classifies err using the policy's Retryable func or DefaultRetryable.
+func (p RetryPolicy) IsRetryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return DefaultRetryable(err)
+}
+
+// permanentError marks an error that retrying cannot fix (bad parameters, unknown type).
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the retry machinery fails the job immediately.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe)
+}
+
+// DefaultRetryable treats everything except permanent errors and cancellation as transient.
+func DefaultRetryable(err error) bool {
+	if err == nil || IsPermanent(err) {
+		return false
+	}
+	return !errors.Is(err, ErrJobCancelled)
+}
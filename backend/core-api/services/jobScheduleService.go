@@ -0,0 +1,391 @@
+// backend/core-api/services/jobScheduleService.go
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/interfaces"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/logger"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"github.com/robfig/cron/v3"
+)
+
+// ErrInvalidSchedule is returned for schedules with a bad cron expression, timezone,
+// job type or overlap policy.
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+var validOverlapPolicies = map[string]bool{"skip": true, "queue": true, "replace": true}
+
+// JobScheduleService runs cron-style recurring jobs. Schedules (including last/next run)
+// are persisted, so the loop picks up where it left off after a restart; runs missed
+// while the service was down fire once, not once per missed tick.
+type JobScheduleService struct {
+	repo   interfaces.JobScheduleRepository
+	jobSvc *JobService
+	quotas *QuotaManager
+	now    func() time.Time
+
+	mu       sync.Mutex // serializes ticks with API updates
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+func NewJobScheduleService(repo interfaces.JobScheduleRepository, jobSvc *JobService) *JobScheduleService {
+	return &JobScheduleService{
+		repo:   repo,
+		jobSvc: jobSvc,
+		now:    time.Now,
+		stopCh: make(chan struct{}),
+	}
+}
+
+// SetQuotas makes scheduled provision jobs count against the provision_jobs quota (as
+// user schedule:<id>) and the droplets quota of their cluster, like jobs created through
+// the API. A run that does not fit is skipped with the quota error as its last error.
+func (s *JobScheduleService) SetQuotas(quotas *QuotaManager) {
+	s.quotas = quotas
+}
+
+// Start runs the scheduler loop, checking for due schedules every interval.
+func (s *JobScheduleService) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stopCh:
+				return
+			case <-ticker.C:
+				s.RunDue()
+			}
+		}
+	}()
+	logger.Infof("Job scheduler started (interval %s)", interval)
+}
+
+// Stop ends the scheduler loop.
+func (s *JobScheduleService) Stop() {
+	s.stopOnce.Do(func() { close(s.stopCh) })
+}
+
+// parseSchedule validates the cron expression and timezone.
+func parseSchedule(expr, tz string) (cron.Schedule, *time.Location, error) {
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidSchedule, tz)
+	}
+	sched, err := cron.ParseStandard(expr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	return sched, loc, nil
+}
+
+// nextRun computes the next fire time after `after`, evaluated in the schedule's timezone.
+func nextRun(js *models.JobSchedule, after time.Time) (*time.Time, error) {
+	sched, loc, err := parseSchedule(js.CronExpr, js.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	next := sched.Next(after.In(loc)).UTC()
+	return &next, nil
+}
+
+func (s *JobScheduleService) validateSchedule(js *models.JobSchedule) error {
+	if err := s.jobSvc.validateJobSpec(js.JobType, jobParams(js)); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	if cid := jobParams(js)["cluster_id"]; cid != "" {
+		if err := checkClusterInProject(s.jobSvc.clusterSvc, js.ProjectID, cid); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+		}
+	}
+	if !validOverlapPolicies[js.OverlapPolicy] {
+		return fmt.Errorf("%w: overlap_policy must be skip, queue or replace", ErrInvalidSchedule)
+	}
+	_, _, err := parseSchedule(js.CronExpr, js.Timezone)
+	return err
+}
+
+func (s *JobScheduleService) CreateSchedule(req *models.CreateScheduleRequest) (*models.JobSchedule, error) {
+	js := &models.JobSchedule{
+		ProjectID:     req.ProjectID,
+		Name:          req.Name,
+		ClusterID:     req.ClusterID,
+		CronExpr:      req.CronExpr,
+		Timezone:      req.Timezone,
+		JobType:       req.JobType,
+		Parameters:    req.Parameters,
+		Enabled:       true,
+		OverlapPolicy: req.OverlapPolicy,
+		CreatedAt:     s.now(),
+		UpdatedAt:     s.now(),
+	}
+	if req.Enabled != nil {
+		js.Enabled = *req.Enabled
+	}
+	if js.Timezone == "" {
+		js.Timezone = "UTC"
+	}
+	if js.OverlapPolicy == "" {
+		js.OverlapPolicy = "skip"
+	}
+	if err := s.validateSchedule(js); err != nil {
+		return nil, err
+	}
+	if js.Enabled {
+		js.NextRunAt, _ = nextRun(js, s.now())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.repo.Create(js); err != nil {
+		return nil, err
+	}
+	logger.Infof("schedule %s created (%s %q, next run %v)", js.ID, js.JobType, js.CronExpr, js.NextRunAt)
+	return js, nil
+}
+
+// GetSchedule returns a schedule of projectID (any project if projectID is empty).
+func (s *JobScheduleService) GetSchedule(projectID, id string) (*models.JobSchedule, error) {
+	js, err := s.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if !models.InProject(projectID, js.ProjectID) {
+		return nil, errNotInProject("schedule")
+	}
+	return js, nil
+}
+
+func (s *JobScheduleService) ListSchedules(projectID string) ([]*models.JobSchedule, error) {
+	return s.repo.List(projectID)
+}
+
+// UpdateSchedule applies the set fields and recomputes the next run.
+func (s *JobScheduleService) UpdateSchedule(projectID, id string, req *models.UpdateScheduleRequest) (*models.JobSchedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	js, err := s.GetSchedule(projectID, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		js.Name = *req.Name
+	}
+	if req.ClusterID != nil {
+		js.ClusterID = *req.ClusterID
+	}
+	if req.CronExpr != nil {
+		js.CronExpr = *req.CronExpr
+	}
+	if req.Timezone != nil {
+		js.Timezone = *req.Timezone
+	}
+	if req.JobType != nil {
+		js.JobType = *req.JobType
+	}
+	if req.Parameters != nil {
+		js.Parameters = *req.Parameters
+	}
+	if req.Enabled != nil {
+		js.Enabled = *req.Enabled
+	}
+	if req.OverlapPolicy != nil {
+		js.OverlapPolicy = *req.OverlapPolicy
+	}
+	if err := s.validateSchedule(js); err != nil {
+		return nil, err
+	}
+	js.NextRunAt = nil
+	if js.Enabled {
+		js.NextRunAt, _ = nextRun(js, s.now())
+	}
+	if !js.Enabled || js.OverlapPolicy != "queue" {
+		js.PendingRun = false
+	}
+	js.UpdatedAt = s.now()
+	if err := s.repo.Update(js); err != nil {
+		return nil, err
+	}
+	return js, nil
+}
+
+func (s *JobScheduleService) DeleteSchedule(projectID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.GetSchedule(projectID, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+// RunDue fires every schedule that is due. It is called by the scheduler loop.
+func (s *JobScheduleService) RunDue() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	due, err := s.repo.ListDue(now)
+	if err != nil {
+		logger.Errorf("Failed to list due schedules: %v", err)
+		return
+	}
+	for _, js := range due {
+		s.fire(js, now)
+	}
+}
+
+// fire runs one schedule, applying its overlap policy when the job from the previous
+// run is still in flight. Every change is claimed first, so when several instances see
+// the same due schedule only one of them acts on it.
+func (s *JobScheduleService) fire(js *models.JobSchedule, now time.Time) {
+	tickDue := js.NextRunAt != nil && !js.NextRunAt.After(now)
+	next := js.NextRunAt
+	if tickDue {
+		next, _ = nextRun(js, now)
+	}
+
+	if s.previousRunActive(js) {
+		switch js.OverlapPolicy {
+		case "queue":
+			// at most one run waits; further ticks collapse into it
+			if tickDue && s.claim(js, next, true) {
+				s.save(js)
+			}
+			return
+		case "replace":
+			if !s.claim(js, next, false) {
+				return
+			}
+			if _, err := s.jobSvc.CancelJob(js.LastJobID); err != nil && !errors.Is(err, ErrJobNotCancellable) {
+				logger.Warnf("schedule %s: failed to cancel previous job %s: %v", js.ID, js.LastJobID, err)
+			}
+		default:
+			if !s.claim(js, next, false) {
+				return
+			}
+			if tickDue {
+				logger.Infof("schedule %s: previous job %s still running, skipping run", js.ID, js.LastJobID)
+			}
+			s.save(js)
+			return
+		}
+	} else if !s.claim(js, next, false) {
+		return
+	}
+
+	js.LastRunAt = &now
+	js.LastError = ""
+	release, err := s.chargeQuotas(js)
+	if err != nil {
+		js.LastError = err.Error()
+		logger.Warnf("schedule %s: skipping run: %v", js.ID, err)
+		s.save(js)
+		return
+	}
+	resp, err := s.jobSvc.CreateJob(&models.CreateJobRequest{Type: js.JobType, Parameters: jobParams(js), ProjectID: js.ProjectID})
+	if err != nil {
+		release()
+		js.LastError = err.Error()
+		logger.Warnf("schedule %s: failed to create job: %v", js.ID, err)
+	}
+	if resp != nil && resp.Job != nil {
+		js.LastJobID = resp.Job.ID
+	}
+	s.save(js)
+}
+
+// claim moves js to its next run and pending flag; it reports false when another
+// instance already handled this tick.
+func (s *JobScheduleService) claim(js *models.JobSchedule, next *time.Time, pending bool) bool {
+	ok, err := s.repo.ClaimRun(js, next, pending)
+	if err != nil {
+		logger.Errorf("Failed to claim schedule %s: %v", js.ID, err)
+		return false
+	}
+	if !ok {
+		logger.Infof("schedule %s: run already claimed by another instance", js.ID)
+		return false
+	}
+	js.NextRunAt = next
+	js.PendingRun = pending
+	return true
+}
+
+// chargeQuotas charges the quotas a run of js uses. The returned func gives them back if
+// the job could not be created.
+func (s *JobScheduleService) chargeQuotas(js *models.JobSchedule) (func(), error) {
+	type charge struct {
+		quota string
+		QuotaCharge
+	}
+	var charges []charge
+	if s.quotas != nil && js.JobType == "provision" {
+		charges = []charge{
+			{QuotaProvisionJobs, QuotaCharge{ScopeID: "schedule:" + js.ID, Units: 1}},
+			{QuotaDroplets, QuotaCharge{ScopeID: jobParams(js)["cluster_id"], Units: 1}},
+		}
+	}
+	var taken []charge
+	release := func() {
+		for _, ch := range taken {
+			if err := s.quotas.Release(ch.quota, []QuotaCharge{ch.QuotaCharge}); err != nil {
+				logger.Warnf("schedule %s: releasing quota %s failed: %v", js.ID, ch.quota, err)
+			}
+		}
+	}
+	for _, ch := range charges {
+		if _, ok := s.quotas.Rule(ch.quota); !ok {
+			continue
+		}
+		usage, ok, err := s.quotas.Consume(ch.quota, []QuotaCharge{ch.QuotaCharge})
+		if err == nil && !ok {
+			err = fmt.Errorf("%s quota exceeded for %s %s: %d of %d per %s used", ch.quota, usage.Scope, usage.ScopeID, usage.Used, usage.Limit, usage.Period)
+		}
+		if err != nil {
+			release()
+			return nil, err
+		}
+		taken = append(taken, ch)
+	}
+	return release, nil
+}
+
+// jobParams returns the parameters of the jobs a schedule creates: its own parameters
+// plus cluster_id.
+func jobParams(js *models.JobSchedule) map[string]string {
+	params := make(map[string]string, len(js.Parameters)+1)
+	for k, v := range js.Parameters {
+		params[k] = v
+	}
+	if js.ClusterID != "" {
+		params["cluster_id"] = js.ClusterID
+	}
+	return params
+}
+
+// previousRunActive reports whether the job created by the last run has not finished yet.
+func (s *JobScheduleService) previousRunActive(js *models.JobSchedule) bool {
+	if js.LastJobID == "" {
+		return false
+	}
+	job, err := s.jobSvc.GetJob("", js.LastJobID)
+	if err != nil {
+		return false
+	}
+	return !models.IsTerminalJobStatus(job.Status)
+}
+
+func (s *JobScheduleService) save(js *models.JobSchedule) {
+	js.UpdatedAt = s.now()
+	if err := s.repo.Update(js); err != nil {
+		logger.Errorf("Failed to persist schedule %s: %v", js.ID, err)
+	}
+}
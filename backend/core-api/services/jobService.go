@@ -3,8 +3,11 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/AvinashMahala/ClusterGenie/backend/core-api/logger"
@@ -24,15 +27,49 @@ type JobService struct {
 		PublishEvent(topic, key string, event interface{}) error
 	}
 	workerPool *WorkerPool
+
+	// cancel funcs for jobs currently being processed, keyed by job id
+	mu      sync.Mutex
+	running map[string]runningJob
+
+	retryPolicies map[string]RetryPolicy
+	timeouts      map[string]time.Duration
+	jobTypes      *JobTypeRegistry
+	jobLogs       interfaces.JobLogRepository
+	concurrency   *ConcurrencyLimiter
+	// when jobs deferred for a concurrency slot started waiting, keyed by job id
+	waitingSince map[string]time.Time
 }
 
+// ErrInvalidJobRequest is returned for jobs with an unknown type, priority or invalid parameters.
+var ErrInvalidJobRequest = errors.New("invalid job request")
+
+// ErrJobCancelled is the cancellation cause used when a job is cancelled via the API.
+var ErrJobCancelled = errors.New("job cancelled")
+
+// ErrJobNotCancellable is returned when cancelling a job that already reached a terminal state.
+var ErrJobNotCancellable = errors.New("job already finished and cannot be cancelled")
+
+// ErrLeaseExpired is recorded for jobs whose worker lease expired mid-run (crash or restart).
+var ErrLeaseExpired = errors.New("worker lease expired before the job finished")
+
+// ErrJobNotDeadLettered is returned when requeueing a job that is not dead-lettered.
+var ErrJobNotDeadLettered = errors.New("only dead-lettered jobs can be requeued")
+
 func NewJobService(jobRepo interfaces.JobRepository, producer interface {
 	PublishEvent(topic, key string, event interface{}) error
 }) *JobService {
-	return &JobService{
-		jobRepo:  jobRepo,
-		producer: producer,
+	s := &JobService{
+		jobRepo:       jobRepo,
+		producer:      producer,
+		running:       make(map[string]runningJob),
+		retryPolicies: make(map[string]RetryPolicy),
+		timeouts:      make(map[string]time.Duration),
+		jobTypes:      NewJobTypeRegistry(),
+		waitingSince:  make(map[string]time.Time),
 	}
+	registerBuiltinJobTypes(s.jobTypes)
+	return s
 }
 
 func (s *JobService) SetProvisioningService(provisioningSvc *ProvisioningService) {
@@ -43,22 +80,81 @@ func (s *JobService) SetClusterService(clusterSvc *ClusterService) {
 	s.clusterSvc = clusterSvc
 }
 
+// SetRetryPolicy overrides the retry policy for a job type.
+func (s *JobService) SetRetryPolicy(jobType string, policy RetryPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retryPolicies[jobType] = policy
+}
+
+func (s *JobService) retryPolicyFor(jobType string) RetryPolicy {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p, ok := s.retryPolicies[jobType]; ok {
+		return p
+	}
+	return DefaultRetryPolicies[jobType]
+}
+
 // SetWorkerPool assigns a worker pool for processing jobs concurrently.
 func (s *JobService) SetWorkerPool(pool *WorkerPool) {
 	s.workerPool = pool
 }
 
+// RegisterJobType adds a job type; it must be called before jobs of that type are
+// created or recovered.
+func (s *JobService) RegisterJobType(h JobHandler) error {
+	return s.jobTypes.Register(h)
+}
+
+// JobTypes lists the registered job types with their timeout and retry settings.
+func (s *JobService) JobTypes() []models.JobTypeSpec {
+	specs := s.jobTypes.Specs()
+	for i := range specs {
+		specs[i].TimeoutSeconds = int(s.timeoutFor(specs[i].Name).Seconds())
+		specs[i].MaxAttempts = s.retryPolicyFor(specs[i].Name).MaxAttempts
+	}
+	return specs
+}
+
+// validateJobSpec checks that the type is registered and its handler accepts params.
+func (s *JobService) validateJobSpec(jobType string, params map[string]string) error {
+	h, ok := s.jobTypes.Get(jobType)
+	if !ok {
+		return fmt.Errorf("unknown job type %q", jobType)
+	}
+	if err := h.Validate(params); err != nil {
+		return fmt.Errorf("%s job: %w", jobType, err)
+	}
+	return nil
+}
+
 func (s *JobService) CreateJob(req *models.CreateJobRequest) (*models.JobResponse, error) {
-	// Validate job type
-	validTypes := map[string]bool{
-		"provision": true,
-		"diagnose":  true,
-		"scale":     true,
-		"monitor":   true,
+	if err := s.validateJobSpec(req.Type, req.Parameters); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJobRequest, err)
+	}
+	if req.Priority != "" {
+		if _, ok := PriorityWeights[req.Priority]; !ok {
+			return nil, fmt.Errorf("%w: invalid priority (use high, normal or low)", ErrInvalidJobRequest)
+		}
 	}
+	if cid := req.Parameters["cluster_id"]; cid != "" {
+		if err := checkClusterInProject(s.clusterSvc, req.ProjectID, cid); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidJobRequest, err)
+		}
+	}
+	// without a user, fairness falls back to the target cluster
+	if req.TenantID == "" && req.Parameters["cluster_id"] != "" {
+		req.TenantID = "cluster:" + req.Parameters["cluster_id"]
+	}
+	req.TimeoutSeconds = int(s.timeoutFor(req.Type).Seconds())
 
-	if !validTypes[req.Type] {
-		return nil, errors.New("invalid job type")
+	// parents must exist in the job's project; their state decides whether the job starts
+	// blocked or skipped
+	for _, parentID := range req.DependsOn {
+		if _, err := s.GetJob(req.ProjectID, parentID); err != nil {
+			return nil, fmt.Errorf("dependency %s: %w", parentID, err)
+		}
 	}
 
 	resp, err := s.jobRepo.CreateJob(req)
@@ -66,313 +162,504 @@ func (s *JobService) CreateJob(req *models.CreateJobRequest) (*models.JobRespons
 		return nil, err
 	}
 
-	// Enqueue for worker pool processing if available, otherwise process in a goroutine
+	s.appendJobLog(resp.Job, models.JobLogInfo, 0, "job created")
+
+	if resp.Job.Status == "blocked" {
+		s.resolveBlocked(resp.Job)
+		if job, err := s.jobRepo.GetJob(resp.Job.ID); err == nil {
+			resp.Job = job
+		}
+		return resp, nil
+	}
+
+	if err := s.enqueue(resp.Job); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
+// submit puts a job on the worker pool queue with its priority and tenant.
+func (s *JobService) submit(job *models.Job) bool {
+	return s.workerPool.SubmitWithOptions(job.ID, SubmitOptions{Priority: job.Priority, Tenant: job.TenantID})
+}
+
+// enqueue hands a pending job to the worker pool if available, otherwise processes it in
+// a goroutine. A full queue marks the job queued_rejected and returns an error.
+func (s *JobService) enqueue(job *models.Job) error {
 	if s.workerPool != nil {
-		ok := s.workerPool.Submit(resp.Job.ID)
+		// mark queued before submitting so a fast worker never sees a stale status
+		_ = s.jobRepo.UpdateJobStatus(job.ID, "queued")
+		job.Status = "queued"
+		ok := s.submit(job)
 		if !ok {
-			// queue full — mark job as rejected and return an error
-			_ = s.jobRepo.UpdateJobStatus(resp.Job.ID, "queued_rejected")
+			// queue full, draining or its store down — mark job as rejected and return an error
+			_ = s.jobRepo.UpdateJobStatus(job.ID, "queued_rejected")
+			job.Status = "queued_rejected"
 			if JobsProcessed != nil {
-				JobsProcessed.WithLabelValues(req.Type, "rejected").Inc()
+				JobsProcessed.WithLabelValues(job.Type, "rejected").Inc()
+			}
+			s.resolveDependents(job.ID)
+			if s.workerPool.Draining() {
+				return errors.New("worker pool is draining — try again later")
 			}
-			return resp, errors.New("job queue full — try again later")
+			if s.workerPool.QueueLength() < s.workerPool.QueueCapacity() {
+				return errors.New("job queue unavailable — try again later")
+			}
+			return errors.New("job queue full — try again later")
 		}
-		// mark queued
-		_ = s.jobRepo.UpdateJobStatus(resp.Job.ID, "queued")
 	} else {
 		// Fallback to previous behavior
 		go func() {
-			err := s.ProcessJob(resp.Job.ID)
+			err := s.ProcessJob(job.ID)
 			if err != nil {
-				logger.Errorf("Failed to process job %s: %v", resp.Job.ID, err)
+				logger.Errorf("Failed to process job %s: %v", job.ID, err)
 			}
 		}()
 	}
-
-	return resp, nil
+	return nil
 }
 
-func (s *JobService) GetJob(id string) (*models.Job, error) {
-	return s.jobRepo.GetJob(id)
+// GetJob returns a job of projectID ("" for any project).
+func (s *JobService) GetJob(projectID, id string) (*models.Job, error) {
+	job, err := s.jobRepo.GetJob(id)
+	if err != nil {
+		return nil, err
+	}
+	if !models.InProject(projectID, job.ProjectID) {
+		return nil, errNotInProject("job")
+	}
+	return job, nil
 }
 
 func (s *JobService) ListJobs(req *models.GetJobsRequest) (*models.ListJobsResponse, error) {
-	return s.jobRepo.ListJobs(req)
+	resp, err := s.jobRepo.ListJobs(req)
+	if errors.Is(err, interfaces.ErrInvalidCursor) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJobRequest, err)
+	}
+	return resp, err
 }
 
-// ProcessJob handles actual job processing based on type
-func (s *JobService) ProcessJob(id string) error {
+// CancelJob stops a job: queued jobs are removed from the worker pool queue, running
+// jobs are signalled through their context. The job ends in the terminal "cancelled"
+// status and a job_cancelled event is published.
+func (s *JobService) CancelJob(id string) (*models.Job, error) {
 	job, err := s.jobRepo.GetJob(id)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	if job.Status != "pending" {
-		return errors.New("job is not in pending status")
+	if models.IsTerminalJobStatus(job.Status) {
+		return job, ErrJobNotCancellable
 	}
 
-	// Update to running
-	err = s.jobRepo.UpdateJobStatus(id, "running")
+	// persist the terminal status first, and only over the status we saw: a worker that
+	// picks the job up afterwards re-reads the status and skips it, and a job that
+	// finished in the meantime keeps its outcome
+	ok, err := s.jobRepo.TransitionJobStatus(id, job.Status, "cancelled")
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	// Process based on job type
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				s.jobRepo.UpdateJobStatus(id, "failed")
-			}
-		}()
-
-		startTS := time.Now()
-		var jobErr error
-		waitForOrchestration := false
-
-		switch job.Type {
-		case "provision":
-			_, jobErr = s.processProvisionJob(job)
-			// provisioning is handled asynchronously by the orchestration consumer
-			if jobErr == nil {
-				waitForOrchestration = true
-			}
-		case "diagnose":
-			_, jobErr = s.processDiagnoseJob(job)
-		case "scale":
-			_, jobErr = s.processScaleJob(job)
-			if jobErr == nil {
-				waitForOrchestration = true
-			}
-		case "monitor":
-			_, jobErr = s.processMonitorJob(job)
-		default:
-			jobErr = errors.New("unknown job type")
-		}
-
-		// Update job status: if this job was handed to orchestration, leave it to the consumer
-		var finalStatus string
-		if jobErr != nil {
-			s.jobRepo.UpdateJobStatus(id, "failed")
-			if JobsProcessed != nil {
-				JobsProcessed.WithLabelValues(job.Type, "failed").Inc()
-			}
-			finalStatus = "failed"
-		} else {
-			if waitForOrchestration {
-				s.jobRepo.UpdateJobStatus(id, "queued")
-				if JobsProcessed != nil {
-					JobsProcessed.WithLabelValues(job.Type, "queued").Inc()
-				}
-				finalStatus = "queued"
-			} else {
-				s.jobRepo.UpdateJobStatus(id, "completed")
-				if JobsProcessed != nil {
-					JobsProcessed.WithLabelValues(job.Type, "completed").Inc()
-				}
-				finalStatus = "completed"
-			}
+	if !ok {
+		if current, err := s.jobRepo.GetJob(id); err == nil {
+			job = current
 		}
+		return job, ErrJobNotCancellable
+	}
+	removed := false
+	if s.workerPool != nil {
+		removed = s.workerPool.Remove(id)
+	}
+	signalled := s.cancelRunning(id, ErrJobCancelled)
+	logger.Infof("job %s cancelled (removed_from_queue=%t running=%t)", id, removed, signalled)
 
-		// record duration histogram
-		if JobProcessingSeconds != nil {
-			dur := time.Since(startTS).Seconds()
-			jt := job.Type
-			if jt == "" {
-				jt = "unknown"
-			}
-			statusLabel := finalStatus
-			if statusLabel == "" {
-				statusLabel = "unknown"
-			}
-			JobProcessingSeconds.WithLabelValues(jt, statusLabel).Observe(dur)
-		}
-	}()
+	if JobsProcessed != nil {
+		JobsProcessed.WithLabelValues(job.Type, "cancelled").Inc()
+	}
+	s.publishJobEvent(job, "job_cancelled", job.Progress, "job cancelled")
+	s.resolveDependents(id)
 
-	return nil
+	return s.jobRepo.GetJob(id)
 }
 
-func (s *JobService) processProvisionJob(job *models.Job) (string, error) {
-	// Publish a job_requested event — provisioning will be handled by the orchestrator
-	var params map[string]string
-	if job.Parameters != "" {
-		if err := json.Unmarshal([]byte(job.Parameters), &params); err != nil {
-			return "", errors.New("invalid job parameters")
-		}
+// cancelRunning cancels the context of a job that is currently being processed.
+func (s *JobService) cancelRunning(id string, cause error) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.running[id]
+	if ok {
+		r.cancel(cause)
 	}
+	return ok
+}
 
-	clusterID, ok := params["cluster_id"]
-	if !ok {
-		return "", errors.New("cluster_id not specified in job parameters")
+// runningJob is the cancellable context of one run of a job and the concurrency slot it holds.
+type runningJob struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	lease  *ConcurrencyLease
+}
+
+// trackRunning registers a cancellable context for a job being processed.
+func (s *JobService) trackRunning(id string) context.Context {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	s.mu.Lock()
+	s.running[id] = runningJob{ctx: ctx, cancel: cancel}
+	s.mu.Unlock()
+	return ctx
+}
+
+// untrackRunning releases the context of the run that owns ctx. A newer run of the
+// same job (e.g. a retry started while the old run unwinds) keeps its entry.
+func (s *JobService) untrackRunning(id string, ctx context.Context) {
+	s.mu.Lock()
+	if r, ok := s.running[id]; ok && r.ctx == ctx {
+		r.cancel(nil)
+		r.lease.Release()
+		delete(s.running, id)
 	}
+	s.mu.Unlock()
+}
 
+// publishJobEvent emits a typed job lifecycle event on cluster-events.
+// Every event is also recorded in the job's log.
+func (s *JobService) publishJobEvent(job *models.Job, eventType string, progress int, message string) {
+	s.appendJobLog(job, jobEventLogLevel(eventType), progress, message)
 	if s.producer == nil {
-		return "", errors.New("event producer not available")
+		return
 	}
-
-	// prefer job.TraceID if available
 	trace := job.TraceID
 	if trace == "" {
 		trace = uuid.NewString()
 	}
-
-	e := events.NewEvent("job_requested")
+	e := events.NewEvent(eventType)
 	e.JobID = job.ID
 	e.JobType = job.Type
-	e.ClusterID = clusterID
-	e.Payload = make(map[string]interface{})
-	for k, v := range params {
-		e.Payload[k] = v
-	}
+	e.ClusterID = job.ClusterID
+	e.ProjectID = job.ProjectID
+	e.Progress = progress
+	e.Message = message
 	e.TraceID = trace
+	_ = s.producer.PublishEvent("cluster-events", job.ID, e)
+}
 
-	if err := s.producer.PublishEvent("cluster-events", job.ID, e); err != nil {
-		return "", err
+// ProcessJob marks a job running and processes it in the background.
+func (s *JobService) ProcessJob(id string) error {
+	ctx, job, err := s.startJob(id)
+	if errors.Is(err, errJobDeferred) {
+		return nil
 	}
+	if err != nil {
+		return err
+	}
+	go s.runJob(ctx, job)
+	return nil
+}
 
-	// indicate the job has been handed off to the orchestration pipeline
-	return "Job queued for provisioning via event orchestration", nil
+// ExecuteJob is ProcessJob for worker pool workers: it returns only once the job
+// finished (or was handed to orchestration), so the worker's lease covers the whole run
+// and pool concurrency bounds the number of running jobs.
+func (s *JobService) ExecuteJob(id string) error {
+	ctx, job, err := s.startJob(id)
+	if errors.Is(err, errJobDeferred) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.runJob(ctx, job)
+	return nil
 }
 
-func (s *JobService) processDiagnoseJob(job *models.Job) (string, error) {
-	// Publish job_started
-	if s.producer != nil {
-		// publish typed event
-		trace := job.TraceID
-		if trace == "" {
-			trace = uuid.NewString()
-		}
-		e := events.NewEvent("job_started")
-		e.JobID = job.ID
-		e.JobType = job.Type
-		e.ClusterID = job.ClusterID
-		e.Progress = 0
-		e.Message = "diagnosis started"
-		e.TraceID = trace
-		_ = s.producer.PublishEvent("cluster-events", job.ID, e)
-	}
-
-	// Simulate diagnosis with progress updates
-	steps := 3
-	for i := 1; i <= steps; i++ {
-		time.Sleep(700 * time.Millisecond)
-		progress := (i * 100) / steps
-		if s.jobRepo != nil {
-			_ = s.jobRepo.UpdateJobProgress(job.ID, progress, "diagnosis step completed")
-		}
-		if s.producer != nil {
-			trace := job.TraceID
-			if trace == "" {
-				trace = uuid.NewString()
-			}
-			e := events.NewEvent("job_progress")
-			e.JobID = job.ID
-			e.JobType = job.Type
-			e.ClusterID = job.ClusterID
-			e.Progress = progress
-			e.Message = "diagnosis in-progress"
-			e.TraceID = trace
-			_ = s.producer.PublishEvent("cluster-events", job.ID, e)
-		}
+// startJob moves a pending/queued job to running and records the attempt.
+func (s *JobService) startJob(id string) (context.Context, *models.Job, error) {
+	// register the cancel func before reading the status so a concurrent CancelJob
+	// either sees us running or we see its "cancelled" status
+	ctx := s.trackRunning(id)
+
+	job, err := s.jobRepo.GetJob(id)
+	if err != nil {
+		s.untrackRunning(id, ctx)
+		return nil, nil, err
 	}
 
-	// Finalize
-	if s.jobRepo != nil {
-		_ = s.jobRepo.UpdateJobProgress(job.ID, 100, "diagnosis completed")
+	if job.Status != "pending" && job.Status != "queued" {
+		s.untrackRunning(id, ctx)
+		return nil, nil, errors.New("job is not in pending status")
 	}
-	if s.producer != nil {
-		trace := job.TraceID
-		if trace == "" {
-			trace = uuid.NewString()
-		}
-		e := events.NewEvent("job_completed")
-		e.JobID = job.ID
-		e.JobType = job.Type
-		e.ClusterID = job.ClusterID
-		e.Progress = 100
-		e.Message = "diagnosis completed"
-		e.TraceID = trace
-		_ = s.producer.PublishEvent("cluster-events", job.ID, e)
+	if err := s.takeConcurrencySlot(ctx, job); err != nil {
+		s.untrackRunning(id, ctx)
+		return nil, nil, err
 	}
 
-	return "Diagnosis completed", nil
+	// Update to running unless a cancel or timeout landed since the read
+	ok, err := s.jobRepo.TransitionJobStatus(id, job.Status, "running")
+	if err == nil && !ok {
+		err = errors.New("job is not in pending status")
+	}
+	if err != nil {
+		s.untrackRunning(id, ctx)
+		return nil, nil, err
+	}
+	job.Attempts++
+	job.MaxAttempts = s.retryPolicyFor(job.Type).MaxAttempts
+	now := time.Now()
+	job.StartedAt = &now
+	fields := map[string]interface{}{"attempts": job.Attempts, "max_attempts": job.MaxAttempts, "next_run_at": nil, "started_at": now, "deadline_at": nil}
+	if job.TimeoutSeconds > 0 {
+		deadline := now.Add(time.Duration(job.TimeoutSeconds) * time.Second)
+		job.DeadlineAt = &deadline
+		fields["deadline_at"] = deadline
+	}
+	_ = s.jobRepo.UpdateJobFields(id, fields)
+	s.appendJobLog(job, models.JobLogInfo, job.Progress, fmt.Sprintf("attempt %d/%d started", job.Attempts, job.MaxAttempts))
+	return ctx, job, nil
 }
 
-func (s *JobService) processScaleJob(job *models.Job) (string, error) {
-	// For scale jobs, publish a job_requested event so orchestrator performs the scale
-	var params map[string]string
-	if job.Parameters != "" {
+// runJob processes a started job based on its type and records the outcome.
+func (s *JobService) runJob(ctx context.Context, job *models.Job) {
+	id := job.ID
+	defer s.untrackRunning(id, ctx)
+	defer func() {
+		if r := recover(); r != nil {
+			s.jobRepo.UpdateJobStatus(id, "failed")
+		}
+	}()
+
+	startTS := time.Now()
+	var jobErr error
+	var result string
+	waitForOrchestration := false
+
+	handler, ok := s.jobTypes.Get(job.Type)
+	params := map[string]string{}
+	if !ok {
+		jobErr = Permanent(errors.New("unknown job type"))
+	} else if job.Parameters != "" {
 		if err := json.Unmarshal([]byte(job.Parameters), &params); err != nil {
-			return "", errors.New("invalid job parameters")
+			jobErr = Permanent(errors.New("invalid job parameters"))
 		}
 	}
+	if jobErr == nil {
+		result, jobErr = handler.Execute(ctx, &JobRun{Job: job, Params: params, svc: s})
+		// orchestrated types are finished asynchronously by the orchestration consumer
+		_, orchestrated := handler.(OrchestratedJobHandler)
+		waitForOrchestration = jobErr == nil && orchestrated
+	}
 
-	clusterID, ok := params["cluster_id"]
-	if !ok {
-		return "", errors.New("cluster_id not specified in job parameters")
+	// Update job status: if this job was handed to orchestration, leave it to the consumer
+	var finalStatus string
+	if errors.Is(jobErr, errJobStoppedRunning) {
+		logger.Warnf("job %s: %v", id, jobErr)
+		return
+	}
+	if cause := context.Cause(ctx); jobErr != nil && cause != nil && !errors.Is(cause, ErrJobCancelled) {
+		// stopped from outside (e.g. lease expiry); whoever stopped it recorded the outcome
+		logger.Warnf("job %s stopped: %v", id, cause)
+		return
+	}
+	if jobErr != nil && context.Cause(ctx) != nil {
+		// cancelled while running; CancelJob recorded the status and resolved the
+		// dependents, so only a job still marked running is ended here
+		if ok, err := s.jobRepo.TransitionJobStatus(id, "running", "cancelled"); err != nil || !ok {
+			return
+		}
+		finalStatus = "cancelled"
+	} else if jobErr != nil {
+		finalStatus = s.handleJobFailure(job, jobErr)
+		if JobsProcessed != nil {
+			JobsProcessed.WithLabelValues(job.Type, finalStatus).Inc()
+		}
+	} else {
+		// only a job still running finishes; a cancel or timeout that landed after the
+		// handler's last check keeps its outcome
+		if waitForOrchestration {
+			// RequestOrchestration already moved the job to queued
+			s.appendJobLog(job, models.JobLogInfo, job.Progress, "handed to orchestration: "+result)
+			if JobsProcessed != nil {
+				JobsProcessed.WithLabelValues(job.Type, "queued").Inc()
+			}
+			finalStatus = "queued"
+		} else {
+			if !s.finishRun(id, "completed") {
+				return
+			}
+			if result != "" {
+				_ = s.jobRepo.UpdateJobFields(id, map[string]interface{}{"result": result})
+			}
+			s.appendJobLog(job, models.JobLogInfo, 100, "completed: "+result)
+			if JobsProcessed != nil {
+				JobsProcessed.WithLabelValues(job.Type, "completed").Inc()
+			}
+			finalStatus = "completed"
+		}
 	}
 
-	if s.producer == nil {
-		return "", errors.New("event producer not available")
+	// record duration histogram
+	if JobProcessingSeconds != nil {
+		dur := time.Since(startTS).Seconds()
+		jt := job.Type
+		if jt == "" {
+			jt = "unknown"
+		}
+		statusLabel := finalStatus
+		if statusLabel == "" {
+			statusLabel = "unknown"
+		}
+		JobProcessingSeconds.WithLabelValues(jt, statusLabel).Observe(dur)
 	}
-	trace := job.TraceID
-	if trace == "" {
-		trace = uuid.NewString()
+
+	if models.IsTerminalJobStatus(finalStatus) {
+		s.resolveDependents(id)
 	}
+}
 
-	e := events.NewEvent("job_requested")
-	e.JobID = job.ID
-	e.JobType = job.Type
-	e.ClusterID = clusterID
-	e.Payload = make(map[string]interface{})
-	for k, v := range params {
-		e.Payload[k] = v
+// finishRun moves a running job to status and reports whether it was still running.
+func (s *JobService) finishRun(id, status string) bool {
+	ok, err := s.jobRepo.TransitionJobStatus(id, "running", status)
+	if err != nil {
+		logger.Errorf("job %s: recording %s failed: %v", id, status, err)
+		return false
 	}
-	e.TraceID = trace
+	if !ok {
+		logger.Warnf("job %s finished after it stopped running; keeping its status", id)
+	}
+	return ok
+}
 
-	if err := s.producer.PublishEvent("cluster-events", job.ID, e); err != nil {
-		return "", err
+// handleJobFailure records a failed run and decides what happens next: schedule a retry
+// with backoff, dead-letter the job once retries are exhausted, or fail it outright for
+// non-retryable errors. It returns the resulting status.
+func (s *JobService) handleJobFailure(job *models.Job, jobErr error) string {
+	policy := s.retryPolicyFor(job.Type)
+	if policy.ShouldRetry(job.Attempts, jobErr) {
+		delay := policy.Delay(job.Attempts)
+		next := time.Now().Add(delay)
+		_ = s.jobRepo.UpdateJobFields(job.ID, map[string]interface{}{"status": "retrying", "error": jobErr.Error(), "next_run_at": next})
+		logger.Warnf("job %s attempt %d/%d failed: %v; retrying in %s", job.ID, job.Attempts, policy.MaxAttempts, jobErr, delay)
+		s.publishJobEvent(job, "job_retry_scheduled", job.Progress, "retrying after error: "+jobErr.Error())
+		s.scheduleRetry(job.ID, delay)
+		return "retrying"
 	}
 
-	return "Scale requested via orchestration", nil
+	status := "failed"
+	if policy.MaxAttempts > 1 && policy.IsRetryable(jobErr) {
+		// retryable error but every attempt has been used
+		status = "dead_lettered"
+	}
+	_ = s.jobRepo.UpdateJobFields(job.ID, map[string]interface{}{"error": jobErr.Error()})
+	_ = s.jobRepo.UpdateJobStatus(job.ID, status)
+	if status == "dead_lettered" {
+		logger.Errorf("job %s dead-lettered after %d attempts: %v", job.ID, job.Attempts, jobErr)
+		s.publishJobEvent(job, "job_dead_lettered", job.Progress, jobErr.Error())
+	} else {
+		s.appendJobLog(job, models.JobLogError, job.Progress, "failed: "+jobErr.Error())
+	}
+	return status
 }
 
-func (s *JobService) processMonitorJob(job *models.Job) (string, error) {
-	// Publish job_started
-	if s.producer != nil {
-		trace := job.TraceID
-		if trace == "" {
-			trace = uuid.NewString()
+// scheduleRetry re-enqueues a retrying job once its backoff elapses. Timers live in
+// memory only; jobs left in "retrying" across a restart keep next_run_at for recovery.
+func (s *JobService) scheduleRetry(id string, delay time.Duration) {
+	time.AfterFunc(delay, func() {
+		job, err := s.jobRepo.GetJob(id)
+		if err != nil || job.Status != "retrying" {
+			// cancelled or otherwise resolved while waiting
+			return
+		}
+		if s.workerPool == nil {
+			_ = s.jobRepo.UpdateJobStatus(id, "pending")
+			if err := s.ProcessJob(id); err != nil {
+				logger.Errorf("Failed to process retry of job %s: %v", id, err)
+			}
+			return
 		}
-		e := events.NewEvent("job_started")
-		e.JobID = job.ID
-		e.JobType = job.Type
-		e.ClusterID = job.ClusterID
-		e.Progress = 0
-		e.Message = "monitoring started"
-		e.TraceID = trace
-		_ = s.producer.PublishEvent("cluster-events", job.ID, e)
-	}
-
-	// Simulate work
-	time.Sleep(1 * time.Second)
-	if s.jobRepo != nil {
-		_ = s.jobRepo.UpdateJobProgress(job.ID, 100, "monitoring completed")
-	}
-
-	if s.producer != nil {
-		trace := job.TraceID
-		if trace == "" {
-			trace = uuid.NewString()
+		_ = s.jobRepo.UpdateJobFields(id, map[string]interface{}{"status": "queued", "started_at": nil})
+		if !s.submit(job) {
+			// queue full or unavailable: stay in retrying and try again after the same delay
+			_ = s.jobRepo.UpdateJobFields(id, map[string]interface{}{"status": "retrying", "next_run_at": time.Now().Add(delay)})
+			s.scheduleRetry(id, delay)
 		}
-		e := events.NewEvent("job_completed")
-		e.JobID = job.ID
-		e.JobType = job.Type
-		e.ClusterID = job.ClusterID
-		e.Progress = 100
-		e.Message = "monitoring completed"
-		e.TraceID = trace
-		_ = s.producer.PublishEvent("cluster-events", job.ID, e)
+	})
+}
+
+// ListDeadLetteredJobs returns jobs of projectID ("" for every project) that exhausted
+// their retries, newest first.
+func (s *JobService) ListDeadLetteredJobs(projectID string, limit int) ([]*models.Job, error) {
+	if projectID == "" {
+		return s.jobRepo.ListJobsByStatus("dead_lettered", limit)
 	}
+	if limit <= 0 {
+		limit = 50
+	}
+	resp, err := s.jobRepo.ListJobs(&models.GetJobsRequest{PageSize: limit, SortBy: "created_at", SortDir: "desc",
+		JobFilter: models.JobFilter{Statuses: []string{"dead_lettered"}, ProjectID: projectID}})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Jobs, nil
+}
 
-	return "Monitoring completed", nil
+// RequeueJob resets a dead-lettered job's attempts and submits it again.
+func (s *JobService) RequeueJob(id string) (*models.Job, error) {
+	job, err := s.jobRepo.GetJob(id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != "dead_lettered" {
+		return job, ErrJobNotDeadLettered
+	}
+	// the transition makes concurrent requeues of the same job start it only once
+	ok, err := s.jobRepo.TransitionJobStatus(id, "dead_lettered", "pending")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return job, ErrJobNotDeadLettered
+	}
+	if err := s.jobRepo.UpdateJobFields(id, map[string]interface{}{
+		"attempts":     0,
+		"error":        "",
+		"next_run_at":  nil,
+		"started_at":   nil,
+		"completed_at": nil,
+		"progress":     0,
+	}); err != nil {
+		return nil, err
+	}
+	logger.Infof("job %s requeued from dead-letter", id)
+
+	if s.workerPool != nil {
+		_ = s.jobRepo.UpdateJobStatus(id, "queued")
+		if !s.submit(job) {
+			// put the job back in the dead-letter queue as it was
+			_ = s.jobRepo.UpdateJobFields(id, map[string]interface{}{
+				"status":       "dead_lettered",
+				"attempts":     job.Attempts,
+				"error":        job.Error,
+				"next_run_at":  job.NextRunAt,
+				"started_at":   job.StartedAt,
+				"completed_at": job.CompletedAt,
+				"progress":     job.Progress,
+			})
+			return nil, errors.New("job queue full — try again later")
+		}
+	} else {
+		go func() {
+			if err := s.ProcessJob(id); err != nil {
+				logger.Errorf("Failed to process job %s: %v", id, err)
+			}
+		}()
+	}
+	return s.jobRepo.GetJob(id)
+}
+
+// sleepCtx waits for d or until ctx is cancelled, returning the cancellation cause.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	case <-t.C:
+		return nil
+	}
 }
@@ -0,0 +1,118 @@
+// backend/core-api/services/jobTimeouts.go
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/logger"
+)
+
+// ErrJobTimedOut is the cancellation cause for jobs stopped by the timeout reaper.
+var ErrJobTimedOut = errors.New("job exceeded its execution timeout")
+
+// DefaultJobTimeouts are the per-attempt execution limits per job type. For provision
+// and scale jobs the limit includes the time spent waiting for the orchestration event.
+var DefaultJobTimeouts = map[string]time.Duration{
+	"provision": 10 * time.Minute,
+	"scale":     10 * time.Minute,
+	"diagnose":  2 * time.Minute,
+	"monitor":   2 * time.Minute,
+}
+
+// timeoutReapBatch bounds how many overdue jobs one reaper pass handles.
+const timeoutReapBatch = 100
+
+// SetJobTimeout overrides the execution timeout for a job type; 0 disables it. The
+// timeout is copied onto each job at creation, so existing jobs keep theirs.
+func (s *JobService) SetJobTimeout(jobType string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timeouts[jobType] = d
+}
+
+func (s *JobService) timeoutFor(jobType string) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d, ok := s.timeouts[jobType]; ok {
+		return d
+	}
+	return DefaultJobTimeouts[jobType]
+}
+
+// ReapTimedOutJobs marks started jobs whose deadline passed as "timed_out": jobs still
+// running here are stopped, and jobs waiting on an orchestration event that never came
+// are given up on. It returns the number of jobs timed out.
+func (s *JobService) ReapTimedOutJobs(now time.Time) int {
+	jobs, err := s.jobRepo.ListOverdueJobs(now, timeoutReapBatch)
+	if err != nil {
+		logger.Errorf("Failed to list overdue jobs: %v", err)
+		return 0
+	}
+	reaped := 0
+	for _, job := range jobs {
+		// the job may have finished since it was listed
+		ok, err := s.jobRepo.TransitionJobStatus(job.ID, job.Status, "timed_out")
+		if err != nil || !ok {
+			continue
+		}
+		phase := "running"
+		if job.Status == "queued" {
+			phase = "orchestration"
+		}
+		msg := fmt.Sprintf("timed out after %ds (%s)", job.TimeoutSeconds, phase)
+		_ = s.jobRepo.UpdateJobFields(job.ID, map[string]interface{}{"error": msg})
+		s.cancelRunning(job.ID, ErrJobTimedOut)
+		logger.Warnf("job %s %s", job.ID, msg)
+
+		if JobsTimedOut != nil {
+			JobsTimedOut.WithLabelValues(job.Type, phase).Inc()
+		}
+		if JobsProcessed != nil {
+			JobsProcessed.WithLabelValues(job.Type, "timed_out").Inc()
+		}
+		s.publishJobEvent(job, "job_timed_out", job.Progress, msg)
+		s.resolveDependents(job.ID)
+		reaped++
+	}
+	return reaped
+}
+
+// JobTimeoutReaper periodically times out overdue jobs.
+type JobTimeoutReaper struct {
+	jobSvc   *JobService
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+func NewJobTimeoutReaper(jobSvc *JobService) *JobTimeoutReaper {
+	return &JobTimeoutReaper{jobSvc: jobSvc, stopCh: make(chan struct{})}
+}
+
+// Start runs the reaper loop, checking for overdue jobs every interval.
+func (r *JobTimeoutReaper) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stopCh:
+				return
+			case now := <-ticker.C:
+				r.jobSvc.ReapTimedOutJobs(now)
+			}
+		}
+	}()
+	logger.Infof("Job timeout reaper started (interval %s)", interval)
+}
+
+// Stop ends the reaper loop.
+func (r *JobTimeoutReaper) Stop() {
+	r.stopOnce.Do(func() { close(r.stopCh) })
+}
@@ -0,0 +1,199 @@
+// backend/core-api/services/jobTypes.go
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/events"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/logger"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+)
+
+// JobHandler implements one job type. Register it on the JobService to make the type
+// available to POST /jobs, workflows and schedules.
+type JobHandler interface {
+	// Spec describes the type: its name, description and parameter schema.
+	Spec() models.JobTypeSpec
+	// Validate checks the parameters when a job is created.
+	Validate(params map[string]string) error
+	// Execute runs one attempt and returns a result message. It should stop when ctx is
+	// cancelled and report progress through run. Errors are retried according to the
+	// type's retry policy unless wrapped with Permanent.
+	Execute(ctx context.Context, run *JobRun) (string, error)
+}
+
+// OrchestratedJobHandler is a JobHandler whose Execute only hands the job to the
+// orchestration pipeline (see JobRun.RequestOrchestration). The job stays "queued"
+// until the Kafka consumer runs Orchestrate for its job_requested event.
+type OrchestratedJobHandler interface {
+	JobHandler
+	Orchestrate(run *JobRun) error
+}
+
+// JobRun is the running job as seen by a JobHandler.
+type JobRun struct {
+	Job    *models.Job
+	Params map[string]string
+	svc    *JobService
+}
+
+// Progress records progress (0-100) with a message and publishes a job_progress event.
+func (r *JobRun) Progress(progress int, message string) {
+	if r.svc.jobRepo != nil {
+		_ = r.svc.jobRepo.UpdateJobProgress(r.Job.ID, progress, message)
+	}
+	r.svc.publishJobEvent(r.Job, "job_progress", progress, message)
+}
+
+// Event publishes a job lifecycle event such as job_started or job_completed.
+func (r *JobRun) Event(eventType string, progress int, message string) {
+	r.svc.publishJobEvent(r.Job, eventType, progress, message)
+}
+
+// errJobStoppedRunning is returned by RequestOrchestration for a job that was cancelled or
+// timed out before it could be handed off; its outcome is already recorded.
+var errJobStoppedRunning = errors.New("job stopped running before it was handed to orchestration")
+
+// RequestOrchestration publishes the job_requested event that the orchestration
+// consumer picks up; the job parameters travel as the event payload.
+func (r *JobRun) RequestOrchestration(ctx context.Context) error {
+	if r.svc.producer == nil {
+		return errors.New("event producer not available")
+	}
+	if err := context.Cause(ctx); err != nil {
+		return err
+	}
+	// record the handoff before publishing so the consumer finds the job queued; a job
+	// cancelled or timed out meanwhile is not handed off
+	ok, err := r.svc.jobRepo.TransitionJobStatus(r.Job.ID, "running", "queued")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errJobStoppedRunning
+	}
+	e := events.NewEvent("job_requested")
+	e.JobID = r.Job.ID
+	e.JobType = r.Job.Type
+	e.ClusterID = r.Params["cluster_id"]
+	e.ProjectID = r.Job.ProjectID
+	e.Payload = make(map[string]interface{}, len(r.Params))
+	for k, v := range r.Params {
+		e.Payload[k] = v
+	}
+	if r.Job.TraceID != "" {
+		e.TraceID = r.Job.TraceID
+	}
+	return r.svc.producer.PublishEvent("cluster-events", r.Job.ID, e)
+}
+
+// ValidateJobParameters checks params against the spec: required parameters must be
+// present and int/bool parameters must parse. Unknown parameters are allowed.
+func ValidateJobParameters(spec models.JobTypeSpec, params map[string]string) error {
+	for _, p := range spec.Parameters {
+		v, ok := params[p.Name]
+		if !ok || v == "" {
+			if p.Required {
+				return fmt.Errorf("parameter %q is required", p.Name)
+			}
+			continue
+		}
+		switch p.Type {
+		case "int":
+			if _, err := strconv.Atoi(v); err != nil {
+				return fmt.Errorf("parameter %q must be an integer", p.Name)
+			}
+		case "bool":
+			if _, err := strconv.ParseBool(v); err != nil {
+				return fmt.Errorf("parameter %q must be a boolean", p.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// JobTypeRegistry maps job type names to their handlers.
+type JobTypeRegistry struct {
+	mu       sync.RWMutex
+	handlers map[string]JobHandler
+}
+
+func NewJobTypeRegistry() *JobTypeRegistry {
+	return &JobTypeRegistry{handlers: make(map[string]JobHandler)}
+}
+
+// Register adds a job type. Names must be unique.
+func (r *JobTypeRegistry) Register(h JobHandler) error {
+	name := h.Spec().Name
+	if name == "" {
+		return errors.New("job type name is required")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.handlers[name]; exists {
+		return fmt.Errorf("job type %q is already registered", name)
+	}
+	r.handlers[name] = h
+	return nil
+}
+
+// Get returns the handler for a job type.
+func (r *JobTypeRegistry) Get(name string) (JobHandler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	h, ok := r.handlers[name]
+	return h, ok
+}
+
+// Specs returns the specs of all registered types, sorted by name.
+func (r *JobTypeRegistry) Specs() []models.JobTypeSpec {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]models.JobTypeSpec, 0, len(r.handlers))
+	for _, h := range r.handlers {
+		spec := h.Spec()
+		_, spec.Orchestrated = h.(OrchestratedJobHandler)
+		if spec.Parameters == nil {
+			spec.Parameters = []models.JobParamSpec{}
+		}
+		out = append(out, spec)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// orchestrate runs the Orchestrate step of an orchestrated job type for a job_requested
+// event consumed from Kafka.
+func (s *JobService) orchestrate(jobID, jobType, clusterID string, e *events.Event) error {
+	h, ok := s.jobTypes.Get(jobType)
+	oh, orchestrated := h.(OrchestratedJobHandler)
+	if !ok || !orchestrated {
+		logger.Warnf("Unhandled job type in orchestration: %s", jobType)
+		return nil
+	}
+	job := orchestrationJob(jobID, jobType, clusterID, e)
+	if stored, err := s.jobRepo.GetJob(jobID); err == nil {
+		job = stored
+	}
+	params := make(map[string]string, len(e.Payload))
+	for k, v := range e.Payload {
+		if str, ok := v.(string); ok {
+			params[k] = str
+		}
+	}
+	if clusterID != "" {
+		params["cluster_id"] = clusterID
+	}
+	return oh.Orchestrate(&JobRun{Job: job, Params: params, svc: s})
+}
+
+// orchestrationJob is the job as described by its job_requested event.
+func orchestrationJob(jobID, jobType, clusterID string, e *events.Event) *models.Job {
+	return &models.Job{ID: jobID, Type: jobType, ClusterID: clusterID, TraceID: e.TraceID}
+}
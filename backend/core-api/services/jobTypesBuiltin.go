@@ -0,0 +1,168 @@
+// backend/core-api/services/jobTypesBuiltin.go
+
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+)
+
+var clusterIDParam = models.JobParamSpec{Name: "cluster_id", Type: "string", Required: true, Description: "Target cluster"}
+
+// registerBuiltinJobTypes adds the job types shipped with ClusterGenie.
+func registerBuiltinJobTypes(r *JobTypeRegistry) {
+	for _, h := range []JobHandler{provisionJob{}, scaleJob{}, diagnoseJob{}, monitorJob{}} {
+		_ = r.Register(h)
+	}
+}
+
+// requireCluster guards against jobs stored without a cluster_id (e.g. created before
+// parameters were validated); they can never succeed.
+func requireCluster(run *JobRun) error {
+	if run.Params["cluster_id"] == "" {
+		return Permanent(errors.New("cluster_id not specified in job parameters"))
+	}
+	return nil
+}
+
+// provisionJob creates a droplet in the cluster through the orchestration pipeline.
+type provisionJob struct{}
+
+func (provisionJob) Spec() models.JobTypeSpec {
+	return models.JobTypeSpec{
+		Name:        "provision",
+		Description: "Provision a droplet in a cluster (via event orchestration)",
+		Parameters:  []models.JobParamSpec{clusterIDParam},
+	}
+}
+
+func (h provisionJob) Validate(params map[string]string) error {
+	return ValidateJobParameters(h.Spec(), params)
+}
+
+func (provisionJob) Execute(ctx context.Context, run *JobRun) (string, error) {
+	if err := requireCluster(run); err != nil {
+		return "", err
+	}
+	if err := run.RequestOrchestration(ctx); err != nil {
+		return "", err
+	}
+	// indicate the job has been handed off to the orchestration pipeline
+	return "Job queued for provisioning via event orchestration", nil
+}
+
+func (provisionJob) Orchestrate(run *JobRun) error {
+	clusterID := run.Params["cluster_id"]
+	ps := run.svc.provisioningSvc
+	if clusterID == "" || ps == nil {
+		return nil
+	}
+	run.Progress(30, "provision: initializing")
+	jobID := run.Job.ID
+	req := &models.CreateDropletRequest{
+		Name:      "droplet-from-job-" + jobID[len(jobID)-8:],
+		Region:    "nyc3",
+		Size:      "s-1vcpu-1gb",
+		Image:     "ubuntu-20-04-x64",
+		ClusterID: &clusterID,
+	}
+	if _, err := ps.CreateDroplet(req); err != nil {
+		return err
+	}
+	run.Progress(75, "provision: completing")
+	return nil
+}
+
+// scaleJob scales a cluster up through the orchestration pipeline.
+type scaleJob struct{}
+
+func (scaleJob) Spec() models.JobTypeSpec {
+	return models.JobTypeSpec{
+		Name:        "scale",
+		Description: "Scale a cluster up by one droplet (via event orchestration)",
+		Parameters:  []models.JobParamSpec{clusterIDParam},
+	}
+}
+
+func (h scaleJob) Validate(params map[string]string) error {
+	return ValidateJobParameters(h.Spec(), params)
+}
+
+func (scaleJob) Execute(ctx context.Context, run *JobRun) (string, error) {
+	if err := requireCluster(run); err != nil {
+		return "", err
+	}
+	if err := run.RequestOrchestration(ctx); err != nil {
+		return "", err
+	}
+	return "Scale requested via orchestration", nil
+}
+
+func (scaleJob) Orchestrate(run *JobRun) error {
+	clusterID := run.Params["cluster_id"]
+	ps := run.svc.provisioningSvc
+	if clusterID == "" || ps == nil {
+		return nil
+	}
+	run.Progress(30, "scale: initializing")
+	if err := ps.ScaleCluster(clusterID, "scale_up"); err != nil {
+		return err
+	}
+	run.Progress(75, "scale: completing")
+	return nil
+}
+
+// diagnoseJob simulates a cluster diagnosis in three steps.
+type diagnoseJob struct{}
+
+func (diagnoseJob) Spec() models.JobTypeSpec {
+	return models.JobTypeSpec{
+		Name:        "diagnose",
+		Description: "Run a cluster diagnosis",
+		Parameters:  []models.JobParamSpec{{Name: "cluster_id", Type: "string", Description: "Cluster to diagnose"}},
+	}
+}
+
+func (h diagnoseJob) Validate(params map[string]string) error {
+	return ValidateJobParameters(h.Spec(), params)
+}
+
+func (diagnoseJob) Execute(ctx context.Context, run *JobRun) (string, error) {
+	run.Event("job_started", 0, "diagnosis started")
+	steps := 3
+	for i := 1; i <= steps; i++ {
+		if err := sleepCtx(ctx, 700*time.Millisecond); err != nil {
+			return "", err
+		}
+		run.Progress((i*100)/steps, "diagnosis in-progress")
+	}
+	run.Event("job_completed", 100, "diagnosis completed")
+	return "Diagnosis completed", nil
+}
+
+// monitorJob simulates a monitoring pass.
+type monitorJob struct{}
+
+func (monitorJob) Spec() models.JobTypeSpec {
+	return models.JobTypeSpec{
+		Name:        "monitor",
+		Description: "Collect a monitoring snapshot",
+		Parameters:  []models.JobParamSpec{{Name: "cluster_id", Type: "string", Description: "Cluster to monitor"}},
+	}
+}
+
+func (h monitorJob) Validate(params map[string]string) error {
+	return ValidateJobParameters(h.Spec(), params)
+}
+
+func (monitorJob) Execute(ctx context.Context, run *JobRun) (string, error) {
+	run.Event("job_started", 0, "monitoring started")
+	if err := sleepCtx(ctx, 1*time.Second); err != nil {
+		return "", err
+	}
+	run.Event("job_completed", 100, "monitoring completed")
+	return "Monitoring completed", nil
+}
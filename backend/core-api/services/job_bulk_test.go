@@ -0,0 +1,81 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+)
+
+func TestBulkJobs_CreateCancelAndRequeue(t *testing.T) {
+	svc, _, _ := setupInMemoryJobService(t)
+	// a pool that is never started keeps jobs queued so they can be cancelled
+	svc.SetWorkerPool(NewWorkerPool(1, 100, func(string) {}))
+
+	reqs := []models.CreateJobRequest{
+		{Type: "diagnose", Parameters: map[string]string{"cluster_id": "cluster-a"}},
+		{Type: "provision"}, // missing cluster_id
+		{Type: "diagnose", Parameters: map[string]string{"cluster_id": "cluster-b"}},
+		{Type: "diagnose", Parameters: map[string]string{"cluster_id": "cluster-a"}},
+	}
+	created, err := svc.CreateJobs(reqs, "user:ops", "")
+	if err != nil {
+		t.Fatalf("CreateJobs failed: %v", err)
+	}
+	if created.Succeeded != 3 || created.Failed != 1 || created.Results[1].Status != 400 || created.Results[1].Index != 1 {
+		t.Fatalf("unexpected bulk create result: %+v", created)
+	}
+	if created.Results[0].Job.TenantID != "user:ops" {
+		t.Fatalf("expected tenant to be applied, got %q", created.Results[0].Job.TenantID)
+	}
+	if _, err := svc.CreateJobs(nil, "", ""); !errors.Is(err, ErrInvalidBulkRequest) {
+		t.Fatalf("expected empty batch to be rejected, got %v", err)
+	}
+	if _, err := svc.CreateJobs(make([]models.CreateJobRequest, MaxBulkJobs+1), "", ""); !errors.Is(err, ErrInvalidBulkRequest) {
+		t.Fatalf("expected oversized batch to be rejected, got %v", err)
+	}
+
+	for _, bad := range []*models.BulkJobActionRequest{
+		{},
+		{Filter: &models.JobFilter{}},
+		{JobIDs: []string{"x"}, Filter: &models.JobFilter{ClusterID: "cluster-a"}},
+		{Filter: &models.JobFilter{ClusterID: "cluster-a"}, Limit: MaxBulkJobs + 1},
+	} {
+		if _, err := svc.CancelJobs(bad); !errors.Is(err, ErrInvalidBulkRequest) {
+			t.Fatalf("expected %+v to be rejected, got %v", bad, err)
+		}
+	}
+
+	cancelled, err := svc.CancelJobs(&models.BulkJobActionRequest{Filter: &models.JobFilter{ClusterID: "cluster-a"}})
+	if err != nil {
+		t.Fatalf("CancelJobs failed: %v", err)
+	}
+	if cancelled.Succeeded != 2 || cancelled.Failed != 0 {
+		t.Fatalf("expected both cluster-a jobs to be cancelled, got %+v", cancelled)
+	}
+	// terminal jobs are not matched by the default filter again
+	again, _ := svc.CancelJobs(&models.BulkJobActionRequest{Filter: &models.JobFilter{ClusterID: "cluster-a"}})
+	if len(again.Results) != 0 {
+		t.Fatalf("expected no cancellable jobs left, got %+v", again)
+	}
+
+	other := created.Results[2].JobID
+	byID, _ := svc.CancelJobs(&models.BulkJobActionRequest{JobIDs: []string{other, created.Results[0].JobID, "job-missing"}})
+	codes := []int{byID.Results[0].Status, byID.Results[1].Status, byID.Results[2].Status}
+	if codes[0] != 200 || codes[1] != 409 || codes[2] != 404 {
+		t.Fatalf("expected 200/409/404 per job, got %v", codes)
+	}
+
+	_ = svc.jobRepo.UpdateJobFields(other, map[string]interface{}{"status": "dead_lettered"})
+	requeued, err := svc.RequeueJobs(&models.BulkJobActionRequest{Filter: &models.JobFilter{Types: []string{"diagnose"}}})
+	if err != nil {
+		t.Fatalf("RequeueJobs failed: %v", err)
+	}
+	if requeued.Succeeded != 1 || requeued.Results[0].JobID != other {
+		t.Fatalf("expected only the dead-lettered job to be requeued, got %+v", requeued)
+	}
+	job, _ := svc.GetJob("", other)
+	if job.Status != "queued" {
+		t.Fatalf("expected requeued job to be queued, got %s", job.Status)
+	}
+}
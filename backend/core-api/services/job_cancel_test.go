@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/events"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/repositories"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// recordingProducer captures published event types for assertions
+type recordingProducer struct {
+	mu     sync.Mutex
+	events []string
+	// when set, cluster-events publishes fail with this error
+	fail error
+}
+
+func (p *recordingProducer) PublishEvent(topic, key string, event interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if e, ok := event.(*events.Event); ok {
+		p.events = append(p.events, e.Type)
+	}
+	if p.fail != nil && topic == "cluster-events" {
+		return p.fail
+	}
+	return nil
+}
+
+func (p *recordingProducer) has(eventType string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, t := range p.events {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func setupInMemoryJobService(t *testing.T) (*JobService, *gorm.DB, *recordingProducer) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Job{}, &models.JobDependency{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	// every new connection to ":memory:" is a fresh empty database
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.SetMaxOpenConns(1)
+	}
+	prod := &recordingProducer{}
+	return NewJobService(repositories.NewJobRepository(db, nil), prod), db, prod
+}
+
+func TestCancelJob_RemovesQueuedJobFromPool(t *testing.T) {
+	svc, _, prod := setupInMemoryJobService(t)
+	processed := make(chan string, 1)
+	// pool is not started so the job stays in the queue
+	pool := NewWorkerPool(1, 10, func(jobID string) { processed <- jobID })
+	svc.SetWorkerPool(pool)
+
+	resp, err := svc.CreateJob(&models.CreateJobRequest{Type: "diagnose"})
+	if err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+	if pool.QueueLength() != 1 {
+		t.Fatalf("expected 1 queued job, got %d", pool.QueueLength())
+	}
+
+	job, err := svc.CancelJob(resp.Job.ID)
+	if err != nil {
+		t.Fatalf("CancelJob failed: %v", err)
+	}
+	if job.Status != "cancelled" || job.CompletedAt == nil {
+		t.Fatalf("expected terminal cancelled job, got status=%s completed_at=%v", job.Status, job.CompletedAt)
+	}
+	if pool.QueueLength() != 0 || len(pool.SnapshotQueue()) != 0 {
+		t.Fatalf("expected job removed from queue")
+	}
+	if !prod.has("job_cancelled") {
+		t.Fatalf("expected job_cancelled event, got %v", prod.events)
+	}
+
+	// a worker must skip the removed id
+	pool.Start()
+	defer pool.Stop(0)
+	select {
+	case id := <-processed:
+		t.Fatalf("removed job %s was processed", id)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if _, err := svc.CancelJob(resp.Job.ID); err != ErrJobNotCancellable {
+		t.Fatalf("expected ErrJobNotCancellable on second cancel, got %v", err)
+	}
+}
+
+func TestCancelJob_StopsRunningDiagnose(t *testing.T) {
+	svc, db, _ := setupInMemoryJobService(t)
+	job := &models.Job{ID: "job-diagnose-cancel", Type: "diagnose", Status: "pending", CreatedAt: time.Now()}
+	if err := db.Create(job).Error; err != nil {
+		t.Fatalf("create job failed: %v", err)
+	}
+
+	if err := svc.ProcessJob(job.ID); err != nil {
+		t.Fatalf("ProcessJob failed: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, err := svc.CancelJob(job.ID); err != nil {
+		t.Fatalf("CancelJob failed: %v", err)
+	}
+
+	// the diagnose steps take ~2s; cancellation must stop them well before that
+	time.Sleep(300 * time.Millisecond)
+	saved, err := svc.GetJob("", job.ID)
+	if err != nil {
+		t.Fatalf("GetJob failed: %v", err)
+	}
+	if saved.Status != "cancelled" {
+		t.Fatalf("expected cancelled status, got %s", saved.Status)
+	}
+	if saved.Progress >= 100 {
+		t.Fatalf("expected diagnosis to stop before completing, progress=%d", saved.Progress)
+	}
+	svc.mu.Lock()
+	running := len(svc.running)
+	svc.mu.Unlock()
+	if running != 0 {
+		t.Fatalf("expected no tracked running jobs, got %d", running)
+	}
+}
+
+// stubbornJob ignores cancellation and succeeds once released.
+type stubbornJob struct{ release chan struct{} }
+
+func (stubbornJob) Spec() models.JobTypeSpec { return models.JobTypeSpec{Name: "stubborn"} }
+
+func (stubbornJob) Validate(map[string]string) error { return nil }
+
+func (h stubbornJob) Execute(context.Context, *JobRun) (string, error) {
+	<-h.release
+	return "done", nil
+}
+
+func TestCancelJob_NotOverwrittenByLateCompletion(t *testing.T) {
+	svc, _, _ := setupInMemoryJobService(t)
+	h := stubbornJob{release: make(chan struct{})}
+	if err := svc.RegisterJobType(h); err != nil {
+		t.Fatalf("RegisterJobType failed: %v", err)
+	}
+	resp, err := svc.CreateJob(&models.CreateJobRequest{Type: "stubborn"})
+	if err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+	waitForJobStatus(t, svc, resp.Job.ID, "running", time.Second)
+	if _, err := svc.CancelJob(resp.Job.ID); err != nil {
+		t.Fatalf("CancelJob failed: %v", err)
+	}
+	// the handler finishes after the cancel, without checking its context
+	close(h.release)
+	time.Sleep(100 * time.Millisecond)
+	saved, err := svc.GetJob("", resp.Job.ID)
+	if err != nil {
+		t.Fatalf("GetJob failed: %v", err)
+	}
+	if saved.Status != "cancelled" {
+		t.Fatalf("expected the cancel to stick, got %s", saved.Status)
+	}
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/repositories"
+)
+
+func TestJobLogs_RecordsHistoryAndPaginates(t *testing.T) {
+	svc, db, _ := setupInMemoryJobService(t)
+	if err := db.AutoMigrate(&models.JobLog{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	if _, err := svc.JobLogs("job-1", 0, 10); !errors.Is(err, ErrJobLogsUnavailable) {
+		t.Fatalf("expected ErrJobLogsUnavailable without a repository, got %v", err)
+	}
+	svc.SetJobLogRepository(repositories.NewJobLogRepository(db))
+	if err := svc.RegisterJobType(snapshotJob{}); err != nil {
+		t.Fatalf("RegisterJobType failed: %v", err)
+	}
+	if err := svc.RegisterJobType(failingJob{}); err != nil {
+		t.Fatalf("RegisterJobType failed: %v", err)
+	}
+
+	resp, err := svc.CreateJob(&models.CreateJobRequest{Type: "snapshot", Parameters: map[string]string{"droplet_id": "d-1"}})
+	if err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+	waitForJobStatus(t, svc, resp.Job.ID, "completed", time.Second)
+
+	all, err := svc.JobLogs(resp.Job.ID, 0, 0)
+	if err != nil {
+		t.Fatalf("JobLogs failed: %v", err)
+	}
+	var messages []string
+	for _, entry := range all.Logs {
+		messages = append(messages, entry.Message)
+	}
+	want := []string{"job created", "attempt 1/", "snapshotting d-1", "completed: snapshot of d-1 taken"}
+	if len(messages) != len(want) {
+		t.Fatalf("expected %d entries, got %v", len(want), messages)
+	}
+	for i := range want {
+		if !strings.HasPrefix(messages[i], want[i]) {
+			t.Fatalf("entry %d: expected %q, got %v", i, want[i], messages)
+		}
+	}
+	if all.Logs[2].Progress != 50 || all.HasMore {
+		t.Fatalf("unexpected progress entry or page: %+v", all)
+	}
+
+	first, err := svc.JobLogs(resp.Job.ID, 0, 3)
+	if err != nil || len(first.Logs) != 3 || !first.HasMore {
+		t.Fatalf("expected a first page of 3 with more, got %+v (%v)", first, err)
+	}
+	rest, err := svc.JobLogs(resp.Job.ID, first.NextAfter, 3)
+	if err != nil || len(rest.Logs) != 1 || rest.HasMore || rest.Logs[0].ID != all.Logs[3].ID {
+		t.Fatalf("expected the last entry on the second page, got %+v (%v)", rest, err)
+	}
+
+	failed, err := svc.CreateJob(&models.CreateJobRequest{Type: "always-fails"})
+	if err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+	waitForJobStatus(t, svc, failed.Job.ID, "failed", time.Second)
+	logs, _ := svc.JobLogs(failed.Job.ID, 0, 0)
+	last := logs.Logs[len(logs.Logs)-1]
+	if last.Level != models.JobLogError || last.Message != "failed: boom" {
+		t.Fatalf("expected an error entry for the failure, got %+v", last)
+	}
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestWorkerPoolStore_RecoversQueueAfterRestart(t *testing.T) {
+	store := NewMemoryQueueStore()
+	first := NewWorkerPool(1, 10, func(string) {})
+	first.SetStore(store, time.Second)
+	first.SubmitWithOptions("job-a", SubmitOptions{Priority: "high", Tenant: "user:alice"})
+	first.Submit("job-b")
+	first.Remove("job-b")
+	// first pool "crashes" before any worker ran
+
+	var mu sync.Mutex
+	var processed []string
+	second := NewWorkerPool(1, 10, func(id string) {
+		mu.Lock()
+		processed = append(processed, id)
+		mu.Unlock()
+	})
+	second.SetStore(store, time.Second)
+	n, err := second.Recover()
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 recovered entry, got %d (err=%v)", n, err)
+	}
+	if d := second.QueueDepthByPriority(); d["high"] != 1 {
+		t.Fatalf("expected priority to survive the restart, got %v", d)
+	}
+	second.Start()
+	defer second.Stop(0)
+	time.Sleep(100 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(processed) != 1 || processed[0] != "job-a" {
+		t.Fatalf("expected job-a processed once, got %v", processed)
+	}
+	if pending, _ := store.Pending(); len(pending) != 0 {
+		t.Fatalf("expected store to be empty after processing, got %v", pending)
+	}
+	if leased, _ := store.Leased("job-a", time.Now()); leased {
+		t.Fatalf("expected lease released after the handler returned")
+	}
+}
+
+// flakyQueueStore fails Add while down and records whether the pool lock was held during it.
+type flakyQueueStore struct {
+	*MemoryQueueStore
+	pool            *WorkerPool
+	down            bool
+	lockedDuringAdd bool
+}
+
+func (f *flakyQueueStore) Add(e QueueEntry) error {
+	if f.pool.mu.TryLock() {
+		f.pool.mu.Unlock()
+	} else {
+		f.lockedDuringAdd = true
+	}
+	if f.down {
+		return errors.New("store unavailable")
+	}
+	return f.MemoryQueueStore.Add(e)
+}
+
+func TestWorkerPoolStore_SubmitPersistsOutsideLock(t *testing.T) {
+	pool := NewWorkerPool(1, 10, func(string) {})
+	store := &flakyQueueStore{MemoryQueueStore: NewMemoryQueueStore(), pool: pool}
+	pool.SetStore(store, time.Second)
+
+	if !pool.Submit("job-a") {
+		t.Fatal("expected submit to succeed")
+	}
+	store.down = true
+	if pool.Submit("job-b") {
+		t.Fatal("expected submit to fail while the store is down")
+	}
+	if store.lockedDuringAdd {
+		t.Fatal("expected the store write to happen outside the pool lock")
+	}
+	if pool.Contains("job-b") || pool.QueueLength() != 1 {
+		t.Fatalf("expected only job-a queued, got %v", pool.SnapshotQueue())
+	}
+	if pending, _ := store.Pending(); len(pending) != 1 || pending[0].JobID != "job-a" {
+		t.Fatalf("expected only job-a persisted, got %+v", pending)
+	}
+}
+
+func TestWorkerPoolStore_LeaseRenewedWhileRunning(t *testing.T) {
+	store := NewMemoryQueueStore()
+	release := make(chan struct{})
+	pool := NewWorkerPool(1, 10, func(string) { <-release })
+	pool.SetStore(store, 60*time.Millisecond)
+	expired := make(chan string, 1)
+	pool.SetLeaseExpiredHandler(func(id string) { expired <- id })
+	pool.Submit("slow-job")
+	pool.Start()
+	defer pool.Stop(0)
+
+	// well past the lease period the job is still leased thanks to renewal
+	time.Sleep(200 * time.Millisecond)
+	if leased, _ := store.Leased("slow-job", time.Now()); !leased {
+		t.Fatalf("expected lease to be renewed while the handler runs")
+	}
+	close(release)
+	select {
+	case id := <-expired:
+		t.Fatalf("unexpected lease expiry for %s", id)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestRecoverJobs_RequeuesQueuedAndRetriesInterrupted(t *testing.T) {
+	svc, db, _ := setupInMemoryJobService(t)
+	pool := NewWorkerPool(1, 10, func(string) {})
+	pool.SetStore(NewMemoryQueueStore(), time.Second)
+	svc.SetWorkerPool(pool)
+
+	started := time.Now().Add(-time.Minute)
+	jobs := []*models.Job{
+		{ID: "job-orphaned", Type: "diagnose", Status: "queued", CreatedAt: time.Now()},
+		{ID: "job-interrupted", Type: "provision", Status: "running", Attempts: 1, StartedAt: &started, CreatedAt: time.Now()},
+		{ID: "job-orchestrating", Type: "provision", Status: "queued", Attempts: 1, StartedAt: &started, CreatedAt: time.Now()},
+		{ID: "job-interrupted-monitor", Type: "monitor", Status: "running", Attempts: 2, StartedAt: &started, CreatedAt: time.Now()},
+	}
+	for _, j := range jobs {
+		if err := db.Create(j).Error; err != nil {
+			t.Fatalf("create job failed: %v", err)
+		}
+	}
+
+	svc.RecoverJobs()
+
+	if !pool.Contains("job-orphaned") || pool.Contains("job-orchestrating") {
+		t.Fatalf("expected only the orphaned job requeued, queue=%v", pool.SnapshotQueue())
+	}
+	if j, _ := svc.GetJob("", "job-interrupted"); j.Status != "retrying" || j.Error != ErrLeaseExpired.Error() {
+		t.Fatalf("expected interrupted job to be retried, got status=%s error=%s", j.Status, j.Error)
+	}
+	// monitor allows 2 attempts, both used
+	if j, _ := svc.GetJob("", "job-interrupted-monitor"); j.Status != "dead_lettered" {
+		t.Fatalf("expected exhausted interrupted job to be dead-lettered, got %s", j.Status)
+	}
+	if j, _ := svc.GetJob("", "job-orchestrating"); j.Status != "queued" {
+		t.Fatalf("expected job handed to orchestration to be left alone, got %s", j.Status)
+	}
+}
+
+func TestRedisQueueStore_ClaimAndReap(t *testing.T) {
+	ctx := context.Background()
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+	client := redis.NewClient(&redis.Options{Addr: redisAddr})
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis not available on %s - skipping integration test", redisAddr)
+	}
+	store := NewRedisQueueStore(client)
+	store.pendingKey = "test:" + store.pendingKey
+	store.inflightKey = "test:" + store.inflightKey
+	store.ownersKey = "test:" + store.ownersKey
+	defer client.Del(ctx, store.pendingKey, store.inflightKey, store.ownersKey)
+
+	if err := store.Add(QueueEntry{JobID: "r1", Priority: "low", Tenant: "user:bob"}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if pending, _ := store.Pending(); len(pending) != 1 || pending[0].Tenant != "user:bob" {
+		t.Fatalf("unexpected pending entries: %v", pending)
+	}
+	if ok, _ := store.Claim("r1", "pool-a", 50*time.Millisecond); !ok {
+		t.Fatalf("expected first claim to succeed")
+	}
+	if ok, _ := store.Claim("r1", "pool-b", time.Second); ok {
+		t.Fatalf("expected second claim to fail")
+	}
+	if leased, _ := store.Leased("r1", time.Now()); !leased {
+		t.Fatalf("expected r1 to be leased")
+	}
+	time.Sleep(80 * time.Millisecond)
+	ids, err := store.ReapExpired(time.Now())
+	if err != nil || len(ids) != 1 || ids[0] != "r1" {
+		t.Fatalf("expected r1 reaped, got %v (err=%v)", ids, err)
+	}
+	if ids, _ := store.ReapExpired(time.Now()); len(ids) != 0 {
+		t.Fatalf("expected each expiry to be reaped once, got %v", ids)
+	}
+	if ok, _ := store.Renew("r1", "pool-a", time.Second); ok {
+		t.Fatalf("expected a reaped lease not to be renewed")
+	}
+}
+
+func TestWorkerPoolStore_SlowOwnerKeepsLeaseWithinGrace(t *testing.T) {
+	store := NewMemoryQueueStore()
+	pool := NewWorkerPool(1, 10, func(string) {})
+	pool.SetStore(store, 50*time.Millisecond)
+	pool.SetLeaseGrace(time.Hour)
+	expired := make(chan string, 1)
+	pool.SetLeaseExpiredHandler(func(id string) { expired <- id })
+	// claimed by another, stalled instance that stopped renewing
+	store.Add(QueueEntry{JobID: "stalled-job"})
+	store.Claim("stalled-job", "stalled-pool", 10*time.Millisecond)
+	pool.Start()
+
+	// several sweeps run while the lease is expired but within the grace period
+	time.Sleep(100 * time.Millisecond)
+	pool.Stop(0)
+	select {
+	case id := <-expired:
+		t.Fatalf("lease of %s reclaimed within the grace period", id)
+	default:
+	}
+	if leased, _ := pool.Leased("stalled-job"); !leased {
+		t.Fatalf("expected a lease within the grace period to count as held")
+	}
+
+	// once reaped and claimed again, the stalled owner can neither renew nor ack the new lease
+	if ids, _ := store.ReapExpired(time.Now()); len(ids) != 1 {
+		t.Fatalf("expected the stalled lease to be reaped, got %v", ids)
+	}
+	store.Add(QueueEntry{JobID: "stalled-job"})
+	store.Claim("stalled-job", "new-pool", time.Minute)
+	if ok, _ := store.Renew("stalled-job", "stalled-pool", time.Minute); ok {
+		t.Fatalf("expected the stalled owner's renewal to be rejected")
+	}
+	store.Ack("stalled-job", "stalled-pool")
+	if leased, _ := store.Leased("stalled-job", time.Now()); !leased {
+		t.Fatalf("expected the stalled owner's ack to leave the new lease in place")
+	}
+}
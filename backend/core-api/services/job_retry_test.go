@@ -0,0 +1,127 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+)
+
+func waitForJobStatus(t *testing.T, svc *JobService, id, status string, timeout time.Duration) *models.Job {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		job, err := svc.GetJob("", id)
+		if err != nil {
+			t.Fatalf("GetJob failed: %v", err)
+		}
+		if job.Status == status {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for status %s, job is %s", status, job.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRetryPolicy_DelayBackoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}
+	for i, w := range want {
+		if got := p.Delay(i + 1); got != w {
+			t.Fatalf("attempt %d: expected delay %s, got %s", i+1, w, got)
+		}
+	}
+	if p.ShouldRetry(5, errors.New("boom")) {
+		t.Fatalf("expected no retry once max attempts reached")
+	}
+	if p.ShouldRetry(1, Permanent(errors.New("bad input"))) {
+		t.Fatalf("expected permanent errors not to be retried")
+	}
+}
+
+func TestRetry_ExhaustedJobIsDeadLetteredAndRequeued(t *testing.T) {
+	svc, db, prod := setupInMemoryJobService(t)
+	prod.fail = errors.New("broker unavailable")
+	svc.SetRetryPolicy("provision", RetryPolicy{MaxAttempts: 2, BaseDelay: 10 * time.Millisecond, MaxDelay: 10 * time.Millisecond})
+
+	job := &models.Job{ID: "job-retry", Type: "provision", Status: "pending", Parameters: `{"cluster_id":"c1"}`, CreatedAt: time.Now()}
+	if err := db.Create(job).Error; err != nil {
+		t.Fatalf("create job failed: %v", err)
+	}
+	if err := svc.ProcessJob(job.ID); err != nil {
+		t.Fatalf("ProcessJob failed: %v", err)
+	}
+
+	saved := waitForJobStatus(t, svc, job.ID, "dead_lettered", 2*time.Second)
+	if saved.Attempts != 2 || saved.Error != "broker unavailable" {
+		t.Fatalf("expected 2 attempts with last error recorded, got attempts=%d error=%v", saved.Attempts, saved.Error)
+	}
+	if !prod.has("job_retry_scheduled") || !prod.has("job_dead_lettered") {
+		t.Fatalf("expected retry and dead-letter events, got %v", prod.events)
+	}
+	dlq, err := svc.ListDeadLetteredJobs("", 10)
+	if err != nil || len(dlq) != 1 {
+		t.Fatalf("expected 1 dead-lettered job, got %d (err=%v)", len(dlq), err)
+	}
+
+	// once the broker recovers a requeued job starts over with a fresh attempt budget
+	prod.mu.Lock()
+	prod.fail = nil
+	prod.mu.Unlock()
+	if _, err := svc.RequeueJob(job.ID); err != nil {
+		t.Fatalf("RequeueJob failed: %v", err)
+	}
+	saved = waitForJobStatus(t, svc, job.ID, "queued", 2*time.Second)
+	if saved.Attempts != 1 {
+		t.Fatalf("expected attempts reset to 1 after requeue, got %d", saved.Attempts)
+	}
+	if _, err := svc.RequeueJob(job.ID); !errors.Is(err, ErrJobNotDeadLettered) {
+		t.Fatalf("expected ErrJobNotDeadLettered, got %v", err)
+	}
+}
+
+func TestRetry_PermanentErrorFailsImmediately(t *testing.T) {
+	svc, db, prod := setupInMemoryJobService(t)
+	job := &models.Job{ID: "job-no-cluster", Type: "provision", Status: "pending", Parameters: `{}`, CreatedAt: time.Now()}
+	if err := db.Create(job).Error; err != nil {
+		t.Fatalf("create job failed: %v", err)
+	}
+	if err := svc.ProcessJob(job.ID); err != nil {
+		t.Fatalf("ProcessJob failed: %v", err)
+	}
+	saved := waitForJobStatus(t, svc, job.ID, "failed", time.Second)
+	if saved.Attempts != 1 {
+		t.Fatalf("expected a single attempt, got %d", saved.Attempts)
+	}
+	if prod.has("job_retry_scheduled") {
+		t.Fatalf("permanent errors must not schedule retries")
+	}
+}
+
+func TestRequeue_FullQueueKeepsDeadLetteredJob(t *testing.T) {
+	svc, db, _ := setupInMemoryJobService(t)
+	// the pool is not started, so one queued job fills it
+	pool := NewWorkerPool(1, 1, func(string) {})
+	svc.SetWorkerPool(pool)
+	if !pool.Submit("job-filler") {
+		t.Fatalf("expected the filler job to be queued")
+	}
+
+	job := &models.Job{ID: "job-dlq", Type: "provision", Status: "dead_lettered", Attempts: 3, Error: "broker unavailable", Progress: 40, CreatedAt: time.Now()}
+	if err := db.Create(job).Error; err != nil {
+		t.Fatalf("create job failed: %v", err)
+	}
+	if _, err := svc.RequeueJob(job.ID); err == nil {
+		t.Fatalf("expected RequeueJob to fail while the queue is full")
+	}
+	saved, err := svc.GetJob("", job.ID)
+	if err != nil {
+		t.Fatalf("GetJob failed: %v", err)
+	}
+	if saved.Status != "dead_lettered" || saved.Attempts != 3 || saved.Error != "broker unavailable" || saved.Progress != 40 {
+		t.Fatalf("expected the job back in the dead-letter queue unchanged, got %+v", saved)
+	}
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/repositories"
+)
+
+// setupScheduleService wires a schedule service to an unstarted worker pool so created
+// jobs stay "queued" and count as still running.
+func setupScheduleService(t *testing.T, now *time.Time) (*JobScheduleService, *JobService) {
+	t.Helper()
+	jobSvc, db, _ := setupInMemoryJobService(t)
+	if err := db.AutoMigrate(&models.JobSchedule{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	jobSvc.SetWorkerPool(NewWorkerPool(1, 10, func(string) {}))
+	svc := NewJobScheduleService(repositories.NewJobScheduleRepository(db), jobSvc)
+	svc.now = func() time.Time { return *now }
+	return svc, jobSvc
+}
+
+func TestJobSchedule_NextRunUsesTimezone(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC) // 07:00 in New York
+	svc, _ := setupScheduleService(t, &now)
+
+	sched, err := svc.CreateSchedule(&models.CreateScheduleRequest{CronExpr: "0 9 * * *", Timezone: "America/New_York", JobType: "diagnose", ClusterID: "cluster-1"})
+	if err != nil {
+		t.Fatalf("CreateSchedule failed: %v", err)
+	}
+	want := time.Date(2026, 1, 10, 14, 0, 0, 0, time.UTC)
+	if sched.NextRunAt == nil || !sched.NextRunAt.Equal(want) {
+		t.Fatalf("expected next run %s, got %v", want, sched.NextRunAt)
+	}
+	if sched.OverlapPolicy != "skip" || !sched.Enabled {
+		t.Fatalf("expected defaults skip/enabled, got %s/%t", sched.OverlapPolicy, sched.Enabled)
+	}
+
+	for _, bad := range []models.CreateScheduleRequest{
+		{CronExpr: "not a cron", JobType: "monitor"},
+		{CronExpr: "* * * * *", Timezone: "Mars/Olympus", JobType: "monitor"},
+		{CronExpr: "* * * * *", JobType: "unknown"},
+		{CronExpr: "* * * * *", JobType: "monitor", OverlapPolicy: "drop"},
+	} {
+		if _, err := svc.CreateSchedule(&bad); !errors.Is(err, ErrInvalidSchedule) {
+			t.Fatalf("expected ErrInvalidSchedule for %+v, got %v", bad, err)
+		}
+	}
+}
+
+func TestJobSchedule_OverlapPolicies(t *testing.T) {
+	for _, policy := range []string{"skip", "queue", "replace"} {
+		t.Run(policy, func(t *testing.T) {
+			now := time.Date(2026, 1, 10, 12, 0, 30, 0, time.UTC)
+			svc, jobSvc := setupScheduleService(t, &now)
+			sched, err := svc.CreateSchedule(&models.CreateScheduleRequest{CronExpr: "* * * * *", JobType: "monitor", ClusterID: "cluster-1", OverlapPolicy: policy})
+			if err != nil {
+				t.Fatalf("CreateSchedule failed: %v", err)
+			}
+
+			now = now.Add(time.Minute)
+			svc.RunDue()
+			first, _ := svc.GetSchedule("", sched.ID)
+			if first.LastJobID == "" || first.LastRunAt == nil {
+				t.Fatalf("expected first run to create a job")
+			}
+			job, _ := jobSvc.GetJob("", first.LastJobID)
+			if job.Parameters != `{"cluster_id":"cluster-1"}` {
+				t.Fatalf("expected cluster_id parameter, got %s", job.Parameters)
+			}
+
+			// the first job is still queued when the next tick fires
+			now = now.Add(time.Minute)
+			svc.RunDue()
+			second, _ := svc.GetSchedule("", sched.ID)
+			if !second.NextRunAt.After(now) {
+				t.Fatalf("expected next run to advance past %s, got %s", now, second.NextRunAt)
+			}
+			prev, _ := jobSvc.GetJob("", first.LastJobID)
+			switch policy {
+			case "skip":
+				if second.LastJobID != first.LastJobID || second.PendingRun {
+					t.Fatalf("expected overlapping run to be skipped")
+				}
+			case "queue":
+				if second.LastJobID != first.LastJobID || !second.PendingRun {
+					t.Fatalf("expected overlapping run to be queued")
+				}
+				// the queued run fires as soon as the previous job finishes
+				if _, err := jobSvc.CancelJob(first.LastJobID); err != nil {
+					t.Fatalf("CancelJob failed: %v", err)
+				}
+				svc.RunDue()
+				third, _ := svc.GetSchedule("", sched.ID)
+				if third.LastJobID == first.LastJobID || third.PendingRun {
+					t.Fatalf("expected queued run to create a new job")
+				}
+			case "replace":
+				if second.LastJobID == first.LastJobID || prev.Status != "cancelled" {
+					t.Fatalf("expected previous job cancelled and replaced, status=%s", prev.Status)
+				}
+			}
+		})
+	}
+}
+
+func TestJobSchedule_TickFiresOnceAcrossInstances(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 30, 0, time.UTC)
+	svc, jobSvc := setupScheduleService(t, &now)
+	// a second instance sharing the database
+	other := NewJobScheduleService(svc.repo, jobSvc)
+	other.now = svc.now
+	sched, err := svc.CreateSchedule(&models.CreateScheduleRequest{CronExpr: "* * * * *", JobType: "monitor", OverlapPolicy: "queue"})
+	if err != nil {
+		t.Fatalf("CreateSchedule failed: %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	// both instances list the schedule as due before either fires it
+	a, _ := svc.repo.ListDue(now)
+	b, _ := other.repo.ListDue(now)
+	if len(a) != 1 || len(b) != 1 {
+		t.Fatalf("expected the schedule to be due on both instances")
+	}
+	svc.fire(a[0], now)
+	other.fire(b[0], now)
+
+	jobs, err := jobSvc.ListJobs(&models.GetJobsRequest{})
+	if err != nil {
+		t.Fatalf("ListJobs failed: %v", err)
+	}
+	if len(jobs.Jobs) != 1 {
+		t.Fatalf("expected one job for the tick, got %d", len(jobs.Jobs))
+	}
+	saved, _ := svc.GetSchedule("", sched.ID)
+	if saved.LastJobID != jobs.Jobs[0].ID || !saved.NextRunAt.After(now) {
+		t.Fatalf("expected the schedule to record the run, got %+v", saved)
+	}
+}
+
+func TestJobSchedule_ProvisionRunsChargeQuotas(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 30, 0, time.UTC)
+	svc, jobSvc := setupScheduleService(t, &now)
+	quotas := NewQuotaManager(nil, nil)
+	quotas.now = svc.now
+	_ = quotas.AddRule(models.QuotaRule{Name: QuotaDroplets, Scope: "cluster", Period: models.QuotaPeriodMonth, Limit: 1})
+	svc.SetQuotas(quotas)
+	sched, err := svc.CreateSchedule(&models.CreateScheduleRequest{CronExpr: "* * * * *", JobType: "provision", ClusterID: "cluster-1", OverlapPolicy: "replace"})
+	if err != nil {
+		t.Fatalf("CreateSchedule failed: %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	svc.RunDue()
+	if usage, _ := quotas.Usage(QuotaDroplets, "cluster-1"); usage.Used != 1 {
+		t.Fatalf("expected the scheduled provision job to use the cluster's droplet quota, got %+v", usage)
+	}
+
+	// the next run does not fit and is skipped
+	now = now.Add(time.Minute)
+	svc.RunDue()
+	saved, _ := svc.GetSchedule("", sched.ID)
+	if saved.LastError == "" || !saved.NextRunAt.After(now) {
+		t.Fatalf("expected the run to be skipped with the quota error, got %+v", saved)
+	}
+	jobs, _ := jobSvc.ListJobs(&models.GetJobsRequest{})
+	if len(jobs.Jobs) != 1 {
+		t.Fatalf("expected one provision job, got %d", len(jobs.Jobs))
+	}
+}
+
+func TestJobSchedule_ScopedToProject(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 30, 0, time.UTC)
+	jobSvc, db, _ := setupInMemoryJobService(t)
+	if err := db.AutoMigrate(&models.JobSchedule{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	jobSvc.SetClusterService(NewClusterService(memClusterRepo{
+		"cluster-a": {ID: "cluster-a", ProjectID: "team-a"},
+		"cluster-b": {ID: "cluster-b", ProjectID: "team-b"},
+	}))
+	jobSvc.SetWorkerPool(NewWorkerPool(1, 10, func(string) {}))
+	svc := NewJobScheduleService(repositories.NewJobScheduleRepository(db), jobSvc)
+	svc.now = func() time.Time { return now }
+
+	if _, err := svc.CreateSchedule(&models.CreateScheduleRequest{ProjectID: "team-a", CronExpr: "* * * * *", JobType: "diagnose", ClusterID: "cluster-b"}); !errors.Is(err, ErrInvalidSchedule) {
+		t.Fatalf("expected a schedule on another project's cluster to be rejected, got %v", err)
+	}
+	sched, err := svc.CreateSchedule(&models.CreateScheduleRequest{ProjectID: "team-a", CronExpr: "* * * * *", JobType: "diagnose", ClusterID: "cluster-a"})
+	if err != nil {
+		t.Fatalf("CreateSchedule failed: %v", err)
+	}
+	if _, err := svc.GetSchedule("team-b", sched.ID); err == nil {
+		t.Fatalf("expected another project's schedule to look missing")
+	}
+	if list, _ := svc.ListSchedules("team-b"); len(list) != 0 {
+		t.Fatalf("expected no schedules in team-b, got %d", len(list))
+	}
+	other := "cluster-b"
+	if _, err := svc.UpdateSchedule("team-a", sched.ID, &models.UpdateScheduleRequest{ClusterID: &other}); !errors.Is(err, ErrInvalidSchedule) {
+		t.Fatalf("expected moving the schedule to another project's cluster to be rejected, got %v", err)
+	}
+	if err := svc.DeleteSchedule("team-b", sched.ID); err == nil {
+		t.Fatalf("expected deleting another project's schedule to fail")
+	}
+
+	now = now.Add(time.Minute)
+	svc.RunDue()
+	jobs, _ := jobSvc.ListJobs(&models.GetJobsRequest{JobFilter: models.JobFilter{ProjectID: "team-a"}})
+	if len(jobs.Jobs) != 1 {
+		t.Fatalf("expected the scheduled job in the schedule's project, got %d", len(jobs.Jobs))
+	}
+}
+
+// memClusterRepo serves fixed clusters without the Redis cache of the real repository.
+type memClusterRepo map[string]*models.Cluster
+
+func (m memClusterRepo) CreateCluster(c *models.Cluster) (*models.Cluster, error) {
+	m[c.ID] = c
+	return c, nil
+}
+
+func (m memClusterRepo) GetCluster(id string) (*models.Cluster, error) {
+	if c, ok := m[id]; ok {
+		return c, nil
+	}
+	return nil, errors.New("cluster not found")
+}
+
+func (m memClusterRepo) ListClusters(projectID string) ([]*models.Cluster, error) {
+	var out []*models.Cluster
+	for _, c := range m {
+		if models.InProject(projectID, c.ProjectID) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (m memClusterRepo) UpdateCluster(id string, c *models.Cluster) (*models.Cluster, error) {
+	m[id] = c
+	return c, nil
+}
+
+func (m memClusterRepo) DeleteCluster(id string) error {
+	delete(m, id)
+	return nil
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+)
+
+func TestReapTimedOutJobs_OrchestrationNeverArrives(t *testing.T) {
+	svc, _, prod := setupInMemoryJobService(t)
+	svc.SetJobTimeout("provision", time.Minute)
+
+	resp, err := svc.CreateJob(&models.CreateJobRequest{Type: "provision", Parameters: map[string]string{"cluster_id": "cluster-1"}})
+	if err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+	if resp.Job.TimeoutSeconds != 60 {
+		t.Fatalf("expected the type's timeout to be stored on the job, got %d", resp.Job.TimeoutSeconds)
+	}
+	child, err := svc.CreateJob(&models.CreateJobRequest{Type: "monitor", DependsOn: []string{resp.Job.ID}})
+	if err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+
+	// handed to orchestration; no consumer runs in this test
+	job := waitForJobStatus(t, svc, resp.Job.ID, "queued", time.Second)
+	if job.StartedAt == nil || job.DeadlineAt == nil {
+		t.Fatalf("expected started_at and deadline_at to be set")
+	}
+	if n := svc.ReapTimedOutJobs(time.Now()); n != 0 {
+		t.Fatalf("expected nothing overdue before the deadline, reaped %d", n)
+	}
+
+	if n := svc.ReapTimedOutJobs(job.DeadlineAt.Add(time.Second)); n != 1 {
+		t.Fatalf("expected 1 job reaped, got %d", n)
+	}
+	job, _ = svc.GetJob("", resp.Job.ID)
+	if job.Status != "timed_out" || job.Error == "" || job.CompletedAt == nil {
+		t.Fatalf("expected timed_out with a reason, got %s (%q)", job.Status, job.Error)
+	}
+	if !prod.has("job_timed_out") {
+		t.Fatalf("expected job_timed_out event, got %v", prod.events)
+	}
+	waitForJobStatus(t, svc, child.Job.ID, "skipped", time.Second)
+}
+
+func TestReapTimedOutJobs_StopsRunningJob(t *testing.T) {
+	svc, _, _ := setupInMemoryJobService(t)
+	svc.SetJobTimeout("monitor", 0)
+
+	// diagnose takes ~2s; the monitor job has no timeout and is never reaped
+	slow, _ := svc.CreateJob(&models.CreateJobRequest{Type: "diagnose"})
+	untimed, _ := svc.CreateJob(&models.CreateJobRequest{Type: "monitor"})
+	waitForJobStatus(t, svc, slow.Job.ID, "running", time.Second)
+	waitForJobStatus(t, svc, untimed.Job.ID, "running", time.Second)
+
+	if n := svc.ReapTimedOutJobs(time.Now().Add(time.Hour)); n != 1 {
+		t.Fatalf("expected only the job with a timeout to be reaped, got %d", n)
+	}
+	// the run is cancelled and must not overwrite the timed_out status
+	deadline := time.Now().Add(time.Second)
+	for {
+		svc.mu.Lock()
+		_, running := svc.running[slow.Job.ID]
+		svc.mu.Unlock()
+		if !running {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the timed out run to stop")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if job, _ := svc.GetJob("", slow.Job.ID); job.Status != "timed_out" {
+		t.Fatalf("expected timed_out, got %s", job.Status)
+	}
+	waitForJobStatus(t, svc, untimed.Job.ID, "completed", 3*time.Second)
+}
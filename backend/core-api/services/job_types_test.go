@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+)
+
+// failingJob is a job type whose runs always fail permanently.
+type failingJob struct{}
+
+func (failingJob) Spec() models.JobTypeSpec {
+	return models.JobTypeSpec{Name: "always-fails"}
+}
+
+func (failingJob) Validate(map[string]string) error { return nil }
+
+func (failingJob) Execute(context.Context, *JobRun) (string, error) {
+	return "", Permanent(errors.New("boom"))
+}
+
+// snapshotJob is a custom job type with a parameter schema.
+type snapshotJob struct{}
+
+func (snapshotJob) Spec() models.JobTypeSpec {
+	return models.JobTypeSpec{
+		Name:        "snapshot",
+		Description: "Snapshot a droplet",
+		Parameters: []models.JobParamSpec{
+			{Name: "droplet_id", Type: "string", Required: true},
+			{Name: "retain_days", Type: "int"},
+		},
+	}
+}
+
+func (h snapshotJob) Validate(params map[string]string) error {
+	return ValidateJobParameters(h.Spec(), params)
+}
+
+func (snapshotJob) Execute(ctx context.Context, run *JobRun) (string, error) {
+	run.Progress(50, "snapshotting "+run.Params["droplet_id"])
+	return "snapshot of " + run.Params["droplet_id"] + " taken", nil
+}
+
+func TestJobTypes_CustomTypeRunsWithoutEditingJobService(t *testing.T) {
+	svc, _, prod := setupInMemoryJobService(t)
+	if err := svc.RegisterJobType(snapshotJob{}); err != nil {
+		t.Fatalf("RegisterJobType failed: %v", err)
+	}
+	if err := svc.RegisterJobType(snapshotJob{}); err == nil {
+		t.Fatalf("expected duplicate registration to fail")
+	}
+
+	for _, params := range []map[string]string{{}, {"droplet_id": "d-1", "retain_days": "soon"}} {
+		if _, err := svc.CreateJob(&models.CreateJobRequest{Type: "snapshot", Parameters: params}); !errors.Is(err, ErrInvalidJobRequest) {
+			t.Fatalf("expected ErrInvalidJobRequest for %v, got %v", params, err)
+		}
+	}
+	if _, err := svc.CreateJob(&models.CreateJobRequest{Type: "resize"}); !errors.Is(err, ErrInvalidJobRequest) {
+		t.Fatalf("expected unknown type to be rejected, got %v", err)
+	}
+
+	resp, err := svc.CreateJob(&models.CreateJobRequest{Type: "snapshot", Parameters: map[string]string{"droplet_id": "d-1", "retain_days": "7"}})
+	if err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+	job := waitForJobStatus(t, svc, resp.Job.ID, "completed", time.Second)
+	if job.Result != "snapshot of d-1 taken" {
+		t.Fatalf("expected handler result to be stored, got %q", job.Result)
+	}
+	if !prod.has("job_progress") {
+		t.Fatalf("expected progress event, got %v", prod.events)
+	}
+
+	var names []string
+	for _, spec := range svc.JobTypes() {
+		names = append(names, spec.Name)
+		switch spec.Name {
+		case "provision":
+			if !spec.Orchestrated || spec.MaxAttempts != 3 || spec.TimeoutSeconds != 600 {
+				t.Fatalf("unexpected provision spec: %+v", spec)
+			}
+		case "snapshot":
+			if spec.Orchestrated || len(spec.Parameters) != 2 {
+				t.Fatalf("unexpected snapshot spec: %+v", spec)
+			}
+		}
+	}
+	want := []string{"diagnose", "monitor", "provision", "scale", "snapshot"}
+	if len(names) != len(want) {
+		t.Fatalf("expected types %v, got %v", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("expected types %v, got %v", want, names)
+		}
+	}
+}
+
+// flakyOrchestratedJob hands itself to orchestration, which always fails with a retryable error.
+type flakyOrchestratedJob struct{}
+
+func (flakyOrchestratedJob) Spec() models.JobTypeSpec {
+	return models.JobTypeSpec{Name: "flaky-orchestrated"}
+}
+
+func (flakyOrchestratedJob) Validate(map[string]string) error { return nil }
+
+func (flakyOrchestratedJob) Execute(ctx context.Context, run *JobRun) (string, error) {
+	return "handed off", run.RequestOrchestration(ctx)
+}
+
+func (flakyOrchestratedJob) Orchestrate(*JobRun) error {
+	return errors.New("provider unavailable")
+}
+
+func TestOrchestrationFailure_FollowsRetryPolicy(t *testing.T) {
+	svc, db, _ := setupInMemoryJobService(t)
+	if err := svc.RegisterJobType(flakyOrchestratedJob{}); err != nil {
+		t.Fatalf("RegisterJobType failed: %v", err)
+	}
+	svc.SetRetryPolicy("flaky-orchestrated", RetryPolicy{MaxAttempts: 2, BaseDelay: time.Hour, MaxDelay: time.Hour})
+	handler := NewEventHandler(svc, nil, nil)
+	request := func(id string) error {
+		return handler.handleJobRequestedTyped(map[string]interface{}{"type": "job_requested", "job_id": id, "job_type": "flaky-orchestrated"})
+	}
+
+	job := &models.Job{ID: "job-flaky", Type: "flaky-orchestrated", Status: "queued", Attempts: 1, CreatedAt: time.Now()}
+	if err := db.Create(job).Error; err != nil {
+		t.Fatalf("create job failed: %v", err)
+	}
+	_ = request(job.ID)
+	if got, _ := svc.GetJob("", job.ID); got.Status != "retrying" || got.Error != "provider unavailable" {
+		t.Fatalf("expected the failed orchestration to be retried, got status=%s error=%s", got.Status, got.Error)
+	}
+
+	// the last attempt is dead-lettered
+	_ = db.Model(&models.Job{}).Where("id = ?", job.ID).Updates(map[string]interface{}{"status": "queued", "attempts": 2}).Error
+	_ = request(job.ID)
+	if got, _ := svc.GetJob("", job.ID); got.Status != "dead_lettered" {
+		t.Fatalf("expected the exhausted job to be dead-lettered, got %s", got.Status)
+	}
+
+	// a job cancelled after the handoff is not brought back to running
+	cancelled := &models.Job{ID: "job-cancelled", Type: "flaky-orchestrated", Status: "cancelled", Attempts: 1, CreatedAt: time.Now()}
+	if err := db.Create(cancelled).Error; err != nil {
+		t.Fatalf("create job failed: %v", err)
+	}
+	_ = request(cancelled.ID)
+	if got, _ := svc.GetJob("", cancelled.ID); got.Status != "cancelled" {
+		t.Fatalf("expected the cancelled job to stay cancelled, got %s", got.Status)
+	}
+}
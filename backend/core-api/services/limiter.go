@@ -1,6 +1,7 @@
 package services
 
 import (
+	"container/list"
 	"context"
 	"fmt"
 	"math"
@@ -18,6 +19,7 @@ type TokenBucket struct {
 	capacity   float64
 	refillRate float64 // tokens per second
 	last       time.Time
+	now        func() time.Time
 }
 
 // NewTokenBucket creates a bucket with refillRate tokens per second and a max capacity.
@@ -27,6 +29,7 @@ func NewTokenBucket(refillRate float64, capacity float64) *TokenBucket {
 		capacity:   capacity,
 		refillRate: refillRate,
 		last:       time.Now(),
+		now:        time.Now,
 	}
 }
 
@@ -35,16 +38,7 @@ func (tb *TokenBucket) Allow(count int) bool {
 	tb.mu.Lock()
 	defer tb.mu.Unlock()
 
-	now := time.Now()
-	elapsed := now.Sub(tb.last).Seconds()
-	if elapsed > 0 {
-		tb.tokens += elapsed * tb.refillRate
-		if tb.tokens > tb.capacity {
-			tb.tokens = tb.capacity
-		}
-		tb.last = now
-	}
-
+	tb.refill()
 	if tb.tokens >= float64(count) {
 		tb.tokens -= float64(count)
 		return true
@@ -59,7 +53,50 @@ func (tb *TokenBucket) Status() (available float64, capacity float64, refillRate
 	defer tb.mu.Unlock()
 
 	// refresh tokens before reporting
-	now := time.Now()
+	tb.refill()
+	return tb.tokens, tb.capacity, tb.refillRate
+}
+
+// RetryAfter returns the time until count tokens have been refilled.
+func (tb *TokenBucket) RetryAfter(count int) time.Duration {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.refill()
+	need := float64(count) - tb.tokens
+	if need <= 0 {
+		return 0
+	}
+	if float64(count) > tb.capacity || tb.refillRate <= 0 {
+		return -1
+	}
+	return secondsToDuration(need / tb.refillRate)
+}
+
+// refund returns count tokens taken by Allow.
+func (tb *TokenBucket) refund(count int) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.tokens = math.Min(tb.capacity, tb.tokens+float64(count))
+}
+
+// reconfigure applies the rate and capacity of a token bucket cfg, keeping the tokens
+// left (clamped to the new capacity). It returns false if cfg uses another algorithm.
+func (tb *TokenBucket) reconfigure(cfg BucketConfig) bool {
+	if cfg.Algorithm != "" && cfg.Algorithm != AlgorithmTokenBucket {
+		return false
+	}
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.refill()
+	tb.refillRate, tb.capacity = cfg.RefillRate, cfg.Capacity
+	tb.tokens = math.Min(tb.tokens, tb.capacity)
+	return true
+}
+
+// refill adds the tokens accumulated since the last call; tb.mu must be held.
+func (tb *TokenBucket) refill() {
+	now := tb.now()
 	elapsed := now.Sub(tb.last).Seconds()
 	if elapsed > 0 {
 		tb.tokens += elapsed * tb.refillRate
@@ -68,8 +105,12 @@ func (tb *TokenBucket) Status() (available float64, capacity float64, refillRate
 		}
 		tb.last = now
 	}
+}
 
-	return tb.tokens, tb.capacity, tb.refillRate
+// secondsToDuration converts s seconds to a duration, rounding up so that waiting for it
+// is always long enough.
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
 }
 
 // LimiterManager holds named token buckets for the application.
@@ -77,6 +118,13 @@ func (tb *TokenBucket) Status() (available float64, capacity float64, refillRate
 type BucketConfig struct {
 	RefillRate float64
 	Capacity   float64
+	// Algorithm is one of the Algorithm* constants; empty means token bucket
+	Algorithm string
+	// WindowSeconds is the window of the sliding window algorithms (default Capacity/RefillRate)
+	WindowSeconds float64
+	// FailureMode is how Redis-backed buckets decide while Redis is unavailable (see
+	// FailoverLimiter); empty fails closed
+	FailureMode string
 }
 
 // LimiterManager supports named buckets with optional scopes (e.g. user:123 or cluster:abc)
@@ -85,6 +133,9 @@ type BucketConfig struct {
 type RateLimiter interface {
 	Allow(count int) bool
 	Status() (available float64, capacity float64, refillRate float64)
+	// RetryAfter returns how long until count requests would be allowed: 0 if they would be
+	// allowed now, negative if never (count exceeds the capacity or nothing is replenished).
+	RetryAfter(count int) time.Duration
 }
 
 type LimiterManager struct {
@@ -93,6 +144,24 @@ type LimiterManager struct {
 	configs    map[string]BucketConfig           // default configs per name
 	redis      *redis.Client
 	redisTTLMS int64 // ttl for redis keys in milliseconds
+	breaker    *CircuitBreaker
+	instances  int // instances sharing the Redis limits; sizes the local fallback buckets
+
+	// overrides of the default config for single scopes, see SetScopeConfig
+	scopeConfigs map[bucketKey]BucketConfig
+
+	// scoped buckets in LRU order (front = most recently used), see SetEviction
+	lru       *list.List
+	lruIndex  map[bucketKey]*list.Element
+	maxScopes int
+	idleTTL   time.Duration
+	now       func() time.Time
+
+	exportMu sync.Mutex
+	exported map[[3]string]struct{} // rate limit gauge series set by ExportMetrics
+
+	recordMu sync.Mutex
+	records  map[string]*requestRecord // recent requests per limiter name, see RecordRequest
 }
 
 // NewLimiterManager optionally accepts a redis client. If redis is non-nil,
@@ -103,10 +172,30 @@ func NewLimiterManager(redisClient *redis.Client) *LimiterManager {
 		configs:    make(map[string]BucketConfig),
 		redis:      redisClient,
 		redisTTLMS: 60000, // default redis key TTL to keep state for 60s of idle
+		breaker:    NewCircuitBreaker("redis", 0, 0),
+		instances:  1,
+		lru:        list.New(),
+		lruIndex:   make(map[bucketKey]*list.Element),
+		maxScopes:  defaultLimiterMaxScopes,
+		idleTTL:    defaultLimiterIdleTTL,
+		now:        time.Now,
 	}
+	lm.scopeConfigs = make(map[bucketKey]BucketConfig)
 	return lm
 }
 
+// SetFailover replaces the circuit breaker guarding the Redis-backed buckets and sets how
+// many instances share the Redis limits, so local fallback buckets get a proportional
+// share. It applies to buckets created afterwards.
+func (m *LimiterManager) SetFailover(breaker *CircuitBreaker, instances int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.breaker = breaker
+	if instances > 0 {
+		m.instances = instances
+	}
+}
+
 // Add registers a default (global) bucket for name
 func (m *LimiterManager) Add(name string, bucket RateLimiter) {
 	m.mu.Lock()
@@ -133,61 +222,119 @@ func NewRedisTokenBucket(client *redis.Client, name string, scope string, refill
 	return &RedisTokenBucket{client: client, key: key, capacity: capacity, refillRate: refillRate, ttlMS: ttlMS}
 }
 
-// Lua script does atomic token refill and consume
-const redisTokenBucketLua = `local key=KEYS[1]
-local capacity=tonumber(ARGV[1])
-local refill=tonumber(ARGV[2])
-local now=tonumber(ARGV[3])
-local req=tonumber(ARGV[4])
-local ttl=tonumber(ARGV[5])
-local vals=redis.call('HMGET', key, 'tokens', 'last')
-local tokens = tonumber(vals[1])
-if tokens == nil then tokens = capacity end
-local last = tonumber(vals[2])
-if last == nil then last = now end
-local elapsed = (now - last)/1000.0
-tokens = math.min(capacity, tokens + elapsed * refill)
-if tokens >= req then
-  tokens = tokens - req
-  redis.call('HMSET', key, 'tokens', tokens, 'last', now)
-  redis.call('PEXPIRE', key, ttl)
-  return {1, tostring(tokens)}
-else
-  return {0, tostring(tokens)}
-end`
+// luaTokenBucket does atomic token refill and consume on a hash of tokens and last refill
+// time (ms). See the limiter Lua functions in limiterAlgorithmsRedis.go for the signature.
+const luaTokenBucket = `local function token_bucket(key, capacity, refill, window, ttl, now, req, id, peek)
+  local vals=redis.call('HMGET', key, 'tokens', 'last')
+  local tokens = tonumber(vals[1])
+  if tokens == nil then tokens = capacity end
+  local last = tonumber(vals[2])
+  if last == nil then last = now end
+  local elapsed = (now - last)/1000.0
+  tokens = math.min(capacity, tokens + elapsed * refill)
+  if tokens >= req then
+    if peek then
+      return {1, tostring(tokens), 0}
+    end
+    tokens = tokens - req
+    redis.call('HMSET', key, 'tokens', tokens, 'last', now)
+    redis.call('PEXPIRE', key, ttl)
+    return {1, tostring(tokens), 0}
+  end
+  local wait = -1
+  if req <= capacity and refill > 0 then
+    wait = math.ceil((req - tokens) / refill * 1000)
+  end
+  return {0, tostring(tokens), wait}
+end
+`
+
+// Lua script does atomic token refill and consume. ARGV = capacity, refill rate, now (ms),
+// count, ttl (ms), peek; with peek (ARGV[6] = 1) nothing is consumed. Returns
+// {allowed, tokens, ms until count tokens are available or -1 if never}.
+const redisTokenBucketLua = luaTokenBucket + `return token_bucket(KEYS[1], tonumber(ARGV[1]), tonumber(ARGV[2]), 0, tonumber(ARGV[5]), tonumber(ARGV[3]), tonumber(ARGV[4]), '', ARGV[6] == '1')`
 
 // Allow checks and consumes count tokens atomically in Redis.
 func (r *RedisTokenBucket) Allow(count int) bool {
-	ctx := context.Background()
-	now := time.Now().UnixMilli()
-	res, err := r.client.Eval(ctx, redisTokenBucketLua, []string{r.key}, fmt.Sprintf("%f", r.capacity), fmt.Sprintf("%f", r.refillRate), fmt.Sprintf("%d", now), fmt.Sprintf("%d", count), fmt.Sprintf("%d", r.ttlMS)).Result()
+	ok, err := r.allow(count)
 	if err != nil {
 		// fallback deny on error
 		return false
 	}
+	return ok
+}
+
+func (r *RedisTokenBucket) allow(count int) (bool, error) {
+	ctx := context.Background()
+	now := time.Now().UnixMilli()
+	res, err := r.client.Eval(ctx, redisTokenBucketLua, []string{r.key}, fmt.Sprintf("%f", r.capacity), fmt.Sprintf("%f", r.refillRate), fmt.Sprintf("%d", now), fmt.Sprintf("%d", count), fmt.Sprintf("%d", r.ttlMS), "0").Result()
+	if err != nil {
+		return false, err
+	}
 	// result expected as array {1/0, tokens}
 	if arr, ok := res.([]interface{}); ok && len(arr) >= 1 {
 		// first element may be int64 or string
 		switch v := arr[0].(type) {
 		case int64:
-			return v == 1
+			return v == 1, nil
 		case int:
-			return v == 1
+			return v == 1, nil
 		case string:
-			return v == "1"
+			return v == "1", nil
 		case []uint8:
-			return string(v) == "1"
+			return string(v) == "1", nil
 		}
 	}
-	return false
+	return false, fmt.Errorf("unexpected limiter script result %v", res)
+}
+
+// RetryAfter asks the Lua script, without consuming tokens, how long until count tokens
+// are available. Errors report 0 since the limiter state is unknown.
+func (r *RedisTokenBucket) RetryAfter(count int) time.Duration {
+	wait, err := r.retryAfter(count)
+	if err != nil {
+		return 0
+	}
+	return wait
+}
+
+func (r *RedisTokenBucket) retryAfter(count int) (time.Duration, error) {
+	ctx := context.Background()
+	now := time.Now().UnixMilli()
+	res, err := r.client.Eval(ctx, redisTokenBucketLua, []string{r.key}, fmt.Sprintf("%f", r.capacity), fmt.Sprintf("%f", r.refillRate), fmt.Sprintf("%d", now), fmt.Sprintf("%d", count), fmt.Sprintf("%d", r.ttlMS), "1").Slice()
+	if err == nil && len(res) < 3 {
+		err = fmt.Errorf("unexpected limiter script result %v", res)
+	}
+	if err != nil {
+		return 0, err
+	}
+	wait, _ := res[2].(int64)
+	if wait < 0 {
+		return -1, nil
+	}
+	return time.Duration(wait) * time.Millisecond, nil
+}
+
+func (r *RedisTokenBucket) compositeArgs() (string, []interface{}) {
+	return r.key, []interface{}{AlgorithmTokenBucket, fmt.Sprintf("%f", r.capacity), fmt.Sprintf("%f", r.refillRate), 0, r.ttlMS}
+}
+
+func (r *RedisTokenBucket) limits() (capacity float64, refillRate float64) {
+	return r.capacity, r.refillRate
 }
 
 // Status reads stored tokens and computes current value (best-effort)
 func (r *RedisTokenBucket) Status() (available float64, capacity float64, refillRate float64) {
+	available, _ = r.status()
+	return available, r.capacity, r.refillRate
+}
+
+// status returns the available tokens.
+func (r *RedisTokenBucket) status() (float64, error) {
 	ctx := context.Background()
 	vals, err := r.client.HMGet(ctx, r.key, "tokens", "last").Result()
 	if err != nil {
-		return 0, r.capacity, r.refillRate
+		return 0, err
 	}
 	var tokens float64
 	var lastMs int64
@@ -221,8 +368,7 @@ func (r *RedisTokenBucket) Status() (available float64, capacity float64, refill
 	}
 	now := time.Now().UnixMilli()
 	elapsed := float64(now-lastMs) / 1000.0
-	available = math.Min(r.capacity, tokens+elapsed*r.refillRate)
-	return available, r.capacity, r.refillRate
+	return math.Min(r.capacity, tokens+elapsed*r.refillRate), nil
 }
 
 // AddDefaultConfig registers config used when creating scoped buckets dynamically
@@ -232,6 +378,26 @@ func (m *LimiterManager) AddDefaultConfig(name string, cfg BucketConfig) {
 	m.configs[name] = cfg
 }
 
+// SetScopeConfig sizes the bucket of one scope of name, e.g. the project:<id> bucket of a
+// project's limits, with the refill rate and capacity of cfg instead of the default
+// config's; nil removes the override. A changed override takes effect when the bucket is
+// next used. A limiter_config hash stored in Redis for the scope itself still wins.
+func (m *LimiterManager) SetScopeConfig(name, scope string, cfg *BucketConfig) {
+	key := bucketKey{name: name, scope: scope}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	old, had := m.scopeConfigs[key]
+	switch {
+	case cfg == nil && !had, cfg != nil && had && old == *cfg:
+		return
+	case cfg == nil:
+		delete(m.scopeConfigs, key)
+	default:
+		m.scopeConfigs[key] = *cfg
+	}
+	m.dropScope(name, scope)
+}
+
 // Get returns the global bucket (scope "") for name
 func (m *LimiterManager) Get(name string) RateLimiter {
 	m.mu.RLock()
@@ -250,6 +416,7 @@ func (m *LimiterManager) GetOrCreate(name string, scope string) RateLimiter {
 		m.buckets[name] = make(map[string]RateLimiter)
 	}
 	if b, ok := m.buckets[name][scope]; ok {
+		m.touch(name, scope)
 		return b
 	}
 	// create using configured defaults if present, otherwise fall back to sensible defaults
@@ -257,48 +424,33 @@ func (m *LimiterManager) GetOrCreate(name string, scope string) RateLimiter {
 	if cfg.Capacity == 0 {
 		cfg = BucketConfig{RefillRate: 0.2, Capacity: 5}
 	}
+	override, overridden := m.scopeConfigs[bucketKey{name: name, scope: scope}]
+	if overridden {
+		cfg.RefillRate, cfg.Capacity = override.RefillRate, override.Capacity
+	}
 	// If redis client is available, create a Redis-backed bucket
 	if m.redis != nil {
-		// If redis contains explicit limiter config for this name+scope use it
+		// If redis contains explicit limiter config for this name+scope use it,
+		// otherwise try the global config for the name unless the scope has an override
 		ctx := context.Background()
 		configKey := fmt.Sprintf("limiter_config:%s:%s", name, scope)
 		vals, err := m.redis.HGetAll(ctx, configKey).Result()
-		if err == nil && len(vals) > 0 {
-			// parse refill_rate and capacity if present
-			if v, ok := vals["refill_rate"]; ok {
-				if f, err := strconv.ParseFloat(v, 64); err == nil {
-					cfg.RefillRate = f
-				}
-			}
-			if v, ok := vals["capacity"]; ok {
-				if f, err := strconv.ParseFloat(v, 64); err == nil {
-					cfg.Capacity = f
-				}
-			}
-		} else {
-			// try global config key for the name
+		if (err != nil || len(vals) == 0) && !overridden {
 			configKey = fmt.Sprintf("limiter_config:%s:global", name)
-			vals2, err2 := m.redis.HGetAll(ctx, configKey).Result()
-			if err2 == nil && len(vals2) > 0 {
-				if v, ok := vals2["refill_rate"]; ok {
-					if f, err := strconv.ParseFloat(v, 64); err == nil {
-						cfg.RefillRate = f
-					}
-				}
-				if v, ok := vals2["capacity"]; ok {
-					if f, err := strconv.ParseFloat(v, 64); err == nil {
-						cfg.Capacity = f
-					}
-				}
-			}
+			vals, err = m.redis.HGetAll(ctx, configKey).Result()
+		}
+		if err == nil && len(vals) > 0 {
+			applyLimiterConfigHash(&cfg, vals)
 		}
 
-		rb := NewRedisTokenBucket(m.redis, name, scope, cfg.RefillRate, cfg.Capacity, m.redisTTLMS)
+		rb := NewFailoverLimiter(name, NewRedisRateLimiter(m.redis, name, scope, cfg, m.redisTTLMS), cfg.FailureMode, m.instances, m.breaker)
+		m.touch(name, scope)
 		m.buckets[name][scope] = rb
 		return rb
 	}
 
-	b := NewTokenBucket(cfg.RefillRate, cfg.Capacity)
+	b := NewRateLimiter(cfg)
+	m.touch(name, scope)
 	m.buckets[name][scope] = b
 	return b
 }
@@ -310,29 +462,39 @@ func (m *LimiterManager) SnapshotStatus() map[string]map[string]struct {
 	Capacity  float64
 	Refill    float64
 } {
+	// copy the bucket refs under the lock; Status may call Redis, so it runs outside it
+	type scopedBucket struct {
+		name, scope string
+		bucket      RateLimiter
+	}
 	m.mu.RLock()
-	defer m.mu.RUnlock()
+	refs := make([]scopedBucket, 0, len(m.buckets))
+	for name, scopes := range m.buckets {
+		for scope, bucket := range scopes {
+			refs = append(refs, scopedBucket{name, scope, bucket})
+		}
+	}
+	m.mu.RUnlock()
+
 	out := make(map[string]map[string]struct {
 		Available float64
 		Capacity  float64
 		Refill    float64
 	})
-	for name, scopes := range m.buckets {
-		out[name] = make(map[string]struct {
-			Available float64
-			Capacity  float64
-			Refill    float64
-		})
-		for scope, bucket := range scopes {
-			avail, cap, rate := bucket.Status()
-			out[name][scope] = struct {
+	for _, ref := range refs {
+		if out[ref.name] == nil {
+			out[ref.name] = make(map[string]struct {
 				Available float64
 				Capacity  float64
 				Refill    float64
-			}{Available: avail, Capacity: cap, Refill: rate}
-			// Note: available tokens returned separately when exposing metrics
-			_ = avail
+			})
 		}
+		avail, cap, rate := ref.bucket.Status()
+		out[ref.name][ref.scope] = struct {
+			Available float64
+			Capacity  float64
+			Refill    float64
+		}{Available: avail, Capacity: cap, Refill: rate}
 	}
 	return out
 }
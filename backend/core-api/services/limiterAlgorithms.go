@@ -0,0 +1,383 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Rate limiting algorithms selectable per limiter name (BucketConfig.Algorithm or the
+// "algorithm" field of the persisted limiter_config hash).
+const (
+	// AlgorithmTokenBucket refills RefillRate tokens per second up to Capacity; bursts of
+	// Capacity are allowed after idle periods.
+	AlgorithmTokenBucket = "token_bucket"
+	// AlgorithmSlidingWindowLog allows at most Capacity requests in any window, tracking
+	// every request's timestamp. Exact, but memory grows with Capacity.
+	AlgorithmSlidingWindowLog = "sliding_window_log"
+	// AlgorithmSlidingWindowCounter approximates the sliding log from the counts of the
+	// current and previous fixed windows, weighting the previous one by its overlap.
+	AlgorithmSlidingWindowCounter = "sliding_window_counter"
+	// AlgorithmGCRA (generic cell rate algorithm) spaces requests 1/RefillRate apart and
+	// tolerates bursts of Capacity; it behaves like a token bucket but stores one timestamp.
+	AlgorithmGCRA = "gcra"
+)
+
+// ValidLimiterAlgorithm reports whether name is a known algorithm ("" means token bucket).
+func ValidLimiterAlgorithm(name string) bool {
+	switch name {
+	case "", AlgorithmTokenBucket, AlgorithmSlidingWindowLog, AlgorithmSlidingWindowCounter, AlgorithmGCRA:
+		return true
+	}
+	return false
+}
+
+// window returns the window of the sliding window algorithms: WindowSeconds, or the
+// time to refill Capacity at RefillRate so the long-term rate matches the token bucket.
+func (c BucketConfig) window() time.Duration {
+	if c.WindowSeconds > 0 {
+		return time.Duration(c.WindowSeconds * float64(time.Second))
+	}
+	if c.RefillRate > 0 {
+		return time.Duration(c.Capacity / c.RefillRate * float64(time.Second))
+	}
+	return time.Second
+}
+
+// applyLimiterConfigHash overrides cfg with the fields of a persisted limiter_config hash.
+func applyLimiterConfigHash(cfg *BucketConfig, vals map[string]string) {
+	if v, ok := vals["refill_rate"]; ok {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.RefillRate = f
+		}
+	}
+	if v, ok := vals["capacity"]; ok {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.Capacity = f
+		}
+	}
+	if v, ok := vals["algorithm"]; ok && ValidLimiterAlgorithm(v) {
+		cfg.Algorithm = v
+	}
+	if v, ok := vals["window_seconds"]; ok {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.WindowSeconds = f
+		}
+	}
+	if v, ok := vals["failure_mode"]; ok && ValidFailureMode(v) {
+		cfg.FailureMode = v
+	}
+}
+
+// NewRateLimiter creates an in-memory limiter using cfg.Algorithm.
+func NewRateLimiter(cfg BucketConfig) RateLimiter {
+	return newRateLimiterWithClock(cfg, time.Now)
+}
+
+// newRateLimiterWithClock creates an in-memory limiter reading the time from now, e.g. the
+// virtual clock of a simulation.
+func newRateLimiterWithClock(cfg BucketConfig, now func() time.Time) RateLimiter {
+	switch cfg.Algorithm {
+	case AlgorithmSlidingWindowLog:
+		l := NewSlidingWindowLog(cfg.Capacity, cfg.window())
+		l.now = now
+		return l
+	case AlgorithmSlidingWindowCounter:
+		w := NewSlidingWindowCounter(cfg.Capacity, cfg.window())
+		w.now = now
+		return w
+	case AlgorithmGCRA:
+		g := NewGCRA(cfg.RefillRate, cfg.Capacity)
+		g.now = now
+		return g
+	}
+	tb := NewTokenBucket(cfg.RefillRate, cfg.Capacity)
+	tb.now = now
+	tb.last = now()
+	return tb
+}
+
+// SlidingWindowLog allows at most limit requests in any window.
+type SlidingWindowLog struct {
+	mu     sync.Mutex
+	limit  float64
+	window time.Duration
+	log    []time.Time // timestamps of allowed requests, oldest first
+	now    func() time.Time
+}
+
+func NewSlidingWindowLog(limit float64, window time.Duration) *SlidingWindowLog {
+	return &SlidingWindowLog{limit: limit, window: window, now: time.Now}
+}
+
+// prune drops requests that left the window.
+func (l *SlidingWindowLog) prune(now time.Time) {
+	cutoff := now.Add(-l.window)
+	i := 0
+	for i < len(l.log) && !l.log[i].After(cutoff) {
+		i++
+	}
+	l.log = l.log[i:]
+}
+
+func (l *SlidingWindowLog) Allow(count int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.prune(now)
+	if float64(len(l.log)+count) > l.limit {
+		return false
+	}
+	for i := 0; i < count; i++ {
+		l.log = append(l.log, now)
+	}
+	return true
+}
+
+// Status reports the requests left in the current window; the rate is limit/window.
+func (l *SlidingWindowLog) Status() (available float64, capacity float64, refillRate float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prune(l.now())
+	return l.limit - float64(len(l.log)), l.limit, l.limit / l.window.Seconds()
+}
+
+// RetryAfter returns the time until enough logged requests have left the window.
+func (l *SlidingWindowLog) RetryAfter(count int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.prune(now)
+	excess := int(math.Ceil(float64(len(l.log)+count) - l.limit))
+	if excess <= 0 {
+		return 0
+	}
+	if float64(count) > l.limit {
+		return -1
+	}
+	// the excess-th oldest request has to leave the window
+	return l.log[excess-1].Add(l.window).Sub(now)
+}
+
+// refund forgets the count most recent requests.
+func (l *SlidingWindowLog) refund(count int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if count > len(l.log) {
+		count = len(l.log)
+	}
+	l.log = l.log[:len(l.log)-count]
+}
+
+// reconfigure applies the limit and window of a sliding window log cfg, keeping the
+// logged requests; if more than the new limit are logged only the newest are kept.
+func (l *SlidingWindowLog) reconfigure(cfg BucketConfig) bool {
+	if cfg.Algorithm != AlgorithmSlidingWindowLog {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit, l.window = cfg.Capacity, cfg.window()
+	l.prune(l.now())
+	if excess := len(l.log) - int(math.Max(0, l.limit)); excess > 0 {
+		l.log = l.log[excess:]
+	}
+	return true
+}
+
+// SlidingWindowCounter estimates the requests in the last window as the current fixed
+// window's count plus the previous window's count scaled by how much of it still overlaps.
+type SlidingWindowCounter struct {
+	mu     sync.Mutex
+	limit  float64
+	window time.Duration
+	index  int64 // current fixed window: unix time / window
+	cur    float64
+	prev   float64
+	now    func() time.Time
+}
+
+func NewSlidingWindowCounter(limit float64, window time.Duration) *SlidingWindowCounter {
+	return &SlidingWindowCounter{limit: limit, window: window, now: time.Now}
+}
+
+// estimate rolls the fixed windows forward and returns the weighted request count.
+func (w *SlidingWindowCounter) estimate(now time.Time) float64 {
+	idx := now.UnixNano() / int64(w.window)
+	switch {
+	case idx == w.index+1:
+		w.prev, w.cur = w.cur, 0
+	case idx > w.index+1:
+		w.prev, w.cur = 0, 0
+	}
+	w.index = idx
+	elapsed := float64(now.UnixNano()-idx*int64(w.window)) / float64(w.window)
+	return w.prev*(1-elapsed) + w.cur
+}
+
+func (w *SlidingWindowCounter) Allow(count int) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.estimate(w.now())+float64(count) > w.limit {
+		return false
+	}
+	w.cur += float64(count)
+	return true
+}
+
+func (w *SlidingWindowCounter) Status() (available float64, capacity float64, refillRate float64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return math.Max(0, w.limit-w.estimate(w.now())), w.limit, w.limit / w.window.Seconds()
+}
+
+// RetryAfter returns the time until the estimate leaves room for count requests. The
+// previous window's weight fades linearly; if that is not enough the current window has
+// to become the previous one and fade in turn.
+func (w *SlidingWindowCounter) RetryAfter(count int) time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := w.now()
+	req := float64(count)
+	if w.estimate(now)+req <= w.limit {
+		return 0
+	}
+	if req > w.limit {
+		return -1
+	}
+	elapsed := float64(now.UnixNano()-w.index*int64(w.window)) / float64(w.window)
+	var wait float64 // in windows
+	if w.prev > 0 && w.cur+req <= w.limit {
+		wait = 1 - (w.limit-w.cur-req)/w.prev - elapsed
+	} else {
+		wait = 1 - elapsed
+		if w.cur > 0 {
+			wait += math.Max(0, 1-(w.limit-req)/w.cur)
+		}
+	}
+	return time.Duration(math.Ceil(wait * float64(w.window)))
+}
+
+func (w *SlidingWindowCounter) refund(count int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.cur = math.Max(0, w.cur-float64(count))
+}
+
+// reconfigure applies the limit and window of a sliding window counter cfg. The fixed
+// windows change, so the current estimate (clamped to the new limit) becomes the count of
+// the new current window.
+func (w *SlidingWindowCounter) reconfigure(cfg BucketConfig) bool {
+	if cfg.Algorithm != AlgorithmSlidingWindowCounter {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := w.now()
+	used := w.estimate(now)
+	w.limit, w.window = cfg.Capacity, cfg.window()
+	w.index = now.UnixNano() / int64(w.window)
+	w.prev, w.cur = 0, math.Min(used, w.limit)
+	return true
+}
+
+// GCRA tracks the theoretical arrival time (tat) of the next request. A request is
+// allowed while tat stays within burst emission intervals of now.
+type GCRA struct {
+	mu       sync.Mutex
+	interval time.Duration // emission interval: 1/rate
+	burst    float64
+	tat      time.Time
+	now      func() time.Time
+}
+
+func NewGCRA(rate float64, burst float64) *GCRA {
+	// without a rate nothing is replenished after the burst; a year is close enough
+	interval := 365 * 24 * time.Hour
+	if rate > 0 {
+		interval = time.Duration(float64(time.Second) / rate)
+	}
+	return &GCRA{interval: interval, burst: burst, now: time.Now}
+}
+
+func (g *GCRA) Allow(count int) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	tat := g.tat
+	if tat.Before(now) {
+		tat = now
+	}
+	newTat := tat.Add(time.Duration(count) * g.interval)
+	if float64(newTat.Sub(now)) > g.burst*float64(g.interval) {
+		return false
+	}
+	g.tat = newTat
+	return true
+}
+
+func (g *GCRA) Status() (available float64, capacity float64, refillRate float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	backlog := 0.0
+	if now := g.now(); g.tat.After(now) {
+		backlog = float64(g.tat.Sub(now)) / float64(g.interval)
+	}
+	return math.Max(0, g.burst-backlog), g.burst, float64(time.Second) / float64(g.interval)
+}
+
+// RetryAfter returns the time until tat has moved close enough to now for count requests.
+func (g *GCRA) RetryAfter(count int) time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	tat := g.tat
+	if tat.Before(now) {
+		tat = now
+	}
+	allowAt := tat.Add(time.Duration((float64(count) - g.burst) * float64(g.interval)))
+	if !allowAt.After(now) {
+		return 0
+	}
+	if float64(count) > g.burst {
+		return -1
+	}
+	return allowAt.Sub(now)
+}
+
+// refund moves tat back by the count requests taken by Allow.
+func (g *GCRA) refund(count int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.tat = g.tat.Add(-time.Duration(count) * g.interval)
+}
+
+// reconfigure applies the rate and burst of a GCRA cfg, keeping the backlog of requests
+// (clamped to the new burst).
+func (g *GCRA) reconfigure(cfg BucketConfig) bool {
+	if cfg.Algorithm != AlgorithmGCRA {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	backlog := 0.0
+	if g.tat.After(now) {
+		backlog = float64(g.tat.Sub(now)) / float64(g.interval)
+	}
+	next := NewGCRA(cfg.RefillRate, cfg.Capacity)
+	g.interval, g.burst = next.interval, next.burst
+	g.tat = now.Add(time.Duration(math.Min(backlog, g.burst) * float64(g.interval)))
+	return true
+}
+
+// limiterKey is the Redis key of a limiter's state; token buckets keep the original
+// limiter:<name>:<scope> layout.
+func limiterKey(algorithm, name, scope string) string {
+	if algorithm == "" || algorithm == AlgorithmTokenBucket {
+		return fmt.Sprintf("limiter:%s:%s", name, scope)
+	}
+	return fmt.Sprintf("limiter:%s:%s:%s", algorithm, name, scope)
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// NewRedisRateLimiter creates a Redis-backed limiter using cfg.Algorithm, so every
+// core-api instance shares the limiter state.
+func NewRedisRateLimiter(client *redis.Client, name string, scope string, cfg BucketConfig, ttlMS int64) RateLimiter {
+	switch cfg.Algorithm {
+	case AlgorithmSlidingWindowLog, AlgorithmSlidingWindowCounter, AlgorithmGCRA:
+		return &RedisScriptLimiter{
+			client:    client,
+			key:       limiterKey(cfg.Algorithm, name, scope),
+			algorithm: cfg.Algorithm,
+			capacity:  cfg.Capacity,
+			rate:      cfg.RefillRate,
+			windowMS:  cfg.window().Milliseconds(),
+		}
+	}
+	return NewRedisTokenBucket(client, name, scope, cfg.RefillRate, cfg.Capacity, ttlMS)
+}
+
+// The limiters are written as Lua functions so the single limiter scripts and the
+// composite script (limiterComposite.go) share them. Each takes
+// (key, capacity, rate per second, window ms, ttl ms, now ms, count, request id, peek) and
+// returns {allowed, available, wait} where wait is the ms until count requests would be
+// allowed (-1 if never). With peek or a count of 0 nothing is recorded.
+
+// luaSlidingWindowLog keeps one sorted-set member per allowed request, scored by its time.
+const luaSlidingWindowLog = `local function sliding_window_log(key, limit, rate, window, ttl, now, req, id, peek)
+  redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+  local count = redis.call('ZCARD', key)
+  if count + req <= limit then
+    if req == 0 or peek then
+      return {1, tostring(limit - count), 0}
+    end
+    for i = 1, req do
+      redis.call('ZADD', key, now, id .. ':' .. i)
+    end
+    redis.call('PEXPIRE', key, window)
+    return {1, tostring(limit - count - req), 0}
+  end
+  local wait = -1
+  if req <= limit then
+    -- the excess-th oldest request has to leave the window
+    local excess = math.ceil(count + req - limit)
+    local oldest = redis.call('ZRANGE', key, excess - 1, excess - 1, 'WITHSCORES')
+    wait = math.max(0, tonumber(oldest[2]) + window - now)
+  end
+  return {0, tostring(limit - count), wait}
+end
+`
+
+// luaSlidingWindowCounter stores the current fixed window index and the counts of the
+// current and previous windows in a hash.
+const luaSlidingWindowCounter = `local function sliding_window_counter(key, limit, rate, window, ttl, now, req, id, peek)
+  local idx = math.floor(now / window)
+  local vals = redis.call('HMGET', key, 'w', 'cur', 'prev')
+  local w = tonumber(vals[1]) or idx
+  local cur = tonumber(vals[2]) or 0
+  local prev = tonumber(vals[3]) or 0
+  if idx == w + 1 then
+    prev = cur
+    cur = 0
+  elseif idx > w + 1 then
+    prev = 0
+    cur = 0
+  end
+  local elapsed = (now - idx * window) / window
+  local estimate = prev * (1 - elapsed) + cur
+  if estimate + req <= limit then
+    if req == 0 or peek then
+      return {1, tostring(math.max(0, limit - estimate)), 0}
+    end
+    cur = cur + req
+    redis.call('HSET', key, 'w', idx, 'cur', cur, 'prev', prev)
+    redis.call('PEXPIRE', key, 2 * window)
+    return {1, tostring(math.max(0, limit - estimate - req)), 0}
+  end
+  local wait = -1
+  if req <= limit then
+    -- the previous window fades first; if that is not enough the current one has to fade too
+    if prev > 0 and cur + req <= limit then
+      wait = (1 - (limit - cur - req) / prev - elapsed) * window
+    else
+      wait = (1 - elapsed) * window
+      if cur > 0 then
+        wait = wait + math.max(0, 1 - (limit - req) / cur) * window
+      end
+    end
+    wait = math.ceil(wait)
+  end
+  return {0, tostring(math.max(0, limit - estimate)), wait}
+end
+`
+
+// luaGCRA stores the theoretical arrival time (ms) of the next request.
+const luaGCRA = `local function gcra(key, burst, rate, window, ttl, now, req, id, peek)
+  local interval = 31536000000
+  if rate > 0 then interval = 1000 / rate end
+  local tat = tonumber(redis.call('GET', key)) or now
+  if tat < now then tat = now end
+  local available = burst - (tat - now) / interval
+  local allowAt = tat + (req - burst) * interval
+  if allowAt <= now then
+    if req == 0 or peek then
+      return {1, tostring(math.max(0, available)), 0}
+    end
+    local newTat = tat + req * interval
+    redis.call('SET', key, tostring(newTat), 'PX', math.ceil(newTat - now))
+    return {1, tostring(math.max(0, available - req)), 0}
+  end
+  local wait = -1
+  if req <= burst then
+    wait = math.ceil(allowAt - now)
+  end
+  return {0, tostring(math.max(0, available)), wait}
+end
+`
+
+// scriptLimiterCall calls fn with KEYS[1] and ARGV = capacity, rate, window (ms), now (ms),
+// count, request id, peek.
+func scriptLimiterCall(fn string) string {
+	return "return " + fn + "(KEYS[1], tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3]), 0, tonumber(ARGV[4]), tonumber(ARGV[5]), ARGV[6], ARGV[7] == '1')"
+}
+
+var (
+	redisSlidingWindowLogLua     = redis.NewScript(luaSlidingWindowLog + scriptLimiterCall("sliding_window_log"))
+	redisSlidingWindowCounterLua = redis.NewScript(luaSlidingWindowCounter + scriptLimiterCall("sliding_window_counter"))
+	redisGCRALua                 = redis.NewScript(luaGCRA + scriptLimiterCall("gcra"))
+)
+
+// RedisScriptLimiter runs one of the sliding window or GCRA Lua scripts atomically in Redis.
+type RedisScriptLimiter struct {
+	client    *redis.Client
+	key       string
+	algorithm string
+	capacity  float64
+	rate      float64
+	windowMS  int64
+}
+
+func (r *RedisScriptLimiter) script() *redis.Script {
+	switch r.algorithm {
+	case AlgorithmSlidingWindowLog:
+		return redisSlidingWindowLogLua
+	case AlgorithmSlidingWindowCounter:
+		return redisSlidingWindowCounterLua
+	}
+	return redisGCRALua
+}
+
+func (r *RedisScriptLimiter) run(count int, peek bool) (bool, float64, time.Duration, error) {
+	peekArg := "0"
+	if peek {
+		peekArg = "1"
+	}
+	args := []interface{}{
+		strconv.FormatFloat(r.capacity, 'f', -1, 64),
+		strconv.FormatFloat(r.rate, 'f', -1, 64),
+		r.windowMS,
+		time.Now().UnixMilli(),
+		count,
+		uuid.NewString(),
+		peekArg,
+	}
+	res, err := r.script().Run(context.Background(), r.client, []string{r.key}, args...).Slice()
+	if err != nil {
+		return false, 0, 0, err
+	}
+	if len(res) < 3 {
+		return false, 0, 0, fmt.Errorf("unexpected limiter script result %v", res)
+	}
+	allowed, _ := res[0].(int64)
+	available, _ := strconv.ParseFloat(fmt.Sprint(res[1]), 64)
+	wait, _ := res[2].(int64)
+	if wait < 0 {
+		return allowed == 1, available, -1, nil
+	}
+	return allowed == 1, available, time.Duration(wait) * time.Millisecond, nil
+}
+
+func (r *RedisScriptLimiter) compositeArgs() (string, []interface{}) {
+	return r.key, []interface{}{
+		r.algorithm,
+		strconv.FormatFloat(r.capacity, 'f', -1, 64),
+		strconv.FormatFloat(r.rate, 'f', -1, 64),
+		r.windowMS,
+		0,
+	}
+}
+
+// limits returns the capacity and the rate reported by Status; the rate of the window
+// algorithms is capacity/window.
+func (r *RedisScriptLimiter) limits() (capacity float64, refillRate float64) {
+	if r.algorithm != AlgorithmGCRA && r.windowMS > 0 {
+		return r.capacity, r.capacity / (float64(r.windowMS) / 1000)
+	}
+	return r.capacity, r.rate
+}
+
+// Allow checks and records count requests atomically in Redis.
+func (r *RedisScriptLimiter) Allow(count int) bool {
+	ok, _, _, err := r.run(count, false)
+	if err != nil {
+		// fallback deny on error
+		return false
+	}
+	return ok
+}
+
+func (r *RedisScriptLimiter) allow(count int) (bool, error) {
+	ok, _, _, err := r.run(count, false)
+	return ok, err
+}
+
+// Status reports the remaining capacity.
+func (r *RedisScriptLimiter) Status() (available float64, capacity float64, refillRate float64) {
+	capacity, rate := r.limits()
+	available, _ = r.status()
+	return available, capacity, rate
+}
+
+// status returns the remaining capacity.
+func (r *RedisScriptLimiter) status() (float64, error) {
+	_, available, _, err := r.run(0, true)
+	if err != nil {
+		return 0, err
+	}
+	return available, nil
+}
+
+// RetryAfter asks the script, without recording anything, how long until count requests
+// would be allowed. Errors report 0 since the limiter state is unknown.
+func (r *RedisScriptLimiter) RetryAfter(count int) time.Duration {
+	wait, err := r.retryAfter(count)
+	if err != nil {
+		return 0
+	}
+	return wait
+}
+
+func (r *RedisScriptLimiter) retryAfter(count int) (time.Duration, error) {
+	_, _, wait, err := r.run(count, true)
+	return wait, err
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// LimitLevel is one level of a hierarchical limit.
+type LimitLevel struct {
+	Level   string // global, project, user or cluster; reported when this level rejects a request
+	Limiter RateLimiter
+}
+
+// LimitDecision is the outcome of CompositeLimiter.Take.
+type LimitDecision struct {
+	Allowed bool
+	// Level is the level that rejected the request or, when it was allowed, the level with
+	// the fewest requests left. Available, Capacity and Rate are that level's status.
+	Level     string
+	Available float64
+	Capacity  float64
+	Rate      float64
+	// RetryAfter is, for rejected requests, the time until every level would allow the
+	// request; negative if some level never will.
+	RetryAfter time.Duration
+}
+
+// CompositeLimiter enforces several limiters together: a request is counted by every
+// level or, if any level rejects it, by none.
+type CompositeLimiter struct {
+	levels  []LimitLevel
+	redis   *redis.Client
+	breaker *CircuitBreaker
+}
+
+// NewCompositeLimiter combines levels, checked in order. When every level is Redis-backed
+// they are checked and consumed by one Lua script; otherwise, or while Redis is unavailable,
+// they are consumed one by one (Redis levels deciding by their failure mode) and the
+// earlier levels are refunded when a later one rejects the request.
+func NewCompositeLimiter(client *redis.Client, levels ...LimitLevel) *CompositeLimiter {
+	return &CompositeLimiter{levels: levels, redis: client}
+}
+
+// Composite combines scoped buckets of the manager into a CompositeLimiter that shares the
+// manager's circuit breaker.
+func (m *LimiterManager) Composite(levels ...LimitLevel) *CompositeLimiter {
+	c := NewCompositeLimiter(m.redis, levels...)
+	m.mu.RLock()
+	c.breaker = m.breaker
+	m.mu.RUnlock()
+	return c
+}
+
+// refunder is an in-memory limiter that can give back requests taken by Allow.
+type refunder interface {
+	refund(count int)
+}
+
+// partialRefunder is a limiter that can give back only some of the requests it allowed,
+// e.g. a FailoverLimiter, whose local bucket decides only while Redis is unavailable.
+type partialRefunder interface {
+	refunder
+	// take works like Allow and also reports whether refund can give the requests back.
+	take(count int) (allowed bool, refundable bool)
+}
+
+// take runs Allow on l and reports whether the requests it took can be refunded.
+func take(l RateLimiter, count int) (allowed bool, refundable bool) {
+	if p, ok := l.(partialRefunder); ok {
+		return p.take(count)
+	}
+	_, ok := l.(refunder)
+	return l.Allow(count), ok
+}
+
+// redisCompositeLevel is a Redis limiter that can take part in the composite script.
+type redisCompositeLevel interface {
+	// compositeArgs returns the key and the algorithm, capacity, rate, window (ms) and ttl (ms).
+	compositeArgs() (string, []interface{})
+	// limits returns the capacity and rate reported by Status.
+	limits() (capacity float64, refillRate float64)
+}
+
+// redisCompositeLua checks every level with peek first and only consumes from all of them
+// if none rejects. KEYS are the level keys; ARGV = now (ms), count, request id, then five
+// values per level: algorithm, capacity, rate, window (ms), ttl (ms). Returns
+// {allowed, level index, available, wait} as described by LimitDecision.
+var redisCompositeLua = redis.NewScript(luaTokenBucket + luaSlidingWindowLog + luaSlidingWindowCounter + luaGCRA + `local now=tonumber(ARGV[1])
+local req=tonumber(ARGV[2])
+local id=ARGV[3]
+local algorithms = {token_bucket=token_bucket, sliding_window_log=sliding_window_log, sliding_window_counter=sliding_window_counter, gcra=gcra}
+local function level(i, peek)
+  local b = 3 + (i - 1) * 5
+  return algorithms[ARGV[b + 1]](KEYS[i], tonumber(ARGV[b + 2]), tonumber(ARGV[b + 3]), tonumber(ARGV[b + 4]), tonumber(ARGV[b + 5]), now, req, id, peek)
+end
+local rejected = 0
+local available = '0'
+local wait = 0
+for i = 1, #KEYS do
+  local res = level(i, true)
+  if res[1] == 0 then
+    if rejected == 0 then
+      rejected = i
+      available = res[2]
+    end
+    if res[3] < 0 or wait < 0 then
+      wait = -1
+    else
+      wait = math.max(wait, res[3])
+    end
+  end
+end
+if rejected > 0 then
+  return {0, rejected, available, wait}
+end
+local least = 0
+for i = 1, #KEYS do
+  local res = level(i, false)
+  if least == 0 or tonumber(res[2]) < tonumber(available) then
+    least = i
+    available = res[2]
+  end
+end
+return {1, least, available, 0}`)
+
+// Take counts count requests against every level, or against none if a level rejects them.
+func (c *CompositeLimiter) Take(count int) LimitDecision {
+	if len(c.levels) == 0 {
+		return LimitDecision{Allowed: true}
+	}
+	if c.redis != nil {
+		if levels, ok := c.redisLevels(); ok {
+			if d, err := c.takeRedis(levels, count); err == nil {
+				return d
+			}
+		}
+	}
+
+	refundable := make([]bool, len(c.levels))
+	for i, l := range c.levels {
+		var allowed bool
+		if allowed, refundable[i] = take(l.Limiter, count); allowed {
+			continue
+		}
+		for j, prev := range c.levels[:i] {
+			if refundable[j] {
+				prev.Limiter.(refunder).refund(count)
+			}
+		}
+		d := LimitDecision{Level: l.Level, RetryAfter: c.retryAfter(count)}
+		d.Available, d.Capacity, d.Rate = l.Limiter.Status()
+		return d
+	}
+	d := LimitDecision{Allowed: true}
+	for i, l := range c.levels {
+		available, capacity, rate := l.Limiter.Status()
+		if i == 0 || available < d.Available {
+			d.Level, d.Available, d.Capacity, d.Rate = l.Level, available, capacity, rate
+		}
+	}
+	return d
+}
+
+// retryAfter is the longest wait of all levels; negative if any level never allows count.
+func (c *CompositeLimiter) retryAfter(count int) time.Duration {
+	var longest time.Duration
+	for _, l := range c.levels {
+		wait := l.Limiter.RetryAfter(count)
+		if wait < 0 {
+			return -1
+		}
+		if wait > longest {
+			longest = wait
+		}
+	}
+	return longest
+}
+
+func (c *CompositeLimiter) redisLevels() ([]redisCompositeLevel, bool) {
+	levels := make([]redisCompositeLevel, len(c.levels))
+	for i, l := range c.levels {
+		rl, ok := l.Limiter.(redisCompositeLevel)
+		if !ok {
+			return nil, false
+		}
+		levels[i] = rl
+	}
+	return levels, true
+}
+
+func (c *CompositeLimiter) takeRedis(levels []redisCompositeLevel, count int) (LimitDecision, error) {
+	if c.breaker != nil && !c.breaker.Allow() {
+		return LimitDecision{}, errCircuitOpen
+	}
+	keys := make([]string, len(levels))
+	args := []interface{}{time.Now().UnixMilli(), count, uuid.NewString()}
+	for i, l := range levels {
+		key, levelArgs := l.compositeArgs()
+		keys[i] = key
+		args = append(args, levelArgs...)
+	}
+	res, err := redisCompositeLua.Run(context.Background(), c.redis, keys, args...).Slice()
+	if err == nil && len(res) < 4 {
+		err = fmt.Errorf("unexpected limiter script result %v", res)
+	}
+	if c.breaker != nil {
+		c.breaker.Record(err)
+	}
+	if err != nil {
+		if LimiterBackendErrors != nil {
+			LimiterBackendErrors.WithLabelValues("composite").Inc()
+		}
+		return LimitDecision{}, err
+	}
+	allowed, _ := res[0].(int64)
+	idx, _ := res[1].(int64)
+	if idx < 1 || int(idx) > len(levels) {
+		idx = 1
+	}
+	d := LimitDecision{Allowed: allowed == 1, Level: c.levels[idx-1].Level}
+	d.Available, _ = strconv.ParseFloat(fmt.Sprint(res[2]), 64)
+	d.Capacity, d.Rate = levels[idx-1].limits()
+	if wait, _ := res[3].(int64); wait < 0 {
+		d.RetryAfter = -1
+	} else {
+		d.RetryAfter = time.Duration(wait) * time.Millisecond
+	}
+	return d, nil
+}
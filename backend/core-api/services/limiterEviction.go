@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Defaults bounding the scoped buckets a LimiterManager keeps.
+const (
+	defaultLimiterMaxScopes = 10000
+	defaultLimiterIdleTTL   = 10 * time.Minute
+	// LimiterOtherScope is the scope_id label aggregating the scopes outside the top N.
+	LimiterOtherScope = "other"
+)
+
+// bucketKey identifies a scoped bucket.
+type bucketKey struct {
+	name  string
+	scope string
+}
+
+// scopedBucketEntry is an element of the manager's LRU list of scoped buckets.
+type scopedBucketEntry struct {
+	key      bucketKey
+	lastUsed time.Time
+}
+
+// SetEviction bounds the scoped (user:/cluster:) buckets: at most maxScopes are kept, the
+// least recently used one being dropped to make room, and buckets unused for idleTTL are
+// dropped by EvictIdle. A dropped bucket is recreated from its config on next use; in-memory
+// buckets start full again, Redis-backed ones keep their state in Redis. Non-positive values
+// keep the current setting. Global buckets are never evicted.
+func (m *LimiterManager) SetEviction(maxScopes int, idleTTL time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if maxScopes > 0 {
+		m.maxScopes = maxScopes
+	}
+	if idleTTL > 0 {
+		m.idleTTL = idleTTL
+	}
+	for m.lru.Len() > m.maxScopes {
+		m.evictOldest("capacity")
+	}
+}
+
+// ScopeCount returns how many scoped buckets are tracked.
+func (m *LimiterManager) ScopeCount() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.lru.Len()
+}
+
+// touch marks a scoped bucket as just used, tracking it if new and evicting the least
+// recently used bucket when over the cap. Callers hold m.mu.
+func (m *LimiterManager) touch(name, scope string) {
+	if scope == "" || scope == "global" {
+		return
+	}
+	key := bucketKey{name: name, scope: scope}
+	if el, ok := m.lruIndex[key]; ok {
+		el.Value.(*scopedBucketEntry).lastUsed = m.now()
+		m.lru.MoveToFront(el)
+		return
+	}
+	for m.lru.Len() >= m.maxScopes {
+		m.evictOldest("capacity")
+	}
+	m.lruIndex[key] = m.lru.PushFront(&scopedBucketEntry{key: key, lastUsed: m.now()})
+}
+
+// evictOldest drops the least recently used scoped bucket. Callers hold m.mu.
+func (m *LimiterManager) evictOldest(reason string) {
+	el := m.lru.Back()
+	if el == nil {
+		return
+	}
+	key := el.Value.(*scopedBucketEntry).key
+	m.dropScope(key.name, key.scope)
+	if LimiterEvictions != nil {
+		LimiterEvictions.WithLabelValues(key.name, reason).Inc()
+	}
+}
+
+// dropScope removes a scoped bucket and its LRU entry. Callers hold m.mu.
+func (m *LimiterManager) dropScope(name, scope string) {
+	delete(m.buckets[name], scope)
+	key := bucketKey{name: name, scope: scope}
+	if el, ok := m.lruIndex[key]; ok {
+		m.lru.Remove(el)
+		delete(m.lruIndex, key)
+	}
+}
+
+// EvictIdle drops the scoped buckets unused for the idle TTL and returns how many.
+func (m *LimiterManager) EvictIdle() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cutoff := m.now().Add(-m.idleTTL)
+	evicted := 0
+	for el := m.lru.Back(); el != nil && !el.Value.(*scopedBucketEntry).lastUsed.After(cutoff); el = m.lru.Back() {
+		m.evictOldest("idle")
+		evicted++
+	}
+	return evicted
+}
+
+// RunEviction calls EvictIdle every half idle TTL until ctx is cancelled.
+func (m *LimiterManager) RunEviction(ctx context.Context) {
+	m.mu.RLock()
+	interval := m.idleTTL / 2
+	m.mu.RUnlock()
+	if interval < time.Second {
+		interval = time.Second
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			m.EvictIdle()
+		}
+	}
+}
+
+// limiterScopeLabels splits a bucket scope into the scope_type and scope_id metric labels.
+func limiterScopeLabels(scope string) (scopeType, scopeID string) {
+	switch {
+	case scope == "":
+		return "global", ""
+	case strings.HasPrefix(scope, "user:"):
+		return "user", strings.TrimPrefix(scope, "user:")
+	case strings.HasPrefix(scope, "cluster:"):
+		return "cluster", strings.TrimPrefix(scope, "cluster:")
+	case strings.HasPrefix(scope, "project:"):
+		return "project", strings.TrimPrefix(scope, "project:")
+	}
+	return "global", scope
+}
+
+// ExportMetrics sets clustergenie_rate_limit_available_tokens and
+// clustergenie_limiter_tracked_scopes from the current buckets. To keep label cardinality
+// bounded, per limiter and scope type only the topN scopes with the fewest available
+// tokens (relative to capacity) get their own series; the rest are averaged into a single
+// series with scope_id "other". Series of scopes that dropped out are deleted.
+func (m *LimiterManager) ExportMetrics(topN int) {
+	if RateLimitAvailable == nil {
+		return
+	}
+	type scopeStatus struct {
+		id        string
+		available float64
+		fill      float64
+	}
+	groups := make(map[[2]string][]scopeStatus) // name, scope type -> scopes
+	for name, scopes := range m.SnapshotStatus() {
+		for scope, s := range scopes {
+			scopeType, scopeID := limiterScopeLabels(scope)
+			fill := 1.0
+			if s.Capacity > 0 {
+				fill = s.Available / s.Capacity
+			}
+			g := [2]string{name, scopeType}
+			groups[g] = append(groups[g], scopeStatus{id: scopeID, available: s.Available, fill: fill})
+		}
+	}
+
+	exported := make(map[[3]string]struct{})
+	for g, statuses := range groups {
+		sort.Slice(statuses, func(i, j int) bool {
+			if statuses[i].fill != statuses[j].fill {
+				return statuses[i].fill < statuses[j].fill
+			}
+			return statuses[i].id < statuses[j].id
+		})
+		if LimiterTrackedScopes != nil && g[1] != "global" {
+			LimiterTrackedScopes.WithLabelValues(g[0], g[1]).Set(float64(len(statuses)))
+		}
+		var rest float64
+		for i, s := range statuses {
+			if topN > 0 && i >= topN {
+				rest += s.available
+				continue
+			}
+			RateLimitAvailable.WithLabelValues(g[0], g[1], s.id).Set(s.available)
+			exported[[3]string{g[0], g[1], s.id}] = struct{}{}
+		}
+		if topN > 0 && len(statuses) > topN {
+			RateLimitAvailable.WithLabelValues(g[0], g[1], LimiterOtherScope).Set(rest / float64(len(statuses)-topN))
+			exported[[3]string{g[0], g[1], LimiterOtherScope}] = struct{}{}
+		}
+	}
+
+	m.exportMu.Lock()
+	defer m.exportMu.Unlock()
+	for series := range m.exported {
+		if _, ok := exported[series]; !ok {
+			RateLimitAvailable.DeleteLabelValues(series[0], series[1], series[2])
+		}
+	}
+	if LimiterTrackedScopes != nil {
+		for series := range m.exported {
+			if series[1] != "global" && groups[[2]string{series[0], series[1]}] == nil {
+				LimiterTrackedScopes.WithLabelValues(series[0], series[1]).Set(0)
+			}
+		}
+	}
+	m.exported = exported
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"math"
+	"time"
+)
+
+// Failure modes decide requests while a Redis limiter cannot reach Redis.
+const (
+	// FailClosed rejects every request (the behaviour of the bare Redis limiters)
+	FailClosed = "fail_closed"
+	// FailOpen allows every request
+	FailOpen = "fail_open"
+	// FailLocal decides with an in-memory token bucket holding this instance's share of the limit
+	FailLocal = "local"
+)
+
+// ValidFailureMode reports whether mode is one of the failure modes.
+func ValidFailureMode(mode string) bool {
+	switch mode {
+	case FailClosed, FailOpen, FailLocal:
+		return true
+	}
+	return false
+}
+
+// redisBackedLimiter is a Redis limiter that reports backend errors instead of hiding them.
+type redisBackedLimiter interface {
+	RateLimiter
+	redisCompositeLevel
+	allow(count int) (bool, error)
+	retryAfter(count int) (time.Duration, error)
+	status() (float64, error)
+}
+
+// FailoverLimiter guards a Redis limiter with a circuit breaker and falls back to its
+// failure mode while Redis errors or the circuit is open.
+type FailoverLimiter struct {
+	name    string
+	backend redisBackedLimiter
+	mode    string
+	local   *TokenBucket // FailLocal only
+	breaker *CircuitBreaker
+}
+
+// NewFailoverLimiter wraps a limiter created by NewRedisRateLimiter; other limiters are
+// returned unchanged. In FailLocal mode the fallback bucket gets 1/instances of the
+// capacity and rate, so all instances together stay close to the shared limit. An empty
+// mode fails closed; breaker may be nil.
+func NewFailoverLimiter(name string, backend RateLimiter, mode string, instances int, breaker *CircuitBreaker) RateLimiter {
+	rb, ok := backend.(redisBackedLimiter)
+	if !ok {
+		return backend
+	}
+	if !ValidFailureMode(mode) {
+		mode = FailClosed
+	}
+	f := &FailoverLimiter{name: name, backend: rb, mode: mode, breaker: breaker}
+	if mode == FailLocal {
+		if instances < 1 {
+			instances = 1
+		}
+		capacity, rate := rb.limits()
+		f.local = NewTokenBucket(rate/float64(instances), math.Max(1, capacity/float64(instances)))
+	}
+	return f
+}
+
+// call runs fn against Redis unless the circuit is open; false means Redis did not decide.
+func (f *FailoverLimiter) call(fn func() error) bool {
+	if f.breaker != nil && !f.breaker.Allow() {
+		return false
+	}
+	err := fn()
+	if f.breaker != nil {
+		f.breaker.Record(err)
+	}
+	if err != nil {
+		if LimiterBackendErrors != nil {
+			LimiterBackendErrors.WithLabelValues(f.name).Inc()
+		}
+		return false
+	}
+	return true
+}
+
+// Allow asks Redis, or the failure mode when Redis is unavailable.
+func (f *FailoverLimiter) Allow(count int) bool {
+	ok, _ := f.take(count)
+	return ok
+}
+
+// take is Allow that also reports whether the local bucket made the decision; only then
+// can refund give the requests back.
+func (f *FailoverLimiter) take(count int) (allowed bool, local bool) {
+	if f.call(func() (err error) { allowed, err = f.backend.allow(count); return }) {
+		return allowed, false
+	}
+	if LimiterFallbackDecisions != nil {
+		LimiterFallbackDecisions.WithLabelValues(f.name, f.mode).Inc()
+	}
+	switch f.mode {
+	case FailOpen:
+		return true, false
+	case FailLocal:
+		return f.local.Allow(count), true
+	}
+	return false, false
+}
+
+// RetryAfter asks Redis, or the local bucket when Redis is unavailable; other modes
+// report 0 since the limiter state is unknown.
+func (f *FailoverLimiter) RetryAfter(count int) time.Duration {
+	var wait time.Duration
+	if f.call(func() (err error) { wait, err = f.backend.retryAfter(count); return }) {
+		return wait
+	}
+	if f.mode == FailLocal {
+		return f.local.RetryAfter(count)
+	}
+	return 0
+}
+
+// Status reports the Redis state, or what the failure mode allows when Redis is unavailable.
+func (f *FailoverLimiter) Status() (available float64, capacity float64, refillRate float64) {
+	capacity, refillRate = f.backend.limits()
+	if f.call(func() (err error) { available, err = f.backend.status(); return }) {
+		return available, capacity, refillRate
+	}
+	switch f.mode {
+	case FailOpen:
+		return capacity, capacity, refillRate
+	case FailLocal:
+		return f.local.Status()
+	}
+	return 0, capacity, refillRate
+}
+
+// Mode returns the failure mode.
+func (f *FailoverLimiter) Mode() string {
+	return f.mode
+}
+
+func (f *FailoverLimiter) compositeArgs() (string, []interface{}) {
+	return f.backend.compositeArgs()
+}
+
+func (f *FailoverLimiter) limits() (capacity float64, refillRate float64) {
+	return f.backend.limits()
+}
+
+// refund gives requests taken by the local bucket back; the composite limiter only calls it
+// for requests take reports as decided locally.
+func (f *FailoverLimiter) refund(count int) {
+	if f.local != nil {
+		f.local.refund(count)
+	}
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// LimiterConfigChannel is the Redis pub/sub channel announcing writes and deletes of
+// limiter_config hashes, so every core-api instance can rebuild the affected buckets.
+const LimiterConfigChannel = "limiter_config_changes"
+
+// LimiterConfigChange names the limiter_config hash that changed.
+type LimiterConfigChange struct {
+	Name  string `json:"name"`
+	Scope string `json:"scope"` // global, user:<id>, cluster:<id> or project:<id>
+}
+
+// PublishLimiterConfigChange announces that limiter_config:<name>:<scope> changed.
+func PublishLimiterConfigChange(ctx context.Context, client *redis.Client, name, scope string) error {
+	payload, err := json.Marshal(LimiterConfigChange{Name: name, Scope: scope})
+	if err != nil {
+		return err
+	}
+	return client.Publish(ctx, LimiterConfigChannel, payload).Err()
+}
+
+// ParseLimiterConfigKey splits a limiter_config:<name>:<scope> key.
+func ParseLimiterConfigKey(key string) (name, scope string, ok bool) {
+	parts := strings.SplitN(key, ":", 3)
+	if len(parts) != 3 || parts[0] != "limiter_config" || parts[1] == "" || parts[2] == "" {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// Reload rebuilds the buckets affected by a change of the limiter_config hash for name and
+// scope. A scoped config only affects its own bucket. The global config also applies to
+// the global ("") bucket, which is reconfigured from the default config plus the hash, and
+// to every scoped bucket without a config of its own. Scoped buckets are dropped and
+// recreated from the current config on their next use; Redis-backed ones keep their state.
+// The global bucket keeps the requests it already counted, so a reload never grants a
+// fresh burst: it is adjusted in place or, if the algorithm changed, replaced by a limiter
+// starting with the tokens left (clamped to the new capacity).
+func (m *LimiterManager) Reload(name, scope string) {
+	if scope != "" && scope != "global" {
+		m.mu.Lock()
+		m.dropScope(name, scope)
+		m.mu.Unlock()
+		return
+	}
+
+	var vals map[string]string
+	if m.redis != nil {
+		var err error
+		vals, err = m.redis.HGetAll(context.Background(), "limiter_config:"+name+":global").Result()
+		if err != nil {
+			logger.Warnf("limiter %s: reading global config failed, keeping current buckets: %v", name, err)
+			return
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	scopes := m.buckets[name]
+	for s := range scopes {
+		if s != "" {
+			m.dropScope(name, s)
+		}
+	}
+	global, ok := scopes[""]
+	if !ok {
+		return
+	}
+	cfg, ok := m.configs[name]
+	if !ok {
+		// registered with Add only: keep its settings as the defaults
+		_, cfg.Capacity, cfg.RefillRate = global.Status()
+	}
+	applyLimiterConfigHash(&cfg, vals)
+	if r, ok := global.(reconfigurable); !ok || !r.reconfigure(cfg) {
+		scopes[""] = carryOver(global, NewRateLimiter(cfg))
+	}
+	logger.Infof("limiter %s reconfigured: algorithm=%q refill_rate=%g capacity=%g", name, cfg.Algorithm, cfg.RefillRate, cfg.Capacity)
+}
+
+// reconfigurable is an in-memory limiter whose limits can change in place. reconfigure
+// returns false if cfg selects a different algorithm.
+type reconfigurable interface {
+	reconfigure(cfg BucketConfig) bool
+}
+
+// carryOver spends from next what old has already used up, so next starts with the tokens
+// old has left.
+func carryOver(old, next RateLimiter) RateLimiter {
+	available, _, _ := old.Status()
+	if _, capacity, _ := next.Status(); available < capacity {
+		if spent := int(math.Floor(capacity - math.Max(0, available))); spent > 0 {
+			next.Allow(spent)
+		}
+	}
+	return next
+}
+
+// ReloadAll rebuilds every limiter from the persisted configs.
+func (m *LimiterManager) ReloadAll() {
+	m.mu.RLock()
+	names := make([]string, 0, len(m.buckets))
+	for name := range m.buckets {
+		names = append(names, name)
+	}
+	m.mu.RUnlock()
+	for _, name := range names {
+		m.Reload(name, "global")
+	}
+}
+
+// WatchConfigChanges applies the limiter config changes published by any instance until
+// ctx is cancelled. Changes published while the subscription is down are lost, so every
+// (re)subscription reloads all limiters; this also applies persisted global configs at
+// startup.
+func (m *LimiterManager) WatchConfigChanges(ctx context.Context) {
+	if m.redis == nil {
+		return
+	}
+	ps := m.redis.Subscribe(ctx, LimiterConfigChannel)
+	defer ps.Close()
+	ch := ps.ChannelWithSubscriptions()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			switch msg := msg.(type) {
+			case *redis.Subscription:
+				if msg.Kind == "subscribe" {
+					m.ReloadAll()
+				}
+			case *redis.Message:
+				var change LimiterConfigChange
+				if err := json.Unmarshal([]byte(msg.Payload), &change); err != nil || change.Name == "" {
+					logger.Warnf("ignoring malformed limiter config change %q", msg.Payload)
+					continue
+				}
+				m.Reload(change.Name, change.Scope)
+			}
+		}
+	}
+}
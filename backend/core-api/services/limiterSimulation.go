@@ -0,0 +1,178 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+)
+
+const (
+	// MaxSimulatedRequests bounds the timeline of one simulation.
+	MaxSimulatedRequests = 100000
+	// limiterRecordSize is how many recent requests each limiter keeps for simulations.
+	limiterRecordSize = 10000
+)
+
+// ErrInvalidSimulation is returned for simulations without a usable config or timeline.
+var ErrInvalidSimulation = errors.New("invalid rate limit simulation")
+
+// recordedRequest is a request seen by a limiter.
+type recordedRequest struct {
+	at    time.Time
+	scope string
+	cost  int
+}
+
+// requestRecord is a ring of the last limiterRecordSize requests of a limiter.
+type requestRecord struct {
+	buf  []recordedRequest
+	next int
+}
+
+// RecordRequest remembers that a request costing cost tokens reached the limiter called
+// name for scope, so simulations can replay the recent traffic. Only the last 10000
+// requests per limiter are kept.
+func (m *LimiterManager) RecordRequest(name, scope string, cost int) {
+	m.recordMu.Lock()
+	defer m.recordMu.Unlock()
+	if m.records == nil {
+		m.records = make(map[string]*requestRecord)
+	}
+	rec := m.records[name]
+	if rec == nil {
+		rec = &requestRecord{}
+		m.records[name] = rec
+	}
+	r := recordedRequest{at: m.now(), scope: scope, cost: cost}
+	if len(rec.buf) < limiterRecordSize {
+		rec.buf = append(rec.buf, r)
+		return
+	}
+	rec.buf[rec.next] = r
+	rec.next = (rec.next + 1) % limiterRecordSize
+}
+
+// RecordedRequests returns the requests recently seen by the limiter called name, oldest
+// first, as a timeline starting at the oldest one.
+func (m *LimiterManager) RecordedRequests(name string) []models.SimulatedRequest {
+	m.recordMu.Lock()
+	defer m.recordMu.Unlock()
+	rec := m.records[name]
+	if rec == nil || len(rec.buf) == 0 {
+		return nil
+	}
+	ordered := append(append([]recordedRequest{}, rec.buf[rec.next:]...), rec.buf[:rec.next]...)
+	start := ordered[0].at
+	out := make([]models.SimulatedRequest, len(ordered))
+	for i, r := range ordered {
+		out[i] = models.SimulatedRequest{OffsetSeconds: r.at.Sub(start).Seconds(), Scope: r.scope, Cost: r.cost}
+	}
+	return out
+}
+
+// Simulate replays req's timeline, or the requests recently recorded for req.Name, against
+// the candidate config. Config fields left out are taken from the named limiter's default
+// config (or its global bucket when it only has one).
+func (m *LimiterManager) Simulate(req models.RateLimitSimulationRequest) (models.RateLimitSimulationResult, error) {
+	var cfg BucketConfig
+	if req.Name != "" {
+		m.mu.RLock()
+		def, ok := m.configs[req.Name]
+		global := m.buckets[req.Name][""]
+		m.mu.RUnlock()
+		if ok {
+			cfg = def
+		} else if global != nil {
+			_, cfg.Capacity, cfg.RefillRate = global.Status()
+		}
+	}
+	if req.RefillRate > 0 {
+		cfg.RefillRate = req.RefillRate
+	}
+	if req.Capacity > 0 {
+		cfg.Capacity = req.Capacity
+	}
+	if req.Algorithm != "" {
+		cfg.Algorithm = req.Algorithm
+	}
+	if req.WindowSeconds > 0 {
+		cfg.WindowSeconds = req.WindowSeconds
+	}
+
+	requests := req.Requests
+	if len(requests) == 0 && req.Name != "" {
+		requests = m.RecordedRequests(req.Name)
+	}
+	return SimulateRateLimit(cfg, requests)
+}
+
+// SimulateRateLimit replays requests against in-memory limiters built from cfg, one per
+// scope, on a virtual clock, and reports how many would have been accepted or rejected.
+func SimulateRateLimit(cfg BucketConfig, requests []models.SimulatedRequest) (models.RateLimitSimulationResult, error) {
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = AlgorithmTokenBucket
+	}
+	switch {
+	case cfg.Capacity <= 0 || cfg.RefillRate < 0:
+		return models.RateLimitSimulationResult{}, fmt.Errorf("%w: capacity must be positive and refill_rate not negative", ErrInvalidSimulation)
+	case !ValidLimiterAlgorithm(cfg.Algorithm):
+		return models.RateLimitSimulationResult{}, fmt.Errorf("%w: algorithm must be token_bucket, sliding_window_log, sliding_window_counter or gcra", ErrInvalidSimulation)
+	case len(requests) == 0:
+		return models.RateLimitSimulationResult{}, fmt.Errorf("%w: no requests to replay", ErrInvalidSimulation)
+	case len(requests) > MaxSimulatedRequests:
+		return models.RateLimitSimulationResult{}, fmt.Errorf("%w: at most %d requests can be replayed", ErrInvalidSimulation, MaxSimulatedRequests)
+	}
+	timeline := append([]models.SimulatedRequest{}, requests...)
+	for _, r := range timeline {
+		if r.OffsetSeconds < 0 || math.IsNaN(r.OffsetSeconds) || math.IsInf(r.OffsetSeconds, 0) {
+			return models.RateLimitSimulationResult{}, fmt.Errorf("%w: offset_seconds must be a non-negative number", ErrInvalidSimulation)
+		}
+	}
+	sort.SliceStable(timeline, func(i, j int) bool { return timeline[i].OffsetSeconds < timeline[j].OffsetSeconds })
+
+	res := models.RateLimitSimulationResult{
+		RefillRate:    cfg.RefillRate,
+		Capacity:      cfg.Capacity,
+		Algorithm:     cfg.Algorithm,
+		WindowSeconds: cfg.WindowSeconds,
+		Requests:      len(timeline),
+	}
+	start := time.Unix(0, 0)
+	now := start
+	clock := func() time.Time { return now }
+	limiters := make(map[string]RateLimiter)
+	for _, r := range timeline {
+		now = start.Add(secondsToDuration(r.OffsetSeconds))
+		l := limiters[r.Scope]
+		if l == nil {
+			l = newRateLimiterWithClock(cfg, clock)
+			limiters[r.Scope] = l
+		}
+		cost := r.Cost
+		if cost < 1 {
+			cost = 1
+		}
+		second := int64(r.OffsetSeconds)
+		if n := len(res.Series); n == 0 || res.Series[n-1].Second != second {
+			res.Series = append(res.Series, models.RateLimitSimulationSecond{Second: second})
+		}
+		point := &res.Series[len(res.Series)-1]
+		if l.Allow(cost) {
+			res.Accepted++
+			point.Accepted++
+			continue
+		}
+		res.Rejected++
+		point.Rejected++
+		if wait := l.RetryAfter(cost); wait < 0 {
+			res.NeverAllowed++
+		} else {
+			res.PeakWaitSeconds = math.Max(res.PeakWaitSeconds, wait.Seconds())
+		}
+	}
+	return res, nil
+}
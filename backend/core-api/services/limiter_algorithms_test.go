@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// allowedNow counts how many single requests the limiter allows right now (up to max).
+func allowedNow(l RateLimiter, max int) int {
+	n := 0
+	for i := 0; i < max && l.Allow(1); i++ {
+		n++
+	}
+	return n
+}
+
+func TestLimiterAlgorithms_BurstBehavior(t *testing.T) {
+	// every limiter: 5 requests burst, 5 per second long-term (window 1s)
+	cfg := BucketConfig{RefillRate: 5, Capacity: 5}
+	clock := time.Unix(1_000_000, 0) // aligned to a window boundary
+	now := func() time.Time { return clock }
+
+	tb := NewTokenBucket(cfg.RefillRate, cfg.Capacity)
+	tb.now, tb.last = now, clock
+	swl := NewRateLimiter(BucketConfig{RefillRate: 5, Capacity: 5, Algorithm: AlgorithmSlidingWindowLog}).(*SlidingWindowLog)
+	swl.now = now
+	swc := NewRateLimiter(BucketConfig{RefillRate: 5, Capacity: 5, Algorithm: AlgorithmSlidingWindowCounter}).(*SlidingWindowCounter)
+	swc.now = now
+	gcra := NewRateLimiter(BucketConfig{RefillRate: 5, Capacity: 5, Algorithm: AlgorithmGCRA}).(*GCRA)
+	gcra.now = now
+
+	limiters := map[string]RateLimiter{AlgorithmTokenBucket: tb, AlgorithmSlidingWindowLog: swl, AlgorithmSlidingWindowCounter: swc, AlgorithmGCRA: gcra}
+	steps := []struct {
+		at   time.Duration // since start
+		want map[string]int
+	}{
+		// a full burst is allowed by every algorithm
+		{0, map[string]int{AlgorithmTokenBucket: 5, AlgorithmSlidingWindowLog: 5, AlgorithmSlidingWindowCounter: 5, AlgorithmGCRA: 5}},
+		// token bucket and GCRA refill continuously; the windows wait for the burst to age out
+		{200 * time.Millisecond, map[string]int{AlgorithmTokenBucket: 1, AlgorithmSlidingWindowLog: 0, AlgorithmSlidingWindowCounter: 0, AlgorithmGCRA: 1}},
+		// the log forgets the burst after a window; the counter still weights the previous window (5 * 0.8)
+		{1200 * time.Millisecond, map[string]int{AlgorithmTokenBucket: 5, AlgorithmSlidingWindowLog: 5, AlgorithmSlidingWindowCounter: 1, AlgorithmGCRA: 5}},
+		// the log still holds the previous step's burst; the counter only weighs what happened since
+		{2000 * time.Millisecond, map[string]int{AlgorithmTokenBucket: 4, AlgorithmSlidingWindowLog: 0, AlgorithmSlidingWindowCounter: 4, AlgorithmGCRA: 4}},
+	}
+	start := clock
+	for _, step := range steps {
+		clock = start.Add(step.at)
+		for name, l := range limiters {
+			if got := allowedNow(l, 10); got != step.want[name] {
+				t.Errorf("%s at +%s: allowed %d, want %d", name, step.at, got, step.want[name])
+			}
+		}
+	}
+
+	// a batch larger than the burst is never allowed, a batch that fits is allowed at once
+	clock = start.Add(time.Hour)
+	for name, l := range limiters {
+		if l.Allow(6) {
+			t.Errorf("%s allowed a batch larger than its capacity", name)
+		}
+		if !l.Allow(5) {
+			t.Errorf("%s rejected a full batch after idling", name)
+		}
+		if avail, capacity, rate := l.Status(); avail > 0.01 || capacity != 5 || rate != 5 {
+			t.Errorf("%s status after full batch: available=%f capacity=%f rate=%f", name, avail, capacity, rate)
+		}
+	}
+}
+
+func TestLimiterAlgorithms_RetryAfter(t *testing.T) {
+	// 5 requests burst, 5 per second long-term (window 1s)
+	cfg := BucketConfig{RefillRate: 5, Capacity: 5}
+	clock := time.Unix(1_000_000, 0) // aligned to a window boundary
+	now := func() time.Time { return clock }
+
+	tb := NewTokenBucket(cfg.RefillRate, cfg.Capacity)
+	tb.now, tb.last = now, clock
+	swl := NewSlidingWindowLog(cfg.Capacity, cfg.window())
+	swl.now = now
+	swc := NewSlidingWindowCounter(cfg.Capacity, cfg.window())
+	swc.now = now
+	gcra := NewGCRA(cfg.RefillRate, cfg.Capacity)
+	gcra.now = now
+	limiters := map[string]RateLimiter{AlgorithmTokenBucket: tb, AlgorithmSlidingWindowLog: swl, AlgorithmSlidingWindowCounter: swc, AlgorithmGCRA: gcra}
+
+	start := clock
+	for name, l := range limiters {
+		if got := l.RetryAfter(5); got != 0 {
+			t.Errorf("%s: expected no wait before the burst, got %s", name, got)
+		}
+		if got := l.RetryAfter(6); got >= 0 {
+			t.Errorf("%s: expected a batch above the capacity to never be allowed, got %s", name, got)
+		}
+		allowedNow(l, 3)
+	}
+	clock = start.Add(400 * time.Millisecond)
+	for _, l := range limiters {
+		allowedNow(l, 2)
+	}
+
+	// 3 requests at 0s and 2 at 400ms; at 600ms, how long until 1 and 4 more are allowed?
+	clock = start.Add(600 * time.Millisecond)
+	want := map[string][2]time.Duration{
+		// tokens refill continuously: 3 are there, the 4th takes 200ms
+		AlgorithmTokenBucket: {0, 200 * time.Millisecond},
+		AlgorithmGCRA:        {0, 200 * time.Millisecond},
+		// the 0s requests leave the window at 1s, the 400ms requests at 1.4s
+		AlgorithmSlidingWindowLog: {400 * time.Millisecond, 800 * time.Millisecond},
+		// all 5 count in the current window; after it ends they fade out over the next one
+		AlgorithmSlidingWindowCounter: {600 * time.Millisecond, 1200 * time.Millisecond},
+	}
+	for name, l := range limiters {
+		for i, count := range []int{1, 4} {
+			got := l.RetryAfter(count)
+			if d := got - want[name][i]; d < -time.Millisecond || d > time.Millisecond {
+				t.Errorf("%s: RetryAfter(%d) = %s, want %s", name, count, got, want[name][i])
+			}
+		}
+	}
+
+	// waiting for RetryAfter is always enough
+	for name, l := range limiters {
+		clock = start.Add(600 * time.Millisecond)
+		clock = clock.Add(l.RetryAfter(4))
+		if !l.Allow(4) {
+			t.Errorf("%s: rejected 4 requests after waiting RetryAfter", name)
+		}
+	}
+
+	// without refill a token bucket can not say when tokens return
+	empty := NewTokenBucket(0, 1)
+	empty.Allow(1)
+	if got := empty.RetryAfter(1); got >= 0 {
+		t.Fatalf("expected a bucket without refill to never allow, got %s", got)
+	}
+}
+
+func TestLimiterManager_SelectsAlgorithmFromConfig(t *testing.T) {
+	m := NewLimiterManager(nil)
+	m.AddDefaultConfig("gcra", BucketConfig{RefillRate: 1, Capacity: 2, Algorithm: AlgorithmGCRA})
+	m.AddDefaultConfig("log", BucketConfig{RefillRate: 1, Capacity: 2, Algorithm: AlgorithmSlidingWindowLog, WindowSeconds: 60})
+	if _, ok := m.GetOrCreate("gcra", "user:alice").(*GCRA); !ok {
+		t.Fatalf("expected a GCRA limiter")
+	}
+	l, ok := m.GetOrCreate("log", "user:alice").(*SlidingWindowLog)
+	if !ok || l.window != time.Minute {
+		t.Fatalf("expected a sliding window log with a 60s window, got %#v", l)
+	}
+
+	cfg := BucketConfig{RefillRate: 1, Capacity: 2}
+	applyLimiterConfigHash(&cfg, map[string]string{"algorithm": "sliding_window_counter", "window_seconds": "10", "capacity": "20"})
+	if cfg.Algorithm != AlgorithmSlidingWindowCounter || cfg.WindowSeconds != 10 || cfg.Capacity != 20 {
+		t.Fatalf("unexpected config from hash: %+v", cfg)
+	}
+	applyLimiterConfigHash(&cfg, map[string]string{"algorithm": "leaky"})
+	if cfg.Algorithm != AlgorithmSlidingWindowCounter {
+		t.Fatalf("expected unknown algorithm to be ignored, got %q", cfg.Algorithm)
+	}
+}
+
+func TestRedisLimiterAlgorithms_BurstBehavior(t *testing.T) {
+	ctx := context.Background()
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+	client := redis.NewClient(&redis.Options{Addr: redisAddr})
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis not available on %s - skipping integration test", redisAddr)
+	}
+
+	scope := "test:" + time.Now().Format("150405.000000")
+	for _, algorithm := range []string{AlgorithmTokenBucket, AlgorithmSlidingWindowLog, AlgorithmSlidingWindowCounter, AlgorithmGCRA} {
+		cfg := BucketConfig{RefillRate: 10, Capacity: 5, Algorithm: algorithm}
+		// two instances share the state through Redis
+		a := NewRedisRateLimiter(client, "algo", scope, cfg, 60000)
+		b := NewRedisRateLimiter(client, "algo", scope, cfg, 60000)
+		if got := allowedNow(a, 3) + allowedNow(b, 10); got != 5 {
+			t.Fatalf("%s: expected a shared burst of 5, got %d", algorithm, got)
+		}
+		if avail, capacity, _ := a.Status(); avail > 0.5 || capacity != 5 {
+			t.Fatalf("%s: unexpected status after burst: available=%f capacity=%f", algorithm, avail, capacity)
+		}
+		if wait := a.RetryAfter(1); wait <= 0 || wait > 500*time.Millisecond {
+			t.Fatalf("%s: expected a wait of at most the 0.5s window after the burst, got %s", algorithm, wait)
+		}
+		if wait := a.RetryAfter(6); wait >= 0 {
+			t.Fatalf("%s: expected a batch above the capacity to never be allowed, got %s", algorithm, wait)
+		}
+		time.Sleep(600 * time.Millisecond) // longer than the 0.5s window and 5 emission intervals
+		if !b.Allow(1) {
+			t.Fatalf("%s: expected capacity to return after the window", algorithm)
+		}
+		_ = client.Del(ctx, limiterKey(algorithm, "algo", scope))
+	}
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestCompositeLimiter_RefundsEarlierLevels(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmTokenBucket, AlgorithmSlidingWindowLog, AlgorithmSlidingWindowCounter, AlgorithmGCRA} {
+		global := NewRateLimiter(BucketConfig{RefillRate: 0.001, Capacity: 5, Algorithm: algorithm})
+		user := NewRateLimiter(BucketConfig{RefillRate: 0.001, Capacity: 2, Algorithm: algorithm})
+		c := NewCompositeLimiter(nil, LimitLevel{Level: "global", Limiter: global}, LimitLevel{Level: "user", Limiter: user})
+
+		d := c.Take(2)
+		if !d.Allowed || d.Level != "user" || d.Available > 0.01 || d.Capacity != 2 {
+			t.Fatalf("%s: expected the user level to be the bottleneck after 2 requests, got %+v", algorithm, d)
+		}
+		d = c.Take(1)
+		if d.Allowed || d.Level != "user" || d.RetryAfter <= 0 {
+			t.Fatalf("%s: expected the user level to reject with a wait, got %+v", algorithm, d)
+		}
+		if avail, _, _ := global.Status(); avail < 2.99 || avail > 3.01 {
+			t.Fatalf("%s: expected the rejected request to be refunded to the global level, have %f", algorithm, avail)
+		}
+		if d := c.Take(3); d.Allowed || d.RetryAfter >= 0 {
+			t.Fatalf("%s: expected a batch above the user capacity to never be allowed, got %+v", algorithm, d)
+		}
+	}
+}
+
+func TestRedisCompositeLimiter_AllOrNothing(t *testing.T) {
+	ctx := context.Background()
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+	client := redis.NewClient(&redis.Options{Addr: redisAddr})
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis not available on %s - skipping integration test", redisAddr)
+	}
+
+	scope := "test:" + time.Now().Format("150405.000000")
+	for _, algorithm := range []string{AlgorithmTokenBucket, AlgorithmSlidingWindowLog, AlgorithmSlidingWindowCounter, AlgorithmGCRA} {
+		global := NewRedisRateLimiter(client, "composite_global", scope, BucketConfig{RefillRate: 0.01, Capacity: 3, Algorithm: algorithm}, 60000)
+		alice := NewRedisRateLimiter(client, "composite", scope+":alice", BucketConfig{RefillRate: 0.01, Capacity: 2, Algorithm: AlgorithmTokenBucket}, 60000)
+		bob := NewRedisRateLimiter(client, "composite", scope+":bob", BucketConfig{RefillRate: 0.01, Capacity: 2, Algorithm: algorithm}, 60000)
+		forAlice := NewCompositeLimiter(client, LimitLevel{Level: "global", Limiter: global}, LimitLevel{Level: "user", Limiter: alice})
+		forBob := NewCompositeLimiter(client, LimitLevel{Level: "global", Limiter: global}, LimitLevel{Level: "user", Limiter: bob})
+
+		if d := forAlice.Take(2); !d.Allowed {
+			t.Fatalf("%s: expected alice's first batch to pass, got %+v", algorithm, d)
+		}
+		if d := forAlice.Take(1); d.Allowed || d.Level != "user" || d.RetryAfter <= 0 {
+			t.Fatalf("%s: expected alice's user level to reject, got %+v", algorithm, d)
+		}
+		// alice's rejected request did not spend the global token bob needs
+		if d := forBob.Take(1); !d.Allowed || d.Level != "global" {
+			t.Fatalf("%s: expected bob to pass with the global level as bottleneck, got %+v", algorithm, d)
+		}
+		if d := forBob.Take(1); d.Allowed || d.Level != "global" {
+			t.Fatalf("%s: expected the global level to reject, got %+v", algorithm, d)
+		}
+		if avail, _, _ := bob.Status(); avail < 0.99 {
+			t.Fatalf("%s: expected bob's rejected request to leave his bucket alone, have %f", algorithm, avail)
+		}
+		_ = client.Del(ctx, limiterKey(algorithm, "composite_global", scope), limiterKey(AlgorithmTokenBucket, "composite", scope+":alice"), limiterKey(algorithm, "composite", scope+":bob"))
+	}
+}
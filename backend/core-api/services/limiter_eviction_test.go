@@ -0,0 +1,140 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func gaugeValue(t *testing.T, g prometheus.Gauge) float64 {
+	t.Helper()
+	var m dto.Metric
+	if err := g.Write(&m); err != nil {
+		t.Fatalf("reading gauge: %v", err)
+	}
+	return m.GetGauge().GetValue()
+}
+
+func TestLimiterManager_EvictsIdleAndLeastRecentlyUsedScopes(t *testing.T) {
+	m := NewLimiterManager(nil)
+	now := time.Now()
+	m.now = func() time.Time { return now }
+	m.AddDefaultConfig("jobs_create", BucketConfig{RefillRate: 0, Capacity: 1})
+	m.Add("jobs_create", NewTokenBucket(0, 1))
+	m.SetEviction(2, time.Minute)
+
+	alice := m.GetOrCreate("jobs_create", "user:alice")
+	alice.Allow(1)
+	now = now.Add(10 * time.Second)
+	bob := m.GetOrCreate("jobs_create", "user:bob")
+	now = now.Add(10 * time.Second)
+	if m.GetOrCreate("jobs_create", "user:alice") != alice {
+		t.Fatalf("expected alice's bucket to be reused")
+	}
+
+	// over the cap the least recently used scope (bob) makes room
+	m.GetOrCreate("jobs_create", "user:carol")
+	if got := m.ScopeCount(); got != 2 {
+		t.Fatalf("expected 2 tracked scopes, got %d", got)
+	}
+	if m.GetOrCreate("jobs_create", "user:alice") != alice {
+		t.Fatalf("expected alice's recently used bucket to be kept")
+	}
+	if m.GetOrCreate("jobs_create", "user:bob") == bob {
+		t.Fatalf("expected bob's bucket to be evicted and recreated")
+	}
+
+	// idle scopes go, the global bucket stays
+	now = now.Add(time.Minute)
+	m.GetOrCreate("jobs_create", "user:carol")
+	if n := m.EvictIdle(); n != 1 {
+		t.Fatalf("expected 1 idle scope evicted, got %d", n)
+	}
+	if m.GetOrCreate("jobs_create", "user:carol") == nil || m.Get("jobs_create") == nil {
+		t.Fatalf("expected carol's and the global bucket to be kept")
+	}
+	if m.GetOrCreate("jobs_create", "user:alice") == alice || !m.GetOrCreate("jobs_create", "user:alice").Allow(1) {
+		t.Fatalf("expected alice's idle bucket to be recreated full")
+	}
+
+	// reloads drop LRU entries along with the buckets
+	m.Reload("jobs_create", "global")
+	if got := m.ScopeCount(); got != 0 {
+		t.Fatalf("expected no tracked scopes after a global reload, got %d", got)
+	}
+}
+
+func TestLimiterManager_ExportMetricsTopN(t *testing.T) {
+	RegisterPrometheusMetrics()
+	m := NewLimiterManager(nil)
+	m.AddDefaultConfig("export_test", BucketConfig{RefillRate: 0, Capacity: 10})
+	m.Add("export_test", NewTokenBucket(0, 10))
+	for i, user := range []string{"a", "b", "c", "d"} {
+		m.GetOrCreate("export_test", "user:"+user).Allow(4 - i) // a is the most used
+	}
+
+	m.ExportMetrics(2)
+	if got := gaugeValue(t, RateLimitAvailable.WithLabelValues("export_test", "user", "a")); got != 6 {
+		t.Fatalf("expected a's series with 6 tokens, got %v", got)
+	}
+	if got := gaugeValue(t, RateLimitAvailable.WithLabelValues("export_test", "user", LimiterOtherScope)); got != 8.5 {
+		t.Fatalf("expected c and d averaged into other (8.5), got %v", got)
+	}
+	if got := gaugeValue(t, LimiterTrackedScopes.WithLabelValues("export_test", "user")); got != 4 {
+		t.Fatalf("expected 4 tracked user scopes, got %v", got)
+	}
+	// a, b and other
+	series := 0
+	for _, id := range []string{"a", "b", "c", "d", LimiterOtherScope} {
+		if RateLimitAvailable.DeleteLabelValues("export_test", "user", id) {
+			series++
+		}
+	}
+	if series != 3 {
+		t.Fatalf("expected 3 user series, got %d", series)
+	}
+
+	// series of evicted scopes are removed on the next export
+	m.ExportMetrics(2)
+	m.SetEviction(1, 0)
+	m.ExportMetrics(2)
+	if RateLimitAvailable.DeleteLabelValues("export_test", "user", LimiterOtherScope) {
+		t.Fatalf("expected the other series to be removed once few scopes are left")
+	}
+	if got := gaugeValue(t, LimiterTrackedScopes.WithLabelValues("export_test", "user")); got != 1 {
+		t.Fatalf("expected 1 tracked user scope, got %v", got)
+	}
+}
+
+// lockingLimiter reports its status through the manager, which deadlocks if Status is
+// called while the manager's lock is held.
+type lockingLimiter struct {
+	*TokenBucket
+	m *LimiterManager
+}
+
+func (l lockingLimiter) Status() (float64, float64, float64) {
+	l.m.Add("other", NewTokenBucket(1, 1))
+	return l.TokenBucket.Status()
+}
+
+func TestLimiterManager_SnapshotStatusOutsideLock(t *testing.T) {
+	m := NewLimiterManager(nil)
+	m.Add("slow", lockingLimiter{TokenBucket: NewTokenBucket(1, 5), m: m})
+	done := make(chan map[string]map[string]struct {
+		Available float64
+		Capacity  float64
+		Refill    float64
+	})
+	go func() { done <- m.SnapshotStatus() }()
+	select {
+	case snap := <-done:
+		if snap["slow"][""].Capacity != 5 {
+			t.Fatalf("unexpected snapshot %+v", snap)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("SnapshotStatus holds the manager lock while calling Status")
+	}
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// unreachableRedis returns a client whose every call fails right away.
+func unreachableRedis() *redis.Client {
+	return redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", DialTimeout: 50 * time.Millisecond, MaxRetries: -1})
+}
+
+func TestCircuitBreaker_OpensAndRecovers(t *testing.T) {
+	b := NewCircuitBreaker("test", 2, time.Second)
+	clock := time.Unix(1000, 0)
+	b.now = func() time.Time { return clock }
+	boom := errors.New("boom")
+
+	b.Allow()
+	b.Record(boom)
+	if b.State() != CircuitClosed {
+		t.Fatalf("expected one failure to keep the circuit closed")
+	}
+	b.Allow()
+	b.Record(boom)
+	if b.State() != CircuitOpen || b.Allow() {
+		t.Fatalf("expected the circuit to open after 2 failures")
+	}
+
+	// after openFor a single trial call goes through
+	clock = clock.Add(time.Second)
+	if !b.Allow() || b.Allow() || b.State() != CircuitHalfOpen {
+		t.Fatalf("expected exactly one trial call while half-open")
+	}
+	b.Record(boom)
+	if b.State() != CircuitOpen || b.Allow() {
+		t.Fatalf("expected a failed trial to open the circuit again")
+	}
+	clock = clock.Add(time.Second)
+	b.Allow()
+	b.Record(nil)
+	if b.State() != CircuitClosed || !b.Allow() {
+		t.Fatalf("expected a successful trial to close the circuit")
+	}
+}
+
+func TestFailoverLimiter_FailureModes(t *testing.T) {
+	client := unreachableRedis()
+	defer client.Close()
+	cfg := BucketConfig{RefillRate: 0.001, Capacity: 4}
+
+	closed := NewFailoverLimiter("test", NewRedisRateLimiter(client, "test", "a", cfg, 60000), "", 1, nil)
+	if closed.Allow(1) {
+		t.Fatalf("expected an empty failure mode to fail closed")
+	}
+	open := NewFailoverLimiter("test", NewRedisRateLimiter(client, "test", "a", cfg, 60000), FailOpen, 1, nil)
+	if !open.Allow(1) {
+		t.Fatalf("expected fail_open to allow requests")
+	}
+
+	// two instances: the local bucket holds half of the capacity
+	for _, algorithm := range []string{AlgorithmTokenBucket, AlgorithmGCRA} {
+		cfg.Algorithm = algorithm
+		breaker := NewCircuitBreaker("test", 2, time.Minute)
+		local := NewFailoverLimiter("test", NewRedisRateLimiter(client, "test", "a", cfg, 60000), FailLocal, 2, breaker)
+		if !local.Allow(1) || !local.Allow(1) || local.Allow(1) {
+			t.Fatalf("%s: expected the local bucket to allow 2 of 4 requests", algorithm)
+		}
+		if local.RetryAfter(1) <= 0 {
+			t.Fatalf("%s: expected the local bucket to report a wait", algorithm)
+		}
+		if available, capacity, _ := local.Status(); available > 0.01 || capacity != 2 {
+			t.Fatalf("%s: expected the local status, got %f/%f", algorithm, available, capacity)
+		}
+		if breaker.State() != CircuitOpen {
+			t.Fatalf("%s: expected Redis failures to open the circuit", algorithm)
+		}
+	}
+
+	// limiters that are not Redis-backed are not wrapped
+	mem := NewTokenBucket(1, 1)
+	if NewFailoverLimiter("test", mem, FailOpen, 1, nil) != mem {
+		t.Fatalf("expected in-memory limiters to be returned as is")
+	}
+}
+
+func TestLimiterManager_DegradesToLocalBuckets(t *testing.T) {
+	client := unreachableRedis()
+	defer client.Close()
+	m := NewLimiterManager(client)
+	m.SetFailover(NewCircuitBreaker("redis", 1, time.Minute), 1)
+	m.AddDefaultConfig("jobs_create", BucketConfig{RefillRate: 0.001, Capacity: 1, FailureMode: FailLocal})
+	m.AddDefaultConfig("jobs_create_global", BucketConfig{RefillRate: 0.001, Capacity: 3, FailureMode: FailLocal})
+
+	global := m.GetOrCreate("jobs_create_global", "global")
+	user := m.GetOrCreate("jobs_create", "user:alice")
+	c := m.Composite(LimitLevel{Level: "global", Limiter: global}, LimitLevel{Level: "user", Limiter: user})
+	if d := c.Take(1); !d.Allowed {
+		t.Fatalf("expected the local buckets to allow the first request, got %+v", d)
+	}
+	if d := c.Take(1); d.Allowed || d.Level != "user" {
+		t.Fatalf("expected the user's local bucket to reject, got %+v", d)
+	}
+	if available, _, _ := global.Status(); available < 1.99 {
+		t.Fatalf("expected the rejected request to be refunded to the global level, have %f", available)
+	}
+}
+
+// flakyRedisLimiter is a Redis-backed limiter whose backend allows every request while up.
+type flakyRedisLimiter struct {
+	*RedisTokenBucket
+	down bool
+}
+
+func (f *flakyRedisLimiter) allow(count int) (bool, error) {
+	if f.down {
+		return false, errors.New("redis down")
+	}
+	return true, nil
+}
+
+func (f *flakyRedisLimiter) retryAfter(count int) (time.Duration, error) {
+	if f.down {
+		return 0, errors.New("redis down")
+	}
+	return 0, nil
+}
+
+func (f *flakyRedisLimiter) status() (float64, error) {
+	if f.down {
+		return 0, errors.New("redis down")
+	}
+	return f.capacity, nil
+}
+
+func TestCompositeLimiter_RefundsOnlyLocalDecisions(t *testing.T) {
+	backend := &flakyRedisLimiter{RedisTokenBucket: NewRedisTokenBucket(nil, "test", "a", 0.001, 2, 60000), down: true}
+	level := NewFailoverLimiter("test", backend, FailLocal, 1, nil).(*FailoverLimiter)
+	empty := NewTokenBucket(0.001, 1)
+	empty.Allow(1)
+	c := NewCompositeLimiter(nil, LimitLevel{Level: "global", Limiter: level}, LimitLevel{Level: "user", Limiter: empty})
+
+	// decided locally while Redis is down: the rejection by the user level refunds it
+	if d := c.Take(1); d.Allowed {
+		t.Fatalf("expected the empty user level to reject")
+	}
+	if available, _, _ := level.local.Status(); available < 1.99 {
+		t.Fatalf("expected the local decision to be refunded, have %f", available)
+	}
+
+	// decided by Redis: nothing was taken from the local bucket, so nothing goes back to it
+	level.local.Allow(1)
+	backend.down = false
+	c.Take(1)
+	if available, _, _ := level.local.Status(); available > 1.01 {
+		t.Fatalf("expected a Redis decision not to refund the local bucket, have %f", available)
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestLimiterManager_ReloadRebuildsBuckets(t *testing.T) {
+	m := NewLimiterManager(nil)
+	cfg := BucketConfig{RefillRate: 0, Capacity: 2}
+	m.Add("jobs_create", NewRateLimiter(cfg))
+	m.AddDefaultConfig("jobs_create", cfg)
+	m.Get("jobs_create").Allow(2)
+	alice := m.GetOrCreate("jobs_create", "user:alice")
+	bob := m.GetOrCreate("jobs_create", "user:bob")
+
+	// a scoped change only rebuilds that scope
+	m.Reload("jobs_create", "user:alice")
+	if m.GetOrCreate("jobs_create", "user:alice") == alice {
+		t.Fatalf("expected alice's bucket to be rebuilt")
+	}
+	if m.GetOrCreate("jobs_create", "user:bob") != bob {
+		t.Fatalf("expected bob's bucket to be kept")
+	}
+	if m.Get("jobs_create").Allow(1) {
+		t.Fatalf("expected the global bucket to be kept (still empty)")
+	}
+
+	// a global change reconfigures the global bucket and rebuilds every scope falling back to it
+	m.Reload("jobs_create", "global")
+	if m.Get("jobs_create").Allow(1) {
+		t.Fatalf("expected the reconfigured global bucket to stay empty")
+	}
+	if m.GetOrCreate("jobs_create", "user:bob") == bob {
+		t.Fatalf("expected bob's bucket to be rebuilt")
+	}
+
+	// buckets registered without a default config keep their settings
+	m.Add("custom", NewTokenBucket(1, 7))
+	m.ReloadAll()
+	if _, capacity, rate := m.Get("custom").Status(); capacity != 7 || rate != 1 {
+		t.Fatalf("expected custom limiter to keep capacity 7 and rate 1, got %f %f", capacity, rate)
+	}
+}
+
+func TestLimiterManager_ReloadKeepsGlobalTokens(t *testing.T) {
+	m := NewLimiterManager(nil)
+	m.Add("diagnosis", NewRateLimiter(BucketConfig{Capacity: 10}))
+	m.AddDefaultConfig("diagnosis", BucketConfig{Capacity: 10})
+	global := m.Get("diagnosis")
+	global.Allow(4)
+
+	// a larger capacity does not refill the bucket
+	m.AddDefaultConfig("diagnosis", BucketConfig{Capacity: 20, RefillRate: 1})
+	m.Reload("diagnosis", "global")
+	if m.Get("diagnosis") != global {
+		t.Fatalf("expected the global bucket to be reconfigured in place")
+	}
+	if available, capacity, rate := global.Status(); math.Floor(available) != 6 || capacity != 20 || rate != 1 {
+		t.Fatalf("expected 6 of 20 tokens at rate 1, got %f of %f at %f", available, capacity, rate)
+	}
+
+	// a smaller capacity clamps the tokens left
+	m.AddDefaultConfig("diagnosis", BucketConfig{Capacity: 3})
+	m.Reload("diagnosis", "global")
+	if available, capacity, _ := global.Status(); available != 3 || capacity != 3 {
+		t.Fatalf("expected 3 of 3 tokens, got %f of %f", available, capacity)
+	}
+
+	// switching algorithms carries the tokens left over
+	global.Allow(2)
+	m.AddDefaultConfig("diagnosis", BucketConfig{Capacity: 5, Algorithm: AlgorithmGCRA})
+	m.Reload("diagnosis", "global")
+	if available, capacity, _ := m.Get("diagnosis").Status(); math.Round(available) != 1 || capacity != 5 {
+		t.Fatalf("expected 1 of 5 requests left after switching to gcra, got %f of %f", available, capacity)
+	}
+}
+
+func TestParseLimiterConfigKey(t *testing.T) {
+	cases := []struct {
+		key, name, scope string
+		ok               bool
+	}{
+		{"limiter_config:diagnosis:global", "diagnosis", "global", true},
+		{"limiter_config:jobs_create:user:alice", "jobs_create", "user:alice", true},
+		{"limiter_config:jobs_create", "", "", false},
+		{"limiter:jobs_create:user:alice", "", "", false},
+	}
+	for _, c := range cases {
+		name, scope, ok := ParseLimiterConfigKey(c.key)
+		if name != c.name || scope != c.scope || ok != c.ok {
+			t.Errorf("ParseLimiterConfigKey(%q) = %q, %q, %v", c.key, name, scope, ok)
+		}
+	}
+}
+
+func TestRedisLimiterConfigHotReload(t *testing.T) {
+	ctx := context.Background()
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+	client := redis.NewClient(&redis.Options{Addr: redisAddr})
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis not available on %s - skipping integration test", redisAddr)
+	}
+
+	name := "reload-" + time.Now().Format("150405.000000")
+	globalKey := "limiter_config:" + name + ":global"
+	userKey := "limiter_config:" + name + ":user:alice"
+	defer client.Del(ctx, globalKey, userKey)
+	if err := client.HSet(ctx, globalKey, "algorithm", AlgorithmGCRA, "capacity", "4").Err(); err != nil {
+		t.Fatalf("hset: %v", err)
+	}
+
+	m := NewLimiterManager(client)
+	cfg := BucketConfig{RefillRate: 1, Capacity: 2}
+	m.Add(name, NewRateLimiter(cfg))
+	m.AddDefaultConfig(name, cfg)
+	watchCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	go m.WatchConfigChanges(watchCtx)
+
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(3 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	// the persisted global config is applied when the watcher subscribes
+	waitFor("global config at startup", func() bool {
+		_, ok := m.Get(name).(*GCRA)
+		return ok
+	})
+
+	// a published change rebuilds the global bucket
+	if err := client.HSet(ctx, globalKey, "capacity", "6").Err(); err != nil {
+		t.Fatalf("hset: %v", err)
+	}
+	if err := PublishLimiterConfigChange(ctx, client, name, "global"); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	waitFor("global config change", func() bool {
+		_, capacity, _ := m.Get(name).Status()
+		return capacity == 6
+	})
+
+	// and a scoped change rebuilds the live scoped bucket
+	if _, capacity, _ := m.GetOrCreate(name, "user:alice").Status(); capacity != 6 {
+		t.Fatalf("expected alice to fall back to the global config, got capacity %f", capacity)
+	}
+	if err := client.HSet(ctx, userKey, "capacity", "9").Err(); err != nil {
+		t.Fatalf("hset: %v", err)
+	}
+	if err := PublishLimiterConfigChange(ctx, client, name, "user:alice"); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	waitFor("scoped config change", func() bool {
+		_, capacity, _ := m.GetOrCreate(name, "user:alice").Status()
+		return capacity == 9
+	})
+}
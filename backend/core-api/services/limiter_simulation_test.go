@@ -0,0 +1,127 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+)
+
+func TestSimulateRateLimit_ReplaysTimeline(t *testing.T) {
+	requests := []models.SimulatedRequest{
+		{OffsetSeconds: 3},
+		{OffsetSeconds: 0},
+		{OffsetSeconds: 0},
+		{OffsetSeconds: 0},
+		{OffsetSeconds: 0.5},
+		{OffsetSeconds: 1.5},
+	}
+	res, err := SimulateRateLimit(BucketConfig{RefillRate: 1, Capacity: 2}, requests)
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	if res.Requests != 6 || res.Accepted != 4 || res.Rejected != 2 || res.NeverAllowed != 0 {
+		t.Fatalf("expected 4 accepted and 2 rejected of 6, got %+v", res)
+	}
+	if res.PeakWaitSeconds != 1 {
+		t.Fatalf("expected a peak wait of 1s, got %v", res.PeakWaitSeconds)
+	}
+	want := []models.RateLimitSimulationSecond{
+		{Second: 0, Accepted: 2, Rejected: 2},
+		{Second: 1, Accepted: 1},
+		{Second: 3, Accepted: 1},
+	}
+	if !reflect.DeepEqual(res.Series, want) {
+		t.Fatalf("unexpected series %+v", res.Series)
+	}
+	if res.Algorithm != AlgorithmTokenBucket {
+		t.Fatalf("expected the token bucket by default, got %q", res.Algorithm)
+	}
+
+	// a sliding window log only frees a slot once the oldest request leaves the window
+	res, err = SimulateRateLimit(BucketConfig{RefillRate: 1, Capacity: 2, Algorithm: AlgorithmSlidingWindowLog}, requests)
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	if res.Accepted != 3 || res.Rejected != 3 {
+		t.Fatalf("expected 3 accepted and 3 rejected with a sliding window log, got %+v", res)
+	}
+}
+
+func TestSimulateRateLimit_BucketPerScope(t *testing.T) {
+	res, err := SimulateRateLimit(BucketConfig{RefillRate: 0, Capacity: 2}, []models.SimulatedRequest{
+		{Scope: "user:alice", Cost: 2},
+		{Scope: "user:bob", Cost: 2},
+		{Scope: "user:alice"},
+		{Scope: "user:bob", Cost: 5},
+	})
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	if res.Accepted != 2 || res.Rejected != 2 || res.NeverAllowed != 2 {
+		t.Fatalf("expected alice and bob to get a bucket each, got %+v", res)
+	}
+}
+
+func TestSimulateRateLimit_Validation(t *testing.T) {
+	cases := []struct {
+		cfg      BucketConfig
+		requests []models.SimulatedRequest
+	}{
+		{BucketConfig{RefillRate: 1}, []models.SimulatedRequest{{}}},
+		{BucketConfig{RefillRate: 1, Capacity: 2, Algorithm: "leaky"}, []models.SimulatedRequest{{}}},
+		{BucketConfig{RefillRate: 1, Capacity: 2}, nil},
+		{BucketConfig{RefillRate: 1, Capacity: 2}, []models.SimulatedRequest{{OffsetSeconds: -1}}},
+	}
+	for i, tc := range cases {
+		if _, err := SimulateRateLimit(tc.cfg, tc.requests); !errors.Is(err, ErrInvalidSimulation) {
+			t.Fatalf("case %d: expected ErrInvalidSimulation, got %v", i, err)
+		}
+	}
+}
+
+func TestLimiterManager_SimulatesRecordedRequests(t *testing.T) {
+	m := NewLimiterManager(nil)
+	now := time.Now()
+	m.now = func() time.Time { return now }
+	m.AddDefaultConfig("jobs_create", BucketConfig{RefillRate: 0.1, Capacity: 3})
+	for i := 0; i < 4; i++ {
+		m.RecordRequest("jobs_create", "user:alice", 1)
+		now = now.Add(100 * time.Millisecond)
+	}
+	now = now.Add(10 * time.Second)
+	m.RecordRequest("jobs_create", "user:alice", 1)
+
+	recorded := m.RecordedRequests("jobs_create")
+	if len(recorded) != 5 || recorded[0].OffsetSeconds != 0 || recorded[4].Scope != "user:alice" {
+		t.Fatalf("unexpected recorded timeline %+v", recorded)
+	}
+
+	// the current config rejects the 4th request of the burst
+	res, err := m.Simulate(models.RateLimitSimulationRequest{Name: "jobs_create"})
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	if res.Capacity != 3 || res.Accepted != 4 || res.Rejected != 1 {
+		t.Fatalf("expected the current config to reject 1 request, got %+v", res)
+	}
+	// a larger capacity lets the whole burst through
+	res, err = m.Simulate(models.RateLimitSimulationRequest{Name: "jobs_create", Capacity: 4})
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	if res.RefillRate != 0.1 || res.Rejected != 0 {
+		t.Fatalf("expected no rejections with capacity 4, got %+v", res)
+	}
+
+	// only the most recent requests are kept
+	for i := 0; i < limiterRecordSize; i++ {
+		m.RecordRequest("jobs_create", "user:bob", 1)
+	}
+	recorded = m.RecordedRequests("jobs_create")
+	if len(recorded) != limiterRecordSize || recorded[0].Scope != "user:bob" {
+		t.Fatalf("expected the ring to hold the last %d requests, got %d starting with %q", limiterRecordSize, len(recorded), recorded[0].Scope)
+	}
+}
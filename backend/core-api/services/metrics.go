@@ -1,6 +1,7 @@
 package services
 
 import (
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/events"
 	"github.com/AvinashMahala/ClusterGenie/backend/core-api/logger"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -11,7 +12,7 @@ var (
 		prometheus.CounterOpts{
 			Name: "clustergenie_rate_limit_exceeded_total",
 			Help: "Total number of requests rejected by rate limiter",
-		}, []string{"endpoint", "scope_type", "scope_id"},
+		}, []string{"endpoint", "scope_type"},
 	)
 
 	RateLimitAvailable = prometheus.NewGaugeVec(
@@ -21,6 +22,62 @@ var (
 		}, []string{"endpoint", "scope_type", "scope_id"},
 	)
 
+	// Scoped limiter buckets kept in memory and the ones dropped to bound them
+	LimiterTrackedScopes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "clustergenie_limiter_tracked_scopes",
+			Help: "Scoped rate limit buckets currently kept per limiter and scope type",
+		}, []string{"endpoint", "scope_type"},
+	)
+	LimiterEvictions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "clustergenie_limiter_evicted_buckets_total",
+			Help: "Scoped rate limit buckets dropped because they were idle or over the scope cap",
+		}, []string{"endpoint", "reason"},
+	)
+
+	// Redis limiter failures and the decisions made without Redis
+	LimiterBackendErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "clustergenie_limiter_backend_errors_total",
+			Help: "Redis errors seen by rate limiters",
+		}, []string{"limiter"},
+	)
+	LimiterFallbackDecisions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "clustergenie_limiter_fallback_decisions_total",
+			Help: "Rate limit decisions made by the failure mode because Redis was unavailable",
+		}, []string{"limiter", "mode"},
+	)
+	LimiterCircuitState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "clustergenie_limiter_circuit_state",
+			Help: "Circuit breaker state of the limiter backend: 0 closed, 1 half-open, 2 open",
+		}, []string{"backend"},
+	)
+
+	// Quota consumption in the current calendar window
+	QuotaUsed = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "clustergenie_quota_used",
+			Help: "Units used of a quota in the current day/month window (top N scopes, the rest averaged as other)",
+		}, []string{"quota", "scope_type", "scope_id"},
+	)
+	QuotaRemaining = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "clustergenie_quota_remaining",
+			Help: "Units left of a quota in the current day/month window (top N scopes, the rest averaged as other)",
+		}, []string{"quota", "scope_type", "scope_id"},
+	)
+
+	// Operations holding a concurrency slot
+	ConcurrencyInFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "clustergenie_concurrency_in_flight",
+			Help: "Operations this instance runs holding a concurrency slot, per limit",
+		}, []string{"limit", "scope_type"},
+	)
+
 	WorkerPoolQueueLength = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "clustergenie_workerpool_queue_length",
@@ -47,6 +104,14 @@ var (
 		}, []string{"job_type", "status"},
 	)
 
+	// Jobs stopped by the timeout reaper; phase is "running" or "orchestration"
+	JobsTimedOut = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "clustergenie_jobs_timed_out_total",
+			Help: "Jobs marked timed_out by job_type and phase",
+		}, []string{"job_type", "phase"},
+	)
+
 	// Observe job processing durations (seconds)
 	// Observe job processing durations (seconds)
 	// Use tighter buckets tuned for expected job durations so histogram_quantile queries are more meaningful.
@@ -76,6 +141,14 @@ var (
 		}, []string{"method", "path", "status"},
 	)
 
+	// Event stream (SSE/WebSocket) subscribers on the in-process broker
+	EventStreamSubscribers = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "clustergenie_event_stream_subscribers",
+			Help: "Number of active SSE/WebSocket event stream subscribers",
+		},
+	)
+
 	// DB-backed cluster metrics exporter (gauge values per cluster/type)
 	ClusterMetricGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -145,12 +218,21 @@ func RegisterPrometheusMetrics() {
 
 	tryRegisterCounterVec(&RateLimitExceeded, RateLimitExceeded, "clustergenie_rate_limit_exceeded_total")
 	tryRegisterGaugeVec(&RateLimitAvailable, RateLimitAvailable, "clustergenie_rate_limit_available_tokens")
+	tryRegisterGaugeVec(&LimiterTrackedScopes, LimiterTrackedScopes, "clustergenie_limiter_tracked_scopes")
+	tryRegisterCounterVec(&LimiterEvictions, LimiterEvictions, "clustergenie_limiter_evicted_buckets_total")
+	tryRegisterCounterVec(&LimiterBackendErrors, LimiterBackendErrors, "clustergenie_limiter_backend_errors_total")
+	tryRegisterCounterVec(&LimiterFallbackDecisions, LimiterFallbackDecisions, "clustergenie_limiter_fallback_decisions_total")
+	tryRegisterGaugeVec(&LimiterCircuitState, LimiterCircuitState, "clustergenie_limiter_circuit_state")
+	tryRegisterGaugeVec(&QuotaUsed, QuotaUsed, "clustergenie_quota_used")
+	tryRegisterGaugeVec(&QuotaRemaining, QuotaRemaining, "clustergenie_quota_remaining")
+	tryRegisterGaugeVec(&ConcurrencyInFlight, ConcurrencyInFlight, "clustergenie_concurrency_in_flight")
 	// Gauges (single)
 	tryRegisterGauge(&WorkerPoolQueueLength, WorkerPoolQueueLength, "clustergenie_workerpool_queue_length")
 	tryRegisterGauge(&WorkerPoolActiveWorkers, WorkerPoolActiveWorkers, "clustergenie_workerpool_active_workers")
 	tryRegisterGauge(&WorkerPoolCount, WorkerPoolCount, "clustergenie_workerpool_worker_count")
 
 	tryRegisterCounterVec(&JobsProcessed, JobsProcessed, "clustergenie_jobs_processed_total")
+	tryRegisterCounterVec(&JobsTimedOut, JobsTimedOut, "clustergenie_jobs_timed_out_total")
 
 	if err := prometheus.Register(JobProcessingSeconds); err != nil {
 		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
@@ -180,4 +262,19 @@ func RegisterPrometheusMetrics() {
 
 	// register cluster metric exporter gauge
 	tryRegisterGaugeVec(&ClusterMetricGauge, ClusterMetricGauge, "clustergenie_cluster_metric_value")
+
+	// event stream observability; drops are read straight from the broker counters
+	tryRegisterGauge(&EventStreamSubscribers, EventStreamSubscribers, "clustergenie_event_stream_subscribers")
+	droppedEvents := prometheus.NewCounterFunc(
+		prometheus.CounterOpts{
+			Name: "clustergenie_event_stream_dropped_total",
+			Help: "Events dropped because an SSE/WebSocket subscriber was too slow",
+		},
+		func() float64 { return float64(events.DefaultBroker.Stats().Dropped) },
+	)
+	if err := prometheus.Register(droppedEvents); err != nil {
+		if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+			logger.Errorf("failed to register event stream dropped counter: %v", err)
+		}
+	}
 }
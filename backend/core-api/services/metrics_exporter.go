@@ -29,7 +29,7 @@ func StartClusterMetricsExporter(clusterRepo interfaces.ClusterRepository, metri
 }
 
 func exportOnce(clusterRepo interfaces.ClusterRepository, metricRepo interfaces.MetricRepository) {
-	clusters, err := clusterRepo.ListClusters()
+	clusters, err := clusterRepo.ListClusters("")
 	if err != nil {
 		logger.Errorf("cluster metrics exporter: failed to list clusters: %v", err)
 		return
@@ -25,7 +25,7 @@ func (m *memDroplets) GetDroplet(id string) (*models.Droplet, error) {
 	}
 	return nil, nil
 }
-func (m *memDroplets) ListDroplets() ([]*models.Droplet, error) {
+func (m *memDroplets) ListDroplets(projectID string) ([]*models.Droplet, error) {
 	out := []*models.Droplet{}
 	for _, d := range m.store {
 		out = append(out, d)
@@ -93,7 +93,7 @@ func (m *memAutoscaleRepo2) GetPolicy(id string) (*models.AutoscalePolicy, error
 	}
 	return nil, nil
 }
-func (m *memAutoscaleRepo2) ListPolicies(clusterID string) ([]*models.AutoscalePolicy, error) {
+func (m *memAutoscaleRepo2) ListPolicies(projectID, clusterID string) ([]*models.AutoscalePolicy, error) {
 	out := []*models.AutoscalePolicy{}
 	for _, v := range m.store {
 		if v.ClusterID == clusterID {
@@ -124,7 +124,7 @@ func TestPhase8IntegrationFlow(t *testing.T) {
 
 	// billing
 	billing := NewBillingService(droprepo, provrepo)
-	est, err := billing.EstimateClusterCost("c-demo")
+	est, err := billing.EstimateClusterCost("", "c-demo")
 	if err != nil {
 		t.Fatalf("billing failed: %v", err)
 	}
@@ -159,5 +159,5 @@ func TestPhase8IntegrationFlow(t *testing.T) {
 	// replace monitoring service with fake for evaluation
 	autosvc.monitoringSvc = &MonitoringService{metricRepo: nil}
 	// could simulate evaluation but it's covered in unit tests; here ensure wiring doesn't panic
-	_, _ = autosvc.ListPolicies("c-demo")
+	_, _ = autosvc.ListPolicies("", "c-demo")
 }
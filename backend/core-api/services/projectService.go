@@ -0,0 +1,207 @@
+// backend/core-api/services/projectService.go
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/interfaces"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/logger"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+)
+
+// ErrInvalidProjectRequest is returned for projects without a name or with a rate limit
+// that has no capacity or a negative refill rate.
+var ErrInvalidProjectRequest = errors.New("projects need a name, and rate limits need a positive capacity and a non-negative refill rate")
+
+// ProjectLimiterScope is the limiter scope of a project's buckets.
+func ProjectLimiterScope(projectID string) string {
+	return "project:" + projectID
+}
+
+// ProjectService manages projects and applies their rate limits to the LimiterManager.
+type ProjectService struct {
+	repo    interfaces.ProjectRepository
+	limiter *LimiterManager
+	now     func() time.Time
+
+	mu      sync.Mutex
+	applied map[string]map[string]models.ProjectRateLimit // project -> limits set on the limiter
+	known   map[string]bool                               // project ids seen by the last SyncLimits or created since
+}
+
+// NewProjectService creates the service; limiter may be nil when rate limits are not used.
+func NewProjectService(repo interfaces.ProjectRepository, limiter *LimiterManager) *ProjectService {
+	return &ProjectService{
+		repo:    repo,
+		limiter: limiter,
+		now:     time.Now,
+		applied: make(map[string]map[string]models.ProjectRateLimit),
+		known:   make(map[string]bool),
+	}
+}
+
+// EnsureDefault creates the default project unless it exists.
+func (s *ProjectService) EnsureDefault() error {
+	if _, err := s.repo.Get(models.DefaultProjectID); err == nil {
+		return nil
+	}
+	now := s.now()
+	return s.repo.Create(&models.Project{ID: models.DefaultProjectID, Name: "Default", CreatedAt: now, UpdatedAt: now})
+}
+
+func validProjectLimits(limits map[string]models.ProjectRateLimit) bool {
+	for name, l := range limits {
+		if name == "" || l.Capacity <= 0 || l.RefillRate < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *ProjectService) Create(req models.CreateProjectRequest) (*models.Project, error) {
+	if req.Name == "" || !validProjectLimits(req.RateLimits) {
+		return nil, ErrInvalidProjectRequest
+	}
+	now := s.now()
+	p := &models.Project{Name: req.Name, Description: req.Description, RateLimits: req.RateLimits, CreatedAt: now, UpdatedAt: now}
+	if err := s.repo.Create(p); err != nil {
+		return nil, err
+	}
+	s.applyLimits(p)
+	s.mu.Lock()
+	s.known[p.ID] = true
+	s.mu.Unlock()
+	logger.Infof("project %s (%s) created", p.ID, p.Name)
+	return p, nil
+}
+
+func (s *ProjectService) Get(id string) (*models.Project, error) {
+	return s.repo.Get(id)
+}
+
+// Exists reports whether the project exists. Projects known from the last SyncLimits are
+// answered from memory; others are looked up, so projects created through other instances
+// work before the next sync.
+func (s *ProjectService) Exists(id string) bool {
+	s.mu.Lock()
+	ok := s.known[id]
+	s.mu.Unlock()
+	if ok {
+		return true
+	}
+	if _, err := s.repo.Get(id); err != nil {
+		return false
+	}
+	s.mu.Lock()
+	s.known[id] = true
+	s.mu.Unlock()
+	return true
+}
+
+func (s *ProjectService) List() ([]*models.Project, error) {
+	return s.repo.List()
+}
+
+// Update changes a project's name, description or rate limits and applies the limits to
+// this instance's limiter right away; other instances pick them up with SyncLimits.
+func (s *ProjectService) Update(id string, req models.UpdateProjectRequest) (*models.Project, error) {
+	p, err := s.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != "" {
+		p.Name = req.Name
+	}
+	if req.Description != nil {
+		p.Description = *req.Description
+	}
+	if req.RateLimits != nil {
+		if !validProjectLimits(*req.RateLimits) {
+			return nil, ErrInvalidProjectRequest
+		}
+		p.RateLimits = *req.RateLimits
+	}
+	p.UpdatedAt = s.now()
+	if err := s.repo.Update(p); err != nil {
+		return nil, err
+	}
+	s.applyLimits(p)
+	return p, nil
+}
+
+// SyncLimits applies the rate limits of every project, including changes made through
+// other instances, and refreshes the projects Exists knows.
+func (s *ProjectService) SyncLimits() error {
+	projects, err := s.repo.List()
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(projects))
+	for _, p := range projects {
+		s.applyLimits(p)
+		known[p.ID] = true
+	}
+	s.mu.Lock()
+	s.known = known
+	s.mu.Unlock()
+	return nil
+}
+
+// RunLimitSync calls SyncLimits every interval until ctx is done.
+func (s *ProjectService) RunLimitSync(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.SyncLimits(); err != nil {
+				logger.Warnf("syncing project rate limits failed: %v", err)
+			}
+		}
+	}
+}
+
+// applyLimits sets the project's limits as scope overrides of the limiter and removes the
+// overrides it no longer lists.
+func (s *ProjectService) applyLimits(p *models.Project) {
+	if s.limiter == nil {
+		return
+	}
+	scope := ProjectLimiterScope(p.ID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name := range s.applied[p.ID] {
+		if _, ok := p.RateLimits[name]; !ok {
+			s.limiter.SetScopeConfig(name, scope, nil)
+		}
+	}
+	applied := make(map[string]models.ProjectRateLimit, len(p.RateLimits))
+	for name, l := range p.RateLimits {
+		s.limiter.SetScopeConfig(name, scope, &BucketConfig{RefillRate: l.RefillRate, Capacity: l.Capacity})
+		applied[name] = l
+	}
+	s.applied[p.ID] = applied
+}
+
+// errNotInProject is what callers see for resources of other projects, so they cannot
+// tell them from missing ones.
+func errNotInProject(kind string) error {
+	return fmt.Errorf("%s not found", kind)
+}
+
+// checkClusterInProject fails unless clusterID is a cluster of projectID. Nothing is
+// checked without a project (internal callers) or a cluster service.
+func checkClusterInProject(clusters *ClusterService, projectID, clusterID string) error {
+	if projectID == "" || clusters == nil {
+		return nil
+	}
+	_, err := clusters.GetCluster(projectID, clusterID)
+	return err
+}
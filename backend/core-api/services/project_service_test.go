@@ -0,0 +1,179 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/interfaces"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/repositories"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupProjectDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed open sqlite: %v", err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.SetMaxOpenConns(1)
+	}
+	if err := db.AutoMigrate(&models.Project{}, &models.Droplet{}, &models.Job{}, &models.JobDependency{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	return db
+}
+
+func TestProjectService_RateLimitsOverrideProjectBuckets(t *testing.T) {
+	db := setupProjectDB(t)
+	limiter := NewLimiterManager(nil)
+	limiter.AddDefaultConfig("jobs_create_project", BucketConfig{RefillRate: 1, Capacity: 2})
+	svc := NewProjectService(repositories.NewProjectRepository(db), limiter)
+	if err := svc.EnsureDefault(); err != nil {
+		t.Fatalf("EnsureDefault failed: %v", err)
+	}
+	if err := svc.EnsureDefault(); err != nil {
+		t.Fatalf("EnsureDefault is not idempotent: %v", err)
+	}
+
+	capacityOf := func(m *LimiterManager, projectID string) float64 {
+		_, capacity, _ := m.GetOrCreate("jobs_create_project", ProjectLimiterScope(projectID)).Status()
+		return capacity
+	}
+	if c := capacityOf(limiter, models.DefaultProjectID); c != 2 {
+		t.Fatalf("expected the default project to use the default config, got capacity %v", c)
+	}
+
+	if _, err := svc.Create(models.CreateProjectRequest{Name: "bad", RateLimits: map[string]models.ProjectRateLimit{"jobs_create_project": {RefillRate: 1}}}); !errors.Is(err, ErrInvalidProjectRequest) {
+		t.Fatalf("expected ErrInvalidProjectRequest for a limit without capacity, got %v", err)
+	}
+	p, err := svc.Create(models.CreateProjectRequest{Name: "payments", RateLimits: map[string]models.ProjectRateLimit{"jobs_create_project": {RefillRate: 0.5, Capacity: 10}}})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if c := capacityOf(limiter, p.ID); c != 10 {
+		t.Fatalf("expected the project's limit to size its bucket, got capacity %v", c)
+	}
+	if c := capacityOf(limiter, models.DefaultProjectID); c != 2 {
+		t.Fatalf("expected other projects to keep the default config, got capacity %v", c)
+	}
+
+	// another instance picks the limits up with SyncLimits
+	other := NewLimiterManager(nil)
+	other.AddDefaultConfig("jobs_create_project", BucketConfig{RefillRate: 1, Capacity: 2})
+	if err := NewProjectService(repositories.NewProjectRepository(db), other).SyncLimits(); err != nil {
+		t.Fatalf("SyncLimits failed: %v", err)
+	}
+	if c := capacityOf(other, p.ID); c != 10 {
+		t.Fatalf("expected SyncLimits to apply the project's limit, got capacity %v", c)
+	}
+
+	// an empty rate_limits object removes the overrides
+	none := map[string]models.ProjectRateLimit{}
+	if _, err := svc.Update(p.ID, models.UpdateProjectRequest{RateLimits: &none}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if c := capacityOf(limiter, p.ID); c != 2 {
+		t.Fatalf("expected the default config after removing the limit, got capacity %v", c)
+	}
+	if _, err := svc.Update("project-missing", models.UpdateProjectRequest{Name: "x"}); err == nil {
+		t.Fatalf("expected an error updating a missing project")
+	}
+}
+
+func TestProjectScoping_DropletsAndJobs(t *testing.T) {
+	db := setupProjectDB(t)
+	provisioning := NewProvisioningService(repositories.NewDropletRepository(db, nil), nil, nil, nil)
+	jobs := NewJobService(repositories.NewJobRepository(db, nil), nil)
+
+	d, err := provisioning.CreateDroplet(&models.CreateDropletRequest{ProjectID: "team-a", Name: "web", Region: "nyc1", Size: "s-1vcpu-1gb", Image: "ubuntu"})
+	if err != nil {
+		t.Fatalf("CreateDroplet failed: %v", err)
+	}
+	if _, err := provisioning.CreateDroplet(&models.CreateDropletRequest{Name: "legacy", Region: "nyc1", Size: "s-1vcpu-1gb", Image: "ubuntu"}); err != nil {
+		t.Fatalf("CreateDroplet failed: %v", err)
+	}
+	if list, _ := provisioning.ListDroplets("team-a"); len(list) != 1 || list[0].ID != d.Droplet.ID {
+		t.Fatalf("expected only team-a's droplet, got %+v", list)
+	}
+	if list, _ := provisioning.ListDroplets(models.DefaultProjectID); len(list) != 1 || list[0].Name != "legacy" {
+		t.Fatalf("expected droplets without a project in the default project, got %+v", list)
+	}
+	if list, _ := provisioning.ListDroplets(""); len(list) != 2 {
+		t.Fatalf("expected internal callers to see every droplet, got %d", len(list))
+	}
+	if _, err := provisioning.GetDroplet("team-b", d.Droplet.ID); err == nil || err.Error() != "droplet not found" {
+		t.Fatalf("expected another project's droplet to look missing, got %v", err)
+	}
+	if err := provisioning.DeleteDroplet("team-b", d.Droplet.ID); err == nil {
+		t.Fatalf("expected deleting another project's droplet to fail")
+	}
+	if _, err := provisioning.GetDroplet("team-a", d.Droplet.ID); err != nil {
+		t.Fatalf("expected the droplet to survive, got %v", err)
+	}
+
+	job, err := jobs.CreateJob(&models.CreateJobRequest{ProjectID: "team-a", Type: "diagnose"})
+	if err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+	if _, err := jobs.GetJob("team-b", job.Job.ID); err == nil {
+		t.Fatalf("expected another project's job to look missing")
+	}
+	if got, err := jobs.GetJob("team-a", job.Job.ID); err != nil || got.ProjectID != "team-a" {
+		t.Fatalf("expected the job in team-a, got %+v, %v", got, err)
+	}
+	list, err := jobs.ListJobs(&models.GetJobsRequest{JobFilter: models.JobFilter{ProjectID: "team-b"}})
+	if err != nil {
+		t.Fatalf("ListJobs failed: %v", err)
+	}
+	if len(list.Jobs) != 0 {
+		t.Fatalf("expected no jobs in team-b, got %d", len(list.Jobs))
+	}
+}
+
+// countingProjectRepo counts project lookups.
+type countingProjectRepo struct {
+	interfaces.ProjectRepository
+	gets int
+}
+
+func (r *countingProjectRepo) Get(id string) (*models.Project, error) {
+	r.gets++
+	return r.ProjectRepository.Get(id)
+}
+
+func TestProjectService_ExistsAnswersSyncedProjectsFromMemory(t *testing.T) {
+	db := setupProjectDB(t)
+	repo := &countingProjectRepo{ProjectRepository: repositories.NewProjectRepository(db)}
+	svc := NewProjectService(repo, nil)
+	if err := svc.EnsureDefault(); err != nil {
+		t.Fatalf("EnsureDefault failed: %v", err)
+	}
+	if err := svc.SyncLimits(); err != nil {
+		t.Fatalf("SyncLimits failed: %v", err)
+	}
+	repo.gets = 0
+	for i := 0; i < 3; i++ {
+		if !svc.Exists(models.DefaultProjectID) {
+			t.Fatalf("expected the default project to exist")
+		}
+	}
+	if repo.gets != 0 {
+		t.Fatalf("expected synced projects to be answered from memory, got %d lookups", repo.gets)
+	}
+
+	// created through another instance after the last sync
+	other := NewProjectService(repositories.NewProjectRepository(db), nil)
+	p, err := other.Create(models.CreateProjectRequest{Name: "team-a"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if !svc.Exists(p.ID) || !svc.Exists(p.ID) || repo.gets != 1 {
+		t.Fatalf("expected a new project to be looked up once, got %d lookups", repo.gets)
+	}
+	if svc.Exists("project-missing") {
+		t.Fatalf("expected a missing project not to exist")
+	}
+}
@@ -35,14 +35,19 @@ func (s *ProvisioningService) CreateDroplet(req *models.CreateDropletRequest) (*
 	if req.Name == "" || req.Region == "" {
 		return nil, errors.New("name and region are required")
 	}
-	// If cluster provided, validate it exists
+	// If cluster provided, validate it exists in the caller's project; droplets created
+	// internally (e.g. by scaling) join the cluster's project
 	if req.ClusterID != nil {
 		if s.clusterSvc == nil {
 			return nil, errors.New("cluster validation unavailable")
 		}
-		if _, err := s.clusterSvc.GetCluster(*req.ClusterID); err != nil {
+		cluster, err := s.clusterSvc.GetCluster(req.ProjectID, *req.ClusterID)
+		if err != nil {
 			return nil, errors.New("cluster not found")
 		}
+		if req.ProjectID == "" {
+			req.ProjectID = cluster.ProjectID
+		}
 	}
 	resp, err := s.dropletRepo.CreateDroplet(req)
 	if err != nil {
@@ -66,15 +71,34 @@ func (s *ProvisioningService) CreateDroplet(req *models.CreateDropletRequest) (*
 	return resp, nil
 }
 
-func (s *ProvisioningService) GetDroplet(id string) (*models.Droplet, error) {
-	return s.dropletRepo.GetDroplet(id)
+// clusters returns the cluster service, if any.
+func (s *ProvisioningService) clusters() *ClusterService {
+	if s == nil {
+		return nil
+	}
+	return s.clusterSvc
+}
+
+// GetDroplet returns a droplet of projectID ("" for any project).
+func (s *ProvisioningService) GetDroplet(projectID, id string) (*models.Droplet, error) {
+	d, err := s.dropletRepo.GetDroplet(id)
+	if err != nil {
+		return nil, err
+	}
+	if !models.InProject(projectID, d.ProjectID) {
+		return nil, errNotInProject("droplet")
+	}
+	return d, nil
 }
 
-func (s *ProvisioningService) ListDroplets() ([]*models.Droplet, error) {
-	return s.dropletRepo.ListDroplets()
+func (s *ProvisioningService) ListDroplets(projectID string) ([]*models.Droplet, error) {
+	return s.dropletRepo.ListDroplets(projectID)
 }
 
-func (s *ProvisioningService) DeleteDroplet(id string) error {
+func (s *ProvisioningService) DeleteDroplet(projectID, id string) error {
+	if _, err := s.GetDroplet(projectID, id); err != nil {
+		return err
+	}
 	return s.dropletRepo.DeleteDroplet(id)
 }
 
@@ -103,12 +127,17 @@ func (s *ProvisioningService) ScaleCluster(clusterID string, action string) erro
 		_, err := s.CreateDroplet(req)
 		return err
 	} else if action == "scale_down" {
-		// Remove a droplet (simplified - remove the first one)
-		droplets, err := s.ListDroplets()
-		if err != nil || len(droplets) == 0 {
-			return errors.New("no droplets to scale down")
+		// Remove a droplet of the cluster (simplified - remove the first one)
+		droplets, err := s.ListDroplets("")
+		if err != nil {
+			return err
+		}
+		for _, d := range droplets {
+			if d.ClusterID != nil && *d.ClusterID == clusterID {
+				return s.DeleteDroplet("", d.ID)
+			}
 		}
-		return s.DeleteDroplet(droplets[0].ID)
+		return errors.New("no droplets to scale down")
 	}
 	return errors.New("invalid scale action")
 }
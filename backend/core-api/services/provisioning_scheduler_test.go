@@ -54,7 +54,7 @@ func (m *memDropRepo) GetDroplet(id string) (*models.Droplet, error) {
 	}
 	return nil, nil
 }
-func (m *memDropRepo) ListDroplets() ([]*models.Droplet, error) {
+func (m *memDropRepo) ListDroplets(projectID string) ([]*models.Droplet, error) {
 	out := []*models.Droplet{}
 	for _, d := range m.store {
 		out = append(out, d)
@@ -85,7 +85,7 @@ func TestScaleClusterUsesScheduler(t *testing.T) {
 	}
 
 	// check that a droplet was created with provider assigned
-	list, _ := dropRepo.ListDroplets()
+	list, _ := dropRepo.ListDroplets("")
 	if len(list) != 1 {
 		t.Fatalf("expected 1 droplet, got %d", len(list))
 	}
@@ -0,0 +1,370 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// Quotas charged by the job endpoints and the scheduler.
+const (
+	QuotaProvisionJobs = "provision_jobs"
+	QuotaDroplets      = "droplets"
+	// AnonymousQuotaScope is the user scope charged for requests without a user, so they
+	// share one quota instead of escaping it.
+	AnonymousQuotaScope = "anonymous"
+)
+
+var (
+	ErrQuotaNotFound    = errors.New("quota not found")
+	ErrInvalidQuotaRule = errors.New("quota rules need a name, a scope of user or cluster, a period of day or month and a positive limit")
+	ErrQuotaUnavailable = errors.New("quota store unavailable")
+	// ErrQuotaTooLarge means a charge exceeds the whole limit, so it can never be allowed
+	ErrQuotaTooLarge = errors.New("request exceeds the quota limit")
+	// quotaWindows returns the calendar window [start, end) containing now, per period
+	quotaWindows = map[string]func(time.Time) (time.Time, time.Time){
+		models.QuotaPeriodDay: func(now time.Time) (time.Time, time.Time) {
+			start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+			return start, start.AddDate(0, 0, 1)
+		},
+		models.QuotaPeriodMonth: func(now time.Time) (time.Time, time.Time) {
+			start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+			return start, start.AddDate(0, 1, 0)
+		},
+	}
+)
+
+// QuotaCharge is what a request uses of a quota for one user or cluster.
+type QuotaCharge struct {
+	ScopeID string
+	Units   int64
+}
+
+// QuotaManager counts quota usage per user or cluster in calendar-aligned windows (days
+// start at midnight, months on the 1st, in the manager's time zone). Counters live in
+// Redis so all instances share them; without Redis they are kept in memory.
+type QuotaManager struct {
+	mu     sync.Mutex
+	rules  map[string]models.QuotaRule
+	redis  *redis.Client
+	loc    *time.Location
+	counts map[string]quotaCount // in-memory counters by window key
+	now    func() time.Time
+
+	exportMu sync.Mutex
+	latest   map[string]map[string]models.QuotaUsage // last seen usage by quota and scope id, see ExportMetrics
+	exported map[[3]string]struct{}                  // quota gauge series set by ExportMetrics
+}
+
+type quotaCount struct {
+	used int64
+	end  time.Time
+}
+
+// NewQuotaManager creates a quota manager; loc defaults to UTC.
+func NewQuotaManager(client *redis.Client, loc *time.Location) *QuotaManager {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &QuotaManager{
+		rules:  make(map[string]models.QuotaRule),
+		redis:  client,
+		loc:    loc,
+		counts: make(map[string]quotaCount),
+		now:    time.Now,
+		latest: make(map[string]map[string]models.QuotaUsage),
+	}
+}
+
+// AddRule registers or replaces a quota rule.
+func (q *QuotaManager) AddRule(rule models.QuotaRule) error {
+	if _, ok := quotaWindows[rule.Period]; !ok || rule.Name == "" || rule.Limit <= 0 ||
+		(rule.Scope != "user" && rule.Scope != "cluster") {
+		return ErrInvalidQuotaRule
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rules[rule.Name] = rule
+	return nil
+}
+
+// Rule returns the named rule.
+func (q *QuotaManager) Rule(name string) (models.QuotaRule, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	rule, ok := q.rules[name]
+	return rule, ok
+}
+
+// Rules returns all rules sorted by name.
+func (q *QuotaManager) Rules() []models.QuotaRule {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := make([]models.QuotaRule, 0, len(q.rules))
+	for _, rule := range q.rules {
+		out = append(out, rule)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// window returns the current calendar window of period.
+func (q *QuotaManager) window(period string) (time.Time, time.Time) {
+	return quotaWindows[period](q.now().In(q.loc))
+}
+
+func quotaKey(rule models.QuotaRule, scopeID string, start time.Time) string {
+	return fmt.Sprintf("quota:%s:%s:%s:%s", rule.Name, rule.Scope, scopeID, start.Format("20060102"))
+}
+
+// quotaUsage builds the usage of scopeID and remembers it for ExportMetrics; unused
+// scopes (e.g. looked up through the usage API) are not remembered.
+func (q *QuotaManager) quotaUsage(rule models.QuotaRule, scopeID string, used int64, end time.Time) models.QuotaUsage {
+	remaining := rule.Limit - used
+	if remaining < 0 {
+		remaining = 0
+	}
+	u := models.QuotaUsage{Name: rule.Name, Scope: rule.Scope, ScopeID: scopeID, Period: rule.Period,
+		Limit: rule.Limit, Used: used, Remaining: remaining, ResetsAt: end}
+	q.exportMu.Lock()
+	defer q.exportMu.Unlock()
+	if used == 0 {
+		delete(q.latest[rule.Name], scopeID)
+		return u
+	}
+	if q.latest[rule.Name] == nil {
+		q.latest[rule.Name] = make(map[string]models.QuotaUsage)
+	}
+	q.latest[rule.Name][scopeID] = u
+	return u
+}
+
+// ExportMetrics sets clustergenie_quota_used and clustergenie_quota_remaining from the
+// usage seen in the current windows. To keep label cardinality bounded, per quota only
+// the topN most used scopes get their own series; the rest are averaged into a single
+// series with scope_id "other". Series of scopes that dropped out are deleted.
+func (q *QuotaManager) ExportMetrics(topN int) {
+	if QuotaUsed == nil || QuotaRemaining == nil {
+		return
+	}
+	now := q.now()
+	q.exportMu.Lock()
+	defer q.exportMu.Unlock()
+	exported := make(map[[3]string]struct{})
+	set := func(u models.QuotaUsage, scopeID string, used, remaining float64) {
+		QuotaUsed.WithLabelValues(u.Name, u.Scope, scopeID).Set(used)
+		QuotaRemaining.WithLabelValues(u.Name, u.Scope, scopeID).Set(remaining)
+		exported[[3]string{u.Name, u.Scope, scopeID}] = struct{}{}
+	}
+	for name, scopes := range q.latest {
+		usages := make([]models.QuotaUsage, 0, len(scopes))
+		for id, u := range scopes {
+			if !now.Before(u.ResetsAt) {
+				delete(scopes, id)
+				continue
+			}
+			usages = append(usages, u)
+		}
+		if len(usages) == 0 {
+			delete(q.latest, name)
+			continue
+		}
+		sort.Slice(usages, func(i, j int) bool {
+			if usages[i].Used != usages[j].Used {
+				return usages[i].Used > usages[j].Used
+			}
+			return usages[i].ScopeID < usages[j].ScopeID
+		})
+		var restUsed, restRemaining float64
+		for i, u := range usages {
+			if topN > 0 && i >= topN {
+				restUsed += float64(u.Used)
+				restRemaining += float64(u.Remaining)
+				continue
+			}
+			set(u, u.ScopeID, float64(u.Used), float64(u.Remaining))
+		}
+		if n := len(usages) - topN; topN > 0 && n > 0 {
+			set(usages[0], LimiterOtherScope, restUsed/float64(n), restRemaining/float64(n))
+		}
+	}
+	for series := range q.exported {
+		if _, ok := exported[series]; !ok {
+			QuotaUsed.DeleteLabelValues(series[0], series[1], series[2])
+			QuotaRemaining.DeleteLabelValues(series[0], series[1], series[2])
+		}
+	}
+	q.exported = exported
+}
+
+// mergeQuotaCharges sums the charges per scope, dropping empty ones.
+func mergeQuotaCharges(charges []QuotaCharge) []QuotaCharge {
+	out := make([]QuotaCharge, 0, len(charges))
+	index := make(map[string]int, len(charges))
+	for _, ch := range charges {
+		if ch.ScopeID == "" || ch.Units <= 0 {
+			continue
+		}
+		if i, ok := index[ch.ScopeID]; ok {
+			out[i].Units += ch.Units
+			continue
+		}
+		index[ch.ScopeID] = len(out)
+		out = append(out, ch)
+	}
+	return out
+}
+
+// redisQuotaConsumeLua checks every key first and only increments them if all charges
+// fit. KEYS are the window keys; ARGV = limit, window end (ms), then the units per key.
+// Returns {1, index of the most used key, its usage} or {0, index of the first key that
+// does not fit, its usage}.
+var redisQuotaConsumeLua = redis.NewScript(`local limit=tonumber(ARGV[1])
+for i = 1, #KEYS do
+  local used = tonumber(redis.call('GET', KEYS[i]) or '0')
+  if used + tonumber(ARGV[i + 2]) > limit then
+    return {0, i, used}
+  end
+end
+local most = 0
+local mostUsed = -1
+for i = 1, #KEYS do
+  local used = redis.call('INCRBY', KEYS[i], ARGV[i + 2])
+  redis.call('PEXPIREAT', KEYS[i], ARGV[2])
+  if used > mostUsed then
+    most = i
+    mostUsed = used
+  end
+end
+return {1, most, mostUsed}`)
+
+// Consume charges the named quota for every scope in charges, or for none if one of them
+// would exceed the limit. It returns the usage of the scope closest to its limit, or of
+// the first scope that did not fit when ok is false. Charges larger than the limit fail
+// with ErrQuotaTooLarge.
+func (q *QuotaManager) Consume(name string, charges []QuotaCharge) (usage models.QuotaUsage, ok bool, err error) {
+	rule, found := q.Rule(name)
+	if !found {
+		return models.QuotaUsage{}, false, ErrQuotaNotFound
+	}
+	charges = mergeQuotaCharges(charges)
+	if len(charges) == 0 {
+		return models.QuotaUsage{Name: rule.Name, Scope: rule.Scope, Period: rule.Period, Limit: rule.Limit, Remaining: rule.Limit}, true, nil
+	}
+	for _, ch := range charges {
+		if ch.Units > rule.Limit {
+			return models.QuotaUsage{Name: rule.Name, Scope: rule.Scope, ScopeID: ch.ScopeID, Period: rule.Period, Limit: rule.Limit}, false, ErrQuotaTooLarge
+		}
+	}
+	start, end := q.window(rule.Period)
+
+	if q.redis != nil {
+		keys := make([]string, len(charges))
+		args := []interface{}{rule.Limit, end.UnixMilli()}
+		for i, ch := range charges {
+			keys[i] = quotaKey(rule, ch.ScopeID, start)
+			args = append(args, ch.Units)
+		}
+		res, err := redisQuotaConsumeLua.Run(context.Background(), q.redis, keys, args...).Int64Slice()
+		if err != nil || len(res) < 3 || res[1] < 1 || int(res[1]) > len(charges) {
+			return models.QuotaUsage{}, false, fmt.Errorf("%w: %v", ErrQuotaUnavailable, err)
+		}
+		return q.quotaUsage(rule, charges[res[1]-1].ScopeID, res[2], end), res[0] == 1, nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.now()
+	for key, c := range q.counts {
+		if !now.Before(c.end) {
+			delete(q.counts, key)
+		}
+	}
+	for _, ch := range charges {
+		used := q.counts[quotaKey(rule, ch.ScopeID, start)].used
+		if used+ch.Units > rule.Limit {
+			return q.quotaUsage(rule, ch.ScopeID, used, end), false, nil
+		}
+	}
+	var most QuotaCharge
+	mostUsed := int64(-1)
+	for _, ch := range charges {
+		key := quotaKey(rule, ch.ScopeID, start)
+		c := q.counts[key]
+		c.used += ch.Units
+		c.end = end
+		q.counts[key] = c
+		if c.used > mostUsed {
+			most, mostUsed = ch, c.used
+		}
+	}
+	return q.quotaUsage(rule, most.ScopeID, mostUsed, end), true, nil
+}
+
+// redisQuotaReleaseLua decrements each key by its units without going below zero.
+var redisQuotaReleaseLua = redis.NewScript(`for i = 1, #KEYS do
+  local used = tonumber(redis.call('GET', KEYS[i]) or '0')
+  if used > 0 then
+    redis.call('DECRBY', KEYS[i], math.min(used, tonumber(ARGV[i])))
+  end
+end
+return 0`)
+
+// Release gives back charges taken by Consume, e.g. when the request failed afterwards.
+// Charges are released from the current window.
+func (q *QuotaManager) Release(name string, charges []QuotaCharge) error {
+	rule, found := q.Rule(name)
+	if !found {
+		return ErrQuotaNotFound
+	}
+	start, _ := q.window(rule.Period)
+	charges = mergeQuotaCharges(charges)
+	if q.redis != nil {
+		keys := make([]string, len(charges))
+		args := make([]interface{}, len(charges))
+		for i, ch := range charges {
+			keys[i] = quotaKey(rule, ch.ScopeID, start)
+			args[i] = ch.Units
+		}
+		return redisQuotaReleaseLua.Run(context.Background(), q.redis, keys, args...).Err()
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, ch := range charges {
+		key := quotaKey(rule, ch.ScopeID, start)
+		if c, ok := q.counts[key]; ok {
+			c.used -= ch.Units
+			if c.used < 0 {
+				c.used = 0
+			}
+			q.counts[key] = c
+		}
+	}
+	return nil
+}
+
+// Usage returns the current window's usage of the named quota by scopeID.
+func (q *QuotaManager) Usage(name string, scopeID string) (models.QuotaUsage, error) {
+	rule, found := q.Rule(name)
+	if !found {
+		return models.QuotaUsage{}, ErrQuotaNotFound
+	}
+	start, end := q.window(rule.Period)
+	key := quotaKey(rule, scopeID, start)
+	if q.redis != nil {
+		used, err := q.redis.Get(context.Background(), key).Int64()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return models.QuotaUsage{}, fmt.Errorf("%w: %v", ErrQuotaUnavailable, err)
+		}
+		return q.quotaUsage(rule, scopeID, used, end), nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.quotaUsage(rule, scopeID, q.counts[key].used, end), nil
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestQuotaManager_CalendarWindows(t *testing.T) {
+	q := NewQuotaManager(nil, nil)
+	clock := time.Date(2026, 1, 31, 23, 0, 0, 0, time.UTC)
+	q.now = func() time.Time { return clock }
+	if err := q.AddRule(models.QuotaRule{Name: "provision_jobs", Scope: "user", Period: models.QuotaPeriodDay, Limit: 3}); err != nil {
+		t.Fatalf("add rule: %v", err)
+	}
+	if err := q.AddRule(models.QuotaRule{Name: "droplets", Scope: "cluster", Period: models.QuotaPeriodMonth, Limit: 2}); err != nil {
+		t.Fatalf("add rule: %v", err)
+	}
+	if err := q.AddRule(models.QuotaRule{Name: "weekly", Scope: "user", Period: "week", Limit: 1}); !errors.Is(err, ErrInvalidQuotaRule) {
+		t.Fatalf("expected an unknown period to be rejected, got %v", err)
+	}
+
+	usage, ok, err := q.Consume("provision_jobs", []QuotaCharge{{ScopeID: "alice", Units: 2}})
+	if err != nil || !ok || usage.Used != 2 || usage.Remaining != 1 || !usage.ResetsAt.Equal(time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected usage after first charge: %+v ok=%v err=%v", usage, ok, err)
+	}
+	if usage, ok, _ := q.Consume("provision_jobs", []QuotaCharge{{ScopeID: "alice", Units: 2}}); ok || usage.Used != 2 {
+		t.Fatalf("expected a charge beyond the limit to be rejected without counting, got %+v ok=%v", usage, ok)
+	}
+	if _, _, err := q.Consume("provision_jobs", []QuotaCharge{{ScopeID: "bob", Units: 4}}); !errors.Is(err, ErrQuotaTooLarge) {
+		t.Fatalf("expected a charge above the whole limit to fail with ErrQuotaTooLarge, got %v", err)
+	}
+
+	// charges for several clusters are counted all together or not at all
+	if _, ok, _ := q.Consume("droplets", []QuotaCharge{{ScopeID: "c1", Units: 1}, {ScopeID: "c2", Units: 1}}); !ok {
+		t.Fatalf("expected droplets for c1 and c2 to fit")
+	}
+	if usage, ok, _ := q.Consume("droplets", []QuotaCharge{{ScopeID: "c2", Units: 1}, {ScopeID: "c1", Units: 1}, {ScopeID: "c1", Units: 1}}); ok || usage.ScopeID != "c1" {
+		t.Fatalf("expected c1 (2 more droplets) to reject the batch, got %+v ok=%v", usage, ok)
+	}
+	if usage, _ := q.Usage("droplets", "c2"); usage.Used != 1 {
+		t.Fatalf("expected the rejected batch not to count for c2, got %+v", usage)
+	}
+	if err := q.Release("droplets", []QuotaCharge{{ScopeID: "c2", Units: 1}}); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if usage, _ := q.Usage("droplets", "c2"); usage.Used != 0 || usage.Remaining != 2 {
+		t.Fatalf("expected the released droplet to be given back, got %+v", usage)
+	}
+
+	// a new day resets the daily quota; the monthly one resets with the month
+	clock = time.Date(2026, 2, 1, 0, 0, 1, 0, time.UTC)
+	if usage, _ := q.Usage("provision_jobs", "alice"); usage.Used != 0 {
+		t.Fatalf("expected a fresh day, got %+v", usage)
+	}
+	if usage, _ := q.Usage("droplets", "c1"); usage.Used != 0 || !usage.ResetsAt.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected a fresh month, got %+v", usage)
+	}
+	clock = time.Date(2026, 2, 20, 12, 0, 0, 0, time.UTC)
+	q.Consume("droplets", []QuotaCharge{{ScopeID: "c1", Units: 2}})
+	clock = time.Date(2026, 2, 28, 23, 59, 0, 0, time.UTC)
+	if _, ok, _ := q.Consume("droplets", []QuotaCharge{{ScopeID: "c1", Units: 1}}); ok {
+		t.Fatalf("expected the month's quota to still be used up")
+	}
+}
+
+func TestQuotaManager_ExportMetricsTopN(t *testing.T) {
+	q := NewQuotaManager(nil, nil)
+	_ = q.AddRule(models.QuotaRule{Name: "export_test", Scope: "user", Period: models.QuotaPeriodDay, Limit: 10})
+	for i, user := range []string{"a", "b", "c", "d"} {
+		q.Consume("export_test", []QuotaCharge{{ScopeID: user, Units: int64(i + 1)}})
+	}
+
+	q.ExportMetrics(2)
+	if used := testutil.ToFloat64(QuotaUsed.WithLabelValues("export_test", "user", "d")); used != 4 {
+		t.Fatalf("expected the most used scope to get its own series, got %f", used)
+	}
+	if used := testutil.ToFloat64(QuotaUsed.WithLabelValues("export_test", "user", LimiterOtherScope)); used != 1.5 {
+		t.Fatalf("expected the rest averaged into other, got %f", used)
+	}
+	if n := testutil.CollectAndCount(QuotaUsed, "clustergenie_quota_used"); n != 3 {
+		t.Fatalf("expected 3 series (top 2 plus other), got %d", n)
+	}
+
+	// series of scopes that dropped out of the top N are deleted
+	q.Release("export_test", []QuotaCharge{{ScopeID: "c", Units: 3}, {ScopeID: "d", Units: 4}})
+	q.Usage("export_test", "c")
+	q.Usage("export_test", "d")
+	q.ExportMetrics(2)
+	if n := testutil.CollectAndCount(QuotaRemaining, "clustergenie_quota_remaining"); n != 2 {
+		t.Fatalf("expected only the series of the used scopes, got %d", n)
+	}
+}
+
+func TestQuotaManager_TimeZone(t *testing.T) {
+	loc := time.FixedZone("UTC+10", 10*60*60)
+	q := NewQuotaManager(nil, loc)
+	q.now = func() time.Time { return time.Date(2026, 3, 1, 15, 0, 0, 0, time.UTC) }
+	_ = q.AddRule(models.QuotaRule{Name: "provision_jobs", Scope: "user", Period: models.QuotaPeriodDay, Limit: 3})
+	usage, _ := q.Usage("provision_jobs", "alice")
+	// 15:00 UTC is 01:00 on March 2nd in UTC+10, so the day ends at March 3rd 00:00 local time
+	if want := time.Date(2026, 3, 3, 0, 0, 0, 0, loc); !usage.ResetsAt.Equal(want) {
+		t.Fatalf("expected the window to end at %s, got %s", want, usage.ResetsAt)
+	}
+}
+
+func TestRedisQuotaManager_SharedCounters(t *testing.T) {
+	ctx := context.Background()
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+	client := redis.NewClient(&redis.Options{Addr: redisAddr})
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis not available on %s - skipping integration test", redisAddr)
+	}
+
+	rule := models.QuotaRule{Name: "quota_test", Scope: "cluster", Period: models.QuotaPeriodMonth, Limit: 3}
+	a := NewQuotaManager(client, nil)
+	b := NewQuotaManager(client, nil)
+	_ = a.AddRule(rule)
+	_ = b.AddRule(rule)
+	cluster := "c-" + time.Now().Format("150405.000000")
+	start, _ := a.window(rule.Period)
+	defer client.Del(ctx, quotaKey(rule, cluster, start), quotaKey(rule, cluster+"-2", start))
+
+	if _, ok, err := a.Consume(rule.Name, []QuotaCharge{{ScopeID: cluster, Units: 2}}); err != nil || !ok {
+		t.Fatalf("expected first charge to fit: ok=%v err=%v", ok, err)
+	}
+	if usage, ok, _ := b.Consume(rule.Name, []QuotaCharge{{ScopeID: cluster + "-2", Units: 1}, {ScopeID: cluster, Units: 2}}); ok || usage.ScopeID != cluster || usage.Used != 2 {
+		t.Fatalf("expected the shared counter to reject the batch, got %+v ok=%v", usage, ok)
+	}
+	if usage, _ := a.Usage(rule.Name, cluster+"-2"); usage.Used != 0 {
+		t.Fatalf("expected the rejected batch not to count, got %+v", usage)
+	}
+	if err := b.Release(rule.Name, []QuotaCharge{{ScopeID: cluster, Units: 5}}); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if usage, _ := a.Usage(rule.Name, cluster); usage.Used != 0 || usage.Remaining != 3 {
+		t.Fatalf("expected release to stop at zero, got %+v", usage)
+	}
+	if ttl := client.PTTL(ctx, quotaKey(rule, cluster, start)).Val(); ttl <= 0 || ttl > 32*24*time.Hour {
+		t.Fatalf("expected the counter to expire with the month, got ttl %s", ttl)
+	}
+}
@@ -73,12 +73,16 @@ func (s *SchedulerService) SchedulePlacement(clusterID string, preferred string,
 	return candidate, region, nil
 }
 
-// MigrateDroplet will update a droplet's provider to target and adjust provider usage counters
-func (s *SchedulerService) MigrateDroplet(dropletID string, targetProvider string) error {
+// MigrateDroplet will update a droplet of projectID ("" for any project) to the target
+// provider and adjust provider usage counters
+func (s *SchedulerService) MigrateDroplet(projectID, dropletID string, targetProvider string) error {
 	d, err := s.dropletRepo.GetDroplet(dropletID)
 	if err != nil {
 		return err
 	}
+	if !models.InProject(projectID, d.ProjectID) {
+		return errNotInProject("droplet")
+	}
 	if d.Provider == targetProvider {
 		return nil
 	}
@@ -45,7 +45,7 @@ func (m *memDropletRepo) GetDroplet(id string) (*models.Droplet, error) {
 	}
 	return nil, nil
 }
-func (m *memDropletRepo) ListDroplets() ([]*models.Droplet, error) {
+func (m *memDropletRepo) ListDroplets(projectID string) ([]*models.Droplet, error) {
 	out := []*models.Droplet{}
 	for _, d := range m.store {
 		out = append(out, d)
@@ -1,23 +1,79 @@
 package services
 
 import (
+	"context"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/AvinashMahala/ClusterGenie/backend/core-api/logger"
+	"github.com/google/uuid"
 )
 
-// WorkerPool processes job IDs using a pool of goroutines and a queue backed by a channel.
+// Scheduling modes for picking the next queued job.
+const (
+	// SchedFIFO runs jobs in submission order.
+	SchedFIFO = "fifo"
+	// SchedPriority always runs the highest priority job first (FIFO within a priority).
+	SchedPriority = "priority"
+	// SchedWFQ does weighted fair queuing across tenants; a job's weight comes from its priority.
+	SchedWFQ = "wfq"
+)
+
+// PriorityWeights are the WFQ weights per job priority; higher weight = larger share.
+var PriorityWeights = map[string]float64{
+	"high":   4,
+	"normal": 2,
+	"low":    1,
+}
+
+var priorityRank = map[string]int{"high": 2, "normal": 1, "low": 0}
+
+// SubmitOptions carries the scheduling attributes of a queued job.
+type SubmitOptions struct {
+	Priority string // high, normal (default), low
+	Tenant   string // fairness key, e.g. "user:alice" or "cluster:cluster-1"
+}
+
+type queueEntry struct {
+	jobID    string
+	priority string
+	tenant   string
+	seq      uint64
+	finish   float64 // WFQ virtual finish tag
+}
+
+// WorkerPool processes job IDs using a pool of goroutines. Queued ids wait in an
+// in-memory queue ordered by the scheduling mode; the items channel only carries one
+// token per queued id so idle workers can block on it.
 type WorkerPool struct {
-	queue       chan string
+	items       chan struct{}
+	capacity    int
 	workerCount int32
 	active      int32
 	stopCh      chan struct{}
 	running     int32
 	handler     func(jobID string)
 	mu          sync.Mutex
-	queued      []string
+	quit        []chan struct{} // one per live worker; closing it retires that worker
+	nextID      int
+	workers     sync.WaitGroup  // live worker goroutines
+	inflight    *sync.WaitGroup // handler calls in progress; replaced on Resume so it is never reused after a Wait
+	draining    bool
+	resumeCh    chan struct{} // closed by Resume to wake workers paused by Drain
+	mode        string
+	pending     []*queueEntry
+	seq         uint64
+	vtime       float64            // WFQ virtual time (self-clocked): finish tag of the last dispatched job
+	lastFinish  map[string]float64 // WFQ finish tag of each tenant's latest queued job
+
+	// optional durable backing for the queue; nil keeps the queue in memory only
+	store          QueueStore
+	lease          time.Duration
+	leaseGrace     time.Duration // how long past expiry a lease is still left to its owner
+	owner          string        // identifies this pool's leases in the store
+	onLeaseExpired func(jobID string)
 }
 
 // NewWorkerPool creates a worker pool with numWorkers and queueSize. handler(jobID) will be called by workers.
@@ -29,94 +85,577 @@ func NewWorkerPool(numWorkers int, queueSize int, handler func(jobID string)) *W
 		queueSize = 100
 	}
 	wp := &WorkerPool{
-		queue:       make(chan string, queueSize),
+		items:       make(chan struct{}, queueSize),
+		capacity:    queueSize,
 		workerCount: int32(numWorkers),
 		active:      0,
 		stopCh:      make(chan struct{}),
 		handler:     handler,
+		mode:        SchedFIFO,
+		lastFinish:  make(map[string]float64),
+		inflight:    new(sync.WaitGroup),
+		owner:       uuid.NewString(),
 	}
 	return wp
 }
 
+// SetStore backs the queue with a QueueStore. Workers claim each job with a lease that
+// is renewed while the handler runs; call before Start. An expired lease is reclaimed
+// only after a further grace period of one lease, so an instance that is slow to renew
+// (GC pause, Redis hiccup) keeps its job.
+func (w *WorkerPool) SetStore(store QueueStore, lease time.Duration) {
+	if lease <= 0 {
+		lease = 30 * time.Second
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.store = store
+	w.lease = lease
+	w.leaseGrace = lease
+}
+
+// SetLeaseGrace overrides how long past expiry a lease is left to its owner before it
+// is reclaimed.
+func (w *WorkerPool) SetLeaseGrace(grace time.Duration) {
+	if grace < 0 {
+		grace = 0
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.leaseGrace = grace
+}
+
+// Leased reports whether jobID is held by a worker of some instance, counting leases
+// that expired within the grace period as still held.
+func (w *WorkerPool) Leased(jobID string) (bool, error) {
+	if w.store == nil {
+		return false, nil
+	}
+	return w.store.Leased(jobID, time.Now().Add(-w.leaseGrace))
+}
+
+// SetLeaseExpiredHandler registers fn to be called for every job whose lease expired
+// without being acked (its worker or process died).
+func (w *WorkerPool) SetLeaseExpiredHandler(fn func(jobID string)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onLeaseExpired = fn
+}
+
+// SetSchedulingMode selects fifo, priority or wfq. Unknown modes fall back to fifo.
+func (w *WorkerPool) SetSchedulingMode(mode string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch mode {
+	case SchedPriority, SchedWFQ:
+		w.mode = mode
+	default:
+		w.mode = SchedFIFO
+	}
+}
+
+// SchedulingMode returns the active scheduling mode.
+func (w *WorkerPool) SchedulingMode() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.mode
+}
+
 // Start launches the worker goroutines.
 func (w *WorkerPool) Start() {
 	if !atomic.CompareAndSwapInt32(&w.running, 0, 1) {
 		return
 	}
-	for i := 0; i < int(w.workerCount); i++ {
-		go w.workerLoop(i)
+	w.mu.Lock()
+	for i := 0; i < int(atomic.LoadInt32(&w.workerCount)); i++ {
+		w.spawn()
+	}
+	w.mu.Unlock()
+	if w.store != nil {
+		go w.sweepLoop()
 	}
-	logger.Infof("WorkerPool started with %d workers and queue size %d", w.workerCount, cap(w.queue))
+	logger.Infof("WorkerPool started with %d workers, queue size %d, scheduling %s", w.WorkerCount(), w.capacity, w.SchedulingMode())
 }
 
-func (w *WorkerPool) workerLoop(id int) {
+// spawn starts one worker goroutine. Callers hold w.mu.
+func (w *WorkerPool) spawn() {
+	quit := make(chan struct{})
+	w.quit = append(w.quit, quit)
+	w.nextID++
+	w.workers.Add(1)
+	go w.workerLoop(w.nextID, quit)
+}
+
+// Resize changes the number of workers while the pool runs. Extra workers are started
+// right away; surplus workers exit once their current job (if any) finishes.
+func (w *WorkerPool) Resize(n int) {
+	if n <= 0 {
+		n = 1
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	atomic.StoreInt32(&w.workerCount, int32(n))
+	if atomic.LoadInt32(&w.running) == 0 {
+		// not started yet (or stopped): Start picks up the new count
+		return
+	}
+	for len(w.quit) < n {
+		w.spawn()
+	}
+	for len(w.quit) > n {
+		last := len(w.quit) - 1
+		close(w.quit[last])
+		w.quit = w.quit[:last]
+	}
+	logger.Infof("WorkerPool resized to %d workers", n)
+}
+
+// Drain stops the pool from accepting new jobs and from starting queued ones, then
+// waits for the jobs already running to finish or ctx to expire. Queued jobs stay
+// queued (and in the store) until Resume, or until the next start when the queue is
+// durable.
+func (w *WorkerPool) Drain(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.draining {
+		w.draining = true
+		w.resumeCh = make(chan struct{})
+		logger.Infof("WorkerPool draining (%d jobs in flight)", atomic.LoadInt32(&w.active))
+	}
+	inflight := w.inflight
+	w.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Resume leaves drain mode: Submit is accepted again and workers pick up queued jobs.
+func (w *WorkerPool) Resume() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.draining {
+		return
+	}
+	w.draining = false
+	close(w.resumeCh)
+	w.resumeCh = nil
+	w.inflight = new(sync.WaitGroup)
+	logger.Info("WorkerPool resumed")
+}
+
+// Draining reports whether the pool is in drain mode.
+func (w *WorkerPool) Draining() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.draining
+}
+
+func (w *WorkerPool) workerLoop(id int, quit chan struct{}) {
+	defer w.workers.Done()
 	for {
+		w.mu.Lock()
+		paused := w.resumeCh
+		w.mu.Unlock()
+		if paused != nil {
+			select {
+			case <-w.stopCh:
+				return
+			case <-quit:
+				return
+			case <-paused:
+			}
+			continue
+		}
+
 		select {
 		case <-w.stopCh:
 			return
-		case jobID := <-w.queue:
-			atomic.AddInt32(&w.active, 1)
-			// call the handler, but protect with recover
-			func() {
-				defer atomic.AddInt32(&w.active, -1)
-				defer func() {
-					if r := recover(); r != nil {
-						logger.Errorf("worker %d recovered from panic: %v", id, r)
-					}
-				}()
-				// allow handler to run
-				// remove job id from the queued list (best-effort)
-				w.mu.Lock()
-				for i, v := range w.queued {
-					if v == jobID {
-						w.queued = append(w.queued[:i], w.queued[i+1:]...)
-						break
-					}
-				}
+		case <-quit:
+			return
+		case <-w.items:
+			w.mu.Lock()
+			if w.draining {
+				// drain started while we waited: hand the token back and pause
+				w.items <- struct{}{}
 				w.mu.Unlock()
+				continue
+			}
+			jobID, ok := w.next()
+			inflight := w.inflight
+			if ok {
+				// registered under w.mu so Drain never misses a job it let start
+				inflight.Add(1)
+			}
+			w.mu.Unlock()
+			if !ok {
+				// the id behind this token was removed (e.g. cancelled) while waiting
+				continue
+			}
+			w.run(id, jobID, inflight)
+		}
+	}
+}
+
+// run executes one dequeued job on worker id.
+func (w *WorkerPool) run(id int, jobID string, inflight *sync.WaitGroup) {
+	defer inflight.Done()
+	if !w.claim(jobID) {
+		return
+	}
 
-				if w.handler != nil {
-					w.handler(jobID)
+	atomic.AddInt32(&w.active, 1)
+	defer atomic.AddInt32(&w.active, -1)
+	// call the handler, but protect with recover
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("worker %d recovered from panic: %v", id, r)
+		}
+	}()
+	if w.store != nil {
+		stop := w.renewLease(jobID)
+		defer w.ack(jobID)
+		defer close(stop)
+	}
+	if w.handler != nil {
+		w.handler(jobID)
+	}
+}
+
+// claim takes the durable lease for a dequeued job. It returns false when another
+// instance already claimed it or it was removed from the store.
+func (w *WorkerPool) claim(jobID string) bool {
+	if w.store == nil {
+		return true
+	}
+	ok, err := w.store.Claim(jobID, w.owner, w.lease)
+	if err != nil {
+		// store unavailable: run the job anyway rather than dropping it
+		logger.Warnf("WorkerPool: failed to claim job %s: %v", jobID, err)
+		return true
+	}
+	return ok
+}
+
+// renewLease extends the job's lease every third of the lease period until stop is closed.
+func (w *WorkerPool) renewLease(jobID string) chan struct{} {
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(w.lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				ok, err := w.store.Renew(jobID, w.owner, w.lease)
+				if err != nil {
+					logger.Warnf("WorkerPool: failed to renew lease of job %s: %v", jobID, err)
+				} else if !ok {
+					// reaped after the grace period; the job is retried elsewhere
+					logger.Warnf("WorkerPool: lost the lease of job %s", jobID)
+					return
 				}
-			}()
+			}
+		}
+	}()
+	return stop
+}
+
+func (w *WorkerPool) ack(jobID string) {
+	if err := w.store.Ack(jobID, w.owner); err != nil {
+		logger.Warnf("WorkerPool: failed to ack job %s: %v", jobID, err)
+	}
+}
+
+// sweepLoop hands leases expired for longer than the grace period to the lease-expired
+// handler and adopts pending entries from the store that this instance does not know
+// about yet (e.g. queued by another instance that went away).
+func (w *WorkerPool) sweepLoop() {
+	ticker := time.NewTicker(w.lease / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stopCh:
+			return
+		case <-ticker.C:
+			w.reapExpired()
+			if _, err := w.Recover(); err != nil {
+				logger.Warnf("WorkerPool: failed to sync queue from store: %v", err)
+			}
+		}
+	}
+}
+
+func (w *WorkerPool) reapExpired() {
+	ids, err := w.store.ReapExpired(time.Now().Add(-w.leaseGrace))
+	if err != nil {
+		logger.Warnf("WorkerPool: failed to reap expired leases: %v", err)
+		return
+	}
+	w.mu.Lock()
+	fn := w.onLeaseExpired
+	w.mu.Unlock()
+	for _, id := range ids {
+		logger.Warnf("WorkerPool: lease of job %s expired", id)
+		if fn != nil {
+			fn(id)
 		}
 	}
 }
 
-// Stop signals workers to shutdown.
+// Recover loads pending entries from the store into the in-memory queue, skipping ids
+// already queued. Entries that do not fit stay in the store for a later sync. It
+// returns the number of entries loaded.
+func (w *WorkerPool) Recover() (int, error) {
+	if w.store == nil {
+		return 0, nil
+	}
+	entries, err := w.store.Pending()
+	if err != nil {
+		return 0, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].EnqueuedAt.Before(entries[j].EnqueuedAt) })
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	known := make(map[string]bool, len(w.pending))
+	for _, e := range w.pending {
+		known[e.jobID] = true
+	}
+	loaded := 0
+	for _, e := range entries {
+		if known[e.JobID] {
+			continue
+		}
+		if len(w.pending) >= w.capacity {
+			break
+		}
+		w.push(e.JobID, SubmitOptions{Priority: e.Priority, Tenant: e.Tenant})
+		loaded++
+	}
+	if loaded > 0 {
+		logger.Infof("WorkerPool: recovered %d queued jobs from store", loaded)
+	}
+	return loaded, nil
+}
+
+// Contains reports whether jobID is waiting in the queue.
+func (w *WorkerPool) Contains(jobID string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, e := range w.pending {
+		if e.jobID == jobID {
+			return true
+		}
+	}
+	return false
+}
+
+// next pops the queued entry chosen by the scheduling mode. Callers hold w.mu.
+func (w *WorkerPool) next() (string, bool) {
+	if len(w.pending) == 0 {
+		return "", false
+	}
+	best := 0
+	for i, e := range w.pending[1:] {
+		if w.before(e, w.pending[best]) {
+			best = i + 1
+		}
+	}
+	e := w.pending[best]
+	w.pending = append(w.pending[:best], w.pending[best+1:]...)
+	if e.finish > w.vtime {
+		w.vtime = e.finish
+	}
+	if w.lastFinish[e.tenant] == e.finish {
+		// tenant has nothing else queued; vtime already covers its finish tag
+		delete(w.lastFinish, e.tenant)
+	}
+	return e.jobID, true
+}
+
+// before reports whether a should run before b under the current mode.
+func (w *WorkerPool) before(a, b *queueEntry) bool {
+	switch w.mode {
+	case SchedPriority:
+		if ra, rb := priorityRank[a.priority], priorityRank[b.priority]; ra != rb {
+			return ra > rb
+		}
+	case SchedWFQ:
+		if a.finish != b.finish {
+			return a.finish < b.finish
+		}
+	}
+	return a.seq < b.seq
+}
+
+// Stop drains the pool and shuts the workers down, waiting up to timeout for running
+// jobs to finish. Jobs still running after the timeout are left to their lease.
 func (w *WorkerPool) Stop(timeout time.Duration) {
 	if !atomic.CompareAndSwapInt32(&w.running, 1, 0) {
 		return
 	}
+	if timeout > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := w.Drain(ctx); err != nil {
+			logger.Warnf("WorkerPool: %d jobs still running after %s", atomic.LoadInt32(&w.active), timeout)
+		}
+	}
 	close(w.stopCh)
-	// give some time for workers to finish
+	w.mu.Lock()
+	w.quit = nil
+	w.mu.Unlock()
 	if timeout > 0 {
-		time.Sleep(timeout)
+		done := make(chan struct{})
+		go func() {
+			w.workers.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(timeout):
+		}
 	}
 }
 
-// Submit enqueues a jobID. If the queue is full, returns false.
+// Submit enqueues a jobID with normal priority and no tenant. If the queue is full or the
+// pool is draining, returns false.
 func (w *WorkerPool) Submit(jobID string) bool {
-	select {
-	case w.queue <- jobID:
-		// track queued id for visualization
-		w.mu.Lock()
-		defer w.mu.Unlock()
-		w.queued = append(w.queued, jobID)
-		return true
-	default:
+	return w.SubmitWithOptions(jobID, SubmitOptions{})
+}
+
+// SubmitWithOptions enqueues a jobID with a priority and tenant. If the queue is full, the
+// pool is draining or the store cannot persist the entry, returns false.
+func (w *WorkerPool) SubmitWithOptions(jobID string, opts SubmitOptions) bool {
+	if _, ok := PriorityWeights[opts.Priority]; !ok {
+		opts.Priority = "normal"
+	}
+	w.mu.Lock()
+	full := w.draining || len(w.pending) >= w.capacity
+	store := w.store
+	w.mu.Unlock()
+	if full {
 		return false
 	}
+	// persist before queueing, outside the lock since the store may be a Redis round trip;
+	// a worker then never dequeues a job it cannot claim
+	if store != nil {
+		if err := store.Add(QueueEntry{JobID: jobID, Priority: opts.Priority, Tenant: opts.Tenant, EnqueuedAt: time.Now()}); err != nil {
+			logger.Warnf("WorkerPool: failed to persist job %s: %v", jobID, err)
+			return false
+		}
+	}
+	w.mu.Lock()
+	if w.draining || len(w.pending) >= w.capacity {
+		w.mu.Unlock()
+		// filled up or started draining while persisting: take the entry back out
+		if store != nil {
+			if err := store.Remove(jobID); err != nil {
+				logger.Warnf("WorkerPool: failed to remove job %s from store: %v", jobID, err)
+			}
+		}
+		return false
+	}
+	w.push(jobID, opts)
+	w.mu.Unlock()
+	return true
+}
+
+// push appends an entry to the in-memory queue. Callers hold w.mu and checked capacity.
+func (w *WorkerPool) push(jobID string, opts SubmitOptions) {
+	w.seq++
+	e := &queueEntry{jobID: jobID, priority: opts.Priority, tenant: opts.Tenant, seq: w.seq}
+	// WFQ: a tenant's job finishes 1/weight after the later of now (virtual) and its
+	// previous job, so backlogged tenants interleave instead of draining in order
+	start := w.vtime
+	if f := w.lastFinish[opts.Tenant]; f > start {
+		start = f
+	}
+	e.finish = start + 1/PriorityWeights[opts.Priority]
+	w.lastFinish[opts.Tenant] = e.finish
+	w.pending = append(w.pending, e)
+	// never blocks: tokens never outnumber pending entries
+	w.items <- struct{}{}
+}
+
+// Remove drops a queued jobID so no worker will process it. It returns false when the
+// id is not waiting in the queue (already picked up by a worker or never submitted).
+func (w *WorkerPool) Remove(jobID string) bool {
+	w.mu.Lock()
+	store, removed := w.store, w.removePending(jobID)
+	w.mu.Unlock()
+	if removed && store != nil {
+		if err := store.Remove(jobID); err != nil {
+			logger.Warnf("WorkerPool: failed to remove job %s from store: %v", jobID, err)
+		}
+	}
+	return removed
+}
+
+// removePending drops jobID from the in-memory queue. Callers hold w.mu.
+func (w *WorkerPool) removePending(jobID string) bool {
+	for i, e := range w.pending {
+		if e.jobID == jobID {
+			w.pending = append(w.pending[:i], w.pending[i+1:]...)
+			if w.lastFinish[e.tenant] == e.finish {
+				delete(w.lastFinish, e.tenant)
+				for _, o := range w.pending {
+					if o.tenant == e.tenant && o.finish > w.lastFinish[e.tenant] {
+						w.lastFinish[e.tenant] = o.finish
+					}
+				}
+			}
+			// drop its token if no worker holds it yet
+			select {
+			case <-w.items:
+			default:
+			}
+			return true
+		}
+	}
+	return false
 }
 
 // QueueLength returns current items in queue.
 func (w *WorkerPool) QueueLength() int {
-	return len(w.queue)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.pending)
+}
+
+// QueueDepthByPriority returns the number of queued jobs per priority.
+func (w *WorkerPool) QueueDepthByPriority() map[string]int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	out := map[string]int{"high": 0, "normal": 0, "low": 0}
+	for _, e := range w.pending {
+		out[e.priority]++
+	}
+	return out
+}
+
+// QueueDepthByTenant returns the number of queued jobs per tenant ("" for untagged jobs).
+func (w *WorkerPool) QueueDepthByTenant() map[string]int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	out := make(map[string]int)
+	for _, e := range w.pending {
+		out[e.tenant]++
+	}
+	return out
 }
 
 // QueueCapacity returns the maximum number of items the queue can buffer.
 func (w *WorkerPool) QueueCapacity() int {
-	return cap(w.queue)
+	return w.capacity
 }
 
 // ActiveWorkers returns the number of active workers processing jobs.
@@ -126,14 +665,16 @@ func (w *WorkerPool) ActiveWorkers() int32 {
 
 // WorkerCount returns configured worker count.
 func (w *WorkerPool) WorkerCount() int32 {
-	return w.workerCount
+	return atomic.LoadInt32(&w.workerCount)
 }
 
-// SnapshotQueue returns a copy of queued job IDs (best-effort). It is thread-safe.
+// SnapshotQueue returns a copy of queued job IDs in submission order (best-effort). It is thread-safe.
 func (w *WorkerPool) SnapshotQueue() []string {
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	out := make([]string, len(w.queued))
-	copy(out, w.queued)
+	out := make([]string, len(w.pending))
+	for i, e := range w.pending {
+		out[i] = e.jobID
+	}
 	return out
 }
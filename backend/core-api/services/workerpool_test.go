@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"sync"
 	"testing"
 	"time"
@@ -24,7 +25,7 @@ func TestWorkerPoolProcessesJobs(t *testing.T) {
 
 	// submit 6 jobs
 	for i := 0; i < 6; i++ {
-		ok := wp.Submit("j-" + string('A'+i))
+		ok := wp.Submit("j-" + string(rune('A'+i)))
 		if !ok {
 			t.Fatalf("expected submit to succeed")
 		}
@@ -65,3 +66,166 @@ func TestWorkerPoolQueueSnapshot(t *testing.T) {
 		t.Fatalf("expected snapshot len %d got %d", len(ids), len(snap))
 	}
 }
+
+// drain pops every queued id in scheduling order without running workers
+func drain(wp *WorkerPool) []string {
+	var out []string
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	for {
+		id, ok := wp.next()
+		if !ok {
+			return out
+		}
+		out = append(out, id)
+	}
+}
+
+func TestWorkerPoolPriorityMode(t *testing.T) {
+	wp := NewWorkerPool(1, 10, func(string) {})
+	wp.SetSchedulingMode(SchedPriority)
+	wp.SubmitWithOptions("low-1", SubmitOptions{Priority: "low"})
+	wp.SubmitWithOptions("normal-1", SubmitOptions{})
+	wp.SubmitWithOptions("high-1", SubmitOptions{Priority: "high"})
+	wp.SubmitWithOptions("high-2", SubmitOptions{Priority: "high"})
+
+	depth := wp.QueueDepthByPriority()
+	if depth["high"] != 2 || depth["normal"] != 1 || depth["low"] != 1 {
+		t.Fatalf("unexpected per-priority depth: %v", depth)
+	}
+	got := drain(wp)
+	want := []string{"high-1", "high-2", "normal-1", "low-1"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected order %v, got %v", want, got)
+		}
+	}
+}
+
+func TestWorkerPoolWFQInterleavesTenants(t *testing.T) {
+	wp := NewWorkerPool(1, 20, func(string) {})
+	wp.SetSchedulingMode(SchedWFQ)
+	// a burst from one user lands before another user's single job
+	for i := 0; i < 6; i++ {
+		wp.SubmitWithOptions("burst-"+string(rune('a'+i)), SubmitOptions{Tenant: "user:bulk"})
+	}
+	wp.SubmitWithOptions("other", SubmitOptions{Tenant: "user:other"})
+	wp.SubmitWithOptions("urgent", SubmitOptions{Tenant: "user:third", Priority: "high"})
+
+	if d := wp.QueueDepthByTenant(); d["user:bulk"] != 6 || d["user:other"] != 1 {
+		t.Fatalf("unexpected per-tenant depth: %v", d)
+	}
+	got := drain(wp)
+	pos := func(id string) int {
+		for i, v := range got {
+			if v == id {
+				return i
+			}
+		}
+		return -1
+	}
+	// fair share: the other tenants run right after the burst's first job, not behind it
+	if pos("urgent") != 0 || pos("other") > 2 {
+		t.Fatalf("expected other tenants to interleave with the burst, got %v", got)
+	}
+	if pos("burst-a") > pos("burst-b") {
+		t.Fatalf("expected FIFO within a tenant, got %v", got)
+	}
+}
+
+func TestWorkerPoolRemoveKeepsCapacity(t *testing.T) {
+	wp := NewWorkerPool(1, 2, func(string) {})
+	if !wp.Submit("a") || !wp.Submit("b") || wp.Submit("c") {
+		t.Fatalf("expected queue of 2 to accept exactly 2 jobs")
+	}
+	if !wp.Remove("a") {
+		t.Fatalf("expected a to be removed")
+	}
+	if !wp.Submit("c") {
+		t.Fatalf("expected a freed slot after Remove")
+	}
+	if got := drain(wp); len(got) != 2 || got[0] != "b" || got[1] != "c" {
+		t.Fatalf("unexpected queue after remove: %v", got)
+	}
+}
+
+func TestWorkerPoolResize(t *testing.T) {
+	release := make(chan struct{})
+	wp := NewWorkerPool(1, 10, func(string) { <-release })
+	wp.Start()
+	defer wp.Stop(time.Second)
+	defer close(release)
+
+	for _, id := range []string{"a", "b", "c"} {
+		wp.Submit(id)
+	}
+	waitFor := func(active int32) {
+		deadline := time.Now().Add(time.Second)
+		for wp.ActiveWorkers() != active {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %d active workers, got %d", active, wp.ActiveWorkers())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	waitFor(1)
+
+	wp.Resize(3)
+	if wp.WorkerCount() != 3 {
+		t.Fatalf("expected worker count 3, got %d", wp.WorkerCount())
+	}
+	waitFor(3)
+
+	// shrinking never interrupts running jobs
+	wp.Resize(1)
+	if wp.WorkerCount() != 1 || wp.ActiveWorkers() != 3 {
+		t.Fatalf("expected 1 worker configured and 3 jobs still running, got %d/%d", wp.WorkerCount(), wp.ActiveWorkers())
+	}
+}
+
+func TestWorkerPoolDrainWaitsForInflight(t *testing.T) {
+	release := make(chan struct{})
+	var done sync.WaitGroup
+	done.Add(1)
+	wp := NewWorkerPool(1, 10, func(id string) {
+		if id == "running" {
+			<-release
+			done.Done()
+		}
+	})
+	wp.Start()
+	defer wp.Stop(time.Second)
+
+	wp.Submit("running")
+	for wp.ActiveWorkers() == 0 {
+		time.Sleep(5 * time.Millisecond)
+	}
+	wp.Submit("queued")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := wp.Drain(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected drain to time out while a job runs, got %v", err)
+	}
+	if wp.Submit("late") {
+		t.Fatalf("expected submit to be rejected while draining")
+	}
+
+	close(release)
+	if err := wp.Drain(context.Background()); err != nil {
+		t.Fatalf("drain failed: %v", err)
+	}
+	done.Wait()
+	// the queued job is not started during drain and runs after resume
+	if got := wp.SnapshotQueue(); len(got) != 1 || got[0] != "queued" {
+		t.Fatalf("expected queued job to stay queued, got %v", got)
+	}
+	wp.Resume()
+	deadline := time.Now().Add(time.Second)
+	for wp.QueueLength() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected queued job to run after resume")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
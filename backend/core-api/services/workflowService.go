@@ -0,0 +1,211 @@
+// backend/core-api/services/workflowService.go
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/interfaces"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/logger"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidWorkflow is returned for malformed workflow definitions (unknown keys, cycles...).
+var ErrInvalidWorkflow = errors.New("invalid workflow")
+
+// WorkflowService submits DAGs of jobs. Dependency resolution itself lives in
+// JobService so standalone jobs with depends_on behave the same way.
+type WorkflowService struct {
+	workflowRepo interfaces.WorkflowRepository
+	jobSvc       *JobService
+}
+
+func NewWorkflowService(workflowRepo interfaces.WorkflowRepository, jobSvc *JobService) *WorkflowService {
+	return &WorkflowService{workflowRepo: workflowRepo, jobSvc: jobSvc}
+}
+
+// CreateWorkflow validates the DAG, creates every job (roots pending, the rest blocked)
+// and only then enqueues the roots, so no parent can finish before its children exist.
+func (s *WorkflowService) CreateWorkflow(req *models.CreateWorkflowRequest) (*models.WorkflowResponse, error) {
+	order, err := topoSortWorkflow(req.Jobs, s.jobSvc)
+	if err != nil {
+		return nil, err
+	}
+	for _, spec := range order {
+		if cid := spec.Parameters["cluster_id"]; cid != "" {
+			if err := checkClusterInProject(s.jobSvc.clusterSvc, req.ProjectID, cid); err != nil {
+				return nil, fmt.Errorf("%w: job %q: %v", ErrInvalidWorkflow, spec.Key, err)
+			}
+		}
+	}
+
+	wf := &models.Workflow{
+		ID:        "wf-" + uuid.NewString(),
+		ProjectID: req.ProjectID,
+		Name:      req.Name,
+		Keys:      make(map[string]string, len(req.Jobs)),
+		CreatedAt: time.Now(),
+	}
+
+	reqs := make([]*models.CreateJobRequest, 0, len(order))
+	for _, spec := range order {
+		tenant := req.TenantID
+		if tenant == "" && spec.Parameters["cluster_id"] != "" {
+			tenant = "cluster:" + spec.Parameters["cluster_id"]
+		}
+		parents := make([]string, 0, len(spec.DependsOn))
+		for _, key := range spec.DependsOn {
+			parents = append(parents, wf.Keys[key])
+		}
+		// IDs are assigned up front so children can name parents stored in the same transaction
+		id := "job-" + spec.Type + "-" + time.Now().Format("20060102150405") + "-" + uuid.NewString()[:8]
+		wf.Keys[spec.Key] = id
+		reqs = append(reqs, &models.CreateJobRequest{
+			ID:             id,
+			Type:           spec.Type,
+			Parameters:     spec.Parameters,
+			DependsOn:      parents,
+			Priority:       spec.Priority,
+			TenantID:       tenant,
+			WorkflowID:     wf.ID,
+			ProjectID:      req.ProjectID,
+			TimeoutSeconds: int(s.jobSvc.timeoutFor(spec.Type).Seconds()),
+		})
+	}
+
+	// the jobs and the workflow are stored together so a failure leaves no orphaned jobs
+	jobs, err := s.workflowRepo.CreateWorkflow(wf, reqs)
+	if err != nil {
+		return nil, err
+	}
+	var roots []*models.Job
+	for _, job := range jobs {
+		if len(job.DependsOn) == 0 {
+			roots = append(roots, job)
+		}
+	}
+	logger.Infof("workflow %s created with %d jobs (%d roots)", wf.ID, len(order), len(roots))
+
+	for _, job := range roots {
+		if err := s.jobSvc.enqueue(job); err != nil {
+			logger.Warnf("workflow %s: failed to enqueue job %s: %v", wf.ID, job.ID, err)
+		}
+	}
+
+	resp, err := s.GetWorkflow("", wf.ID)
+	if err != nil {
+		return nil, err
+	}
+	resp.Message = "Workflow created successfully"
+	return resp, nil
+}
+
+// GetWorkflow returns a workflow of projectID ("" for any project) with its jobs and a
+// status derived from them.
+func (s *WorkflowService) GetWorkflow(projectID, id string) (*models.WorkflowResponse, error) {
+	wf, err := s.workflowRepo.GetWorkflow(id)
+	if err != nil {
+		return nil, err
+	}
+	if !models.InProject(projectID, wf.ProjectID) {
+		return nil, errNotInProject("workflow")
+	}
+	jobs, err := s.jobSvc.jobRepo.ListJobsByWorkflow(id)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, j := range jobs {
+		counts[j.Status]++
+	}
+	wf.Status = workflowStatus(jobs)
+	return &models.WorkflowResponse{Workflow: wf, Jobs: jobs, Counts: counts}, nil
+}
+
+// workflowStatus is "running" until every job is terminal, then "completed" if all
+// completed, "cancelled" if a job was cancelled and none failed, otherwise "failed".
+func workflowStatus(jobs []*models.Job) string {
+	cancelled, failed := false, false
+	for _, j := range jobs {
+		switch {
+		case !models.IsTerminalJobStatus(j.Status):
+			return "running"
+		case j.Status == "cancelled":
+			cancelled = true
+		case j.Status != "completed" && j.Status != "skipped":
+			failed = true
+		}
+	}
+	if failed {
+		return "failed"
+	}
+	if cancelled {
+		return "cancelled"
+	}
+	return "completed"
+}
+
+// topoSortWorkflow validates node keys, types, parameters, priorities and dependencies
+// and returns the nodes ordered so that every parent precedes its children. Cycles are rejected.
+func topoSortWorkflow(specs []models.WorkflowJobSpec, jobSvc *JobService) ([]models.WorkflowJobSpec, error) {
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("%w: at least one job is required", ErrInvalidWorkflow)
+	}
+	byKey := make(map[string]models.WorkflowJobSpec, len(specs))
+	for _, spec := range specs {
+		if spec.Key == "" {
+			return nil, fmt.Errorf("%w: every job needs a key", ErrInvalidWorkflow)
+		}
+		if _, dup := byKey[spec.Key]; dup {
+			return nil, fmt.Errorf("%w: duplicate key %q", ErrInvalidWorkflow, spec.Key)
+		}
+		if err := jobSvc.validateJobSpec(spec.Type, spec.Parameters); err != nil {
+			return nil, fmt.Errorf("%w: job %q: %v", ErrInvalidWorkflow, spec.Key, err)
+		}
+		if _, ok := PriorityWeights[spec.Priority]; spec.Priority != "" && !ok {
+			return nil, fmt.Errorf("%w: job %q has invalid priority %q", ErrInvalidWorkflow, spec.Key, spec.Priority)
+		}
+		byKey[spec.Key] = spec
+	}
+
+	// Kahn's algorithm, keeping the request order among ready nodes
+	indegree := make(map[string]int, len(specs))
+	children := make(map[string][]string)
+	for _, spec := range specs {
+		for _, dep := range spec.DependsOn {
+			if _, ok := byKey[dep]; !ok {
+				return nil, fmt.Errorf("%w: job %q depends on unknown key %q", ErrInvalidWorkflow, spec.Key, dep)
+			}
+			if dep == spec.Key {
+				return nil, fmt.Errorf("%w: job %q depends on itself", ErrInvalidWorkflow, spec.Key)
+			}
+			indegree[spec.Key]++
+			children[dep] = append(children[dep], spec.Key)
+		}
+	}
+	var ready []string
+	for _, spec := range specs {
+		if indegree[spec.Key] == 0 {
+			ready = append(ready, spec.Key)
+		}
+	}
+	order := make([]models.WorkflowJobSpec, 0, len(specs))
+	for len(ready) > 0 {
+		key := ready[0]
+		ready = ready[1:]
+		order = append(order, byKey[key])
+		for _, child := range children[key] {
+			indegree[child]--
+			if indegree[child] == 0 {
+				ready = append(ready, child)
+			}
+		}
+	}
+	if len(order) != len(specs) {
+		return nil, fmt.Errorf("%w: dependency cycle detected", ErrInvalidWorkflow)
+	}
+	return order, nil
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/repositories"
+)
+
+func TestTopoSortWorkflow_RejectsCyclesAndUnknownKeys(t *testing.T) {
+	jobSvc := NewJobService(nil, nil)
+	cyclic := []models.WorkflowJobSpec{
+		{Key: "a", Type: "monitor", DependsOn: []string{"c"}},
+		{Key: "b", Type: "monitor", DependsOn: []string{"a"}},
+		{Key: "c", Type: "monitor", DependsOn: []string{"b"}},
+	}
+	if _, err := topoSortWorkflow(cyclic, jobSvc); !errors.Is(err, ErrInvalidWorkflow) {
+		t.Fatalf("expected cycle to be rejected, got %v", err)
+	}
+	unknown := []models.WorkflowJobSpec{{Key: "a", Type: "monitor", DependsOn: []string{"missing"}}}
+	if _, err := topoSortWorkflow(unknown, jobSvc); !errors.Is(err, ErrInvalidWorkflow) {
+		t.Fatalf("expected unknown dependency to be rejected, got %v", err)
+	}
+
+	invalidParams := []models.WorkflowJobSpec{{Key: "a", Type: "provision"}}
+	if _, err := topoSortWorkflow(invalidParams, jobSvc); !errors.Is(err, ErrInvalidWorkflow) {
+		t.Fatalf("expected missing required parameter to be rejected, got %v", err)
+	}
+
+	order, err := topoSortWorkflow([]models.WorkflowJobSpec{
+		{Key: "diagnose", Type: "diagnose", DependsOn: []string{"monitor"}},
+		{Key: "monitor", Type: "monitor", DependsOn: []string{"provision"}},
+		{Key: "provision", Type: "provision", Parameters: map[string]string{"cluster_id": "cluster-1"}},
+	}, jobSvc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order[0].Key != "provision" || order[1].Key != "monitor" || order[2].Key != "diagnose" {
+		t.Fatalf("unexpected order: %v", order)
+	}
+}
+
+func TestWorkflow_ChildrenWaitForParentsAndSkipOnFailure(t *testing.T) {
+	jobSvc, db, prod := setupInMemoryJobService(t)
+	if err := db.AutoMigrate(&models.Workflow{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	svc := NewWorkflowService(repositories.NewWorkflowRepository(db), jobSvc)
+	if err := jobSvc.RegisterJobType(failingJob{}); err != nil {
+		t.Fatalf("RegisterJobType failed: %v", err)
+	}
+
+	resp, err := svc.CreateWorkflow(&models.CreateWorkflowRequest{
+		Name: "runbook",
+		Jobs: []models.WorkflowJobSpec{
+			{Key: "watch", Type: "monitor"},
+			{Key: "after-watch", Type: "monitor", DependsOn: []string{"watch"}},
+			{Key: "broken", Type: "always-fails"},
+			{Key: "child", Type: "diagnose", DependsOn: []string{"broken"}},
+			{Key: "grandchild", Type: "monitor", DependsOn: []string{"child", "watch"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateWorkflow failed: %v", err)
+	}
+	keys := resp.Workflow.Keys
+	if len(resp.Jobs) != 5 || resp.Workflow.Status != "running" {
+		t.Fatalf("expected 5 jobs in a running workflow, got %d (%s)", len(resp.Jobs), resp.Workflow.Status)
+	}
+	if job, _ := jobSvc.GetJob("", keys["after-watch"]); job.Status != "blocked" {
+		t.Fatalf("expected child to be blocked while parent runs, got %s", job.Status)
+	}
+
+	// failure propagates as skip through every descendant
+	child := waitForJobStatus(t, jobSvc, keys["child"], "skipped", time.Second)
+	if child.Error == "" {
+		t.Fatalf("expected skip reason to be recorded")
+	}
+	waitForJobStatus(t, jobSvc, keys["grandchild"], "skipped", time.Second)
+	if !prod.has("job_skipped") {
+		t.Fatalf("expected job_skipped events, got %v", prod.events)
+	}
+
+	// the monitor job takes ~1s; its child is released once it completes
+	waitForJobStatus(t, jobSvc, keys["watch"], "completed", 3*time.Second)
+	deadline := time.Now().Add(time.Second)
+	for {
+		job, _ := jobSvc.GetJob("", keys["after-watch"])
+		if job.Status != "blocked" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected child to be released after parent completed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	waitForJobStatus(t, jobSvc, keys["after-watch"], "completed", 3*time.Second)
+
+	got, err := svc.GetWorkflow("", resp.Workflow.ID)
+	if err != nil {
+		t.Fatalf("GetWorkflow failed: %v", err)
+	}
+	if got.Workflow.Status != "failed" || got.Counts["skipped"] != 2 || got.Counts["completed"] != 2 {
+		t.Fatalf("unexpected workflow state: status=%s counts=%v", got.Workflow.Status, got.Counts)
+	}
+}
+
+func TestCreateJob_DependsOnFinishedParent(t *testing.T) {
+	svc, db, _ := setupInMemoryJobService(t)
+	parent := &models.Job{ID: "job-parent", Type: "monitor", Status: "cancelled", CreatedAt: time.Now()}
+	if err := db.Create(parent).Error; err != nil {
+		t.Fatalf("create job failed: %v", err)
+	}
+	resp, err := svc.CreateJob(&models.CreateJobRequest{Type: "monitor", DependsOn: []string{parent.ID}})
+	if err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+	if resp.Job.Status != "skipped" {
+		t.Fatalf("expected job depending on a cancelled parent to be skipped, got %s", resp.Job.Status)
+	}
+	if _, err := svc.CreateJob(&models.CreateJobRequest{Type: "monitor", DependsOn: []string{"job-missing"}}); err == nil {
+		t.Fatalf("expected error for unknown dependency")
+	}
+}
+
+func TestCreateJob_DiagnoseParentReleasesChild(t *testing.T) {
+	svc, _, _ := setupInMemoryJobService(t)
+	parent, err := svc.CreateJob(&models.CreateJobRequest{Type: "diagnose"})
+	if err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+	child, err := svc.CreateJob(&models.CreateJobRequest{Type: "monitor", DependsOn: []string{parent.Job.ID}})
+	if err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+
+	// diagnose reports 100% progress before it returns; only its completion releases the child
+	done := waitForJobStatus(t, svc, parent.Job.ID, "completed", 4*time.Second)
+	if done.Result != "Diagnosis completed" {
+		t.Fatalf("expected the diagnose result to be recorded, got %q", done.Result)
+	}
+	waitForJobStatus(t, svc, child.Job.ID, "completed", 3*time.Second)
+}
+
+func TestWorkflow_FailedInsertLeavesNoJobs(t *testing.T) {
+	jobSvc, db, _ := setupInMemoryJobService(t)
+	// without a workflows table the workflow row cannot be stored
+	svc := NewWorkflowService(repositories.NewWorkflowRepository(db), jobSvc)
+
+	if _, err := svc.CreateWorkflow(&models.CreateWorkflowRequest{
+		Name: "runbook",
+		Jobs: []models.WorkflowJobSpec{
+			{Key: "watch", Type: "monitor"},
+			{Key: "after-watch", Type: "monitor", DependsOn: []string{"watch"}},
+		},
+	}); err == nil {
+		t.Fatalf("expected CreateWorkflow to fail")
+	}
+	var jobs, deps int64
+	db.Model(&models.Job{}).Count(&jobs)
+	db.Model(&models.JobDependency{}).Count(&deps)
+	if jobs != 0 || deps != 0 {
+		t.Fatalf("expected the jobs to be rolled back, got %d jobs and %d dependencies", jobs, deps)
+	}
+}
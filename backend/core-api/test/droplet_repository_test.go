@@ -88,7 +88,7 @@ func TestCreateDroplet_AssignsClusterAndReturnsWithCluster(t *testing.T) {
 	}
 
 	// list droplets and expect cluster present
-	list, err := repo.ListDroplets()
+	list, err := repo.ListDroplets("")
 	if err != nil {
 		t.Fatalf("ListDroplets failed: %v", err)
 	}
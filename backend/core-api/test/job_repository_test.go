@@ -1,9 +1,12 @@
 package coreapitest
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/AvinashMahala/ClusterGenie/backend/core-api/interfaces"
 	"github.com/AvinashMahala/ClusterGenie/backend/core-api/models"
 	"github.com/AvinashMahala/ClusterGenie/backend/core-api/repositories"
 	"gorm.io/driver/sqlite"
@@ -17,7 +20,7 @@ func setupInMemoryJobDB(t *testing.T) *gorm.DB {
 		t.Fatalf("failed open sqlite: %v", err)
 	}
 
-	if err := db.AutoMigrate(&models.Job{}); err != nil {
+	if err := db.AutoMigrate(&models.Job{}, &models.JobDependency{}); err != nil {
 		t.Fatalf("auto migrate failed: %v", err)
 	}
 	return db
@@ -51,12 +54,114 @@ func TestUpdateJobProgress(t *testing.T) {
 		t.Fatalf("expected progress 40, got %d", saved.Progress)
 	}
 
-	// mark complete
+	// full progress does not complete the job; only a status transition does
 	if err := repo.UpdateJobProgress(j.ID, 100, "done"); err != nil {
-		t.Fatalf("UpdateJobProgress to complete failed: %v", err)
+		t.Fatalf("UpdateJobProgress to 100 failed: %v", err)
 	}
 	saved2, _ := repo.GetJob(j.ID)
-	if saved2.Progress != 100 || saved2.Status != "completed" || saved2.CompletedAt == nil {
-		t.Fatalf("expected completed job; got progress=%d status=%s completedAt=%v", saved2.Progress, saved2.Status, saved2.CompletedAt)
+	if saved2.Progress != 100 || saved2.Status != "pending" || saved2.CompletedAt != nil {
+		t.Fatalf("expected progress 100 without a status change; got progress=%d status=%s completedAt=%v", saved2.Progress, saved2.Status, saved2.CompletedAt)
+	}
+
+	// a finished job keeps its progress and result
+	if err := repo.UpdateJobStatus(j.ID, "cancelled"); err != nil {
+		t.Fatalf("UpdateJobStatus failed: %v", err)
+	}
+	if err := repo.UpdateJobProgress(j.ID, 50, "late"); err != nil {
+		t.Fatalf("UpdateJobProgress failed: %v", err)
+	}
+	saved3, _ := repo.GetJob(j.ID)
+	if saved3.Status != "cancelled" || saved3.Progress != 100 || saved3.Result != "done" {
+		t.Fatalf("expected the cancelled job untouched; got status=%s progress=%d result=%s", saved3.Status, saved3.Progress, saved3.Result)
+	}
+}
+
+func TestListJobs_FiltersAndCursorPagination(t *testing.T) {
+	db := setupInMemoryJobDB(t)
+	repo := repositories.NewJobRepository(db, nil)
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		j := &models.Job{
+			ID:        fmt.Sprintf("job-%02d", i),
+			ClusterID: []string{"cluster-a", "cluster-b"}[i%2],
+			Type:      []string{"diagnose", "monitor"}[i%2],
+			Status:    "running",
+			TraceID:   fmt.Sprintf("trace-%02d", i),
+			// pairs of jobs share a timestamp so the id tie-break matters
+			CreatedAt: base.Add(time.Duration(i/2) * time.Minute),
+			Progress:  (i % 3) * 10,
+		}
+		if i < 4 {
+			done := base.Add(time.Hour + time.Duration(i)*time.Minute)
+			j.Status = "completed"
+			j.CompletedAt = &done
+		}
+		if err := db.Create(j).Error; err != nil {
+			t.Fatalf("create job failed: %v", err)
+		}
+	}
+
+	resp, err := repo.ListJobs(&models.GetJobsRequest{JobFilter: models.JobFilter{Statuses: []string{"completed"}, Types: []string{"diagnose"}}})
+	if err != nil || resp.Total != 2 || len(resp.Jobs) != 2 || resp.NextCursor != "" {
+		t.Fatalf("expected 2 completed diagnose jobs on one page, got %+v (%v)", resp, err)
+	}
+	after, before := base.Add(time.Minute), base.Add(3*time.Minute)
+	resp, _ = repo.ListJobs(&models.GetJobsRequest{JobFilter: models.JobFilter{ClusterID: "cluster-b", CreatedAfter: &after, CreatedBefore: &before}})
+	if resp.Total != 2 || resp.Jobs[0].ID != "job-05" || resp.Jobs[1].ID != "job-03" {
+		t.Fatalf("unexpected cluster/created range result: %+v", resp.Jobs)
+	}
+	resp, _ = repo.ListJobs(&models.GetJobsRequest{JobFilter: models.JobFilter{TraceID: "trace-07"}})
+	if resp.Total != 1 || resp.Jobs[0].ID != "job-07" {
+		t.Fatalf("unexpected trace_id result: %+v", resp.Jobs)
+	}
+
+	for _, sortBy := range []string{"created_at", "completed_at", "progress", "id"} {
+		for _, dir := range []string{"asc", "desc"} {
+			all, err := repo.ListJobs(&models.GetJobsRequest{PageSize: 100, SortBy: sortBy, SortDir: dir})
+			if err != nil {
+				t.Fatalf("ListJobs %s %s failed: %v", sortBy, dir, err)
+			}
+			req := &models.GetJobsRequest{PageSize: 3, SortBy: sortBy, SortDir: dir}
+			var paged []string
+			for page := 0; page < 10; page++ {
+				resp, err := repo.ListJobs(req)
+				if err != nil {
+					t.Fatalf("ListJobs %s %s page %d failed: %v", sortBy, dir, page, err)
+				}
+				for _, j := range resp.Jobs {
+					paged = append(paged, j.ID)
+				}
+				if resp.NextCursor == "" {
+					break
+				}
+				req.Cursor = resp.NextCursor
+			}
+			var want []string
+			for _, j := range all.Jobs {
+				want = append(want, j.ID)
+			}
+			if fmt.Sprint(paged) != fmt.Sprint(want) {
+				t.Fatalf("%s %s: cursor pages %v differ from full listing %v", sortBy, dir, paged, want)
+			}
+		}
+	}
+
+	// a job created after the first page does not shift the next one
+	first, _ := repo.ListJobs(&models.GetJobsRequest{PageSize: 3})
+	if err := db.Create(&models.Job{ID: "job-new", Type: "monitor", Status: "pending", CreatedAt: base.Add(time.Hour)}).Error; err != nil {
+		t.Fatalf("create job failed: %v", err)
+	}
+	second, _ := repo.ListJobs(&models.GetJobsRequest{PageSize: 3, Cursor: first.NextCursor})
+	if second.Jobs[0].ID != "job-06" {
+		t.Fatalf("expected the second page to continue at job-06, got %+v", second.Jobs)
+	}
+
+	if _, err := repo.ListJobs(&models.GetJobsRequest{Cursor: "not-a-cursor"}); !errors.Is(err, interfaces.ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor, got %v", err)
+	}
+	first, _ = repo.ListJobs(&models.GetJobsRequest{PageSize: 2, SortBy: "progress"})
+	if _, err := repo.ListJobs(&models.GetJobsRequest{PageSize: 2, SortBy: "created_at", Cursor: first.NextCursor}); !errors.Is(err, interfaces.ErrInvalidCursor) {
+		t.Fatalf("expected a cursor for another sort to be rejected, got %v", err)
 	}
 }
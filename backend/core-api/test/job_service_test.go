@@ -49,12 +49,41 @@ func (f *fakeJobRepo) UpdateJobStatus(id string, status string) error {
 func (f *fakeJobRepo) UpdateJobProgress(id string, progress int, message string) error {
 	if f.save != nil {
 		f.save.Progress = progress
-		if progress >= 100 {
-			f.save.Status = "completed"
+	}
+	return nil
+}
+func (f *fakeJobRepo) UpdateJobFields(id string, fields map[string]interface{}) error {
+	if f.save != nil {
+		if st, ok := fields["status"].(string); ok {
+			f.save.Status = st
+		}
+		if n, ok := fields["attempts"].(int); ok {
+			f.save.Attempts = n
 		}
 	}
 	return nil
 }
+func (f *fakeJobRepo) ListJobsByStatus(status string, limit int) ([]*models.Job, error) {
+	if f.save != nil && f.save.Status == status {
+		return []*models.Job{f.save}, nil
+	}
+	return nil, nil
+}
+func (f *fakeJobRepo) TransitionJobStatus(id, from, to string) (bool, error) {
+	if f.save == nil || f.save.Status != from {
+		return false, nil
+	}
+	f.save.Status = to
+	return true, nil
+}
+func (f *fakeJobRepo) ListDependentJobs(parentID string) ([]*models.Job, error) { return nil, nil }
+func (f *fakeJobRepo) ListJobsByWorkflow(workflowID string) ([]*models.Job, error) {
+	return nil, nil
+}
+
+func (f *fakeJobRepo) ListOverdueJobs(now time.Time, limit int) ([]*models.Job, error) {
+	return nil, nil
+}
 
 func TestProcessProvisionJob_PublishesEvent(t *testing.T) {
 	prod := &fakeProducer{}
@@ -80,7 +80,7 @@ func TestCreateDroplet_SucceedsAndAddsToCluster(t *testing.T) {
 	}
 
 	// ensure cluster has the droplet id appended
-	updated, err := clusterSvc.GetCluster(cluster.ID)
+	updated, err := clusterSvc.GetCluster("", cluster.ID)
 	if err != nil {
 		t.Fatalf("failed to get cluster: %v", err)
 	}
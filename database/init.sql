@@ -1,16 +1,30 @@
 -- database/init.sql
 
+CREATE TABLE projects (
+    id VARCHAR(255) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    rate_limits TEXT,  -- JSON object of limiter name -> {refill_rate, capacity}
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+INSERT INTO projects (id, name, created_at, updated_at) VALUES ('default', 'Default', NOW(), NOW());
+
 CREATE TABLE clusters (
     id VARCHAR(255) PRIMARY KEY,
+    project_id VARCHAR(255) NOT NULL DEFAULT 'default',
     name VARCHAR(255) NOT NULL,
     region VARCHAR(255) NOT NULL,
     droplets TEXT,  -- JSON array of droplet IDs
     status VARCHAR(50) NOT NULL,
-    last_checked DATETIME NOT NULL
+    last_checked DATETIME NOT NULL,
+    INDEX idx_clusters_project_id (project_id)
 );
 
 CREATE TABLE droplets (
     id VARCHAR(255) PRIMARY KEY,
+    project_id VARCHAR(255) NOT NULL DEFAULT 'default',
     cluster_id VARCHAR(255),
     name VARCHAR(255) NOT NULL,
     region VARCHAR(255) NOT NULL,
@@ -20,25 +34,115 @@ CREATE TABLE droplets (
     status VARCHAR(50) NOT NULL,
     created_at DATETIME NOT NULL,
     ip_address VARCHAR(45),
+    INDEX idx_droplets_project_id (project_id),
     FOREIGN KEY (cluster_id) REFERENCES clusters(id) ON DELETE CASCADE
 );
 
 CREATE TABLE jobs (
     id VARCHAR(255) PRIMARY KEY,
+    project_id VARCHAR(255) NOT NULL DEFAULT 'default',
     cluster_id VARCHAR(255) NOT NULL,
     type VARCHAR(50) NOT NULL,
     status VARCHAR(50) NOT NULL,
     trace_id VARCHAR(255),
     progress INT DEFAULT 0,
     created_at DATETIME NOT NULL,
+    started_at DATETIME NULL,
     completed_at DATETIME,
     result TEXT,
     error TEXT,
     parameters TEXT,  -- JSON string of parameters
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 0,
+    next_run_at DATETIME NULL,
+    workflow_id VARCHAR(255) NULL,
+    depends_on TEXT NULL,  -- JSON array of parent job IDs
+    priority VARCHAR(20) NOT NULL DEFAULT 'normal',
+    tenant_id VARCHAR(255) NULL,
+    timeout_seconds INT NOT NULL DEFAULT 0,
+    deadline_at DATETIME NULL,
     INDEX idx_cluster_id (cluster_id),
+    INDEX idx_jobs_status_next_run_at (status, next_run_at),
+    INDEX idx_jobs_workflow_id (workflow_id),
+    INDEX idx_jobs_status_deadline (status, deadline_at),
+    INDEX idx_jobs_created_at_id (created_at, id),
+    INDEX idx_jobs_completed_at_id (completed_at, id),
+    INDEX idx_jobs_progress_id (progress, id),
+    INDEX idx_jobs_status_created_at (status, created_at, id),
+    INDEX idx_jobs_type_created_at (type, created_at, id),
+    INDEX idx_jobs_cluster_id_created_at (cluster_id, created_at, id),
+    INDEX idx_jobs_trace_id (trace_id),
+    INDEX idx_jobs_project_id (project_id),
     FOREIGN KEY (cluster_id) REFERENCES clusters(id) ON DELETE CASCADE
 );
 
+CREATE TABLE workflows (
+    id VARCHAR(255) PRIMARY KEY,
+    project_id VARCHAR(255) NOT NULL DEFAULT 'default',
+    name VARCHAR(255),
+    `keys` TEXT,  -- JSON object: node key -> job ID
+    created_at DATETIME NOT NULL,
+    INDEX idx_workflows_project_id (project_id)
+);
+
+CREATE TABLE job_schedules (
+    id VARCHAR(255) PRIMARY KEY,
+    project_id VARCHAR(255) NOT NULL DEFAULT 'default',
+    name VARCHAR(255),
+    cluster_id VARCHAR(255),
+    cron_expr VARCHAR(255) NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    job_type VARCHAR(50) NOT NULL,
+    parameters TEXT,  -- JSON object of job parameters
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    overlap_policy VARCHAR(20) NOT NULL DEFAULT 'skip',
+    pending_run BOOLEAN NOT NULL DEFAULT FALSE,
+    last_run_at DATETIME NULL,
+    next_run_at DATETIME NULL,
+    last_job_id VARCHAR(255),
+    last_error TEXT,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    INDEX idx_job_schedules_project_id (project_id),
+    INDEX idx_job_schedules_next_run_at (next_run_at)
+);
+
+CREATE TABLE job_logs (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    job_id VARCHAR(255) NOT NULL,
+    timestamp DATETIME(3) NOT NULL,
+    level VARCHAR(10) NOT NULL DEFAULT 'info',
+    message TEXT,
+    progress INT NOT NULL DEFAULT 0,
+    trace_id VARCHAR(255),
+    INDEX idx_job_logs_job_id_id (job_id, id)
+);
+
+CREATE TABLE job_dependencies (
+    job_id VARCHAR(255) NOT NULL,
+    depends_on_id VARCHAR(255) NOT NULL,
+    PRIMARY KEY (job_id, depends_on_id),
+    INDEX idx_job_dependencies_depends_on_id (depends_on_id)
+);
+
+CREATE TABLE api_keys (
+    id VARCHAR(255) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(32) NOT NULL,
+    key_hash CHAR(64) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    role VARCHAR(50) NOT NULL,
+    project_id VARCHAR(255) NOT NULL DEFAULT 'default',  -- empty for admin keys, which pick a project per request
+    scopes TEXT,  -- JSON array of API areas
+    expires_at DATETIME,
+    revoked_at DATETIME,
+    last_used_at DATETIME,
+    created_by VARCHAR(255),
+    created_at DATETIME NOT NULL,
+    UNIQUE INDEX idx_api_keys_key_hash (key_hash),
+    INDEX idx_api_keys_project_id (project_id)
+);
+
 CREATE TABLE metrics (
     id VARCHAR(255) PRIMARY KEY,
     cluster_id VARCHAR(255) NOT NULL,
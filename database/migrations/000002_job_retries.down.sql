@@ -0,0 +1,8 @@
+-- 000002_job_retries.down.sql - Remove retry bookkeeping from jobs
+
+DROP INDEX idx_jobs_status_next_run_at ON jobs;
+
+ALTER TABLE jobs
+    DROP COLUMN next_run_at,
+    DROP COLUMN max_attempts,
+    DROP COLUMN attempts;
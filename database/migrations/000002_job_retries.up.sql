@@ -0,0 +1,8 @@
+-- 000002_job_retries.up.sql - Retry bookkeeping for jobs
+
+ALTER TABLE jobs
+    ADD COLUMN attempts INT NOT NULL DEFAULT 0,
+    ADD COLUMN max_attempts INT NOT NULL DEFAULT 0,
+    ADD COLUMN next_run_at DATETIME NULL;
+
+CREATE INDEX idx_jobs_status_next_run_at ON jobs (status, next_run_at);
@@ -0,0 +1,9 @@
+-- 000003_workflows.down.sql - Remove job dependencies and workflows
+
+DROP INDEX idx_jobs_workflow_id ON jobs;
+
+ALTER TABLE jobs
+    DROP COLUMN depends_on,
+    DROP COLUMN workflow_id;
+
+DROP TABLE IF EXISTS workflows;
@@ -0,0 +1,14 @@
+-- 000003_workflows.up.sql - Job dependencies and workflows (DAGs of jobs)
+
+CREATE TABLE IF NOT EXISTS workflows (
+    id VARCHAR(255) PRIMARY KEY,
+    name VARCHAR(255),
+    `keys` TEXT,  -- JSON object: node key -> job ID
+    created_at DATETIME NOT NULL
+);
+
+ALTER TABLE jobs
+    ADD COLUMN workflow_id VARCHAR(255) NULL,
+    ADD COLUMN depends_on TEXT NULL;  -- JSON array of parent job IDs
+
+CREATE INDEX idx_jobs_workflow_id ON jobs (workflow_id);
@@ -0,0 +1,3 @@
+-- 000004_job_schedules.down.sql - Remove cron schedules
+
+DROP TABLE IF EXISTS job_schedules;
@@ -0,0 +1,21 @@
+-- 000004_job_schedules.up.sql - Cron schedules for recurring jobs
+
+CREATE TABLE IF NOT EXISTS job_schedules (
+    id VARCHAR(255) PRIMARY KEY,
+    name VARCHAR(255),
+    cluster_id VARCHAR(255),
+    cron_expr VARCHAR(255) NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    job_type VARCHAR(50) NOT NULL,
+    parameters TEXT,  -- JSON object of job parameters
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    overlap_policy VARCHAR(20) NOT NULL DEFAULT 'skip',
+    pending_run BOOLEAN NOT NULL DEFAULT FALSE,
+    last_run_at DATETIME NULL,
+    next_run_at DATETIME NULL,
+    last_job_id VARCHAR(255),
+    last_error TEXT,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    INDEX idx_job_schedules_next_run_at (next_run_at)
+);
@@ -0,0 +1,5 @@
+-- 000005_job_priority.down.sql - Remove job priority and tenant
+
+ALTER TABLE jobs
+    DROP COLUMN tenant_id,
+    DROP COLUMN priority;
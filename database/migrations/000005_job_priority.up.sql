@@ -0,0 +1,5 @@
+-- 000005_job_priority.up.sql - Job priority and fairness tenant for worker pool scheduling
+
+ALTER TABLE jobs
+    ADD COLUMN priority VARCHAR(20) NOT NULL DEFAULT 'normal',
+    ADD COLUMN tenant_id VARCHAR(255) NULL;
@@ -0,0 +1,4 @@
+-- 000006_job_started_at.down.sql - Remove job started_at
+
+ALTER TABLE jobs
+    DROP COLUMN started_at;
@@ -0,0 +1,4 @@
+-- 000006_job_started_at.up.sql - Track when the current attempt of a job started
+
+ALTER TABLE jobs
+    ADD COLUMN started_at DATETIME NULL;
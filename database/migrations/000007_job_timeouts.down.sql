@@ -0,0 +1,7 @@
+-- 000007_job_timeouts.down.sql - Remove job timeouts
+
+DROP INDEX idx_jobs_status_deadline ON jobs;
+
+ALTER TABLE jobs
+    DROP COLUMN deadline_at,
+    DROP COLUMN timeout_seconds;
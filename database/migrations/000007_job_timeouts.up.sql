@@ -0,0 +1,7 @@
+-- 000007_job_timeouts.up.sql - Per-attempt execution timeout and deadline for the timeout reaper
+
+ALTER TABLE jobs
+    ADD COLUMN timeout_seconds INT NOT NULL DEFAULT 0,
+    ADD COLUMN deadline_at DATETIME NULL;
+
+CREATE INDEX idx_jobs_status_deadline ON jobs (status, deadline_at);
@@ -0,0 +1,3 @@
+-- 000008_job_logs.down.sql - Remove the per-job log
+
+DROP TABLE IF EXISTS job_logs;
@@ -0,0 +1,12 @@
+-- 000008_job_logs.up.sql - Append-only per-job log
+
+CREATE TABLE IF NOT EXISTS job_logs (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    job_id VARCHAR(255) NOT NULL,
+    timestamp DATETIME(3) NOT NULL,
+    level VARCHAR(10) NOT NULL DEFAULT 'info',
+    message TEXT,
+    progress INT NOT NULL DEFAULT 0,
+    trace_id VARCHAR(255),
+    INDEX idx_job_logs_job_id_id (job_id, id)
+);
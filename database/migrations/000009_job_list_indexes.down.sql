@@ -0,0 +1,9 @@
+-- 000009_job_list_indexes.down.sql - Drop the ListJobs indexes
+
+DROP INDEX idx_jobs_created_at_id ON jobs;
+DROP INDEX idx_jobs_completed_at_id ON jobs;
+DROP INDEX idx_jobs_progress_id ON jobs;
+DROP INDEX idx_jobs_status_created_at ON jobs;
+DROP INDEX idx_jobs_type_created_at ON jobs;
+DROP INDEX idx_jobs_cluster_id_created_at ON jobs;
+DROP INDEX idx_jobs_trace_id ON jobs;
@@ -0,0 +1,10 @@
+-- 000009_job_list_indexes.up.sql - Indexes for ListJobs filters, sorts and keyset pagination
+-- Every sort is (column, id) so pages can continue from a cursor without OFFSET.
+
+CREATE INDEX idx_jobs_created_at_id ON jobs (created_at, id);
+CREATE INDEX idx_jobs_completed_at_id ON jobs (completed_at, id);
+CREATE INDEX idx_jobs_progress_id ON jobs (progress, id);
+CREATE INDEX idx_jobs_status_created_at ON jobs (status, created_at, id);
+CREATE INDEX idx_jobs_type_created_at ON jobs (type, created_at, id);
+CREATE INDEX idx_jobs_cluster_id_created_at ON jobs (cluster_id, created_at, id);
+CREATE INDEX idx_jobs_trace_id ON jobs (trace_id);
@@ -0,0 +1,3 @@
+-- 000010_job_dependencies.down.sql - Remove job dependency edges
+
+DROP TABLE IF EXISTS job_dependencies;
@@ -0,0 +1,15 @@
+-- 000010_job_dependencies.up.sql - Job dependency edges, so children are found without scanning depends_on
+
+CREATE TABLE IF NOT EXISTS job_dependencies (
+    job_id VARCHAR(255) NOT NULL,
+    depends_on_id VARCHAR(255) NOT NULL,
+    PRIMARY KEY (job_id, depends_on_id),
+    INDEX idx_job_dependencies_depends_on_id (depends_on_id)
+);
+
+-- backfill the edges of existing jobs from their depends_on JSON arrays
+INSERT IGNORE INTO job_dependencies (job_id, depends_on_id)
+SELECT jobs.id, deps.parent_id
+FROM jobs,
+     JSON_TABLE(jobs.depends_on, '$[*]' COLUMNS (parent_id VARCHAR(255) PATH '$')) AS deps
+WHERE jobs.depends_on IS NOT NULL AND jobs.depends_on <> '' AND jobs.depends_on <> 'null';
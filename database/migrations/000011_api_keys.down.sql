@@ -0,0 +1,3 @@
+-- 000011_api_keys.down.sql - Remove API keys
+
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,17 @@
+-- 000011_api_keys.up.sql - API keys; only the SHA-256 hash of each key is stored
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id VARCHAR(255) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(32) NOT NULL,
+    key_hash CHAR(64) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    role VARCHAR(50) NOT NULL,
+    scopes TEXT,
+    expires_at DATETIME,
+    revoked_at DATETIME,
+    last_used_at DATETIME,
+    created_by VARCHAR(255),
+    created_at DATETIME NOT NULL,
+    UNIQUE INDEX idx_api_keys_key_hash (key_hash)
+);
@@ -0,0 +1,10 @@
+-- 000012_projects.down.sql - Remove projects
+
+ALTER TABLE api_keys DROP INDEX idx_api_keys_project_id, DROP COLUMN project_id;
+ALTER TABLE job_schedules DROP INDEX idx_job_schedules_project_id, DROP COLUMN project_id;
+ALTER TABLE workflows DROP INDEX idx_workflows_project_id, DROP COLUMN project_id;
+ALTER TABLE jobs DROP INDEX idx_jobs_project_id, DROP COLUMN project_id;
+ALTER TABLE droplets DROP INDEX idx_droplets_project_id, DROP COLUMN project_id;
+ALTER TABLE clusters DROP INDEX idx_clusters_project_id, DROP COLUMN project_id;
+
+DROP TABLE IF EXISTS projects;
@@ -0,0 +1,40 @@
+-- 000012_projects.up.sql - Projects; every cluster, droplet, job, workflow, schedule and API key belongs to one
+
+CREATE TABLE IF NOT EXISTS projects (
+    id VARCHAR(255) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    rate_limits TEXT,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+INSERT IGNORE INTO projects (id, name, created_at, updated_at) VALUES ('default', 'Default', NOW(), NOW());
+
+-- existing rows move to the default project
+ALTER TABLE clusters
+    ADD COLUMN project_id VARCHAR(255) NOT NULL DEFAULT 'default' AFTER id,
+    ADD INDEX idx_clusters_project_id (project_id);
+
+ALTER TABLE droplets
+    ADD COLUMN project_id VARCHAR(255) NOT NULL DEFAULT 'default' AFTER id,
+    ADD INDEX idx_droplets_project_id (project_id);
+
+ALTER TABLE jobs
+    ADD COLUMN project_id VARCHAR(255) NOT NULL DEFAULT 'default' AFTER id,
+    ADD INDEX idx_jobs_project_id (project_id);
+
+ALTER TABLE workflows
+    ADD COLUMN project_id VARCHAR(255) NOT NULL DEFAULT 'default' AFTER id,
+    ADD INDEX idx_workflows_project_id (project_id);
+
+ALTER TABLE job_schedules
+    ADD COLUMN project_id VARCHAR(255) NOT NULL DEFAULT 'default' AFTER id,
+    ADD INDEX idx_job_schedules_project_id (project_id);
+
+ALTER TABLE api_keys
+    ADD COLUMN project_id VARCHAR(255) NOT NULL DEFAULT 'default' AFTER role,
+    ADD INDEX idx_api_keys_project_id (project_id);
+
+-- admin keys are not bound to a project; they pick one per request
+UPDATE api_keys SET project_id = '' WHERE role = 'admin';